@@ -0,0 +1,22 @@
+"""Prints the total spend of one user for the current year.
+
+    make client-python && pip install ./clients/generated/python
+    python clients/examples/summary.py <user-id>
+"""
+import datetime
+import os
+import sys
+
+import subscription_client
+
+config = subscription_client.Configuration(host=os.environ.get("API_URL", "http://localhost:8080"))
+with subscription_client.ApiClient(config) as client:
+    api = subscription_client.SubscriptionsApi(client)
+
+    year = datetime.date.today().year
+    summary = api.get_summary(user_id=sys.argv[1], start=f"{year}-01", end=f"{year}-12")
+    print(f"total: {summary.total_price} RUB")
+
+    page = api.list_subscriptions(page=1, limit=10)
+    for sub in page.items or []:
+        print(f"{sub.service_name}: {sub.price_rub} RUB")
@@ -0,0 +1,21 @@
+// Prints the total spend of one user for the current year.
+//
+//   make client-ts && npx tsx clients/examples/summary.ts <user-id>
+import { Configuration, SubscriptionsApi } from "../generated/typescript";
+
+const api = new SubscriptionsApi(
+  new Configuration({ basePath: process.env.API_URL ?? "http://localhost:8080" }),
+);
+
+const year = new Date().getFullYear();
+const summary = await api.getSummary({
+  userId: process.argv[2],
+  start: `${year}-01`,
+  end: `${year}-12`,
+});
+console.log(`total: ${summary.totalPrice} RUB`);
+
+const page = await api.listSubscriptions({ page: 1, limit: 10 });
+for (const sub of page.items ?? []) {
+  console.log(`${sub.serviceName}: ${sub.priceRub} RUB`);
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/app"
+	"github.com/beheryahmed1991/subscription-service.git/internal/backfill"
+)
+
+// jobs registers every available data migration by name.
+var jobs = map[string]backfill.Job{
+	backfill.PriceToMoney{}.Name(): backfill.PriceToMoney{},
+}
+
+func main() {
+	var (
+		jobName    = flag.String("job", "", "data migration to run")
+		batchSize  = flag.Int("batch-size", 1000, "rows per batch")
+		pause      = flag.Duration("pause", 100*time.Millisecond, "pause between batches")
+		dryRun     = flag.Bool("dry-run", false, "report remaining rows without changing data")
+		verifyOnly = flag.Bool("verify", false, "only run the verification queries")
+		confirm    = flag.Bool("confirm", false, "required to modify data")
+		list       = flag.Bool("list", false, "list available jobs")
+	)
+	flag.Parse()
+
+	if *list {
+		names := make([]string, 0, len(jobs))
+		for name := range jobs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	job, ok := jobs[*jobName]
+	if !ok {
+		log.Fatalf("unknown job %q, use -list to see available jobs", *jobName)
+	}
+	if !*dryRun && !*verifyOnly && !*confirm {
+		log.Fatal("refusing to modify data without -confirm (use -dry-run to preview)")
+	}
+
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	infra, err := app.NewInfra(ctx, cfg, app.CLIPool)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer infra.Close()
+
+	if err := infra.Migrate(ctx); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	runner := backfill.NewRunner(infra.DB, infra.Logger)
+	if *verifyOnly {
+		if err := runner.Verify(ctx, job); err != nil {
+			log.Fatalf("verify %s: %v", job.Name(), err)
+		}
+		fmt.Printf("%s verified\n", job.Name())
+		return
+	}
+
+	if err := runner.Run(ctx, job, backfill.Options{
+		BatchSize: *batchSize,
+		Pause:     *pause,
+		DryRun:    *dryRun,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "run %s: %v\n", job.Name(), err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,48 @@
+// Command migrate helps write schema migrations.
+//
+//	go run ./cmd/migrate new add_widgets    # create migrations/<version>_add_widgets.sql
+//	go run ./cmd/migrate check              # validate the migration files
+//
+// Migrations are applied by the service at startup, not by this command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/migrate"
+)
+
+func main() {
+	dir := flag.String("dir", "migrations", "migrations directory")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate [-dir migrations] new <name> | check")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "new":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		path, err := migrate.Create(*dir, flag.Arg(1), time.Now())
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Println(path)
+		fmt.Println("remember to regenerate migrations/schema.txt with go run ./cmd/schema-manifest")
+	case "check":
+		if err := migrate.Validate(os.DirFS(*dir)); err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Println("migrations ok")
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
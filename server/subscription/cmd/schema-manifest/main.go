@@ -0,0 +1,59 @@
+// Command schema-manifest regenerates migrations/schema.txt, the expected
+// schema checked for drift at startup. Run it against a fresh, empty
+// database after adding a migration:
+//
+//	go run ./cmd/schema-manifest -out migrations/schema.txt
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/app"
+	"github.com/beheryahmed1991/subscription-service.git/internal/migrate"
+)
+
+func main() {
+	out := flag.String("out", "migrations/schema.txt", "manifest file to write")
+	flag.Parse()
+
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	infra, err := app.NewInfra(ctx, cfg, app.CLIPool)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer infra.Close()
+
+	// Only the main set: the manifest must not depend on DB_STRICT_CONSTRAINTS.
+	if err := migrate.Up(ctx, infra.DB); err != nil {
+		log.Fatalf("%v", err)
+	}
+	schema, err := migrate.Inspect(ctx, infra.DB)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("create manifest: %v", err)
+	}
+	if err := schema.Write(f); err != nil {
+		f.Close()
+		log.Fatalf("write manifest: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("write manifest: %v", err)
+	}
+	log.Printf("wrote %d schema objects to %s", len(schema), *out)
+}
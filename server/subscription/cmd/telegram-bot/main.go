@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/app"
+	"github.com/beheryahmed1991/subscription-service.git/internal/telegram"
+)
+
+func main() {
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	infra, err := app.NewInfra(ctx, cfg, app.CLIPool)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer infra.Close()
+
+	bot, err := telegram.New(telegram.Config{
+		Token:       cfg.Telegram.Token,
+		PollTimeout: cfg.Telegram.PollTimeout,
+	}, infra.SubscriptionService(), infra.Logger)
+	if err != nil {
+		log.Fatalf("create telegram bot: %v", err)
+	}
+
+	infra.Logger.Info("telegram bot started")
+	if err := bot.Run(ctx); err != nil {
+		log.Fatalf("run telegram bot: %v", err)
+	}
+	infra.Logger.Info("telegram bot stopped")
+}
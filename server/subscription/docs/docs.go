@@ -15,62 +15,36 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/admin/analytics/spend-distribution": {
             "get": {
-                "description": "List all subscriptions ordered by creation date",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "subscriptions"
-                ],
-                "summary": "List subscriptions",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/subscription.Subscription"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Create a new subscription entry",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Percentiles (p50/p90/p99) of per-user monthly spend over a period, plus a per-month trend",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "admin"
                 ],
-                "summary": "Create subscription",
+                "summary": "Spend distribution",
+                "operationId": "getSpendDistribution",
                 "parameters": [
                     {
-                        "description": "Subscription payload",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/subscription.createSubscriptionRequest"
-                        }
+                        "type": "string",
+                        "description": "Start month (YYYY-MM or MM-YYYY), defaults to 11 months before end",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End month (YYYY-MM or MM-YYYY), defaults to the current month",
+                        "name": "end",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/subscription.Subscription"
+                            "$ref": "#/definitions/subscription.SpendDistribution"
                         }
                     },
                     "400": {
@@ -88,39 +62,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/summary": {
+        "/admin/anomalies": {
             "get": {
-                "description": "Calculate total subscription cost within optional filters",
+                "description": "Users whose spend in a closed month rose above the configured threshold over the month before",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "admin"
                 ],
-                "summary": "Sum subscriptions",
+                "summary": "Spend anomalies",
+                "operationId": "listSpendAnomalies",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Start month (YYYY-MM or MM-YYYY)",
-                        "name": "start",
+                        "description": "Month (YYYY-MM)",
+                        "name": "month",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "End month (YYYY-MM or MM-YYYY)",
-                        "name": "end",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "user_id",
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum rows (\u003c=500)",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Service name",
-                        "name": "service_name",
+                        "description": "Order, e.g. change_pct desc; sortable: month, change_pct, current_total, detected_at",
+                        "name": "sort",
                         "in": "query"
                     }
                 ],
@@ -128,117 +104,274 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/subscription.summaryResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/anomaly.Anomaly"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/admin/config": {
             "get": {
-                "description": "Get subscription by ID",
+                "description": "Every setting the process runs with, where it came from (default, profile, file or env) and secrets masked. Reloaded settings show their new values.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "admin"
                 ],
-                "summary": "Get subscription",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                "summary": "Effective configuration",
+                "operationId": "getConfig",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.configResponse"
+                        }
                     }
+                }
+            }
+        },
+        "/admin/config/reload": {
+            "post": {
+                "description": "Re-read non-critical settings (log level, pagination, load shedding) without a restart. Applied changes are recorded in the audit log.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
                 ],
+                "summary": "Reload configuration",
+                "operationId": "reloadConfig",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/subscription.Subscription"
+                            "$ref": "#/definitions/admin.reloadResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    }
+                }
+            }
+        },
+        "/admin/indexes/rebuild": {
+            "get": {
+                "description": "State of the current or last index rebuild, with the build phase and block counts of the index in progress",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Index rebuild progress",
+                "operationId": "getIndexRebuild",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "$ref": "#/definitions/admin.RebuildStatus"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Reindexes the search and summary indexes concurrently, creating missing ones, without blocking writes. Runs in the background; poll GET for progress.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Rebuild search and summary indexes",
+                "operationId": "startIndexRebuild",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/admin.RebuildStatus"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/info": {
+            "get": {
+                "description": "Runs, failures, last success and failure times and observed values such as queue depths for every background subsystem",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Background subsystem status",
+                "operationId": "getSubsystemInfo",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/monitor.Info"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/maintenance": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Maintenance mode state",
+                "operationId": "getMaintenance",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.MaintenanceConfig"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete subscription by ID",
+            "put": {
+                "description": "While enabled, mutating endpoints return 503 and reads keep working",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "admin"
                 ],
-                "summary": "Delete subscription",
+                "summary": "Toggle maintenance mode",
+                "operationId": "setMaintenance",
+                "parameters": [
+                    {
+                        "description": "Desired state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/admin.maintenanceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.MaintenanceConfig"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quotas/{user_id}": {
+            "get": {
+                "description": "The user's requests-per-minute override. Users without one get the configured default.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get rate limit override",
+                "operationId": "getUserQuota",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Subscription ID",
-                        "name": "id",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/quota.Quota"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
-            "patch": {
-                "description": "Partially update subscription fields",
+            "put": {
+                "description": "Give the user a requests-per-minute limit other than the configured default",
                 "consumes": [
                     "application/json"
                 ],
@@ -246,24 +379,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "admin"
                 ],
-                "summary": "Update subscription",
+                "summary": "Set rate limit override",
+                "operationId": "setUserQuota",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Subscription ID",
-                        "name": "id",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Fields to update",
+                        "description": "Limit",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/subscription.updateSubscriptionRequest"
+                            "$ref": "#/definitions/quota.setQuotaRequest"
                         }
                     }
                 ],
@@ -271,74 +405,4211 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/subscription.Subscription"
+                            "$ref": "#/definitions/quota.Quota"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Return the user to the configured default limit",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Remove rate limit override",
+                "operationId": "deleteUserQuota",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/subscription.errorResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "subscription.Subscription": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
+        },
+        "/admin/slo": {
+            "get": {
+                "description": "Error budget and burn rates per route class. A page alert fires when the 1h and 5m burn rates are both at least 14.4, a ticket when the 6h and 30m rates are both at least 6.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "SLO status",
+                "operationId": "getSLO",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slo.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats/live": {
+            "get": {
+                "description": "Subscriptions charged in the current month, total and per service, as of the last periodic refresh",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Live subscription counts",
+                "operationId": "getLiveStats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.LiveStats"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/templates": {
+            "get": {
+                "description": "Lists the transactional email templates and whether the deployment overrides their copy",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Email templates",
+                "operationId": "listEmailTemplates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/mailtmpl.templateInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/templates/{name}/preview": {
+            "get": {
+                "description": "Renders the template with sample data. The variables in the response are the ones the template can use. format=html returns the body alone, for viewing in a browser.",
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Preview email template",
+                "operationId": "previewEmailTemplate",
+                "parameters": [
+                    {
+                        "enum": [
+                            "digest",
+                            "price-increase",
+                            "reminder"
+                        ],
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "html"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mailtmpl.previewResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Answers 200 whenever the process serves HTTP. It checks no dependencies, so a database outage does not get the pod restarted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "operationId": "healthz",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/dashboard": {
+            "get": {
+                "description": "Current-month total, active count, top 5 services by spend and the next 3 renewals for the caller. Without authentication, callers name the user with user_id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Account dashboard",
+                "operationId": "getDashboard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID, for anonymous callers",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.Dashboard"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Runs every dependency check (Postgres reachable, migrations applied) with a short timeout and reports each one. Answers 503 when any check fails.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "operationId": "readyz",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/plan-suggestions": {
+            "get": {
+                "description": "For every subscription the user runs this month whose service has a plan cheaper than what it pays, those plans, the closest in price first, and the monthly saving of the cheapest. Subscriptions that could save the most come first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Cheaper plans",
+                "operationId": "suggestCheaperPlans",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User to suggest for, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/catalog.PlanSuggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/popular": {
+            "get": {
+                "description": "Services most users run this month, with how many users and subscriptions and their average monthly price, for \"people also track\" suggestions. Served from a rollup refreshed hourly; services too few users run are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Popular services",
+                "operationId": "popularServices",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum services (\u003c=50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/catalog.Popular"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/suggest": {
+            "get": {
+                "description": "Ranked service names for typeahead: catalog entries plus the user's own past names, ranked by trigram similarity with prefix matches first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Suggest service names",
+                "operationId": "suggestServices",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "What the user has typed so far",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Include this user's service names, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum suggestions (\u003c=25)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/catalog.Suggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{name}/plans": {
+            "get": {
+                "description": "The tiers a catalog service is sold in, e.g. Basic, Standard and Premium, with their monthly price and features, from the most limited up. Subscriptions reference one by plan_id. Services without plans return an empty list.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Service plans",
+                "operationId": "listServicePlans",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service name, case-insensitive",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/catalog.Plan"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/catalog.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "List subscriptions with pagination, ordered by sort_by and order or else the configured default sort, with the ID breaking ties. Malformed or out-of-range parameters are rejected with 400. Callers other than support and admins only see their own subscriptions. Responses carry an ETag; send it back in If-None-Match to get 304 when nothing changed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "List subscriptions",
+                "operationId": "listSubscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (\u003e=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page (\u003c=100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "price_rub",
+                            "start_month",
+                            "end_month",
+                            "service_name",
+                            "created_at",
+                            "updated_at"
+                        ],
+                        "type": "string",
+                        "description": "Column to sort by",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction, ascending unless desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Only subscriptions currently in this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.listResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the given ETag"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new subscription entry. The ID may be supplied by the client; an ID already in use returns 409. Callers other than admins may only create subscriptions for themselves; another user_id returns 403. The price is given in currency (default RUB) as whole units in price or minor units in amount_minor, and converted to price_rub at the configured exchange rate. Rules that inform without blocking add to warnings. A request retried with the same Idempotency-Key gets the first response back with Idempotent-Replayed: true; reusing a key for a different payload returns 422, and a retry while the first request is still running returns 409, and a keyed body over 1 MiB returns 413.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create subscription",
+                "operationId": "createSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client-chosen key, at most 255 characters, that makes retries safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subscription payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.createSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Apply the same partial update to every subscription matching the filters, e.g. set end_date on all subscriptions to a service that shut down. All matches change in one transaction or none do; at most 1000 may match.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Update matching subscriptions",
+                "operationId": "bulkUpdateSubscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service name, case-insensitive",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.updateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.BulkResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/batch-get": {
+            "post": {
+                "description": "Load up to 100 subscriptions by ID in one call. Items follow the order of the request; IDs that match nothing are listed under missing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get several subscriptions",
+                "operationId": "batchGetSubscriptions",
+                "parameters": [
+                    {
+                        "description": "Subscription IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.batchGetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.batchGetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/bulk-delete": {
+            "post": {
+                "description": "First step of a bulk delete: returns how many subscriptions match, a sample and a confirmation token valid for 10 minutes. Nothing is deleted until the token is confirmed. At most 10000 may match. Tokens and jobs are kept in the memory of the replica that issued them, so the confirmation and progress requests must reach the same one (e.g. through sticky sessions); elsewhere they return 404.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Preview a filter-wide delete",
+                "operationId": "previewBulkDelete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service name, case-insensitive",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.BulkDeletePreview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/bulk-delete/confirm": {
+            "post": {
+                "description": "Second step of a bulk delete: starts deleting the subscriptions of the preview in the background. A token works once, only for the caller who requested the preview, and only on the replica that issued it; other replicas return 404.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Confirm a filter-wide delete",
+                "operationId": "confirmBulkDelete",
+                "parameters": [
+                    {
+                        "description": "Token from the preview",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.confirmBulkDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.BulkDeleteJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/bulk-delete/{job_id}": {
+            "get": {
+                "description": "Progress of a confirmed bulk delete. Only the caller who confirmed it, support and admins can see a job; others get 404. Jobs are kept in the memory of the replica running them, so other replicas and restarts return 404 as well.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Bulk delete progress",
+                "operationId": "getBulkDelete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.BulkDeleteJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/by-key/{user_id}/{service_name}/{start_month}": {
+            "put": {
+                "description": "Idempotent write for sync jobs that do not track IDs. The subscription is identified by owner, service name (case-insensitive) and start month. If none exists it is created (201); otherwise its price, currency, billing period, plan and end date are replaced to match the body (200). Sending the same body again changes nothing, so jobs can re-run without checking first. If several subscriptions share the key, the oldest is updated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create or update subscription by natural key",
+                "operationId": "upsertSubscriptionByKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Owner (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start month, e.g. 2025-03",
+                        "name": "start_month",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Price and end of the subscription",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.upsertSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/search": {
+            "post": {
+                "description": "Filter subscriptions with a JSON document of conditions combined with and/or/not. status matches the current status, expired included; tags eq matches subscriptions carrying the tag and in any of the tags. Text comparisons ignore case. Callers other than support and admins only match their own subscriptions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Search subscriptions",
+                "operationId": "searchSubscriptions",
+                "parameters": [
+                    {
+                        "description": "Search document",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.searchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.listResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stream": {
+            "get": {
+                "description": "Stream matching subscriptions as newline-delimited JSON, one object per line, flushed per row. Callers other than support and admins only see their own subscriptions. A failure mid-stream, including running past the configured stream timeout, ends it with a line holding only an \"error\" field.",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Stream subscriptions",
+                "operationId": "streamSubscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort, e.g. \\",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Only subscriptions currently in this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of rows; unlimited by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/summary": {
+            "get": {
+                "description": "Calculate total subscription cost within optional filters. Prices billed other than monthly are prorated over the months they cover, so a yearly price counts one twelfth per month. group_by=tag returns a tagSummaryResponse instead, with one total per tag and a null tag for untagged subscriptions; tag_mode=each counts a subscription in all of its tags, primary only in its first. Callers other than support and admins are limited to their own subscriptions; another user_id returns 403.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Sum subscriptions",
+                "operationId": "getSummary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start month (YYYY-MM or MM-YYYY)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End month (YYYY-MM or MM-YYYY)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Saved summary preset of the user, see /users/{id}/summary-presets",
+                        "name": "preset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return spend to date and the projected total through end (requires end)",
+                        "name": "projected",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "tag"
+                        ],
+                        "type": "string",
+                        "description": "Return one total per tag",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "each",
+                            "primary"
+                        ],
+                        "type": "string",
+                        "default": "each",
+                        "description": "Tags a subscription counts toward with group_by=tag",
+                        "name": "tag_mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.projectedSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/summary/batch": {
+            "post": {
+                "description": "Calculate total subscription cost for several users in one call. Callers other than support and admins may only name themselves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Sum subscriptions per user",
+                "operationId": "getSummaryBatch",
+                "parameters": [
+                    {
+                        "description": "Users and period",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.batchSummaryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.batchSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/summary/timeseries": {
+            "get": {
+                "description": "Calculate subscription cost per calendar bucket within optional filters. Callers other than support and admins are limited to their own subscriptions; another user_id returns 403.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Subscription cost over time",
+                "operationId": "getSummaryTimeSeries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start month (YYYY-MM or MM-YYYY)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End month (YYYY-MM or MM-YYYY)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Saved summary preset of the user, see /users/{id}/summary-presets",
+                        "name": "preset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "month",
+                            "quarter",
+                            "year"
+                        ],
+                        "type": "string",
+                        "default": "month",
+                        "description": "Bucket size",
+                        "name": "granularity",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.timeSeriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Get subscription by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscription",
+                "operationId": "getSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete subscription by ID. Locked subscriptions return 423 until unlocked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Delete subscription",
+                "operationId": "deleteSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Partially update subscription fields. Locked subscriptions only accept updates that set locked to false. A new price is in the subscription's currency unless currency changes it; changing only the currency keeps the amount. Rules that inform without blocking add to warnings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Update subscription",
+                "operationId": "updateSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.updateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "description": "Cancel an active or paused subscription for good. Unless it already ends earlier, it is ended with the current month, or where it starts if it has not started yet. Cancelled and expired subscriptions cannot change status again. Allowed for the owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cancel subscription",
+                "operationId": "cancelSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pause": {
+            "post": {
+                "description": "Pause an active subscription. A paused subscription keeps its dates, but cost summaries leave out the months from this one until it is resumed; earlier months stay charged. Allowed for the owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Pause subscription",
+                "operationId": "pauseSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/price-check": {
+            "get": {
+                "description": "Compares the price with the service's catalog list price, or failing that with the average of running subscriptions, and flags entries that are likely outdated",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Compare a subscription's price with the catalog",
+                "operationId": "checkSubscriptionPrice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.PriceCheck"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/resume": {
+            "post": {
+                "description": "Make a paused subscription active again. It is charged again from the current month. Allowed for the owner or an admin.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Resume subscription",
+                "operationId": "resumeSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/timeline": {
+            "get": {
+                "description": "Chronological feed of a subscription's life for its detail page: creation, every audited change with the fields it touched (price changes, lock and unlock, transfers, undos), and the months it started and ended. Paged oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Subscription timeline",
+                "operationId": "getSubscriptionTimeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (\u003e=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Items per page (\u003c=100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.timelineResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/transfer": {
+            "post": {
+                "description": "Hand a subscription over to another user. Allowed for the current owner or an admin. Callers who cannot see the subscription get 404; support staff who can see but not change it get 403.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Transfer subscription",
+                "operationId": "transferSubscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "New owner",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.transferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/undo": {
+            "post": {
+                "description": "Revert the fields changed by the most recent update of a subscription, if it happened within the configured window (15 minutes by default). Fails with 409 when the last change was not an update, is too old, or when a reverted field has changed again since.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Undo the last update",
+                "operationId": "undoSubscriptionUpdate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID or slug",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner to resolve a slug against, defaults to the caller",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/report-schedules": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "List report schedules",
+                "operationId": "listReportSchedules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/report.Schedule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Deliver a weekly or monthly summary or full export by email or webhook",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Create report schedule",
+                "operationId": "createReportSchedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/report.createScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/report.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/report-schedules/{scheduleID}": {
+            "delete": {
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Delete report schedule",
+                "operationId": "deleteReportSchedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "scheduleID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/summary-presets": {
+            "get": {
+                "description": "List the summary filters the user saved, by name.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List summary presets",
+                "operationId": "listSummaryPresets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/subscription.summaryPresetResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/summary-presets/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get summary preset",
+                "operationId": "getSummaryPreset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preset name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.summaryPresetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Save summary filters under a name for GET /subscriptions/summary?preset=name. Names are lowercase letters, digits, - and _, up to 40 characters. Saving an existing name replaces its filters (200); a new name returns 201.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Save summary preset",
+                "operationId": "saveSummaryPreset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preset name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Filters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.summaryPresetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.summaryPresetResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.summaryPresetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete summary preset",
+                "operationId": "deleteSummaryPreset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preset name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/takeout": {
+            "get": {
+                "description": "Stream a JSON archive of every subscription owned by the user, with the audit history of their changes. format=csv streams a read-only spreadsheet copy of the subscriptions alone instead, with numbers, dates and the field separator in the locale from the locale parameter or Accept-Language (e.g. \"199,00\", \"₽\" and semicolons for ru).",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export account data",
+                "operationId": "exportTakeout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Archive format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "en",
+                            "ru",
+                            "de",
+                            "fr"
+                        ],
+                        "type": "string",
+                        "description": "CSV locale, overrides Accept-Language",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred CSV locales",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.takeoutArchive"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Recreate subscriptions from a takeout archive. Existing records are skipped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Restore account data",
+                "operationId": "restoreTakeout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Takeout archive",
+                        "name": "archive",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.takeoutArchive"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.RestoreResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{id}/retry": {
+            "post": {
+                "description": "Send a finished delivery again, with its original payload, to the webhook's current URL",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Retry webhook delivery",
+                "operationId": "retryWebhookDelivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/report.Delivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Recent deliveries of a webhook report schedule, newest first, with attempt counts, response codes and payload snapshots",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "List webhook deliveries",
+                "operationId": "listWebhookDeliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook (report schedule) ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum rows (\u003c=200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/report.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/report.errorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "admin.Change": {
+            "type": "object",
+            "properties": {
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                },
+                "setting": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.IndexProgress": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "blocks_done": {
+                    "type": "integer"
+                },
+                "blocks_total": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.RebuildStatus": {
+            "type": "object",
+            "properties": {
+                "finished_at": {
+                    "type": "string"
+                },
+                "indexes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/admin.IndexProgress"
+                    }
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.configResponse": {
+            "type": "object",
+            "properties": {
+                "env": {
+                    "type": "string"
+                },
+                "settings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.Setting"
+                    }
+                }
+            }
+        },
+        "admin.maintenanceRequest": {
+            "type": "object",
+            "required": [
+                "enabled"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "admin.reloadResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/admin.Change"
+                    }
+                }
+            }
+        },
+        "anomaly.Anomaly": {
+            "type": "object",
+            "properties": {
+                "change_pct": {
+                    "type": "number"
+                },
+                "current_total": {
+                    "type": "integer"
+                },
+                "detected_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "previous_total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "catalog.Plan": {
+            "type": "object",
+            "properties": {
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "price_rub": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "description": "Rank orders the tiers of a service from the most limited up.",
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "Standard"
+                }
+            }
+        },
+        "catalog.PlanSuggestion": {
+            "type": "object",
+            "properties": {
+                "cheaper": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/catalog.Plan"
+                    }
+                },
+                "max_saving_rub": {
+                    "description": "MaxSavingRUB is the monthly saving of switching to the cheapest plan.",
+                    "type": "integer"
+                },
+                "plan_id": {
+                    "description": "PlanID is the plan the subscription says it is on, if any.",
+                    "type": "integer"
+                },
+                "price_rub": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "catalog.Popular": {
+            "type": "object",
+            "properties": {
+                "avg_price_rub": {
+                    "description": "AveragePrice is the mean monthly price, as in Price.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "refreshed_at": {
+                    "type": "string"
+                },
+                "subscribers": {
+                    "description": "Subscribers counts the distinct users running the service this month,\nSubscriptions their subscriptions to it.",
+                    "type": "integer"
+                },
+                "subscriptions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "catalog.Price": {
+            "type": "object",
+            "properties": {
+                "avg_price_rub": {
+                    "description": "AveragePrice is the mean monthly price of the subscriptions running\nthis month, over Samples of them. Prices billed per week, quarter or\nyear are spread over the months first.",
+                    "type": "integer"
+                },
+                "list_price_rub": {
+                    "description": "ListPrice is the provider's current price, maintained by hand.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price_samples": {
+                    "type": "integer"
+                },
+                "prices_refreshed_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "catalog.Suggestion": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "catalog",
+                        "history"
+                    ]
+                }
+            }
+        },
+        "catalog.errorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "config.Setting": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "fail"
+                    ]
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "fail"
+                    ]
+                }
+            }
+        },
+        "mailtmpl.previewResponse": {
+            "type": "object",
+            "properties": {
+                "html": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "digest"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "variables": {
+                    "description": "Variables is the sample data the preview was rendered with."
+                }
+            }
+        },
+        "mailtmpl.templateInfo": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "digest"
+                },
+                "overridden": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "middleware.MaintenanceConfig": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "monitor.Info": {
+            "type": "object",
+            "properties": {
+                "goroutines": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "subsystems": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/monitor.Subsystem"
+                    }
+                }
+            }
+        },
+        "monitor.Subsystem": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_failure": {
+                    "type": "string"
+                },
+                "last_success": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "running": {
+                    "type": "integer"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "values": {
+                    "description": "Values holds the observed gauges, such as queue depths.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "notify.DeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "DeliveryPending",
+                "DeliveryDelivered",
+                "DeliveryFailed"
+            ]
+        },
+        "quota.Quota": {
+            "type": "object",
+            "properties": {
+                "requests_per_minute": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "quota.setQuotaRequest": {
+            "type": "object",
+            "required": [
+                "requests_per_minute"
+            ],
+            "properties": {
+                "requests_per_minute": {
+                    "type": "integer"
+                }
+            }
+        },
+        "report.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "response_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "failed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/notify.DeliveryStatus"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "report.Frequency": {
+            "type": "string",
+            "enum": [
+                "weekly",
+                "monthly"
+            ],
+            "x-enum-varnames": [
+                "Weekly",
+                "Monthly"
+            ]
+        },
+        "report.Kind": {
+            "type": "string",
+            "enum": [
+                "summary",
+                "export"
+            ],
+            "x-enum-varnames": [
+                "KindSummary",
+                "KindExport"
+            ]
+        },
+        "report.Schedule": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "frequency": {
+                    "$ref": "#/definitions/report.Frequency"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/report.Kind"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "report.createScheduleRequest": {
+            "type": "object",
+            "required": [
+                "channel",
+                "destination",
+                "frequency",
+                "kind"
+            ],
+            "properties": {
+                "channel": {
+                    "type": "string",
+                    "enum": [
+                        "email",
+                        "webhook"
+                    ]
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "frequency": {
+                    "enum": [
+                        "weekly",
+                        "monthly"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/report.Frequency"
+                        }
+                    ]
+                },
+                "kind": {
+                    "enum": [
+                        "summary",
+                        "export"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/report.Kind"
+                        }
+                    ]
+                }
+            }
+        },
+        "report.errorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "slo.ClassReport": {
+            "type": "object",
+            "properties": {
+                "availability": {
+                    "$ref": "#/definitions/slo.Indicator"
+                },
+                "class": {
+                    "type": "string"
+                },
+                "latency": {
+                    "$ref": "#/definitions/slo.Indicator"
+                },
+                "latency_threshold_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "slo.Indicator": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "description": "Alerts lists the severities currently firing.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "bad": {
+                    "type": "integer"
+                },
+                "budget_remaining": {
+                    "description": "BudgetRemaining is the share of the error budget left; it goes negative\nonce the objective is missed.",
+                    "type": "number"
+                },
+                "burn_rates": {
+                    "description": "BurnRates maps a trailing window (\"1h\") to how many times faster than\nallowed the budget is being spent.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "requests": {
+                    "description": "Requests and Bad cover the whole SLO window.",
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "number"
+                }
+            }
+        },
+        "slo.Report": {
+            "type": "object",
+            "properties": {
+                "classes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slo.ClassReport"
+                    }
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "window": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.ArchivedAuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "update",
+                        "transfer",
+                        "undo"
+                    ]
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "after": {
+                    "$ref": "#/definitions/subscription.ArchivedSubscription"
+                },
+                "before": {
+                    "$ref": "#/definitions/subscription.ArchivedSubscription"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.ArchivedSubscription": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "type": "integer"
+                },
+                "billing_period": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
                 "end_month": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "end_month_inclusive": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "plan_id": {
+                    "type": "integer"
+                },
+                "price_rub": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "start_month": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.BulkDeleteJob": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "done"
+                    ]
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.BulkDeletePreview": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "sample": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.SubscriptionResponse"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.BulkResult": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "subscription.Dashboard": {
+            "type": "object",
+            "properties": {
+                "active_count": {
+                    "type": "integer"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "top_services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.ServiceSpend"
+                    }
+                },
+                "total_price": {
+                    "type": "integer"
+                },
+                "upcoming_renewals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.Renewal"
+                    }
+                }
+            }
+        },
+        "subscription.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "from": {},
+                "to": {}
+            }
+        },
+        "subscription.Granularity": {
+            "type": "string",
+            "enum": [
+                "month",
+                "quarter",
+                "year"
+            ],
+            "x-enum-varnames": [
+                "GranularityMonth",
+                "GranularityQuarter",
+                "GranularityYear"
+            ]
+        },
+        "subscription.LiveStats": {
+            "type": "object",
+            "properties": {
+                "by_service": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.ServiceCount"
+                    }
+                },
+                "refreshed_at": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.MonthlySpendStats": {
+            "type": "object",
+            "properties": {
+                "mean": {
+                    "type": "number"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "p50": {
+                    "type": "number"
+                },
+                "p90": {
+                    "type": "number"
+                },
+                "p99": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.PriceCheck": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ]
+                },
+                "catalog": {
+                    "description": "Catalog is null when the service is not in the catalog.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/catalog.Price"
+                        }
+                    ]
+                },
+                "difference_pct": {
+                    "type": "number"
+                },
+                "likely_outdated": {
+                    "description": "LikelyOutdated is set when the price is below the provider's list\nprice by more than the tolerance, which usually means the provider\nraised prices and the entry was never updated.",
+                    "type": "boolean"
+                },
+                "monthly_price_rub": {
+                    "description": "MonthlyPriceRUB is PriceRUB spread over the months of its billing\nperiod, the figure compared with the reference.",
+                    "type": "integer"
+                },
+                "price_rub": {
+                    "type": "integer"
+                },
+                "reference_price_rub": {
+                    "description": "ReferencePrice is the list price when curated, otherwise the average\nof enough running subscriptions.",
+                    "type": "integer"
+                },
+                "reference_source": {
+                    "type": "string",
+                    "enum": [
+                        "list",
+                        "average"
+                    ]
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
+                },
+                "verdict": {
+                    "type": "string",
+                    "enum": [
+                        "unknown",
+                        "ok",
+                        "below_reference",
+                        "above_reference"
+                    ]
+                }
+            }
+        },
+        "subscription.Renewal": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "price_rub": {
+                    "type": "integer"
+                },
+                "renews_on": {
+                    "type": "string",
+                    "example": "2025-04-01T00:00:00Z"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.RestoreResult": {
+            "type": "object",
+            "properties": {
+                "restored": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.SearchNode": {
+            "type": "object",
+            "properties": {
+                "and": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.SearchNode"
+                    }
+                },
+                "field": {
+                    "type": "string",
+                    "enum": [
+                        "service_name",
+                        "price_rub",
+                        "user_id",
+                        "start_month",
+                        "end_month",
+                        "created_at",
+                        "updated_at",
+                        "status",
+                        "tags"
+                    ]
+                },
+                "not": {
+                    "$ref": "#/definitions/subscription.SearchNode"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "eq",
+                        "ne",
+                        "gt",
+                        "gte",
+                        "lt",
+                        "lte",
+                        "between",
+                        "in",
+                        "like",
+                        "is_null",
+                        "is_not_null"
+                    ]
+                },
+                "or": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.SearchNode"
+                    }
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        },
+        "subscription.ServiceCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.ServiceSpend": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "total_price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.SpendDistribution": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "overall": {
+                    "$ref": "#/definitions/subscription.SpendStats"
+                },
+                "start": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "trend": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.MonthlySpendStats"
+                    }
+                }
+            }
+        },
+        "subscription.SpendStats": {
+            "type": "object",
+            "properties": {
+                "mean": {
+                    "type": "number"
+                },
+                "p50": {
+                    "type": "number"
+                },
+                "p90": {
+                    "type": "number"
+                },
+                "p99": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.SubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "type": "integer",
+                    "example": 999
+                },
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ]
+                },
+                "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "end_month": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "end_month_inclusive": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "string"
                 },
+                "locked": {
+                    "type": "boolean"
+                },
+                "plan_id": {
+                    "type": "integer"
+                },
                 "price_rub": {
                     "type": "integer"
                 },
                 "service_name": {
                     "type": "string"
                 },
-                "start_month": {
+                "slug": {
                     "type": "string"
                 },
+                "start_month": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "paused",
+                        "cancelled",
+                        "expired"
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "updated_at": {
                     "type": "string"
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.Warning"
+                    }
+                }
+            }
+        },
+        "subscription.TimeSeriesPoint": {
+            "type": "object",
+            "properties": {
+                "period": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "total_price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.TimelineItem": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.FieldChange"
+                    }
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated",
+                        "price_changed",
+                        "locked",
+                        "unlocked",
+                        "transferred",
+                        "undone",
+                        "started",
+                        "ended",
+                        "paused",
+                        "resumed",
+                        "cancelled"
+                    ]
+                }
+            }
+        },
+        "subscription.Warning": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "price 9000 is unusually high"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "price_high"
+                }
+            }
+        },
+        "subscription.batchGetRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "subscription.batchGetResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.SubscriptionResponse"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "subscription.batchSummaryRequest": {
+            "type": "object",
+            "required": [
+                "user_ids"
+            ],
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "subscription.batchSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "totals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.userTotal"
+                    }
+                }
+            }
+        },
+        "subscription.confirmBulkDeleteRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
                 }
             }
         },
         "subscription.createSubscriptionRequest": {
             "type": "object",
             "required": [
-                "price",
                 "service_name",
                 "start_date",
                 "user_id"
             ],
             "properties": {
+                "amount_minor": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "billing_period": {
+                    "description": "BillingPeriod is how often the price is charged; omitted means\nmonthly.",
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ]
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
                 "end_date": {
                     "type": "string"
                 },
+                "end_month_inclusive": {
+                    "description": "EndInclusive controls whether end_date itself is charged; omitted means\nthe deployment default.",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "ID is optional; clients that create records offline send their own\n(v4 or v7) UUID so retried syncs cannot create duplicates.",
+                    "type": "string",
+                    "example": "0193a4f2-7c1e-7d3a-9b1f-2f6c8e4d5a10"
+                },
+                "plan_id": {
+                    "description": "PlanID is a plan from GET /services/{name}/plans of this service.",
+                    "type": "integer"
+                },
                 "price": {
+                    "description": "PriceRUB is the price in whole units of Currency; AmountMinor, when\nset, gives it in minor units instead, for prices such as 9.99 USD.",
                     "type": "integer",
                     "minimum": 0
                 },
@@ -348,6 +4619,17 @@ const docTemplate = `{
                 "start_date": {
                     "type": "string"
                 },
+                "tags": {
+                    "description": "Tags are stored lowercased, at most 20 of up to 32 characters.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work",
+                        "video"
+                    ]
+                },
                 "user_id": {
                     "type": "string"
                 }
@@ -361,20 +4643,214 @@ const docTemplate = `{
                 }
             }
         },
-        "subscription.summaryResponse": {
+        "subscription.listResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.SubscriptionResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.projectedSummaryResponse": {
             "type": "object",
             "properties": {
+                "actual_to_date": {
+                    "type": "integer"
+                },
+                "projected_total": {
+                    "type": "integer"
+                },
                 "total_price": {
                     "type": "integer"
                 }
             }
         },
+        "subscription.searchRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "$ref": "#/definitions/subscription.SearchNode"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "sort": {
+                    "type": "string",
+                    "example": "price_rub desc"
+                }
+            }
+        },
+        "subscription.summaryPresetRequest": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string",
+                    "example": "2025-12"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string",
+                    "example": "2025-01"
+                }
+            }
+        },
+        "subscription.summaryPresetResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "work"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.takeoutArchive": {
+            "type": "object",
+            "properties": {
+                "exported_at": {
+                    "type": "string"
+                },
+                "history": {
+                    "description": "History is the audit log of the subscriptions, oldest first. It is\nfor the owner's records; restores ignore it.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.ArchivedAuditEntry"
+                    }
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.ArchivedSubscription"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.timeSeriesResponse": {
+            "type": "object",
+            "properties": {
+                "granularity": {
+                    "enum": [
+                        "month",
+                        "quarter",
+                        "year"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/subscription.Granularity"
+                        }
+                    ]
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.TimeSeriesPoint"
+                    }
+                }
+            }
+        },
+        "subscription.timelineResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.TimelineItem"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.transferRequest": {
+            "type": "object",
+            "required": [
+                "to_user_id"
+            ],
+            "properties": {
+                "to_user_id": {
+                    "type": "string"
+                }
+            }
+        },
         "subscription.updateSubscriptionRequest": {
             "type": "object",
             "properties": {
+                "amount_minor": {
+                    "type": "integer"
+                },
+                "billing_period": {
+                    "description": "BillingPeriod changes how often the price is charged.",
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ]
+                },
+                "currency": {
+                    "type": "string"
+                },
                 "end_date": {
                     "type": "string"
                 },
+                "end_month_inclusive": {
+                    "type": "boolean"
+                },
+                "locked": {
+                    "description": "Locked protects the subscription from deletion and changes until it\nis set back to false.",
+                    "type": "boolean"
+                },
+                "plan_id": {
+                    "description": "PlanID changes the catalog plan; 0 clears it.",
+                    "type": "integer"
+                },
                 "price": {
                     "type": "integer"
                 },
@@ -383,9 +4859,78 @@ const docTemplate = `{
                 },
                 "start_date": {
                     "type": "string"
+                },
+                "tags": {
+                    "description": "Tags replaces the tags; an empty list clears them.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "subscription.upsertSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "amount_minor": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "billing_period": {
+                    "description": "BillingPeriod omitted means monthly.",
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ]
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_date": {
+                    "description": "EndMonth omitted or empty means the subscription has no end.",
+                    "type": "string"
+                },
+                "end_month_inclusive": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "ID is only used when the upsert creates the subscription.",
+                    "type": "string",
+                    "example": "0193a4f2-7c1e-7d3a-9b1f-2f6c8e4d5a10"
+                },
+                "plan_id": {
+                    "description": "PlanID omitted means the subscription is on no particular plan.",
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "subscription.userTotal": {
+            "type": "object",
+            "properties": {
+                "total_price": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "JWT bearer token (\"Bearer \u003ctoken\u003e\"), required when authentication is configured",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
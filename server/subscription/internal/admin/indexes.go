@@ -0,0 +1,265 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ManagedIndex is an index the rebuild endpoint may recreate. Definition is
+// the part of CREATE INDEX after the index name.
+type ManagedIndex struct {
+	Name       string
+	Definition string
+}
+
+// ManagedIndexes are the search and summary indexes, as created by the
+// migrations.
+var ManagedIndexes = []ManagedIndex{
+	{"subscriptions_user_id_idx", "ON subscriptions (user_id)"},
+	{"subscriptions_service_name_lower_idx", "ON subscriptions (LOWER(service_name))"},
+	{"subscriptions_period_idx", "ON subscriptions (start_month, end_month)"},
+	{"subscriptions_service_name_trgm_idx", "ON subscriptions USING gin (service_name gin_trgm_ops)"},
+	{"service_catalog_name_trgm_idx", "ON service_catalog USING gin (name gin_trgm_ops)"},
+	{"subscriptions_user_created_idx", "ON subscriptions (user_id, created_at DESC, id)"},
+	{"subscriptions_ongoing_idx", "ON subscriptions (user_id, start_month) WHERE end_month IS NULL"},
+	{"subscriptions_user_service_start_idx", "ON subscriptions (user_id, LOWER(service_name), start_month)"},
+}
+
+// Index rebuild states.
+const (
+	IndexPending = "pending"
+	IndexRunning = "running"
+	IndexDone    = "done"
+	IndexFailed  = "failed"
+)
+
+// IndexProgress is the state of one index in a rebuild. Phase and the block
+// counters come from pg_stat_progress_create_index while the build runs.
+type IndexProgress struct {
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Action      string `json:"action,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Phase       string `json:"phase,omitempty"`
+	BlocksDone  int64  `json:"blocks_done,omitempty"`
+	BlocksTotal int64  `json:"blocks_total,omitempty"`
+}
+
+// RebuildStatus describes the current or last index rebuild.
+type RebuildStatus struct {
+	Running    bool            `json:"running"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Indexes    []IndexProgress `json:"indexes"`
+}
+
+// IndexRebuilder rebuilds the managed indexes without blocking writes:
+// existing indexes are reindexed concurrently, missing ones are created
+// concurrently, and invalid leftovers of a failed build are dropped first.
+type IndexRebuilder struct {
+	db     *sql.DB
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	status RebuildStatus
+	pid    int
+}
+
+// NewIndexRebuilder creates an IndexRebuilder on db.
+func NewIndexRebuilder(db *sql.DB, logger *slog.Logger) *IndexRebuilder {
+	return &IndexRebuilder{db: db, logger: logger, status: RebuildStatus{Indexes: []IndexProgress{}}}
+}
+
+// RegisterRoutes mounts GET and POST /indexes/rebuild on the admin group.
+func (r *IndexRebuilder) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/indexes/rebuild", r.get)
+	group.POST("/indexes/rebuild", r.start)
+}
+
+// Start begins a rebuild in the background. It returns false if one is
+// already running.
+func (r *IndexRebuilder) Start() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.status.Running {
+		return false
+	}
+	now := time.Now().UTC()
+	r.status = RebuildStatus{Running: true, StartedAt: &now, Indexes: make([]IndexProgress, len(ManagedIndexes))}
+	for i, idx := range ManagedIndexes {
+		r.status.Indexes[i] = IndexProgress{Name: idx.Name, State: IndexPending}
+	}
+	// The rebuild must outlive the request that started it.
+	go r.run(context.Background())
+	return true
+}
+
+// Status returns the current or last rebuild, with live progress for the
+// index being built.
+func (r *IndexRebuilder) Status(ctx context.Context) RebuildStatus {
+	r.mu.Lock()
+	status := r.status
+	status.Indexes = append([]IndexProgress(nil), r.status.Indexes...)
+	pid := r.pid
+	r.mu.Unlock()
+
+	if !status.Running || pid == 0 {
+		return status
+	}
+	for i := range status.Indexes {
+		if status.Indexes[i].State != IndexRunning {
+			continue
+		}
+		err := r.db.QueryRowContext(ctx,
+			`SELECT phase, blocks_done, blocks_total FROM pg_stat_progress_create_index WHERE pid = $1`, pid,
+		).Scan(&status.Indexes[i].Phase, &status.Indexes[i].BlocksDone, &status.Indexes[i].BlocksTotal)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn("read index build progress failed", "error", err)
+		}
+	}
+	return status
+}
+
+func (r *IndexRebuilder) run(ctx context.Context) {
+	defer func() {
+		r.mu.Lock()
+		now := time.Now().UTC()
+		r.status.Running = false
+		r.status.FinishedAt = &now
+		r.pid = 0
+		r.mu.Unlock()
+	}()
+
+	// Concurrent builds cannot run in a transaction, and the progress view is
+	// keyed by backend, so everything runs on one dedicated connection.
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		r.failAll(fmt.Errorf("acquire connection: %w", err))
+		return
+	}
+	defer conn.Close()
+
+	var pid int
+	if err := conn.QueryRowContext(ctx, `SELECT pg_backend_pid()`).Scan(&pid); err != nil {
+		r.failAll(fmt.Errorf("read backend pid: %w", err))
+		return
+	}
+	// Index builds on a large table can legitimately take longer than any
+	// per-query limit configured for API traffic.
+	if _, err := conn.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		r.failAll(fmt.Errorf("disable statement timeout: %w", err))
+		return
+	}
+	// The connection goes back to the pool afterwards and must get the
+	// session limit back.
+	defer conn.ExecContext(context.WithoutCancel(ctx), `RESET statement_timeout`)
+	r.mu.Lock()
+	r.pid = pid
+	r.mu.Unlock()
+
+	for i, idx := range ManagedIndexes {
+		r.update(i, func(p *IndexProgress) { p.State = IndexRunning })
+		action, err := rebuildIndex(ctx, conn, idx)
+		if err != nil {
+			r.logger.Error("index rebuild failed", "index", idx.Name, "action", action, "error", err)
+			r.update(i, func(p *IndexProgress) { p.State, p.Action, p.Error = IndexFailed, action, err.Error() })
+			continue
+		}
+		r.logger.Info("index rebuilt", "index", idx.Name, "action", action)
+		r.update(i, func(p *IndexProgress) { p.State, p.Action = IndexDone, action })
+	}
+}
+
+// rebuildIndex brings idx up to date and returns what it did.
+func rebuildIndex(ctx context.Context, conn *sql.Conn, idx ManagedIndex) (string, error) {
+	var valid sql.NullBool
+	err := conn.QueryRowContext(ctx, `
+        SELECT i.indisvalid
+        FROM pg_class c
+        JOIN pg_index i ON i.indexrelid = c.oid
+        WHERE c.relname = $1 AND c.relkind = 'i'`, idx.Name,
+	).Scan(&valid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("inspect index: %w", err)
+	}
+
+	name := pq.QuoteIdentifier(idx.Name)
+	switch {
+	case valid.Valid && valid.Bool:
+		if _, err := conn.ExecContext(ctx, `REINDEX INDEX CONCURRENTLY `+name); err != nil {
+			return "reindex", err
+		}
+		return "reindex", nil
+	case valid.Valid:
+		// An invalid index is what a failed concurrent build leaves behind;
+		// it costs writes without serving reads, so replace it.
+		if _, err := conn.ExecContext(ctx, `DROP INDEX CONCURRENTLY IF EXISTS `+name); err != nil {
+			return "recreate", err
+		}
+		if _, err := conn.ExecContext(ctx, `CREATE INDEX CONCURRENTLY `+name+` `+idx.Definition); err != nil {
+			return "recreate", err
+		}
+		return "recreate", nil
+	default:
+		if _, err := conn.ExecContext(ctx, `CREATE INDEX CONCURRENTLY IF NOT EXISTS `+name+` `+idx.Definition); err != nil {
+			return "create", err
+		}
+		return "create", nil
+	}
+}
+
+func (r *IndexRebuilder) update(i int, fn func(*IndexProgress)) {
+	r.mu.Lock()
+	fn(&r.status.Indexes[i])
+	r.mu.Unlock()
+}
+
+func (r *IndexRebuilder) failAll(err error) {
+	r.logger.Error("index rebuild aborted", "error", err)
+	r.mu.Lock()
+	for i := range r.status.Indexes {
+		r.status.Indexes[i].State = IndexFailed
+		r.status.Indexes[i].Error = err.Error()
+	}
+	r.mu.Unlock()
+}
+
+// get godoc
+// @Summary Index rebuild progress
+// @Description State of the current or last index rebuild, with the build phase and block counts of the index in progress
+// @Tags admin
+// @Produce json
+// @Success 200 {object} RebuildStatus
+// @ID getIndexRebuild
+// @Router /admin/indexes/rebuild [get]
+func (r *IndexRebuilder) get(c *gin.Context) {
+	c.JSON(http.StatusOK, r.Status(c.Request.Context()))
+}
+
+// start godoc
+// @Summary Rebuild search and summary indexes
+// @Description Reindexes the search and summary indexes concurrently, creating missing ones, without blocking writes. Runs in the background; poll GET for progress.
+// @Tags admin
+// @Produce json
+// @Success 202 {object} RebuildStatus
+// @Failure 409 {object} map[string]string
+// @ID startIndexRebuild
+// @Router /admin/indexes/rebuild [post]
+func (r *IndexRebuilder) start(c *gin.Context) {
+	if !r.Start() {
+		c.JSON(http.StatusConflict, gin.H{"error": "an index rebuild is already running"})
+		return
+	}
+	r.logger.Warn("index rebuild started", "source", "api")
+	c.JSON(http.StatusAccepted, r.Status(c.Request.Context()))
+}
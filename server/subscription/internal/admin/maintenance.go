@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/middleware"
+)
+
+// MaintenanceHandler exposes the maintenance-mode toggle.
+type MaintenanceHandler struct {
+	mode   *middleware.Maintenance
+	logger *slog.Logger
+}
+
+// NewMaintenanceHandler creates a MaintenanceHandler for mode.
+func NewMaintenanceHandler(mode *middleware.Maintenance, logger *slog.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{mode: mode, logger: logger}
+}
+
+// RegisterRoutes mounts GET and PUT /maintenance on the admin group.
+func (h *MaintenanceHandler) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/maintenance", h.get)
+	group.PUT("/maintenance", h.set)
+}
+
+type maintenanceRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Message string `json:"message"`
+}
+
+// get godoc
+// @Summary Maintenance mode state
+// @Tags admin
+// @Produce json
+// @Success 200 {object} middleware.MaintenanceConfig
+// @ID getMaintenance
+// @Router /admin/maintenance [get]
+func (h *MaintenanceHandler) get(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.State())
+}
+
+// set godoc
+// @Summary Toggle maintenance mode
+// @Description While enabled, mutating endpoints return 503 and reads keep working
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body maintenanceRequest true "Desired state"
+// @Success 200 {object} middleware.MaintenanceConfig
+// @Failure 400 {object} map[string]string
+// @ID setMaintenance
+// @Router /admin/maintenance [put]
+func (h *MaintenanceHandler) set(c *gin.Context) {
+	var req maintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	next := h.mode.State()
+	next.Enabled = *req.Enabled
+	if req.Message != "" {
+		next.Message = req.Message
+	}
+	h.mode.Set(next)
+
+	h.logger.Warn("maintenance mode changed", "source", "api", "enabled", next.Enabled, "message", next.Message)
+	c.JSON(http.StatusOK, h.mode.State())
+}
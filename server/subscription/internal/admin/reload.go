@@ -0,0 +1,243 @@
+// Package admin hosts operational endpoints that are not part of the public API.
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/config"
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+)
+
+// ActionConfigReload is the audit_log action of an applied reload.
+const ActionConfigReload = "config.reload"
+
+// Change records one setting altered by a reload.
+type Change struct {
+	Setting string `json:"setting"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+}
+
+// reloadable lists the settings that may change without a restart. Anything
+// else (ports, database credentials) keeps its startup value.
+var reloadable = []reloadableSetting{
+	{"LOG_LEVEL", func(c config.Config) string { return c.Log.Level }},
+	{"LIST_DEFAULT_SORT", func(c config.Config) string { return c.List.DefaultSort }},
+	{"LIST_DEFAULT_LIMIT", func(c config.Config) string { return fmt.Sprint(c.List.DefaultLimit) }},
+	{"LIST_MAX_LIMIT", func(c config.Config) string { return fmt.Sprint(c.List.MaxLimit) }},
+	{"REDACT_FIELDS", func(c config.Config) string { return fmt.Sprint(c.List.RedactFields) }},
+	{"LOADSHED_MAX_IN_FLIGHT", func(c config.Config) string { return fmt.Sprint(c.LoadShed.MaxInFlight) }},
+	{"LOADSHED_P99_BUDGET", func(c config.Config) string { return c.LoadShed.P99Budget.String() }},
+	{"LOADSHED_RETRY_AFTER", func(c config.Config) string { return c.LoadShed.RetryAfter.String() }},
+	{"MAINTENANCE_MODE", func(c config.Config) string { return fmt.Sprint(c.Maintenance.Enabled) }},
+	{"MAINTENANCE_MESSAGE", func(c config.Config) string { return c.Maintenance.Message }},
+}
+
+type reloadableSetting struct {
+	name string
+	get  func(config.Config) string
+}
+
+// Reloader re-reads configuration and applies the non-critical settings.
+type Reloader struct {
+	mu      sync.Mutex
+	current config.Config
+	load    func() (config.Config, error)
+	apply   func(config.Config) error
+	logger  *slog.Logger
+	audit   *sql.DB
+}
+
+// NewReloader creates a Reloader. load produces a fresh configuration and
+// apply pushes it into the running components; apply must validate before
+// changing anything so a rejected reload leaves the old settings in place.
+func NewReloader(initial config.Config, load func() (config.Config, error), apply func(config.Config) error, logger *slog.Logger) *Reloader {
+	return &Reloader{current: initial, load: load, apply: apply, logger: logger}
+}
+
+// UseAuditLog records every applied reload in audit_log, with the old and
+// new values of the changed settings and the caller that asked for it.
+func (r *Reloader) UseAuditLog(db *sql.DB) {
+	r.audit = db
+}
+
+// Reload loads the configuration, applies it and returns what changed.
+func (r *Reloader) Reload(ctx context.Context, source string) ([]Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	var changes []Change
+	for _, setting := range reloadable {
+		oldValue, newValue := setting.get(r.current), setting.get(next)
+		if oldValue != newValue {
+			changes = append(changes, Change{Setting: setting.name, Old: oldValue, New: newValue})
+		}
+	}
+	if len(changes) == 0 {
+		r.logger.Info("config reload: no changes", "source", source)
+		return []Change{}, nil
+	}
+
+	if err := r.apply(next); err != nil {
+		return nil, fmt.Errorf("apply config: %w", err)
+	}
+
+	for _, change := range changes {
+		r.logger.Info("config reload: setting changed",
+			"source", source,
+			"setting", change.Setting,
+			"old", change.Old,
+			"new", change.New,
+		)
+	}
+	r.current = mergeReloadable(r.current, next)
+	// The new settings are live whether or not the entry is written, so a
+	// failure is logged rather than reported as a failed reload.
+	if err := r.recordAudit(ctx, source, changes); err != nil {
+		r.logger.Error("config reload: audit entry failed", "source", source, "error", err)
+	}
+	return changes, nil
+}
+
+func (r *Reloader) recordAudit(ctx context.Context, source string, changes []Change) error {
+	if r.audit == nil {
+		return nil
+	}
+	before := map[string]string{"source": source}
+	after := map[string]string{"source": source}
+	for _, change := range changes {
+		before[change.Setting] = change.Old
+		after[change.Setting] = change.New
+	}
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	var actor any
+	if caller, ok := identity.FromContext(ctx); ok {
+		actor = caller.UserID
+	}
+	_, err = r.audit.ExecContext(ctx,
+		`INSERT INTO audit_log (action, actor_id, before, after) VALUES ($1, $2, $3, $4)`,
+		ActionConfigReload, actor, string(beforeJSON), string(afterJSON),
+	)
+	return err
+}
+
+// mergeReloadable copies only the reloadable sections, so startup-only settings
+// keep reporting the values the process is actually running with.
+func mergeReloadable(current, next config.Config) config.Config {
+	current.Log = next.Log
+	current.List = next.List
+	current.LoadShed = next.LoadShed
+	current.Maintenance.Enabled = next.Maintenance.Enabled
+	current.Maintenance.Message = next.Maintenance.Message
+
+	reloaded := make(map[string]config.Setting)
+	for _, s := range next.Settings {
+		reloaded[s.Key] = s
+	}
+	settings := make([]config.Setting, len(current.Settings))
+	for i, s := range current.Settings {
+		if slices.ContainsFunc(reloadable, func(setting reloadableSetting) bool { return setting.name == s.Key }) {
+			if next, ok := reloaded[s.Key]; ok {
+				s = next
+			}
+		}
+		settings[i] = s
+	}
+	current.Settings = settings
+	return current
+}
+
+// WatchSignals reloads the configuration on SIGHUP until ctx is cancelled.
+func (r *Reloader) WatchSignals(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if _, err := r.Reload(ctx, "sighup"); err != nil {
+					r.logger.Error("config reload failed", "source", "sighup", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// RegisterRoutes mounts GET /config and POST /config/reload on the admin
+// group.
+func (r *Reloader) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/config", r.show)
+	group.POST("/config/reload", r.reload)
+}
+
+type configResponse struct {
+	Env      string           `json:"env"`
+	Settings []config.Setting `json:"settings"`
+}
+
+// show godoc
+// @Summary Effective configuration
+// @Description Every setting the process runs with, where it came from (default, profile, file or env) and secrets masked. Reloaded settings show their new values.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} configResponse
+// @ID getConfig
+// @Router /admin/config [get]
+func (r *Reloader) show(c *gin.Context) {
+	r.mu.Lock()
+	current := r.current
+	r.mu.Unlock()
+	c.JSON(http.StatusOK, configResponse{Env: current.App.Env, Settings: current.Settings})
+}
+
+type reloadResponse struct {
+	Changes []Change `json:"changes"`
+}
+
+// reload godoc
+// @Summary Reload configuration
+// @Description Re-read non-critical settings (log level, pagination, load shedding) without a restart. Applied changes are recorded in the audit log.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} reloadResponse
+// @Failure 400 {object} map[string]string
+// @ID reloadConfig
+// @Router /admin/config/reload [post]
+func (r *Reloader) reload(c *gin.Context) {
+	changes, err := r.Reload(c.Request.Context(), "api")
+	if err != nil {
+		r.logger.Error("config reload failed", "source", "api", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reloadResponse{Changes: changes})
+}
@@ -0,0 +1,161 @@
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/lease"
+	"github.com/beheryahmed1991/subscription-service.git/internal/mailtmpl"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+	"github.com/beheryahmed1991/subscription-service.git/internal/report"
+)
+
+// Recipients lists where a user receives notifications. Users are told about
+// anomalies on the destinations of their report schedules.
+type Recipients interface {
+	ListByUser(context.Context, uuid.UUID) ([]report.Schedule, error)
+}
+
+// Enqueuer is the part of notify.Pool the detector needs.
+type Enqueuer interface {
+	Enqueue(notify.Message) error
+}
+
+// Detector periodically analyzes the last closed month for spend jumps.
+type Detector struct {
+	store      Store
+	thresholds Thresholds
+	interval   time.Duration
+	logger     *slog.Logger
+
+	recipients Recipients
+	out        Enqueuer
+	monitor    *monitor.Monitor
+	leases     *lease.Leases
+	templates  *mailtmpl.Registry
+}
+
+// NewDetector creates a Detector that runs every interval, daily by default.
+func NewDetector(store Store, thresholds Thresholds, interval time.Duration, logger *slog.Logger) *Detector {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &Detector{store: store, thresholds: thresholds, interval: interval, logger: logger, templates: mailtmpl.Builtin()}
+}
+
+// NotifyUsers makes the detector tell users about their own anomalies.
+func (d *Detector) NotifyUsers(recipients Recipients, out Enqueuer) {
+	d.recipients, d.out = recipients, out
+}
+
+// UseTemplates renders anomaly emails from templates instead of the
+// built-in copy.
+func (d *Detector) UseTemplates(templates *mailtmpl.Registry) {
+	d.templates = templates
+}
+
+// ReportTo records every analysis on m.
+func (d *Detector) ReportTo(m *monitor.Monitor) {
+	d.monitor = m
+}
+
+// UseLeases runs the analysis on one replica at a time through l.
+func (d *Detector) UseLeases(l *lease.Leases) {
+	d.leases = l
+}
+
+// Run analyzes until ctx is cancelled.
+func (d *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		err := d.monitor.Track("anomaly_detector", func() error {
+			return d.leases.Do(ctx, "anomaly_detector", d.interval, func() error { return d.Analyze(ctx) })
+		})
+		if err != nil && ctx.Err() == nil {
+			d.logger.Error("spend anomaly analysis failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Analyze flags the anomalies of the last closed month. Re-running it for a
+// month already analyzed records and notifies nothing new.
+func (d *Detector) Analyze(ctx context.Context) error {
+	month, err := d.store.LastClosedMonth(ctx)
+	if err != nil || month == nil {
+		return err
+	}
+
+	found, err := d.store.Detect(ctx, *month, d.thresholds)
+	if err != nil {
+		return err
+	}
+	if len(found) > 0 {
+		d.logger.Warn("spend anomalies detected", "month", month.Format("2006-01"), "count", len(found))
+	}
+	if d.out == nil {
+		return nil
+	}
+	for _, a := range found {
+		if err := d.notify(ctx, a); err != nil {
+			d.logger.Error("spend anomaly notification failed", "user_id", a.UserID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (d *Detector) notify(ctx context.Context, a Anomaly) error {
+	schedules, err := d.recipients.ListByUser(ctx, a.UserID)
+	if err != nil {
+		return fmt.Errorf("list recipients: %w", err)
+	}
+
+	email, err := d.templates.Render(mailtmpl.PriceIncrease, mailtmpl.PriceIncreaseData{
+		Month:       a.Month.Format("2006-01"),
+		PreviousRUB: a.PreviousTotal,
+		CurrentRUB:  a.CurrentTotal,
+		ChangePct:   a.ChangePct,
+	})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(schedules))
+	for _, s := range schedules {
+		key := s.Channel + "|" + s.Destination
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		msg := notify.Message{
+			Channel:     s.Channel,
+			Destination: s.Destination,
+			Kind:        "anomaly.spend",
+			Subject:     email.Subject,
+		}
+		if s.Channel == notify.ChannelEmail {
+			msg.ContentType, msg.Payload = "text/html; charset=utf-8", []byte(email.HTML)
+		} else if msg.Payload, err = json.Marshal(a); err != nil {
+			return fmt.Errorf("encode: %w", err)
+		} else {
+			msg.WebhookID = s.ID
+		}
+		if err := d.out.Enqueue(msg); err != nil {
+			return fmt.Errorf("enqueue: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package anomaly
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// Handler exposes recorded anomalies to operators.
+type Handler struct {
+	store  Store
+	logger *slog.Logger
+}
+
+// NewHandler creates a Handler backed by store.
+func NewHandler(store Store, logger *slog.Logger) *Handler {
+	return &Handler{store: store, logger: logger}
+}
+
+// RegisterRoutes mounts GET /anomalies on the admin group.
+func (h *Handler) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/anomalies", h.list)
+}
+
+// list godoc
+// @Summary Spend anomalies
+// @Description Users whose spend in a closed month rose above the configured threshold over the month before
+// @Tags admin
+// @Produce json
+// @Param month query string false "Month (YYYY-MM)"
+// @Param user_id query string false "User ID"
+// @Param limit query int false "Maximum rows (<=500)" default(50)
+// @Param sort query string false "Order, e.g. change_pct desc; sortable: month, change_pct, current_total, detected_at"
+// @Success 200 {array} Anomaly
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @ID listSpendAnomalies
+// @Router /admin/anomalies [get]
+func (h *Handler) list(c *gin.Context) {
+	filter := ListFilter{Limit: defaultListLimit}
+
+	if value := strings.TrimSpace(c.Query("month")); value != "" {
+		month, err := time.Parse("2006-01", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be in YYYY-MM format"})
+			return
+		}
+		filter.Month = &month
+	}
+	if value := c.Query("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		filter.UserID = &userID
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		filter.Limit = min(limit, maxListLimit)
+	}
+
+	if value := c.Query("sort"); value != "" {
+		sort, err := listOrdering.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Sort = sort
+	}
+
+	anomalies, err := h.store.List(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to list spend anomalies", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, anomalies)
+}
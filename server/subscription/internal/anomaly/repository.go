@@ -0,0 +1,163 @@
+// Package anomaly flags users whose monthly spend jumps sharply, from the
+// closed months in the charges ledger.
+package anomaly
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/db"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+// Anomaly is a month whose spend rose sharply over the month before.
+type Anomaly struct {
+	ID            int64       `json:"id"`
+	UserID        uuid.UUID   `json:"user_id"`
+	Month         types.Month `json:"month" swaggertype:"string" example:"2025-03-01T00:00:00Z"`
+	PreviousTotal int64       `json:"previous_total"`
+	CurrentTotal  int64       `json:"current_total"`
+	ChangePct     float64     `json:"change_pct"`
+	DetectedAt    time.Time   `json:"detected_at"`
+}
+
+// Thresholds decide what counts as an anomaly. A user is flagged when spend
+// grew by more than ChangePct percent and by at least MinIncrease rubles.
+type Thresholds struct {
+	ChangePct   int
+	MinIncrease int64
+}
+
+// ListFilter narrows List. Zero values mean no filter; a zero Sort keeps the
+// default order.
+type ListFilter struct {
+	Month  *time.Time
+	UserID *uuid.UUID
+	Limit  int
+	Sort   db.Sort
+}
+
+// listOrdering whitelists the columns List may order by. By default the
+// newest months come first, largest change first within a month.
+var listOrdering = db.NewOrderBy(
+	[]string{"month", "change_pct", "current_total", "detected_at"},
+	[]db.Sort{{Column: "month", Desc: true}, {Column: "change_pct", Desc: true}},
+	"id",
+)
+
+// Store persists detected anomalies.
+type Store interface {
+	LastClosedMonth(ctx context.Context) (*time.Time, error)
+	Detect(ctx context.Context, month time.Time, t Thresholds) ([]Anomaly, error)
+	List(ctx context.Context, filter ListFilter) ([]Anomaly, error)
+}
+
+var anomalyColumns = `id, user_id, month, previous_total, current_total, change_pct::float8, detected_at`
+
+// detectSQL compares per-user totals of month $1 with month $2 and records
+// the jumps. Users with no spend the month before are not flagged: there is
+// no rate of change to speak of. Only newly recorded rows are returned.
+var detectSQL = `
+WITH totals AS (
+    SELECT user_id, month, SUM(amount) AS total
+    FROM charges
+    WHERE month IN ($1::date, $2::date)
+    GROUP BY user_id, month
+),
+pairs AS (
+    SELECT cur.user_id, prev.total AS previous_total, cur.total AS current_total
+    FROM totals cur
+    JOIN totals prev ON prev.user_id = cur.user_id AND prev.month = $2::date
+    WHERE cur.month = $1::date AND prev.total > 0
+)
+INSERT INTO spend_anomalies (user_id, month, previous_total, current_total, change_pct)
+SELECT user_id, $1::date, previous_total, current_total,
+       round((current_total - previous_total) * 100.0 / previous_total, 2)
+FROM pairs
+WHERE (current_total - previous_total) * 100.0 / previous_total > $3
+  AND current_total - previous_total >= $4
+ON CONFLICT (user_id, month) DO NOTHING
+RETURNING ` + anomalyColumns + `;
+`
+
+// Repository is the postgres implementation of Store.
+type Repository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewRepository wires the DB and logger into a Repository.
+func NewRepository(db *sql.DB, logger *slog.Logger) *Repository {
+	return &Repository{db: db, logger: logger}
+}
+
+// LastClosedMonth returns the latest month in the charges ledger, or nil
+// when it is empty.
+func (r *Repository) LastClosedMonth(ctx context.Context) (*time.Time, error) {
+	var month sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(month) FROM charge_months`).Scan(&month); err != nil {
+		return nil, fmt.Errorf("read last closed month: %w", err)
+	}
+	if !month.Valid {
+		return nil, nil
+	}
+	return &month.Time, nil
+}
+
+// Detect records the anomalies of month against the month before and returns
+// the ones not recorded by an earlier run.
+func (r *Repository) Detect(ctx context.Context, month time.Time, t Thresholds) ([]Anomaly, error) {
+	previous := month.AddDate(0, -1, 0)
+	rows, err := r.db.QueryContext(ctx, detectSQL, month, previous, t.ChangePct, t.MinIncrease)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("detect spend anomalies failed", "error", err)
+		}
+		return nil, fmt.Errorf("detect spend anomalies: %w", err)
+	}
+	return scanAnomalies(rows)
+}
+
+// List returns recorded anomalies in filter.Sort order.
+func (r *Repository) List(ctx context.Context, filter ListFilter) ([]Anomaly, error) {
+	var month, user interface{}
+	if filter.Month != nil {
+		month = *filter.Month
+	}
+	if filter.UserID != nil {
+		user = *filter.UserID
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT `+anomalyColumns+`
+        FROM spend_anomalies
+        WHERE ($1::date IS NULL OR month = $1::date)
+          AND ($2::uuid IS NULL OR user_id = $2::uuid)
+        ORDER BY `+listOrdering.SQL(filter.Sort)+`
+        LIMIT $3`, month, user, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("list spend anomalies: %w", err)
+	}
+	return scanAnomalies(rows)
+}
+
+func scanAnomalies(rows *sql.Rows) ([]Anomaly, error) {
+	defer rows.Close()
+
+	anomalies := []Anomaly{}
+	for rows.Next() {
+		var a Anomaly
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Month, &a.PreviousTotal, &a.CurrentTotal, &a.ChangePct, &a.DetectedAt); err != nil {
+			return nil, fmt.Errorf("scan spend anomaly: %w", err)
+		}
+		anomalies = append(anomalies, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return anomalies, nil
+}
@@ -0,0 +1,153 @@
+// Package apidocs serves the generated OpenAPI spec as two documents: the
+// public API at /swagger and the admin API at /admin/swagger. swag generates
+// a single spec from the handler annotations, so it is split at startup by
+// path instead of maintaining two sets of annotations.
+package apidocs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag"
+	"golang.org/x/net/webdav"
+)
+
+// Instance names the split documents are registered under with swag.
+const (
+	PublicInstance = "public"
+	AdminInstance  = "admin"
+)
+
+// document is a swag.Swagger whose content is set after registration, since
+// swag panics when a name is registered twice but the spec depends on the
+// configured host.
+type document struct {
+	doc atomic.Pointer[string]
+}
+
+func (d *document) ReadDoc() string {
+	if doc := d.doc.Load(); doc != nil {
+		return *doc
+	}
+	return "{}"
+}
+
+var public, admin = &document{}, &document{}
+
+func init() {
+	swag.Register(PublicInstance, public)
+	swag.Register(AdminInstance, admin)
+}
+
+// IsAdminPath reports whether a spec path belongs to the admin document.
+func IsAdminPath(path string) bool {
+	return path == "/admin" || strings.HasPrefix(path, "/admin/")
+}
+
+// Publish splits spec into the public and admin documents.
+func Publish(spec swag.Swagger) error {
+	publicDoc, adminDoc, err := Split(spec.ReadDoc(), IsAdminPath)
+	if err != nil {
+		return err
+	}
+	public.doc.Store(&publicDoc)
+	admin.doc.Store(&adminDoc)
+	return nil
+}
+
+// Split returns doc with only the paths isAdmin rejects and doc with only
+// the paths it accepts. Each keeps just the definitions its paths reference,
+// so model names do not leak admin endpoints either.
+func Split(doc string, isAdmin func(path string) bool) (publicDoc, adminDoc string, err error) {
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+		return "", "", fmt.Errorf("parse spec: %w", err)
+	}
+	paths, _ := spec["paths"].(map[string]any)
+	definitions, _ := spec["definitions"].(map[string]any)
+
+	publicPaths, adminPaths := map[string]any{}, map[string]any{}
+	for path, item := range paths {
+		if isAdmin(path) {
+			adminPaths[path] = item
+		} else {
+			publicPaths[path] = item
+		}
+	}
+
+	if publicDoc, err = render(spec, publicPaths, definitions); err != nil {
+		return "", "", err
+	}
+	if adminDoc, err = render(spec, adminPaths, definitions); err != nil {
+		return "", "", err
+	}
+	return publicDoc, adminDoc, nil
+}
+
+func render(spec, paths, definitions map[string]any) (string, error) {
+	out := make(map[string]any, len(spec))
+	for k, v := range spec {
+		out[k] = v
+	}
+	out["paths"] = paths
+	if definitions != nil {
+		out["definitions"] = referenced(paths, definitions)
+	}
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("encode spec: %w", err)
+	}
+	return string(raw), nil
+}
+
+// referenced returns the definitions reachable from paths through $ref,
+// following references between definitions.
+func referenced(paths, definitions map[string]any) map[string]any {
+	const prefix = "#/definitions/"
+	kept := map[string]any{}
+	var walk func(node any)
+	walk = func(node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, prefix) {
+				name := strings.TrimPrefix(ref, prefix)
+				if def, ok := definitions[name]; ok {
+					if _, seen := kept[name]; !seen {
+						kept[name] = def
+						walk(def)
+					}
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(paths)
+	return kept
+}
+
+// PublicHandler serves the Swagger UI for the public document.
+func PublicHandler() gin.HandlerFunc {
+	return ginSwagger.WrapHandler(newFiles(), ginSwagger.InstanceName(PublicInstance))
+}
+
+// AdminHandler serves the Swagger UI for the admin document.
+func AdminHandler() gin.HandlerFunc {
+	return ginSwagger.WrapHandler(newFiles(), ginSwagger.InstanceName(AdminInstance))
+}
+
+// newFiles returns a handler for the UI assets. Every mount needs its own,
+// because gin-swagger fixes the handler's prefix on the first request.
+func newFiles() *webdav.Handler {
+	return &webdav.Handler{FileSystem: swaggerFiles.FS, LockSystem: webdav.NewMemLS()}
+}
@@ -0,0 +1,54 @@
+package apidocs_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/beheryahmed1991/subscription-service.git/docs"
+	"github.com/beheryahmed1991/subscription-service.git/internal/apidocs"
+)
+
+// TestSplitGeneratedSpec splits the committed spec, so a stale docs/ that
+// lost the admin endpoints or leaks them into the public document fails.
+func TestSplitGeneratedSpec(t *testing.T) {
+	publicDoc, adminDoc, err := apidocs.Split(docs.SwaggerInfo.ReadDoc(), apidocs.IsAdminPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, admin := paths(t, publicDoc), paths(t, adminDoc)
+	if len(admin) == 0 {
+		t.Fatal("admin document has no paths; run make swagger")
+	}
+	for _, path := range []string{"/subscriptions", "/subscriptions/{id}", "/subscriptions/summary"} {
+		if !public[path] {
+			t.Errorf("public document lacks %s; run make swagger", path)
+		}
+	}
+	for path := range public {
+		if apidocs.IsAdminPath(path) {
+			t.Errorf("public document lists admin path %s", path)
+		}
+	}
+	for path := range admin {
+		if !strings.HasPrefix(path, "/admin") {
+			t.Errorf("admin document lists public path %s", path)
+		}
+	}
+}
+
+func paths(t *testing.T, doc string) map[string]bool {
+	t.Helper()
+	var spec struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]bool, len(spec.Paths))
+	for path := range spec.Paths {
+		out[path] = true
+	}
+	return out
+}
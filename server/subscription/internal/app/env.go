@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/config"
+)
+
+// LoadConfig reads the .env files and the environment.
+func LoadConfig() (config.Config, error) {
+	_ = godotenv.Load(envFiles...)
+	return config.Load()
+}
+
+// envFiles are the .env files read at startup and on reload. A key set in an
+// earlier file wins over a later one.
+var envFiles = []string{"../.env", ".env"}
+
+// processEnv holds the variables set before any .env file was read. They win
+// over the files at startup and on every reload.
+var processEnv = func() map[string]bool {
+	keys := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		keys[key] = true
+	}
+	return keys
+}()
+
+var (
+	dotenvMu sync.Mutex
+	// dotenvKeys are the keys the last reload took from the files, so keys
+	// removed from them are unset again.
+	dotenvKeys map[string]bool
+)
+
+// ReloadConfig re-reads the .env files with the same precedence as
+// LoadConfig: the process environment wins and the files only fill the rest.
+func ReloadConfig() (config.Config, error) {
+	dotenvMu.Lock()
+	defer dotenvMu.Unlock()
+
+	values := make(map[string]string)
+	for _, file := range envFiles {
+		read, err := godotenv.Read(file)
+		if err != nil {
+			continue
+		}
+		for key, value := range read {
+			if _, ok := values[key]; !ok {
+				values[key] = value
+			}
+		}
+	}
+
+	previous := dotenvKeys
+	if previous == nil {
+		// Before the first reload, every key set since start came from the
+		// files.
+		previous = make(map[string]bool)
+		for _, kv := range os.Environ() {
+			if key, _, _ := strings.Cut(kv, "="); !processEnv[key] {
+				previous[key] = true
+			}
+		}
+	}
+	dotenvKeys = make(map[string]bool)
+	for key, value := range values {
+		if processEnv[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return config.Config{}, fmt.Errorf("set %s: %w", key, err)
+		}
+		dotenvKeys[key] = true
+	}
+	for key := range previous {
+		if !dotenvKeys[key] {
+			os.Unsetenv(key)
+		}
+	}
+	return config.Load()
+}
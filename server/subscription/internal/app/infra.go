@@ -0,0 +1,429 @@
+// Package app assembles the application's components. Entry points (the HTTP
+// server, CLIs, tests) build an Infra and then only the parts they need, so a
+// new subsystem is wired here once instead of in every main package.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/anomaly"
+	"github.com/beheryahmed1991/subscription-service.git/internal/auth"
+	"github.com/beheryahmed1991/subscription-service.git/internal/bus"
+	"github.com/beheryahmed1991/subscription-service.git/internal/catalog"
+	"github.com/beheryahmed1991/subscription-service.git/internal/config"
+	"github.com/beheryahmed1991/subscription-service.git/internal/db"
+	"github.com/beheryahmed1991/subscription-service.git/internal/health"
+	"github.com/beheryahmed1991/subscription-service.git/internal/idempotency"
+	"github.com/beheryahmed1991/subscription-service.git/internal/lease"
+	"github.com/beheryahmed1991/subscription-service.git/internal/logger"
+	"github.com/beheryahmed1991/subscription-service.git/internal/mailtmpl"
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+	"github.com/beheryahmed1991/subscription-service.git/internal/migrate"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+	"github.com/beheryahmed1991/subscription-service.git/internal/quota"
+	"github.com/beheryahmed1991/subscription-service.git/internal/report"
+	"github.com/beheryahmed1991/subscription-service.git/internal/slo"
+	"github.com/beheryahmed1991/subscription-service.git/internal/storage"
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+// Pool sizes the database connection pool for an entry point.
+type Pool struct {
+	MaxOpenConns int
+	MaxIdleConns int
+}
+
+var (
+	// ServerPool is sized for the HTTP server.
+	ServerPool = Pool{MaxOpenConns: 10, MaxIdleConns: 5}
+	// CLIPool is sized for short-lived or single-purpose commands.
+	CLIPool = Pool{MaxOpenConns: 5, MaxIdleConns: 2}
+)
+
+// Infra holds process-wide dependencies shared by every entry point.
+type Infra struct {
+	Config   config.Config
+	Logger   *slog.Logger
+	LogLevel *slog.LevelVar
+	DB       *sql.DB
+	// Rules are the configured subscription sanity limits, applied to every
+	// service built from this Infra.
+	Rules subscription.Rules
+	// Metrics is the registry served on /metrics.
+	Metrics *metrics.Registry
+	// Storage is the configured blob store, or nil when none is configured.
+	Storage storage.Blob
+	// Events carries subscription changes from the service to the caches and
+	// metrics that react to them.
+	Events *bus.Bus[subscription.Event]
+	// Monitor records the runs of the background jobs built from this Infra.
+	Monitor *monitor.Monitor
+	// Leases keep the periodic jobs built from this Infra to one replica.
+	Leases *lease.Leases
+	// Templates render the emails the jobs built from this Infra send.
+	Templates *mailtmpl.Registry
+
+	// dsn is what new database connections dial; see SetDSN.
+	dsn *atomic.Pointer[string]
+}
+
+// NewInfra connects to the database and builds the logger.
+func NewInfra(ctx context.Context, cfg config.Config, pool Pool) (*Infra, error) {
+	rules, err := subscription.NewRules(
+		cfg.Rules.MaxPrice,
+		cfg.Rules.EarliestStart,
+		cfg.Rules.LatestEnd,
+		cfg.Rules.BannedServices,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation rules: %w", err)
+	}
+	rules.WarnPrice, rules.WarnOnly = cfg.Rules.WarnPrice, cfg.Rules.WarnOnly
+	if err := types.Use(cfg.App.DateFormat); err != nil {
+		return nil, err
+	}
+	templates, err := mailtmpl.New(cfg.SMTP.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email templates: %w", err)
+	}
+	blobs, err := storage.New(storage.Config{
+		Backend:     cfg.Storage.Backend,
+		LocalDir:    cfg.Storage.LocalDir,
+		LocalURL:    cfg.Storage.PublicURL,
+		LocalSecret: cfg.Storage.LinkSecret,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Region:    cfg.Storage.S3Region,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+		S3PathStyle: cfg.Storage.S3PathStyle,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage configuration: %w", err)
+	}
+
+	dsn, url := new(atomic.Pointer[string]), cfg.DB.DSN()
+	dsn.Store(&url)
+	database, err := db.New(ctx, db.Config{
+		DSN:              func() string { return *dsn.Load() },
+		MaxOpenConns:     pool.MaxOpenConns,
+		MaxIdleConns:     pool.MaxIdleConns,
+		ConnMaxLifetime:  time.Hour,
+		ConnMaxIdleTime:  cfg.DB.ConnMaxIdleTime,
+		ConnectAttempts:  cfg.DB.ConnectAttempts,
+		ConnectBackoff:   cfg.DB.ConnectBackoff,
+		PgBouncer:        cfg.DB.PgBouncer,
+		StatementTimeout: cfg.DB.StatementTimeouts.Session,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+
+	appLogger, level := logger.NewLeveled(cfg.Log.Level)
+	logSettings(appLogger, cfg)
+	registry := metrics.NewRegistry()
+	events := bus.New[subscription.Event]("subscription_events", registry, appLogger)
+	events.Subscribe("metrics", 0, subscription.CountEvents(registry))
+	jobs := monitor.New(registry)
+	jobs.Observe("event_bus", "queued", func() float64 {
+		total := 0
+		for _, n := range events.Queued() {
+			total += n
+		}
+		return float64(total)
+	})
+	return &Infra{
+		Config:    cfg,
+		Logger:    appLogger,
+		LogLevel:  level,
+		DB:        database,
+		Rules:     rules,
+		Metrics:   registry,
+		Storage:   blobs,
+		Events:    events,
+		Monitor:   jobs,
+		Leases:    lease.New(database, registry, appLogger),
+		Templates: templates,
+		dsn:       dsn,
+	}, nil
+}
+
+// SetDSN points new database connections at dsn, so credentials or hosts
+// changed by a config reload apply without a restart. Open connections keep
+// their server until the pool retires them.
+func (i *Infra) SetDSN(dsn string) {
+	i.dsn.Store(&dsn)
+}
+
+// logSettings prints the effective configuration, with the source of every
+// value and secrets masked, so a deployment shows what it actually runs with.
+func logSettings(logger *slog.Logger, cfg config.Config) {
+	attrs := make([]any, 0, len(cfg.Settings))
+	for _, s := range cfg.Settings {
+		attrs = append(attrs, slog.String(s.Key, s.Value+" ("+s.Source+")"))
+	}
+	logger.Info("effective configuration", "env", cfg.App.Env, slog.Group("settings", attrs...))
+}
+
+// Close drains the event bus and releases the database pool.
+func (i *Infra) Close() error {
+	i.Events.Close()
+	return i.DB.Close()
+}
+
+// Migrate applies the schema migrations, including the optional strict set,
+// and then checks the schema for drift. Read-only deployments leave the
+// schema to the primary but still check it.
+func (i *Infra) Migrate(ctx context.Context) error {
+	if i.Config.App.ReadOnly {
+		i.Logger.Info("read-only mode, skipping migrations")
+		return i.checkSchema(ctx)
+	}
+	if err := migrate.Up(ctx, i.DB); err != nil {
+		return fmt.Errorf("run migrations: %w", err)
+	}
+	if i.Config.DB.StrictConstraints {
+		if err := migrate.Strict(ctx, i.DB); err != nil {
+			return fmt.Errorf("run strict migrations: %w", err)
+		}
+	}
+	return i.checkSchema(ctx)
+}
+
+// checkSchema compares the live schema with the migrations manifest, which
+// catches hotfixes applied by hand. Drift is logged, or fails startup when
+// DB_SCHEMA_DRIFT is fail.
+func (i *Infra) checkSchema(ctx context.Context) error {
+	mode := i.Config.DB.SchemaDrift
+	if mode == "off" {
+		return nil
+	}
+
+	drift, err := migrate.CheckSchema(ctx, i.DB)
+	if errors.Is(err, migrate.ErrNoManifest) {
+		i.Logger.Warn("schema manifest not generated, skipping drift check")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check schema drift: %w", err)
+	}
+	for _, d := range drift {
+		i.Logger.Warn("schema drift", "difference", d)
+	}
+	if len(drift) > 0 && mode == "fail" {
+		return fmt.Errorf("schema drift: %d difference(s) from migrations/schema.txt", len(drift))
+	}
+	return nil
+}
+
+// SubscriptionRepository builds the subscription store.
+func (i *Infra) SubscriptionRepository() *subscription.Repository {
+	timeouts, statements := i.Config.DB.Timeouts, i.Config.DB.StatementTimeouts
+	return subscription.NewRepository(i.DB, i.Logger,
+		subscription.WithEndMonthInclusive(i.Config.Summary.EndMonthInclusive),
+		subscription.WithLedgerReads(i.Config.Summary.FromLedger),
+		subscription.WithTimeouts(subscription.Timeouts{
+			Get:     timeouts.Get,
+			List:    timeouts.List,
+			Write:   timeouts.Write,
+			Summary: timeouts.Summary,
+		}),
+		subscription.WithStatementTimeouts(subscription.Timeouts{
+			List:    statements.List,
+			Write:   statements.Write,
+			Summary: statements.Summary,
+		}),
+	)
+}
+
+// SubscriptionService builds the subscription service on top of the store,
+// with identical concurrent summaries coalesced and prices converted at the
+// configured exchange rates. The configured rules run before any extra hooks.
+func (i *Infra) SubscriptionService(hooks ...subscription.ValidationHook) subscription.Service {
+	hooks = append([]subscription.ValidationHook{i.Rules}, hooks...)
+	store := subscription.NewCoalescingStore(i.SubscriptionRepository(), i.Metrics)
+	sink := subscription.MultiSink(
+		subscription.LogEvents(i.Logger),
+		subscription.EventSinkFunc(func(_ context.Context, e subscription.Event) { i.Events.Publish(e) }),
+	)
+	return subscription.NewServiceWithRates(store, sink, i.Config.Currency.Rates, hooks...)
+}
+
+// NotificationPool builds the outbound notification pool with a sender for
+// every configured channel and webhook deliveries logged. The caller starts
+// and stops it.
+func (i *Infra) NotificationPool() *notify.Pool {
+	smtp, hooks := i.Config.SMTP, i.Config.Webhooks
+	pool := notify.NewPool(notify.PoolConfig{
+		Workers:        hooks.Workers,
+		QueueSize:      hooks.QueueSize,
+		PerDestination: hooks.PerDestination,
+		MaxAttempts:    hooks.MaxAttempts,
+		BaseBackoff:    hooks.BaseBackoff,
+		MaxBackoff:     hooks.MaxBackoff,
+	}, notify.Mux{
+		notify.ChannelWebhook: notify.WebhookSender{Client: notify.NewWebhookClient(hooks.Timeout)},
+		notify.ChannelEmail: notify.EmailSender{
+			Addr:     smtp.Addr,
+			From:     smtp.From,
+			Username: smtp.Username,
+			Password: smtp.Password,
+		},
+	}, i.Logger)
+	pool.LogDeliveries(i.DeliveryRepository())
+	i.Monitor.Observe("notifications", "queue_depth", func() float64 { return float64(pool.Stats().QueueDepth) })
+	i.Monitor.Observe("notifications", "in_flight", func() float64 { return float64(pool.Stats().InFlight) })
+	return pool
+}
+
+// ReportRepository builds the report schedule store.
+func (i *Infra) ReportRepository() *report.Repository {
+	return report.NewRepository(i.DB, i.Logger)
+}
+
+// DeliveryRepository builds the webhook delivery log.
+func (i *Infra) DeliveryRepository() *report.DeliveryRepository {
+	return report.NewDeliveryRepository(i.DB, i.Logger)
+}
+
+// ReportScheduler builds the scheduled report job delivering through out.
+// Exports are uploaded to blob storage when it is configured.
+func (i *Infra) ReportScheduler(subs subscription.Service, out report.Enqueuer) *report.Scheduler {
+	scheduler := report.NewScheduler(i.ReportRepository(), subs, out, i.Config.Reports.PollInterval, i.Logger)
+	if i.Storage != nil {
+		scheduler.StoreExports(i.Storage, i.Config.Storage.LinkTTL)
+	}
+	scheduler.ReportTo(i.Monitor)
+	scheduler.UseTemplates(i.Templates)
+	return scheduler
+}
+
+// LiveCounter builds the job that publishes active subscription counts.
+func (i *Infra) LiveCounter() *subscription.LiveCounter {
+	live := subscription.NewLiveCounter(i.SubscriptionRepository(), i.Metrics, i.Config.Stats.LiveInterval, i.Logger)
+	live.ReportTo(i.Monitor)
+	return live
+}
+
+// Dashboards builds the cached account dashboard endpoint. Its cache drops a
+// user's entry when their subscriptions change.
+func (i *Infra) Dashboards() *subscription.Dashboards {
+	dashboards := subscription.NewDashboards(i.SubscriptionRepository(), i.Config.Dashboard.CacheTTL, i.Logger)
+	i.Events.Subscribe("dashboards", 0, dashboards.Invalidate)
+	return dashboards
+}
+
+// SLOTracker builds the SLO tracker for the read and write route classes.
+func (i *Infra) SLOTracker() *slo.Tracker {
+	cfg := i.Config.SLO
+	return slo.NewTracker(slo.Config{
+		Window:          cfg.Window,
+		RefreshInterval: cfg.RefreshInterval,
+		Objectives: []slo.Objective{
+			{Class: slo.ClassRead, Availability: cfg.ReadAvailability, Latency: cfg.ReadLatency, LatencyTarget: cfg.LatencyTarget},
+			{Class: slo.ClassWrite, Availability: cfg.WriteAvailability, Latency: cfg.WriteLatency, LatencyTarget: cfg.LatencyTarget},
+		},
+	}, i.Metrics, i.Logger)
+}
+
+// CatalogRepository builds the service catalog store.
+func (i *Infra) CatalogRepository() *catalog.Repository {
+	return catalog.NewRepository(i.DB, i.Logger)
+}
+
+// CatalogPriceJob builds the job that refreshes the catalog's average
+// prices.
+func (i *Infra) CatalogPriceJob() *catalog.PriceJob {
+	job := catalog.NewPriceJob(i.CatalogRepository(), i.Config.Catalog.PriceInterval, i.Logger)
+	job.ReportTo(i.Monitor)
+	job.UseLeases(i.Leases)
+	return job
+}
+
+// CatalogPopularityJob builds the job that refreshes the service popularity
+// rollup.
+func (i *Infra) CatalogPopularityJob() *catalog.PopularityJob {
+	job := catalog.NewPopularityJob(i.CatalogRepository(), i.Config.Catalog.PopularInterval, i.Logger)
+	job.ReportTo(i.Monitor)
+	job.UseLeases(i.Leases)
+	return job
+}
+
+// LedgerJob builds the job that closes finished months into the charges
+// ledger.
+func (i *Infra) LedgerJob() *subscription.LedgerJob {
+	job := subscription.NewLedgerJob(i.SubscriptionRepository(), i.Config.Ledger.Interval, i.Logger)
+	job.ReportTo(i.Monitor)
+	job.UseLeases(i.Leases)
+	return job
+}
+
+// AnomalyRepository builds the spend anomaly store.
+func (i *Infra) AnomalyRepository() *anomaly.Repository {
+	return anomaly.NewRepository(i.DB, i.Logger)
+}
+
+// AnomalyDetector builds the spend anomaly job. When out is non-nil and
+// notifications are enabled, users are told about their own anomalies.
+func (i *Infra) AnomalyDetector(out anomaly.Enqueuer) *anomaly.Detector {
+	cfg := i.Config.Anomaly
+	detector := anomaly.NewDetector(i.AnomalyRepository(), anomaly.Thresholds{
+		ChangePct:   cfg.ChangePct,
+		MinIncrease: int64(cfg.MinIncrease),
+	}, cfg.Interval, i.Logger)
+	if cfg.Notify && out != nil {
+		detector.NotifyUsers(i.ReportRepository(), out)
+	}
+	detector.ReportTo(i.Monitor)
+	detector.UseLeases(i.Leases)
+	detector.UseTemplates(i.Templates)
+	return detector
+}
+
+// IdempotencyKeys builds the Idempotency-Key store and its purge job.
+func (i *Infra) IdempotencyKeys() *idempotency.Keys {
+	keys := idempotency.NewKeys(idempotency.NewRepository(i.DB, i.Logger), i.Config.Idempotency.TTL, i.Logger)
+	keys.ReportTo(i.Monitor)
+	return keys
+}
+
+// Verifier builds the bearer token verifier, or returns nil when
+// authentication is not configured.
+func (i *Infra) Verifier() (*auth.Verifier, error) {
+	cfg := i.Config.Auth
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	return auth.NewVerifier(auth.Config{
+		Secret:      cfg.JWTSecret,
+		JWKSURL:     cfg.JWKSURL,
+		Issuer:      cfg.Issuer,
+		Audience:    cfg.Audience,
+		JWKSRefresh: cfg.JWKSRefresh,
+	}, i.Logger)
+}
+
+// QuotaStore builds the rate limit override store, cached for the configured
+// TTL.
+func (i *Infra) QuotaStore() *quota.Cache {
+	return quota.NewCache(quota.NewRepository(i.DB, i.Logger), i.Config.RateLimit.CacheTTL)
+}
+
+// Health builds the probe handler, checking that Postgres answers and that
+// every embedded migration has been applied.
+func (i *Infra) Health() *health.Handler {
+	h := health.NewHandler(i.Config.Health.CheckTimeout, i.Logger)
+	h.Add("postgres", i.DB.PingContext)
+	h.Add("migrations", func(ctx context.Context) error {
+		return migrate.CheckApplied(ctx, i.DB)
+	})
+	return h
+}
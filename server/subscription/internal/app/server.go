@@ -0,0 +1,386 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	docs "github.com/beheryahmed1991/subscription-service.git/docs"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/admin"
+	"github.com/beheryahmed1991/subscription-service.git/internal/anomaly"
+	"github.com/beheryahmed1991/subscription-service.git/internal/apidocs"
+	"github.com/beheryahmed1991/subscription-service.git/internal/catalog"
+	"github.com/beheryahmed1991/subscription-service.git/internal/config"
+	"github.com/beheryahmed1991/subscription-service.git/internal/idempotency"
+	"github.com/beheryahmed1991/subscription-service.git/internal/logger"
+	"github.com/beheryahmed1991/subscription-service.git/internal/mailtmpl"
+	"github.com/beheryahmed1991/subscription-service.git/internal/middleware"
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+	"github.com/beheryahmed1991/subscription-service.git/internal/quota"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+	"github.com/beheryahmed1991/subscription-service.git/internal/report"
+	"github.com/beheryahmed1991/subscription-service.git/internal/slo"
+	"github.com/beheryahmed1991/subscription-service.git/internal/storage"
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// readOnlyPOSTs are POST routes that only read, so they stay available in
+// maintenance mode.
+var readOnlyPOSTs = map[string]bool{
+	"/subscriptions/search":        true,
+	"/subscriptions/batch-get":     true,
+	"/subscriptions/summary/batch": true,
+}
+
+// processOnlyAdminRoutes are admin routes that change in-memory state only,
+// so they stay available in read-only mode.
+var processOnlyAdminRoutes = map[string]bool{
+	"/admin/config/reload": true,
+	"/admin/maintenance":   true,
+}
+
+// publicRoute reports whether a route may be called without a bearer token:
+// probes, metrics, the public docs, the admin docs (which take basic auth)
+// and signed blob links.
+func publicRoute(c *gin.Context) bool {
+	path := c.FullPath()
+	return path == "/hello" || path == "/healthz" || path == "/readyz" || path == "/metrics" ||
+		path == "/blobs/*key" || strings.HasPrefix(path, "/swagger/") || strings.HasPrefix(path, "/admin/swagger/")
+}
+
+// Server is the fully wired HTTP server.
+type Server struct {
+	infra     *Infra
+	router    *gin.Engine
+	reloader  *admin.Reloader
+	notifier  *notify.Pool
+	scheduler *report.Scheduler
+	live      *subscription.LiveCounter
+	slo       *slo.Tracker
+	ledger    *subscription.LedgerJob
+	prices    *catalog.PriceJob
+	popular   *catalog.PopularityJob
+	anomalies *anomaly.Detector
+	idemKeys  *idempotency.Keys
+	// metrics serves /metrics when it has its own port.
+	metrics *gin.Engine
+}
+
+// NewServer wires the HTTP layer on top of infra.
+func NewServer(infra *Infra) (*Server, error) {
+	return NewServerWithService(infra, infra.SubscriptionService())
+}
+
+// NewServerWithService wires the HTTP layer around a caller-provided service,
+// which lets tests substitute a fake Service while keeping production routing.
+func NewServerWithService(infra *Infra, subService subscription.Service) (*Server, error) {
+	cfg := infra.Config
+
+	handlerCfg, err := handlerConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid list config: %w", err)
+	}
+	// The repository keeps its startup default, so reloads keep it too.
+	handlerCfg.EndMonthInclusive = &cfg.Summary.EndMonthInclusive
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(middleware.RequestLogger(infra.Logger))
+
+	shedder := middleware.NewLoadShedder(loadShedConfig(cfg), infra.Logger)
+	router.Use(shedder.Track())
+
+	sloTracker := infra.SLOTracker()
+	router.Use(sloTracker.Middleware(routeClass))
+
+	verifier, err := infra.Verifier()
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth config: %w", err)
+	}
+	if verifier != nil {
+		router.Use(verifier.Middleware(publicRoute))
+	} else {
+		infra.Logger.Warn("authentication disabled, every request is anonymous; set AUTH_JWT_SECRET or AUTH_JWKS_URL")
+	}
+
+	if cfg.App.ReadOnly {
+		router.Use(middleware.ReadOnly(func(c *gin.Context) bool {
+			return readOnlyPOSTs[c.FullPath()] || processOnlyAdminRoutes[c.FullPath()]
+		}))
+	}
+
+	maintenance := middleware.NewMaintenance(maintenanceConfig(cfg), infra.Logger)
+	router.Use(maintenance.Guard(func(c *gin.Context) bool {
+		return strings.HasPrefix(c.FullPath(), "/admin/") || readOnlyPOSTs[c.FullPath()]
+	}))
+
+	quotas := infra.QuotaStore()
+	limiter := middleware.NewRateLimiter(cfg.RateLimit.PerMinute, quotas, infra.Logger)
+	if cfg.RateLimit.Enabled {
+		router.Use(limiter.Limit(func(c *gin.Context) bool {
+			return c.FullPath() == "/metrics" || c.FullPath() == "/healthz" || c.FullPath() == "/readyz" ||
+				strings.HasPrefix(c.FullPath(), "/swagger/") ||
+				strings.HasPrefix(c.FullPath(), "/admin/swagger/")
+		}))
+	}
+
+	router.GET("/hello", func(c *gin.Context) {
+		c.String(200, "Hello, ahmed. this for testing !")
+	})
+	infra.Health().RegisterRoutes(router)
+
+	catalogRepo := infra.CatalogRepository()
+	idemKeys := infra.IdempotencyKeys()
+	subHandler := subscription.NewHandler(subService, infra.Logger, handlerCfg)
+	subHandler.UsePriceCatalog(catalogRepo, cfg.Catalog.PriceMinSamples, cfg.Catalog.PriceTolerancePct)
+	subHandler.UseIdempotency(idemKeys.Middleware())
+	subHandler.UseSummaryPresets(infra.SubscriptionRepository())
+	subHandler.RegisterRoutes(router, shedder.Shed())
+	infra.Dashboards().RegisterRoutes(router)
+	report.NewHandler(infra.ReportRepository(), infra.Logger).RegisterRoutes(router)
+	catalog.NewHandler(catalogRepo, cfg.Catalog.PopularMinUsers, infra.Logger).RegisterRoutes(router)
+
+	// Maintenance mode is also toggled through the API, so a reload only
+	// touches it when the configured values themselves changed.
+	loadedMaintenance := cfg.Maintenance
+	reloader := admin.NewReloader(cfg, ReloadConfig, func(next config.Config) error {
+		nextHandlerCfg, err := handlerConfig(next)
+		if err != nil {
+			return err
+		}
+		nextHandlerCfg.EndMonthInclusive = handlerCfg.EndMonthInclusive
+		logger.SetLevel(infra.LogLevel, next.Log.Level)
+		infra.SetDSN(next.DB.DSN())
+		subHandler.UpdateConfig(nextHandlerCfg)
+		shedder.SetThresholds(loadShedConfig(next))
+		limiter.SetDefault(next.RateLimit.PerMinute)
+		if next.Maintenance != loadedMaintenance {
+			maintenance.Set(maintenanceConfig(next))
+			loadedMaintenance = next.Maintenance
+		}
+		return nil
+	}, infra.Logger)
+	reloader.UseAuditLog(infra.DB)
+
+	// Anonymous callers, which authentication being off lets through, need the
+	// admin token; the role check then keeps support callers to reads.
+	adminGroup := router.Group("/admin", middleware.RequireAdmin(cfg.Swagger.AdminToken), rbac.RequireAdmin())
+	reloader.RegisterRoutes(adminGroup)
+	admin.NewMaintenanceHandler(maintenance, infra.Logger).RegisterRoutes(adminGroup)
+	admin.NewIndexRebuilder(infra.DB, infra.Logger).RegisterRoutes(adminGroup)
+	anomaly.NewHandler(infra.AnomalyRepository(), infra.Logger).RegisterRoutes(adminGroup)
+	quota.NewHandler(quotas, infra.Logger).RegisterRoutes(adminGroup)
+	mailtmpl.NewHandler(infra.Templates, infra.Logger).RegisterRoutes(adminGroup)
+	subHandler.RegisterAdminRoutes(adminGroup)
+	live := infra.LiveCounter()
+	live.RegisterRoutes(adminGroup)
+	sloTracker.RegisterRoutes(adminGroup)
+	infra.Monitor.RegisterRoutes(adminGroup)
+
+	protectMetrics, err := middleware.ProtectMetrics(middleware.MetricsAccess{
+		AllowedIPs: cfg.Metrics.AllowedIPs,
+		Username:   cfg.Metrics.Username,
+		Password:   cfg.Metrics.Password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics config: %w", err)
+	}
+	var metricsRouter *gin.Engine
+	if cfg.Metrics.Port != "" {
+		metricsRouter = gin.New()
+		metricsRouter.Use(gin.Recovery())
+		metricsRouter.GET("/metrics", protectMetrics, gin.WrapH(infra.Metrics.Handler()))
+	} else {
+		router.GET("/metrics", protectMetrics, gin.WrapH(infra.Metrics.Handler()))
+	}
+
+	// The public docs leave the admin API out; it is documented behind auth.
+	docs.SwaggerInfo.Host = cfg.Swagger.Host
+	if err := apidocs.Publish(docs.SwaggerInfo); err != nil {
+		return nil, fmt.Errorf("split api docs: %w", err)
+	}
+	router.GET("/swagger/*any", apidocs.PublicHandler())
+	adminGroup.GET("/swagger/*any", apidocs.AdminHandler())
+
+	srv := &Server{infra: infra, router: router, reloader: reloader, live: live, slo: sloTracker, idemKeys: idemKeys, metrics: metricsRouter}
+	if cfg.App.ReadOnly {
+		// Only the live counter is read-only; the other jobs claim schedules,
+		// log deliveries and store ledger months, anomalies, catalog prices and
+		// service popularity.
+		infra.Logger.Info("read-only mode, background jobs disabled")
+	} else {
+		srv.wireJobs(subService)
+	}
+
+	var retries report.Enqueuer
+	if srv.notifier != nil {
+		retries = srv.notifier
+	}
+	report.NewDeliveryHandler(infra.ReportRepository(), infra.DeliveryRepository(), retries, infra.Logger).RegisterRoutes(router)
+	if local, ok := infra.Storage.(*storage.Local); ok {
+		local.RegisterRoutes(router)
+	}
+	return srv, nil
+}
+
+// wireJobs builds the enabled background jobs and the notification pool they
+// deliver through.
+func (s *Server) wireJobs(subService subscription.Service) {
+	cfg := s.infra.Config
+	if cfg.Reports.Enabled || (cfg.Anomaly.Enabled && cfg.Anomaly.Notify) {
+		s.notifier = s.infra.NotificationPool()
+	}
+	if cfg.Reports.Enabled {
+		s.scheduler = s.infra.ReportScheduler(subService, s.notifier)
+	}
+	if cfg.Ledger.Enabled {
+		s.ledger = s.infra.LedgerJob()
+	}
+	if cfg.Catalog.PricesEnabled {
+		s.prices = s.infra.CatalogPriceJob()
+	}
+	if cfg.Catalog.PopularEnabled {
+		s.popular = s.infra.CatalogPopularityJob()
+	}
+	if cfg.Anomaly.Enabled {
+		var out anomaly.Enqueuer
+		if s.notifier != nil {
+			out = s.notifier
+		}
+		s.anomalies = s.infra.AnomalyDetector(out)
+	}
+}
+
+// Handler exposes the router, e.g. for httptest.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Run serves HTTP until ctx is cancelled and then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	s.reloader.WatchSignals(ctx)
+	go s.live.Run(ctx)
+	go s.slo.Run(ctx)
+	go s.infra.Monitor.Run(ctx)
+	if s.ledger != nil {
+		go s.ledger.Run(ctx)
+	}
+	if s.anomalies != nil {
+		go s.anomalies.Run(ctx)
+	}
+	if s.prices != nil {
+		go s.prices.Run(ctx)
+	}
+	if s.popular != nil {
+		go s.popular.Run(ctx)
+	}
+	if !s.infra.Config.App.ReadOnly {
+		go s.idemKeys.Run(ctx)
+	}
+
+	if s.notifier != nil {
+		// Workers outlive ctx so queued notifications drain during shutdown.
+		s.notifier.Start(context.WithoutCancel(ctx))
+	}
+	if s.scheduler != nil {
+		go s.scheduler.Run(ctx)
+	}
+
+	srv := &http.Server{
+		Addr:    ":" + s.infra.Config.App.Port,
+		Handler: s.router,
+	}
+	servers := []*http.Server{srv}
+	if s.metrics != nil {
+		servers = append(servers, &http.Server{
+			Addr:    ":" + s.infra.Config.Metrics.Port,
+			Handler: s.metrics,
+		})
+	}
+
+	errCh := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("http server on %s: %w", server.Addr, err)
+			}
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("graceful shutdown: %w", err)
+		}
+	}
+	if s.notifier != nil {
+		if err := s.notifier.Stop(shutdownCtx); err != nil {
+			return fmt.Errorf("drain notifications: %w", err)
+		}
+	}
+	return nil
+}
+
+// routeClass assigns API requests to the read or write SLO. Admin, metrics,
+// docs and unmatched paths are left out.
+func routeClass(c *gin.Context) string {
+	path := c.FullPath()
+	switch {
+	case path == "", path == "/metrics", path == "/hello", path == "/healthz", path == "/readyz",
+		strings.HasPrefix(path, "/admin/"), strings.HasPrefix(path, "/swagger/"), strings.HasPrefix(path, "/blobs/"):
+		return ""
+	case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || readOnlyPOSTs[path]:
+		return slo.ClassRead
+	default:
+		return slo.ClassWrite
+	}
+}
+
+func handlerConfig(cfg config.Config) (subscription.HandlerConfig, error) {
+	defaultSort, err := subscription.ParseSort(cfg.List.DefaultSort)
+	if err != nil {
+		return subscription.HandlerConfig{}, fmt.Errorf("LIST_DEFAULT_SORT: %w", err)
+	}
+	return subscription.HandlerConfig{
+		DefaultSort:        defaultSort,
+		DefaultLimit:       cfg.List.DefaultLimit,
+		MaxLimit:           cfg.List.MaxLimit,
+		Redaction:          subscription.RedactionPolicy{Fields: cfg.List.RedactFields},
+		UndoWindow:         cfg.Audit.UndoWindow,
+		StreamTimeout:      cfg.List.StreamTimeout,
+		StreamWriteTimeout: cfg.List.StreamWriteTimeout,
+	}, nil
+}
+
+func loadShedConfig(cfg config.Config) middleware.LoadShedConfig {
+	return middleware.LoadShedConfig{
+		MaxInFlight: int64(cfg.LoadShed.MaxInFlight),
+		P99Budget:   cfg.LoadShed.P99Budget,
+		Window:      cfg.LoadShed.Window,
+		RetryAfter:  cfg.LoadShed.RetryAfter,
+	}
+}
+
+func maintenanceConfig(cfg config.Config) middleware.MaintenanceConfig {
+	return middleware.MaintenanceConfig{
+		Enabled:     cfg.Maintenance.Enabled,
+		Message:     cfg.Maintenance.Message,
+		BypassToken: cfg.Maintenance.BypassToken,
+	}
+}
@@ -0,0 +1,218 @@
+// Package auth authenticates API callers from JWT bearer tokens and stores
+// them in the request context through the identity package.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, badly signed,
+// expired or missing a required claim.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config selects how tokens are verified. Secret enables HS256/384/512 and
+// JWKSURL enables RS256/384/512 and ES256/384 with the keys published there;
+// at least one is required.
+type Config struct {
+	Secret  string
+	JWKSURL string
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// JWKSRefresh is how often the key set is fetched again.
+	JWKSRefresh time.Duration
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// Verifier checks bearer tokens and turns their claims into a caller.
+type Verifier struct {
+	cfg    Config
+	keys   *keySet
+	logger *slog.Logger
+}
+
+// NewVerifier creates a Verifier for cfg.
+func NewVerifier(cfg Config, logger *slog.Logger) (*Verifier, error) {
+	if cfg.Secret == "" && cfg.JWKSURL == "" {
+		return nil, errors.New("auth needs a signing secret or a JWKS URL")
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = 30 * time.Second
+	}
+	v := &Verifier{cfg: cfg, logger: logger}
+	if cfg.JWKSURL != "" {
+		v.keys = newKeySet(cfg.JWKSURL, cfg.JWKSRefresh)
+	}
+	return v, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// claims are the registered claims the service reads, plus roles. aud may be
+// a string or a list.
+type claims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+	Roles     []string        `json:"roles"`
+	Role      string          `json:"role"`
+}
+
+// Verify checks token and returns the caller it was issued to. The sub claim
+// must be the user's UUID; roles (or a single role) grant identity roles.
+func (v *Verifier) Verify(ctx context.Context, token string) (identity.Caller, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return identity.Caller{}, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return identity.Caller{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return identity.Caller{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if err := v.verifySignature(ctx, h, parts[0]+"."+parts[1], signature); err != nil {
+		return identity.Caller{}, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return identity.Caller{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	return v.caller(c, time.Now())
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, h header, signed string, signature []byte) error {
+	hash, ok := hashes[h.Alg]
+	if !ok {
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, h.Alg)
+	}
+
+	if strings.HasPrefix(h.Alg, "HS") {
+		if v.cfg.Secret == "" {
+			return fmt.Errorf("%w: %s tokens are not accepted", ErrInvalidToken, h.Alg)
+		}
+		mac := hmac.New(hash.New, []byte(v.cfg.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+
+	if v.keys == nil {
+		return fmt.Errorf("%w: %s tokens are not accepted", ErrInvalidToken, h.Alg)
+	}
+	key, err := v.keys.get(ctx, h.Kid)
+	if err != nil {
+		return err
+	}
+	digest := hash.New()
+	digest.Write([]byte(signed))
+	sum := digest.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(h.Alg, "RS") || rsa.VerifyPKCS1v15(pub, hash, sum, signature) != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(h.Alg, "ES") || len(signature) != 2*size {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, sum, r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key type", ErrInvalidToken)
+	}
+	return nil
+}
+
+var hashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+}
+
+func (v *Verifier) caller(c claims, now time.Time) (identity.Caller, error) {
+	if c.ExpiresAt == nil {
+		return identity.Caller{}, fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(v.cfg.Leeway)) {
+		return identity.Caller{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if c.NotBefore != nil && now.Add(v.cfg.Leeway).Before(time.Unix(*c.NotBefore, 0)) {
+		return identity.Caller{}, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if v.cfg.Issuer != "" && c.Issuer != v.cfg.Issuer {
+		return identity.Caller{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.cfg.Audience != "" && !hasAudience(c.Audience, v.cfg.Audience) {
+		return identity.Caller{}, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return identity.Caller{}, fmt.Errorf("%w: sub must be a user ID", ErrInvalidToken)
+	}
+
+	caller := identity.Caller{UserID: userID}
+	roles := c.Roles
+	if c.Role != "" {
+		roles = append(roles, c.Role)
+	}
+	for _, role := range roles {
+		caller.Roles = append(caller.Roles, identity.Role(strings.ToLower(role)))
+	}
+	return caller, nil
+}
+
+func hasAudience(raw json.RawMessage, want string) bool {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return one == want
+	}
+	var many []string
+	return json.Unmarshal(raw, &many) == nil && slices.Contains(many, want)
+}
+
+func decodeSegment(segment string, dst any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+)
+
+// Middleware authenticates every request from its Authorization: Bearer
+// header and stores the caller with identity.WithCaller. Requests without a
+// valid token get 401, except on routes where optional returns true: there a
+// token is still checked when present, but anonymous requests go through.
+func (v *Verifier) Middleware(optional func(c *gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearer(c.GetHeader("Authorization"))
+		if !ok {
+			if optional != nil && optional(c) {
+				c.Next()
+				return
+			}
+			unauthorized(c, "bearer token required")
+			return
+		}
+
+		ctx := c.Request.Context()
+		caller, err := v.Verify(ctx, token)
+		if err != nil {
+			v.logger.Info("rejected bearer token", "path", c.FullPath(), "error", err)
+			unauthorized(c, "invalid bearer token")
+			return
+		}
+		c.Request = c.Request.WithContext(identity.WithCaller(ctx, caller))
+		c.Next()
+	}
+}
+
+func bearer(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(c *gin.Context, msg string) {
+	c.Header("WWW-Authenticate", `Bearer realm="subscriptions"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": msg})
+}
@@ -0,0 +1,268 @@
+// Package backfill runs large data migrations in resumable, checkpointed batches.
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrLocked is returned when another process is already running the job.
+var ErrLocked = errors.New("backfill job is already running elsewhere")
+
+// Job is a single data migration. Batches are keyed by an ordered string key so
+// the runner can checkpoint the last processed key and resume after a crash.
+type Job interface {
+	Name() string
+	// Remaining reports how many rows are still to be processed after key.
+	Remaining(ctx context.Context, db *sql.DB, after string) (int64, error)
+	// Batch processes up to limit rows after key inside tx and returns the last
+	// key it handled and how many rows it touched. Zero rows means done.
+	Batch(ctx context.Context, tx *sql.Tx, after string, limit int) (last string, n int, err error)
+	// Verify checks the end state once all batches completed.
+	Verify(ctx context.Context, db *sql.DB) error
+}
+
+// Options tunes a run.
+type Options struct {
+	BatchSize int
+	Pause     time.Duration
+	DryRun    bool
+}
+
+// Checkpoint is the persisted progress of a job.
+type Checkpoint struct {
+	LastKey   string
+	Processed int64
+	Completed bool
+}
+
+// Runner executes jobs against a database.
+type Runner struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewRunner creates a Runner.
+func NewRunner(db *sql.DB, logger *slog.Logger) *Runner {
+	return &Runner{db: db, logger: logger}
+}
+
+// Run processes job from its last checkpoint until no rows remain, makes a
+// final pass over every row, then runs verification. A job marked completed
+// is not run again. Only one process may run a given job at a time.
+func (r *Runner) Run(ctx context.Context, job Job, opts Options) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, job.Name()).Scan(&locked); err != nil {
+		return fmt.Errorf("acquire job lock: %w", err)
+	}
+	if !locked {
+		return ErrLocked
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, job.Name()); err != nil {
+			r.logger.Error("release job lock failed", "job", job.Name(), "error", err)
+		}
+	}()
+
+	cp, err := r.Checkpoint(ctx, job.Name())
+	if err != nil {
+		return err
+	}
+	if cp.Completed {
+		r.logger.Info("backfill already completed", "job", job.Name(), "processed", cp.Processed)
+		return nil
+	}
+
+	remaining, err := job.Remaining(ctx, r.db, cp.LastKey)
+	if err != nil {
+		return fmt.Errorf("count remaining rows: %w", err)
+	}
+	r.logger.Info("backfill starting",
+		"job", job.Name(),
+		"resume_after", cp.LastKey,
+		"processed", cp.Processed,
+		"remaining", remaining,
+		"dry_run", opts.DryRun,
+	)
+	if opts.DryRun {
+		return nil
+	}
+
+	started := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		last, n, err := r.step(ctx, job, cp.LastKey, opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("batch after %q: %w", cp.LastKey, err)
+		}
+		if n == 0 {
+			break
+		}
+
+		cp.LastKey = last
+		cp.Processed += int64(n)
+		remaining -= int64(n)
+		rate := float64(cp.Processed) / time.Since(started).Seconds()
+		r.logger.Info("backfill progress",
+			"job", job.Name(),
+			"last_key", last,
+			"processed", cp.Processed,
+			"remaining", max(remaining, 0),
+			"rows_per_sec", int(rate),
+		)
+
+		if err := pause(ctx, opts.Pause); err != nil {
+			return err
+		}
+	}
+
+	// Keys only move forward, so rows written below the checkpoint while the
+	// job ran were never seen. One more pass over every key picks them up.
+	swept, err := r.sweep(ctx, job, opts)
+	if err != nil {
+		return fmt.Errorf("final pass: %w", err)
+	}
+	r.logger.Info("backfill final pass done", "job", job.Name(), "rows", swept)
+
+	if err := job.Verify(ctx, r.db); err != nil {
+		return fmt.Errorf("verify %s: %w", job.Name(), err)
+	}
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE data_migrations SET completed_at = now(), updated_at = now() WHERE name = $1`, job.Name(),
+	); err != nil {
+		return fmt.Errorf("mark job completed: %w", err)
+	}
+
+	r.logger.Info("backfill completed", "job", job.Name(), "processed", cp.Processed)
+	return nil
+}
+
+// Verify runs only the verification step of job.
+func (r *Runner) Verify(ctx context.Context, job Job) error {
+	return job.Verify(ctx, r.db)
+}
+
+// Checkpoint loads the stored progress of the named job.
+func (r *Runner) Checkpoint(ctx context.Context, name string) (Checkpoint, error) {
+	var (
+		cp        Checkpoint
+		completed sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx,
+		`SELECT last_key, processed, completed_at FROM data_migrations WHERE name = $1`, name,
+	).Scan(&cp.LastKey, &cp.Processed, &completed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Checkpoint{}, nil
+	}
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("load checkpoint: %w", err)
+	}
+	cp.Completed = completed.Valid
+	return cp, nil
+}
+
+// sweep runs every batch from the first key without touching the
+// checkpoint and returns how many rows the batches touched.
+func (r *Runner) sweep(ctx context.Context, job Job, opts Options) (int64, error) {
+	var (
+		after string
+		total int64
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		last, n, err := r.batch(ctx, job, after, opts.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("batch after %q: %w", after, err)
+		}
+		if n == 0 {
+			return total, nil
+		}
+		after = last
+		total += int64(n)
+		if err := pause(ctx, opts.Pause); err != nil {
+			return total, err
+		}
+	}
+}
+
+// batch runs one batch in its own transaction.
+func (r *Runner) batch(ctx context.Context, job Job, after string, limit int) (string, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("begin batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	last, n, err := job.Batch(ctx, tx, after, limit)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", 0, fmt.Errorf("commit batch: %w", err)
+	}
+	return last, n, nil
+}
+
+func pause(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// step runs one batch and stores the checkpoint in the same transaction, so a
+// crash never loses or repeats committed work.
+func (r *Runner) step(ctx context.Context, job Job, after string, limit int) (string, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("begin batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	last, n, err := job.Batch(ctx, tx, after, limit)
+	if err != nil {
+		return "", 0, err
+	}
+	if n == 0 {
+		return after, 0, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO data_migrations (name, last_key, processed)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET last_key = EXCLUDED.last_key,
+    processed = data_migrations.processed + EXCLUDED.processed,
+    updated_at = now()`, job.Name(), last, n); err != nil {
+		return "", 0, fmt.Errorf("save checkpoint: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", 0, fmt.Errorf("commit batch: %w", err)
+	}
+	return last, n, nil
+}
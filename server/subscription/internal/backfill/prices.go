@@ -0,0 +1,68 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PriceToMoney copies price_rub into the subscription_prices money table as
+// kopecks. The repository keeps the table in sync on every write, so the job
+// only fills rows that predate it; amounts that drifted are corrected.
+type PriceToMoney struct{}
+
+func (PriceToMoney) Name() string {
+	return "price-to-money"
+}
+
+func (PriceToMoney) Remaining(ctx context.Context, db *sql.DB, after string) (int64, error) {
+	var n int64
+	err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM subscriptions WHERE id::text > $1`, after,
+	).Scan(&n)
+	return n, err
+}
+
+func (PriceToMoney) Batch(ctx context.Context, tx *sql.Tx, after string, limit int) (string, int, error) {
+	var (
+		n    int
+		last sql.NullString
+	)
+	err := tx.QueryRowContext(ctx, `
+WITH batch AS (
+    SELECT id, price_rub
+    FROM subscriptions
+    WHERE id::text > $1
+    ORDER BY id::text
+    LIMIT $2
+),
+inserted AS (
+    INSERT INTO subscription_prices (subscription_id, amount_minor, currency)
+    SELECT id, price_rub::bigint * 100, 'RUB' FROM batch
+    ON CONFLICT (subscription_id) DO UPDATE
+    SET amount_minor = EXCLUDED.amount_minor
+    WHERE subscription_prices.amount_minor <> EXCLUDED.amount_minor
+)
+SELECT COUNT(*), MAX(id::text) FROM batch`, after, limit).Scan(&n, &last)
+	if err != nil {
+		return "", 0, fmt.Errorf("copy prices: %w", err)
+	}
+	return last.String, n, nil
+}
+
+func (PriceToMoney) Verify(ctx context.Context, db *sql.DB) error {
+	var missing, mismatched int64
+	err := db.QueryRowContext(ctx, `
+SELECT
+    COUNT(*) FILTER (WHERE p.subscription_id IS NULL),
+    COUNT(*) FILTER (WHERE p.subscription_id IS NOT NULL AND p.amount_minor <> s.price_rub::bigint * 100)
+FROM subscriptions s
+LEFT JOIN subscription_prices p ON p.subscription_id = s.id`).Scan(&missing, &mismatched)
+	if err != nil {
+		return fmt.Errorf("verification query: %w", err)
+	}
+	if missing > 0 || mismatched > 0 {
+		return fmt.Errorf("%d subscriptions without a price row, %d with mismatched amounts", missing, mismatched)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Package billing knows the periods subscriptions are charged in and spreads
+// their prices over months, the unit every total and catalog price is kept
+// in.
+package billing
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Billing periods. A price is charged once per period.
+const (
+	Weekly    = "weekly"
+	Monthly   = "monthly"
+	Quarterly = "quarterly"
+	Yearly    = "yearly"
+)
+
+// chargesPerYear is how many times a year each billing period charges. A year
+// is taken as 52 weeks, as most weekly plans bill.
+var chargesPerYear = map[string]int{
+	Weekly:    52,
+	Monthly:   12,
+	Quarterly: 4,
+	Yearly:    1,
+}
+
+// Valid reports whether period is a known billing period.
+func Valid(period string) bool {
+	_, ok := chargesPerYear[period]
+	return ok
+}
+
+// Periods lists the billing periods, most frequent first.
+func Periods() []string {
+	periods := make([]string, 0, len(chargesPerYear))
+	for period := range chargesPerYear {
+		periods = append(periods, period)
+	}
+	slices.SortFunc(periods, func(a, b string) int { return chargesPerYear[b] - chargesPerYear[a] })
+	return periods
+}
+
+// MonthlyPrice is price, charged every period, spread evenly over the months
+// and rounded to whole rubles. Unknown periods count as monthly.
+func MonthlyPrice(price int, period string) int {
+	perYear, ok := chargesPerYear[period]
+	if !ok {
+		perYear = 12
+	}
+	return int(math.Round(float64(price) * float64(perYear) / 12))
+}
+
+// MonthlyPriceSQL is the SQL counterpart of MonthlyPrice over the price and
+// period columns, left unrounded. It is numeric so sums over many months
+// round once rather than every month.
+func MonthlyPriceSQL(price, period string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s::numeric * CASE %s", price, period)
+	for _, p := range Periods() {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", p, chargesPerYear[p])
+	}
+	b.WriteString(" ELSE 12 END / 12")
+	return b.String()
+}
@@ -0,0 +1,288 @@
+// Package bus is an in-process publish/subscribe bus. Publishers hand events
+// to every subscriber without knowing who they are, so a package can announce
+// changes without importing the caches, streams and metrics that react to
+// them.
+package bus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+)
+
+// DefaultBuffer is the queue length used when Subscribe is given none.
+const DefaultBuffer = 64
+
+// Handler processes one event. Errors are logged and do not affect other
+// subscribers or later events. ctx is cancelled when the subscriber is
+// disconnected.
+type Handler[T any] func(context.Context, T) error
+
+// Policy decides what happens when an event does not fit a subscriber's
+// queue.
+type Policy int
+
+const (
+	// DropNewest discards the event being published.
+	DropNewest Policy = iota
+	// DropOldest discards the longest-queued event to make room, for
+	// subscribers that only care about recent state.
+	DropOldest
+	// Disconnect unsubscribes the subscriber, for consumers such as client
+	// streams that cannot recover from a gap and should reconnect instead.
+	Disconnect
+)
+
+// ParsePolicy parses drop_newest, drop_oldest or disconnect.
+func ParsePolicy(value string) (Policy, error) {
+	switch value {
+	case "drop_newest", "":
+		return DropNewest, nil
+	case "drop_oldest":
+		return DropOldest, nil
+	case "disconnect":
+		return Disconnect, nil
+	}
+	return 0, fmt.Errorf("unknown drop policy %q", value)
+}
+
+func (p Policy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop_oldest"
+	case Disconnect:
+		return "disconnect"
+	default:
+		return "drop_newest"
+	}
+}
+
+// Options tune one subscriber.
+type Options struct {
+	// Buffer is the queue length, DefaultBuffer when zero.
+	Buffer int
+	// Policy applies when the queue is full.
+	Policy Policy
+	// OnDisconnect, if set, runs once when the Disconnect policy removes the
+	// subscriber. It is not called on unsubscribe.
+	OnDisconnect func()
+}
+
+// Bus delivers events of type T to its subscribers. Each subscriber has its
+// own bounded queue and goroutine, so a slow or failing subscriber never
+// blocks publishers or other subscribers, and memory stays bounded: what
+// happens to events that do not fit a queue is the subscriber's Policy, and
+// every dropped event is counted.
+type Bus[T any] struct {
+	name   string
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	subs   map[*subscriber[T]]struct{}
+	closed bool
+
+	dropped      *metrics.CounterVec
+	disconnected *metrics.CounterVec
+	failed       *metrics.CounterVec
+}
+
+type subscriber[T any] struct {
+	name    string
+	opts    Options
+	handler Handler[T]
+	queue   chan T
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// New creates a Bus. name labels its log lines and metrics.
+func New[T any](name string, reg *metrics.Registry, logger *slog.Logger) *Bus[T] {
+	return &Bus[T]{
+		name:   name,
+		logger: logger,
+		subs:   make(map[*subscriber[T]]struct{}),
+		dropped: reg.Counter("bus_events_dropped_total",
+			"Events dropped because a subscriber's queue was full.", "bus", "subscriber", "policy"),
+		disconnected: reg.Counter("bus_subscribers_disconnected_total",
+			"Subscribers removed because their queue was full.", "bus", "subscriber"),
+		failed: reg.Counter("bus_handler_errors_total",
+			"Events a subscriber failed to handle.", "bus", "subscriber"),
+	}
+}
+
+// Subscribe starts delivering events to handler through a queue of buffer
+// events (DefaultBuffer when zero), dropping new events when it is full. The
+// returned function unsubscribes and waits for events already queued to be
+// handled.
+func (b *Bus[T]) Subscribe(name string, buffer int, handler Handler[T]) (unsubscribe func()) {
+	return b.SubscribeWith(name, Options{Buffer: buffer}, handler)
+}
+
+// SubscribeWith is Subscribe with the queue length and full-queue policy
+// given by opts.
+func (b *Bus[T]) SubscribeWith(name string, opts Options, handler Handler[T]) (unsubscribe func()) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultBuffer
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &subscriber[T]{
+		name:    name,
+		opts:    opts,
+		handler: handler,
+		queue:   make(chan T, opts.Buffer),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		cancel()
+		close(sub.done)
+		return func() {}
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go b.run(sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			if _, ok := b.subs[sub]; ok {
+				delete(b.subs, sub)
+				close(sub.queue)
+			}
+			b.mu.Unlock()
+			<-sub.done
+		})
+	}
+}
+
+// Publish queues event for every subscriber without blocking, applying each
+// full subscriber's policy.
+func (b *Bus[T]) Publish(event T) {
+	var slow []*subscriber[T]
+
+	b.mu.RLock()
+	for sub := range b.subs {
+		if !b.offer(sub, event) {
+			slow = append(slow, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range slow {
+		b.disconnect(sub)
+	}
+}
+
+// offer queues event for sub and reports false when sub must be
+// disconnected.
+func (b *Bus[T]) offer(sub *subscriber[T], event T) bool {
+	select {
+	case sub.queue <- event:
+		return true
+	default:
+	}
+
+	switch sub.opts.Policy {
+	case Disconnect:
+		return false
+	case DropOldest:
+		// Publishers and the consumer race for the queue, so both steps
+		// may fail; then the new event is dropped instead.
+		select {
+		case <-sub.queue:
+		default:
+		}
+		select {
+		case sub.queue <- event:
+		default:
+		}
+	}
+	b.dropped.Inc(b.name, sub.name, sub.opts.Policy.String())
+	b.logger.Warn("bus subscriber queue full, event dropped",
+		"bus", b.name, "subscriber", sub.name, "policy", sub.opts.Policy.String())
+	return true
+}
+
+// disconnect removes a subscriber whose queue overflowed. Its handler sees
+// its context cancelled while the queued events drain.
+func (b *Bus[T]) disconnect(sub *subscriber[T]) {
+	b.mu.Lock()
+	_, ok := b.subs[sub]
+	if ok {
+		delete(b.subs, sub)
+		close(sub.queue)
+	}
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	sub.cancel()
+	b.disconnected.Inc(b.name, sub.name)
+	b.logger.Warn("bus subscriber too slow, disconnected", "bus", b.name, "subscriber", sub.name)
+	if sub.opts.OnDisconnect != nil {
+		sub.opts.OnDisconnect()
+	}
+}
+
+// Queued returns how many events wait in each subscriber's queue.
+func (b *Bus[T]) Queued() map[string]int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	queued := make(map[string]int, len(b.subs))
+	for sub := range b.subs {
+		queued[sub.name] += len(sub.queue)
+	}
+	return queued
+}
+
+// Close stops accepting subscribers, lets every subscriber finish its queued
+// events and waits for them.
+func (b *Bus[T]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	subs := make([]*subscriber[T], 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+		close(sub.queue)
+	}
+	clear(b.subs)
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		<-sub.done
+	}
+}
+
+func (b *Bus[T]) run(sub *subscriber[T]) {
+	defer close(sub.done)
+	defer sub.cancel()
+	for event := range sub.queue {
+		if err := b.handle(sub, event); err != nil {
+			b.failed.Inc(b.name, sub.name)
+			b.logger.Error("bus subscriber failed", "bus", b.name, "subscriber", sub.name, "error", err)
+		}
+	}
+}
+
+// handle runs the handler, turning a panic into an error so one bad event
+// does not kill the subscriber.
+func (b *Bus[T]) handle(sub *subscriber[T], event T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handler(sub.ctx, event)
+}
@@ -0,0 +1,204 @@
+package catalog
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 25
+	maxQueryLength      = 100
+	defaultPopularLimit = 10
+	maxPopularLimit     = 50
+)
+
+// Handler exposes the service name suggestions, popularity ranking and
+// plans.
+type Handler struct {
+	store Store
+	// minSubscribers is how many users a service needs to be listed as
+	// popular.
+	minSubscribers int
+	logger         *slog.Logger
+}
+
+// NewHandler creates a Handler backed by store.
+func NewHandler(store Store, minSubscribers int, logger *slog.Logger) *Handler {
+	return &Handler{store: store, minSubscribers: minSubscribers, logger: logger}
+}
+
+// RegisterRoutes mounts GET /services/suggest, /services/popular,
+// /services/plan-suggestions and /services/{name}/plans.
+func (h *Handler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/services/suggest", h.suggest)
+	router.GET("/services/popular", h.popular)
+	router.GET("/services/plan-suggestions", h.planSuggestions)
+	router.GET("/services/:name/plans", h.plans)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// suggest godoc
+// @Summary Suggest service names
+// @Description Ranked service names for typeahead: catalog entries plus the user's own past names, ranked by trigram similarity with prefix matches first
+// @Tags services
+// @Produce json
+// @Param q query string true "What the user has typed so far"
+// @Param user_id query string false "Include this user's service names, defaults to the caller"
+// @Param limit query int false "Maximum suggestions (<=25)" default(10)
+// @Success 200 {array} Suggestion
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID suggestServices
+// @Router /services/suggest [get]
+func (h *Handler) suggest(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if utf8.RuneCountInString(query) > maxQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is too long"})
+		return
+	}
+
+	limit := defaultSuggestLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(parsed, maxSuggestLimit)
+	}
+
+	caller, authenticated := identity.FromContext(c.Request.Context())
+	var userID *uuid.UUID
+	if value := c.Query("user_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		if !rbac.CanAccessUser(c.Request.Context(), parsed, rbac.ReadAnyUser) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this user's data"})
+			return
+		}
+		userID = &parsed
+	} else if authenticated {
+		userID = &caller.UserID
+	}
+
+	suggestions, err := h.store.Suggest(c.Request.Context(), query, userID, limit)
+	if err != nil {
+		h.logger.Error("failed to suggest services", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// popular godoc
+// @Summary Popular services
+// @Description Services most users run this month, with how many users and subscriptions and their average monthly price, for "people also track" suggestions. Served from a rollup refreshed hourly; services too few users run are left out.
+// @Tags services
+// @Produce json
+// @Param limit query int false "Maximum services (<=50)" default(10)
+// @Success 200 {array} Popular
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID popularServices
+// @Router /services/popular [get]
+func (h *Handler) popular(c *gin.Context) {
+	limit := defaultPopularLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(parsed, maxPopularLimit)
+	}
+
+	popular, err := h.store.Popular(c.Request.Context(), h.minSubscribers, limit)
+	if err != nil {
+		h.logger.Error("failed to list popular services", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, popular)
+}
+
+// plans godoc
+// @Summary Service plans
+// @Description The tiers a catalog service is sold in, e.g. Basic, Standard and Premium, with their monthly price and features, from the most limited up. Subscriptions reference one by plan_id. Services without plans return an empty list.
+// @Tags services
+// @Produce json
+// @Param name path string true "Service name, case-insensitive"
+// @Success 200 {array} Plan
+// @Failure 500 {object} errorResponse
+// @ID listServicePlans
+// @Router /services/{name}/plans [get]
+func (h *Handler) plans(c *gin.Context) {
+	plans, err := h.store.Plans(c.Request.Context(), strings.TrimSpace(c.Param("name")))
+	if err != nil {
+		h.logger.Error("failed to list service plans", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// planSuggestions godoc
+// @Summary Cheaper plans
+// @Description For every subscription the user runs this month whose service has a plan cheaper than what it pays, those plans, the closest in price first, and the monthly saving of the cheapest. Subscriptions that could save the most come first.
+// @Tags services
+// @Produce json
+// @Param user_id query string false "User to suggest for, defaults to the caller"
+// @Success 200 {array} PlanSuggestion
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID suggestCheaperPlans
+// @Router /services/plan-suggestions [get]
+func (h *Handler) planSuggestions(c *gin.Context) {
+	var userID uuid.UUID
+	if value := c.Query("user_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		if !rbac.CanAccessUser(c.Request.Context(), parsed, rbac.ReadAnyUser) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this user's data"})
+			return
+		}
+		userID = parsed
+	} else if caller, ok := identity.FromContext(c.Request.Context()); ok {
+		userID = caller.UserID
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	suggestions, err := h.store.PlanSuggestions(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to suggest plans", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Plan is one tier a catalog service is sold in.
+type Plan struct {
+	ID          int64  `json:"id"`
+	ServiceName string `json:"service_name"`
+	Tier        string `json:"tier" example:"Standard"`
+	// Rank orders the tiers of a service from the most limited up.
+	Rank     int      `json:"rank"`
+	PriceRUB int      `json:"price_rub"`
+	Features []string `json:"features"`
+}
+
+// PlanSuggestion lists the plans of a service that cost less than what a
+// subscription the user runs this month pays, the most similar first.
+type PlanSuggestion struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	PriceRUB       int       `json:"price_rub"`
+	// PlanID is the plan the subscription says it is on, if any.
+	PlanID  *int64 `json:"plan_id"`
+	Cheaper []Plan `json:"cheaper"`
+	// MaxSavingRUB is the monthly saving of switching to the cheapest plan.
+	MaxSavingRUB int `json:"max_saving_rub"`
+}
+
+const plansSQL = `
+SELECT id, service_name, tier, rank, price_rub, features
+FROM service_plans
+WHERE LOWER(service_name) = LOWER($1)
+ORDER BY rank, price_rub, id;
+`
+
+// planSuggestionsSQL pairs the user's subscriptions running this month with
+// every plan of the same service that is cheaper than what they pay. Plans
+// are priced monthly, so only monthly subscriptions compare.
+const planSuggestionsSQL = `
+SELECT s.id, s.service_name, s.price_rub, s.plan_id,
+       p.id, p.service_name, p.tier, p.rank, p.price_rub, p.features
+FROM subscriptions s
+JOIN service_plans p ON LOWER(p.service_name) = LOWER(s.service_name)
+WHERE s.user_id = $1
+  AND s.billing_period = 'monthly'
+  AND s.start_month <= date_trunc('month', now())::date
+  AND (s.end_month IS NULL OR s.end_month >= date_trunc('month', now())::date)
+  AND p.price_rub < s.price_rub
+ORDER BY s.price_rub DESC, s.id, p.price_rub DESC, p.id;
+`
+
+// Plans returns the plans of the service named name, compared
+// case-insensitively, from the most limited up. Services without plans get
+// an empty list.
+func (r *Repository) Plans(ctx context.Context, name string) ([]Plan, error) {
+	rows, err := r.db.QueryContext(ctx, plansSQL, name)
+	if err != nil {
+		return nil, fmt.Errorf("select service plans: %w", err)
+	}
+	defer rows.Close()
+
+	plans := []Plan{}
+	for rows.Next() {
+		var p Plan
+		if err := rows.Scan(&p.ID, &p.ServiceName, &p.Tier, &p.Rank, &p.PriceRUB, pq.Array(&p.Features)); err != nil {
+			return nil, fmt.Errorf("scan service plan: %w", err)
+		}
+		plans = append(plans, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return plans, nil
+}
+
+// PlanSuggestions returns, for every subscription userID runs this month
+// with a cheaper plan available, those plans. The subscriptions that could
+// save the most come first.
+func (r *Repository) PlanSuggestions(ctx context.Context, userID uuid.UUID) ([]PlanSuggestion, error) {
+	rows, err := r.db.QueryContext(ctx, planSuggestionsSQL, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select plan suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []PlanSuggestion{}
+	for rows.Next() {
+		var s PlanSuggestion
+		var p Plan
+		if err := rows.Scan(&s.SubscriptionID, &s.ServiceName, &s.PriceRUB, &s.PlanID,
+			&p.ID, &p.ServiceName, &p.Tier, &p.Rank, &p.PriceRUB, pq.Array(&p.Features)); err != nil {
+			return nil, fmt.Errorf("scan plan suggestion: %w", err)
+		}
+		if n := len(suggestions); n == 0 || suggestions[n-1].SubscriptionID != s.SubscriptionID {
+			suggestions = append(suggestions, s)
+		}
+		last := &suggestions[len(suggestions)-1]
+		last.Cheaper = append(last.Cheaper, p)
+		last.MaxSavingRUB = last.PriceRUB - p.PriceRUB
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	slices.SortStableFunc(suggestions, func(a, b PlanSuggestion) int {
+		return b.MaxSavingRUB - a.MaxSavingRUB
+	})
+	return suggestions, nil
+}
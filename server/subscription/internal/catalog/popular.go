@@ -0,0 +1,154 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/billing"
+	"github.com/beheryahmed1991/subscription-service.git/internal/lease"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+)
+
+// Popular is one service in the popularity rollup.
+type Popular struct {
+	Name string `json:"name"`
+	// Subscribers counts the distinct users running the service this month,
+	// Subscriptions their subscriptions to it.
+	Subscribers   int `json:"subscribers"`
+	Subscriptions int `json:"subscriptions"`
+	// AveragePrice is the mean monthly price, as in Price.
+	AveragePrice int       `json:"avg_price_rub"`
+	RefreshedAt  time.Time `json:"refreshed_at"`
+}
+
+// refreshPopularitySQL rebuilds the rollup from the subscriptions running in
+// the current month, grouping names case-insensitively.
+var refreshPopularitySQL = `
+INSERT INTO service_popularity (name_key, name, subscribers, subscriptions, avg_price_rub, refreshed_at)
+SELECT LOWER(service_name),
+       mode() WITHIN GROUP (ORDER BY service_name),
+       COUNT(DISTINCT user_id)::int,
+       COUNT(*)::int,
+       ROUND(AVG(` + billing.MonthlyPriceSQL("price_rub", "billing_period") + `))::int,
+       now()
+FROM subscriptions
+WHERE start_month <= date_trunc('month', now())::date
+  AND (end_month IS NULL OR end_month >= date_trunc('month', now())::date)
+GROUP BY LOWER(service_name);
+`
+
+const popularSQL = `
+SELECT name, subscribers, subscriptions, avg_price_rub, refreshed_at
+FROM service_popularity
+WHERE subscribers >= $1
+ORDER BY subscribers DESC, subscriptions DESC, name
+LIMIT $2;
+`
+
+// Popular returns up to limit services ordered by how many users run them.
+// Services with fewer than minSubscribers users are left out, so the rollup
+// does not reveal what a single user pays for.
+func (r *Repository) Popular(ctx context.Context, minSubscribers, limit int) ([]Popular, error) {
+	rows, err := r.db.QueryContext(ctx, popularSQL, minSubscribers, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select popular services: %w", err)
+	}
+	defer rows.Close()
+
+	popular := []Popular{}
+	for rows.Next() {
+		var p Popular
+		if err := rows.Scan(&p.Name, &p.Subscribers, &p.Subscriptions, &p.AveragePrice, &p.RefreshedAt); err != nil {
+			return nil, fmt.Errorf("scan popular service: %w", err)
+		}
+		popular = append(popular, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return popular, nil
+}
+
+// RefreshPopularity replaces the rollup in one transaction, so readers see
+// either the previous or the new ranking, and returns how many services it
+// holds.
+func (r *Repository) RefreshPopularity(ctx context.Context) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin popularity refresh: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM service_popularity`); err != nil {
+		return 0, fmt.Errorf("clear service popularity: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, refreshPopularitySQL)
+	if err != nil {
+		return 0, fmt.Errorf("refresh service popularity: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit popularity refresh: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PopularityRefresher is the part of the store PopularityJob writes through.
+type PopularityRefresher interface {
+	RefreshPopularity(ctx context.Context) (int64, error)
+}
+
+// PopularityJob keeps the popularity rollup current.
+type PopularityJob struct {
+	store    PopularityRefresher
+	interval time.Duration
+	logger   *slog.Logger
+	monitor  *monitor.Monitor
+	leases   *lease.Leases
+}
+
+// NewPopularityJob creates a PopularityJob that runs every interval, hourly
+// by default.
+func NewPopularityJob(store PopularityRefresher, interval time.Duration, logger *slog.Logger) *PopularityJob {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &PopularityJob{store: store, interval: interval, logger: logger}
+}
+
+// ReportTo records every refresh on m.
+func (j *PopularityJob) ReportTo(m *monitor.Monitor) {
+	j.monitor = m
+}
+
+// UseLeases runs the refresh on one replica at a time through l.
+func (j *PopularityJob) UseLeases(l *lease.Leases) {
+	j.leases = l
+}
+
+// Run refreshes the rollup until ctx is cancelled.
+func (j *PopularityJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		err := j.monitor.Track("service_popularity", func() error {
+			return j.leases.Do(ctx, "service_popularity", j.interval, func() error {
+				n, err := j.store.RefreshPopularity(ctx)
+				if err == nil {
+					j.logger.Info("service popularity refreshed", "services", n)
+				}
+				return err
+			})
+		})
+		if err != nil && ctx.Err() == nil {
+			j.logger.Error("service popularity refresh failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
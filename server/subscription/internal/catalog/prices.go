@@ -0,0 +1,151 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/billing"
+	"github.com/beheryahmed1991/subscription-service.git/internal/lease"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+)
+
+// Price is what the catalog knows about a service's monthly price, in rubles.
+type Price struct {
+	Name string `json:"name"`
+	// ListPrice is the provider's current price, maintained by hand.
+	ListPrice *int `json:"list_price_rub"`
+	// AveragePrice is the mean monthly price of the subscriptions running
+	// this month, over Samples of them. Prices billed per week, quarter or
+	// year are spread over the months first.
+	AveragePrice *int       `json:"avg_price_rub"`
+	Samples      int        `json:"price_samples"`
+	RefreshedAt  *time.Time `json:"prices_refreshed_at"`
+}
+
+const priceSQL = `
+SELECT name, list_price_rub, avg_price_rub, price_samples, prices_refreshed_at
+FROM service_catalog
+WHERE LOWER(name) = LOWER($1)
+LIMIT 1;
+`
+
+// refreshPricesSQL recomputes the average monthly price of every catalog
+// service from the subscriptions running in the current month. Services
+// nobody subscribes to any more lose their average.
+var refreshPricesSQL = `
+WITH running AS (
+    SELECT LOWER(service_name) AS name, ROUND(AVG(` + billing.MonthlyPriceSQL("price_rub", "billing_period") + `))::int AS avg_price, COUNT(*)::int AS samples
+    FROM subscriptions
+    WHERE start_month <= date_trunc('month', now())::date
+      AND (end_month IS NULL OR end_month >= date_trunc('month', now())::date)
+    GROUP BY LOWER(service_name)
+)
+UPDATE service_catalog c
+SET avg_price_rub = running.avg_price,
+    price_samples = COALESCE(running.samples, 0),
+    prices_refreshed_at = now()
+FROM service_catalog target
+LEFT JOIN running ON running.name = LOWER(target.name)
+WHERE c.name = target.name;
+`
+
+// PriceOf returns the reference prices of the catalog service named name,
+// compared case-insensitively. It reports false for services not in the
+// catalog.
+func (r *Repository) PriceOf(ctx context.Context, name string) (Price, bool, error) {
+	var p Price
+	var list, avg sql.NullInt64
+	var refreshed sql.NullTime
+	err := r.db.QueryRowContext(ctx, priceSQL, name).Scan(&p.Name, &list, &avg, &p.Samples, &refreshed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Price{}, false, nil
+	}
+	if err != nil {
+		return Price{}, false, fmt.Errorf("select catalog price: %w", err)
+	}
+	if list.Valid {
+		v := int(list.Int64)
+		p.ListPrice = &v
+	}
+	if avg.Valid {
+		v := int(avg.Int64)
+		p.AveragePrice = &v
+	}
+	if refreshed.Valid {
+		p.RefreshedAt = &refreshed.Time
+	}
+	return p, true, nil
+}
+
+// RefreshPrices recomputes the catalog averages and returns how many
+// services were updated.
+func (r *Repository) RefreshPrices(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, refreshPricesSQL)
+	if err != nil {
+		return 0, fmt.Errorf("refresh catalog prices: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PriceRefresher is the part of the store PriceJob writes through.
+type PriceRefresher interface {
+	RefreshPrices(ctx context.Context) (int64, error)
+}
+
+// PriceJob keeps the catalog's average prices current.
+type PriceJob struct {
+	store    PriceRefresher
+	interval time.Duration
+	logger   *slog.Logger
+	monitor  *monitor.Monitor
+	leases   *lease.Leases
+}
+
+// NewPriceJob creates a PriceJob that runs every interval, nightly by
+// default.
+func NewPriceJob(store PriceRefresher, interval time.Duration, logger *slog.Logger) *PriceJob {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &PriceJob{store: store, interval: interval, logger: logger}
+}
+
+// ReportTo records every refresh on m.
+func (j *PriceJob) ReportTo(m *monitor.Monitor) {
+	j.monitor = m
+}
+
+// UseLeases runs the refresh on one replica at a time through l.
+func (j *PriceJob) UseLeases(l *lease.Leases) {
+	j.leases = l
+}
+
+// Run refreshes the prices until ctx is cancelled.
+func (j *PriceJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		err := j.monitor.Track("catalog_prices", func() error {
+			return j.leases.Do(ctx, "catalog_prices", j.interval, func() error {
+				n, err := j.store.RefreshPrices(ctx)
+				if err == nil {
+					j.logger.Info("catalog prices refreshed", "services", n)
+				}
+				return err
+			})
+		})
+		if err != nil && ctx.Err() == nil {
+			j.logger.Error("catalog price refresh failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
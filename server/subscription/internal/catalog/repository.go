@@ -0,0 +1,118 @@
+// Package catalog suggests service names from the curated catalog and from
+// the names a user has already used, ranks services by popularity, and knows
+// the plans services are sold in.
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Suggestion sources.
+const (
+	SourceCatalog = "catalog"
+	SourceHistory = "history"
+)
+
+// Suggestion is one ranked service name.
+type Suggestion struct {
+	Name   string  `json:"name"`
+	Source string  `json:"source" enums:"catalog,history"`
+	Score  float64 `json:"score"`
+}
+
+// Store looks up service name suggestions, popularity and plans.
+type Store interface {
+	Suggest(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]Suggestion, error)
+	Popular(ctx context.Context, minSubscribers, limit int) ([]Popular, error)
+	Plans(ctx context.Context, name string) ([]Plan, error)
+	PlanSuggestions(ctx context.Context, userID uuid.UUID) ([]PlanSuggestion, error)
+}
+
+// suggestSQL ranks catalog entries and the user's own service names by
+// trigram similarity to $1, boosting prefix matches and names the user has
+// used before. $2 is a LIKE pattern for substring matches, which keeps short
+// queries (below trigram length) useful. Names are deduplicated
+// case-insensitively, keeping the best-ranked spelling.
+const suggestSQL = `
+WITH candidates AS (
+    SELECT name, 'catalog' AS source
+    FROM service_catalog
+    WHERE name % $1 OR name ILIKE $2
+    UNION ALL
+    SELECT DISTINCT service_name, 'history'
+    FROM subscriptions
+    WHERE $3::uuid IS NOT NULL
+      AND user_id = $3::uuid
+      AND (service_name % $1 OR service_name ILIKE $2)
+),
+ranked AS (
+    SELECT DISTINCT ON (LOWER(name))
+        name,
+        source,
+        similarity(name, $1)
+            + CASE WHEN LOWER(name) LIKE LOWER($4) THEN 0.5 ELSE 0 END
+            + CASE WHEN source = 'history' THEN 0.25 ELSE 0 END AS score
+    FROM candidates
+    ORDER BY LOWER(name), score DESC
+)
+SELECT name, source, score::float8
+FROM ranked
+ORDER BY score DESC, name
+LIMIT $5;
+`
+
+// Repository is the postgres implementation of Store.
+type Repository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewRepository wires the DB and logger into a Repository.
+func NewRepository(db *sql.DB, logger *slog.Logger) *Repository {
+	return &Repository{db: db, logger: logger}
+}
+
+// Suggest returns up to limit names matching query. userID, when set, adds
+// that user's historical service names.
+func (r *Repository) Suggest(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]Suggestion, error) {
+	var user interface{}
+	if userID != nil {
+		user = *userID
+	}
+	escaped := escapeLike(query)
+
+	rows, err := r.db.QueryContext(ctx, suggestSQL, query, "%"+escaped+"%", user, escaped+"%", limit)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("suggest services query failed", "error", err)
+		}
+		return nil, fmt.Errorf("suggest services: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []Suggestion{}
+	for rows.Next() {
+		var s Suggestion
+		if err := rows.Scan(&s.Name, &s.Source, &s.Score); err != nil {
+			return nil, fmt.Errorf("scan suggestion: %w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return suggestions, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes query match literally inside a LIKE pattern.
+func escapeLike(query string) string {
+	return likeEscaper.Replace(query)
+}
@@ -1,23 +1,64 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"net/netip"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/money"
 )
 
 // Config aggregates every tunable part of the application.
 type Config struct {
-	App     AppConfig
-	DB      DBConfig
-	Log     LogConfig
-	Swagger SwaggerConfig
+	App         AppConfig
+	DB          DBConfig
+	Log         LogConfig
+	Swagger     SwaggerConfig
+	Auth        AuthConfig
+	List        ListConfig
+	Telegram    TelegramConfig
+	LoadShed    LoadShedConfig
+	Summary     SummaryConfig
+	Maintenance MaintenanceConfig
+	Reports     ReportsConfig
+	SMTP        SMTPConfig
+	Rules       RulesConfig
+	Stats       StatsConfig
+	Ledger      LedgerConfig
+	Anomaly     AnomalyConfig
+	RateLimit   RateLimitConfig
+	Dashboard   DashboardConfig
+	Storage     StorageConfig
+	SLO         SLOConfig
+	Catalog     CatalogConfig
+	Audit       AuditConfig
+	Idempotency IdempotencyConfig
+	Health      HealthConfig
+	Webhooks    WebhooksConfig
+	Currency    CurrencyConfig
+	Metrics     MetricsConfig
+
+	// Settings lists every key Load resolved, with its source and secrets
+	// masked, for printing the effective configuration.
+	Settings []Setting
 }
 
 // AppConfig contains settings related to the HTTP server.
 type AppConfig struct {
 	Port string
 	Env  string
+	// DateFormat names the format months and dates are rendered with in JSON
+	// responses, exports and notifications.
+	DateFormat string
+	// ReadOnly rejects mutating requests and skips migrations and the jobs
+	// that write, for replicas pointed at a read-only database.
+	ReadOnly bool
 }
 
 // DBConfig represents PostgreSQL connection settings.
@@ -28,6 +69,44 @@ type DBConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+	// StrictConstraints enables the optional strict constraint migration set.
+	StrictConstraints bool
+	// SchemaDrift is what startup does when the live schema differs from
+	// migrations/schema.txt: off, warn or fail.
+	SchemaDrift string
+	// Timeouts are default per-operation query deadlines.
+	Timeouts DBTimeouts
+	// StatementTimeouts are enforced by Postgres itself.
+	StatementTimeouts DBStatementTimeouts
+	// ConnMaxIdleTime, ConnectAttempts and ConnectBackoff tune how the pool
+	// rides out a failover.
+	ConnMaxIdleTime time.Duration
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+	// PgBouncer sends every query in one round trip, for a transaction
+	// pooling PgBouncer in front of the database.
+	PgBouncer bool
+}
+
+// DBTimeouts are the default query deadlines per repository operation class.
+type DBTimeouts struct {
+	Get     time.Duration
+	List    time.Duration
+	Write   time.Duration
+	Summary time.Duration
+}
+
+// DBStatementTimeouts set statement_timeout, which Postgres enforces even
+// when a cancelled context fails to stop a query. Zero disables a limit.
+type DBStatementTimeouts struct {
+	// Session applies to every statement on every connection, migrations
+	// included. It is not sent through PgBouncer.
+	Session time.Duration
+	// List, Write and Summary apply per operation class, set locally on the
+	// transaction that runs it.
+	List    time.Duration
+	Write   time.Duration
+	Summary time.Duration
 }
 
 // DSN builds the postgres connection string from the individual fields.
@@ -66,66 +145,671 @@ type LogConfig struct {
 // SwaggerConfig configures the generated documentation.
 type SwaggerConfig struct {
 	Host string
+	// AdminToken is the basic auth password for the admin API, including the
+	// admin docs at /admin/swagger. Empty means only callers authenticated
+	// with an admin or support role can reach it.
+	AdminToken string
+}
+
+// AuthConfig configures JWT bearer authentication. It is enabled when
+// JWTSecret or JWKSURL is set; otherwise requests stay anonymous.
+type AuthConfig struct {
+	// JWTSecret verifies HMAC-signed (HS256/384/512) tokens.
+	JWTSecret string
+	// JWKSURL publishes the keys for RSA and ECDSA signed tokens.
+	JWKSURL     string
+	JWKSRefresh time.Duration
+	// Issuer and Audience, when set, must match the token's iss and aud.
+	Issuer   string
+	Audience string
+}
+
+// Enabled reports whether requests must carry a bearer token.
+func (a AuthConfig) Enabled() bool {
+	return a.JWTSecret != "" || a.JWKSURL != ""
+}
+
+// ListConfig controls defaults for the list endpoint.
+type ListConfig struct {
+	DefaultSort  string
+	DefaultLimit int
+	MaxLimit     int
+	// RedactFields are hidden from callers that neither own a record nor are support or admins.
+	RedactFields []string
+	// StreamTimeout bounds a whole /subscriptions/stream response and
+	// StreamWriteTimeout each row written to a client that stopped reading.
+	StreamTimeout      time.Duration
+	StreamWriteTimeout time.Duration
+}
+
+// TelegramConfig configures the optional Telegram bot binary.
+type TelegramConfig struct {
+	Token       string
+	PollTimeout time.Duration
+}
+
+// LoadShedConfig sets the thresholds for shedding low-priority requests.
+type LoadShedConfig struct {
+	MaxInFlight int
+	P99Budget   time.Duration
+	Window      int
+	RetryAfter  time.Duration
+}
+
+// SummaryConfig controls cost calculation semantics.
+type SummaryConfig struct {
+	// EndMonthInclusive charges the end month itself ("through March") unless a
+	// subscription overrides it.
+	EndMonthInclusive bool
+	// FromLedger reads closed months from the charges ledger instead of
+	// recomputing them.
+	FromLedger bool
+}
+
+// RulesConfig holds the subscription sanity limits. Zero values disable a
+// rule; months use the API formats (YYYY-MM or MM-YYYY).
+type RulesConfig struct {
+	MaxPrice       int
+	EarliestStart  string
+	LatestEnd      string
+	BannedServices []string
+	// WarnPrice accepts prices above it with a warning; WarnOnly turns every
+	// violation into a warning.
+	WarnPrice int
+	WarnOnly  bool
+}
+
+// ReportsConfig controls the scheduled report delivery job.
+type ReportsConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+}
+
+// StatsConfig controls the live statistics exported on /metrics.
+type StatsConfig struct {
+	// LiveInterval is how often the active subscription counts are refreshed.
+	LiveInterval time.Duration
+}
+
+// LedgerConfig controls the job that closes finished months into the charges
+// ledger.
+type LedgerConfig struct {
+	Enabled  bool
+	Interval time.Duration
 }
 
-// Load reads environment variables and validates the final configuration.
+// AnomalyConfig controls the spend anomaly analysis, which reads closed
+// months from the charges ledger and so needs the ledger job running.
+type AnomalyConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	// ChangePct is the month-over-month growth, in percent, above which a
+	// user is flagged; MinIncrease ignores jumps smaller than this many rubles.
+	ChangePct   int
+	MinIncrease int
+	// Notify tells users on their report schedule destinations.
+	Notify bool
+}
+
+// RateLimitConfig controls per-caller request limits. Users may have an
+// override in the user_quotas table.
+type RateLimitConfig struct {
+	Enabled   bool
+	PerMinute int
+	// CacheTTL is how long a user's override is cached between lookups.
+	CacheTTL time.Duration
+}
+
+// DashboardConfig controls GET /me/dashboard.
+type DashboardConfig struct {
+	// CacheTTL is how long a user's dashboard is served from memory.
+	CacheTTL time.Duration
+}
+
+// StorageConfig selects the blob store for exports and other files. Backend
+// is empty (disabled), local or s3; s3 also covers MinIO.
+type StorageConfig struct {
+	Backend string
+	// LinkTTL is how long signed download links stay valid.
+	LinkTTL time.Duration
+
+	LocalDir string
+	// PublicURL is this service's external base URL, used in local download
+	// links, which are signed with LinkSecret.
+	PublicURL  string
+	LinkSecret string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// CatalogConfig controls the catalog reference prices behind
+// GET /subscriptions/{id}/price-check and the popularity rollup behind
+// GET /services/popular.
+type CatalogConfig struct {
+	// PricesEnabled runs the job that refreshes the average prices.
+	PricesEnabled bool
+	PriceInterval time.Duration
+	// PriceMinSamples is how many running subscriptions an average needs
+	// before it is used as a reference.
+	PriceMinSamples int
+	// PriceTolerancePct is how far, in percent, a price may differ from the
+	// reference before it is flagged.
+	PriceTolerancePct int
+	// PopularEnabled runs the job that refreshes the rollup behind
+	// GET /services/popular.
+	PopularEnabled  bool
+	PopularInterval time.Duration
+	// PopularMinUsers is how many users must run a service before it is
+	// listed as popular.
+	PopularMinUsers int
+}
+
+// AuditConfig controls what the audit log is used for.
+type AuditConfig struct {
+	// UndoWindow is how long after an update POST /subscriptions/{id}/undo
+	// can still revert it.
+	UndoWindow time.Duration
+}
+
+// WebhooksConfig tunes the outbound notification pool that delivers
+// webhooks and report emails.
+type WebhooksConfig struct {
+	// Timeout bounds one webhook request.
+	Timeout   time.Duration
+	Workers   int
+	QueueSize int
+	// PerDestination caps concurrent deliveries to one host.
+	PerDestination int
+	// MaxAttempts, BaseBackoff and MaxBackoff shape the retries of a failed
+	// delivery.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// CurrencyConfig sets how prices in other currencies are converted to
+// rubles.
+type CurrencyConfig struct {
+	// Rates is rubles per whole unit of each currency. A currency without a
+	// rate cannot be used.
+	Rates money.StaticRates
+}
+
+// MetricsConfig controls who can scrape /metrics and where it is served.
+type MetricsConfig struct {
+	// Port serves /metrics on its own listener instead of the API port, so it
+	// can stay off the public network; empty keeps it on the API port.
+	Port string
+	// AllowedIPs are addresses or CIDR ranges that may scrape without
+	// credentials.
+	AllowedIPs []string
+	// Username and Password let other scrapers in with basic auth. With no
+	// allowlist and no credentials the endpoint is open.
+	Username string
+	Password string
+}
+
+// HealthConfig controls the readiness probe.
+type HealthConfig struct {
+	// CheckTimeout bounds all dependency checks of one /readyz request.
+	CheckTimeout time.Duration
+}
+
+// IdempotencyConfig controls Idempotency-Key handling on create.
+type IdempotencyConfig struct {
+	// TTL is how long a key and its stored response are kept.
+	TTL time.Duration
+}
+
+// SLOConfig sets the availability and latency objectives per route class.
+// Targets are fractions, e.g. 0.999.
+type SLOConfig struct {
+	// Window is the period each error budget covers.
+	Window          time.Duration
+	RefreshInterval time.Duration
+
+	ReadAvailability  float64
+	ReadLatency       time.Duration
+	WriteAvailability float64
+	WriteLatency      time.Duration
+	// LatencyTarget is the share of requests that must beat the class latency.
+	LatencyTarget float64
+}
+
+// SMTPConfig is the outgoing mail server for email notifications.
+type SMTPConfig struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+	// TemplateDir holds <name>.subject.txt and <name>.html files that
+	// replace the built-in email copy; empty keeps it.
+	TemplateDir string
+}
+
+// MaintenanceConfig controls maintenance mode, during which mutating
+// endpoints return 503.
+type MaintenanceConfig struct {
+	Enabled     bool
+	Message     string
+	BypassToken string
+}
+
+// Load resolves every setting and validates the final configuration.
+// Environment variables win over the YAML file named by CONFIG_FILE, which
+// wins over the defaults of the APP_ENV profile (dev, test, staging or prod),
+// which win over the built-in defaults.
 func Load() (Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	r, err := newResolver()
+	if err != nil {
+		return Config{}, err
+	}
+	active = r
+	defer func() { active = nil }()
+
 	cfg := Config{
 		App: AppConfig{
-			Port: getEnv("APP_PORT", "8080"),
-			Env:  getEnv("APP_ENV", "dev"),
+			Port:       getEnv("APP_PORT", "8080"),
+			Env:        getEnv("APP_ENV", "dev"),
+			DateFormat: getEnv("DATE_FORMAT", "rfc3339"),
+			ReadOnly:   getEnvBool("READ_ONLY", false),
 		},
 		DB: DBConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", ""),
-			Password: getEnv("DB_PASSWORD", ""),
-			Name:     getEnv("DB_NAME", ""),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:              getEnv("DB_HOST", "localhost"),
+			Port:              getEnv("DB_PORT", "5432"),
+			User:              getEnv("DB_USER", ""),
+			Password:          getEnv("DB_PASSWORD", ""),
+			Name:              getEnv("DB_NAME", ""),
+			SSLMode:           getEnv("DB_SSLMODE", "disable"),
+			StrictConstraints: getEnvBool("DB_STRICT_CONSTRAINTS", false),
+			SchemaDrift:       strings.ToLower(getEnv("DB_SCHEMA_DRIFT", "warn")),
+			Timeouts: DBTimeouts{
+				Get:     getEnvDuration("DB_TIMEOUT_GET", 500*time.Millisecond),
+				List:    getEnvDuration("DB_TIMEOUT_LIST", 2*time.Second),
+				Write:   getEnvDuration("DB_TIMEOUT_WRITE", 2*time.Second),
+				Summary: getEnvDuration("DB_TIMEOUT_SUMMARY", 3*time.Second),
+			},
+			StatementTimeouts: DBStatementTimeouts{
+				Session: getEnvDuration("DB_STATEMENT_TIMEOUT", 0),
+				List:    getEnvDuration("DB_STATEMENT_TIMEOUT_LIST", 0),
+				Write:   getEnvDuration("DB_STATEMENT_TIMEOUT_WRITE", 0),
+				Summary: getEnvDuration("DB_STATEMENT_TIMEOUT_SUMMARY", 0),
+			},
+			ConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+			ConnectAttempts: getEnvInt("DB_CONNECT_ATTEMPTS", 3),
+			ConnectBackoff:  getEnvDuration("DB_CONNECT_BACKOFF", 200*time.Millisecond),
+			PgBouncer:       getEnvBool("DB_PGBOUNCER", false),
 		},
 		Log: LogConfig{
 			Level: strings.ToLower(getEnv("LOG_LEVEL", "info")),
 		},
 		Swagger: SwaggerConfig{
-			Host: getEnv("SWAGGER_HOST", ""),
+			Host:       getEnv("SWAGGER_HOST", ""),
+			AdminToken: getEnv("SWAGGER_ADMIN_TOKEN", ""),
+		},
+		Auth: AuthConfig{
+			JWTSecret:   getEnv("AUTH_JWT_SECRET", ""),
+			JWKSURL:     getEnv("AUTH_JWKS_URL", ""),
+			JWKSRefresh: getEnvDuration("AUTH_JWKS_REFRESH", time.Hour),
+			Issuer:      getEnv("AUTH_ISSUER", ""),
+			Audience:    getEnv("AUTH_AUDIENCE", ""),
+		},
+		List: ListConfig{
+			DefaultSort:        getEnv("LIST_DEFAULT_SORT", "created_at desc"),
+			DefaultLimit:       getEnvInt("LIST_DEFAULT_LIMIT", 0),
+			MaxLimit:           getEnvInt("LIST_MAX_LIMIT", 0),
+			RedactFields:       getEnvList("REDACT_FIELDS", []string{"user_id"}),
+			StreamTimeout:      getEnvDuration("LIST_STREAM_TIMEOUT", 5*time.Minute),
+			StreamWriteTimeout: getEnvDuration("LIST_STREAM_WRITE_TIMEOUT", 10*time.Second),
+		},
+		Telegram: TelegramConfig{
+			Token:       getEnv("TELEGRAM_BOT_TOKEN", ""),
+			PollTimeout: getEnvDuration("TELEGRAM_POLL_TIMEOUT", 30*time.Second),
+		},
+		LoadShed: LoadShedConfig{
+			MaxInFlight: getEnvInt("LOADSHED_MAX_IN_FLIGHT", 200),
+			P99Budget:   getEnvDuration("LOADSHED_P99_BUDGET", 2*time.Second),
+			Window:      getEnvInt("LOADSHED_WINDOW", 512),
+			RetryAfter:  getEnvDuration("LOADSHED_RETRY_AFTER", 5*time.Second),
+		},
+		Summary: SummaryConfig{
+			EndMonthInclusive: getEnvBool("SUMMARY_END_MONTH_INCLUSIVE", true),
+			FromLedger:        getEnvBool("SUMMARY_FROM_LEDGER", false),
+		},
+		Maintenance: MaintenanceConfig{
+			Enabled:     getEnvBool("MAINTENANCE_MODE", false),
+			Message:     getEnv("MAINTENANCE_MESSAGE", ""),
+			BypassToken: getEnv("MAINTENANCE_BYPASS_TOKEN", ""),
+		},
+		Rules: RulesConfig{
+			MaxPrice:       getEnvInt("RULES_MAX_PRICE", 0),
+			EarliestStart:  getEnv("RULES_EARLIEST_START", ""),
+			LatestEnd:      getEnv("RULES_LATEST_END", ""),
+			BannedServices: getEnvList("RULES_BANNED_SERVICES", nil),
+			WarnPrice:      getEnvInt("RULES_WARN_PRICE", 0),
+			WarnOnly:       getEnvBool("RULES_WARN_ONLY", false),
+		},
+		Reports: ReportsConfig{
+			Enabled:      getEnvBool("REPORTS_ENABLED", true),
+			PollInterval: getEnvDuration("REPORTS_POLL_INTERVAL", time.Minute),
+		},
+		Stats: StatsConfig{
+			LiveInterval: getEnvDuration("STATS_LIVE_INTERVAL", time.Minute),
+		},
+		Ledger: LedgerConfig{
+			Enabled:  getEnvBool("LEDGER_ENABLED", true),
+			Interval: getEnvDuration("LEDGER_INTERVAL", 24*time.Hour),
+		},
+		Anomaly: AnomalyConfig{
+			Enabled:     getEnvBool("ANOMALY_ENABLED", true),
+			Interval:    getEnvDuration("ANOMALY_INTERVAL", 24*time.Hour),
+			ChangePct:   getEnvInt("ANOMALY_CHANGE_PCT", 50),
+			MinIncrease: getEnvInt("ANOMALY_MIN_INCREASE", 0),
+			Notify:      getEnvBool("ANOMALY_NOTIFY", false),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   getEnvBool("RATE_LIMIT_ENABLED", false),
+			PerMinute: getEnvInt("RATE_LIMIT_PER_MINUTE", 600),
+			CacheTTL:  getEnvDuration("RATE_LIMIT_CACHE_TTL", time.Minute),
+		},
+		Dashboard: DashboardConfig{
+			CacheTTL: getEnvDuration("DASHBOARD_CACHE_TTL", 30*time.Second),
+		},
+		Storage: StorageConfig{
+			Backend:     strings.ToLower(getEnv("STORAGE_BACKEND", "")),
+			LinkTTL:     getEnvDuration("STORAGE_LINK_TTL", 24*time.Hour),
+			LocalDir:    getEnv("STORAGE_LOCAL_DIR", "data/blobs"),
+			PublicURL:   getEnv("STORAGE_PUBLIC_URL", ""),
+			LinkSecret:  getEnv("STORAGE_LINK_SECRET", ""),
+			S3Endpoint:  getEnv("STORAGE_S3_ENDPOINT", ""),
+			S3Region:    getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("STORAGE_S3_BUCKET", ""),
+			S3AccessKey: getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("STORAGE_S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvBool("STORAGE_S3_PATH_STYLE", false),
+		},
+		Catalog: CatalogConfig{
+			PricesEnabled:     getEnvBool("CATALOG_PRICES_ENABLED", true),
+			PriceInterval:     getEnvDuration("CATALOG_PRICE_INTERVAL", 24*time.Hour),
+			PriceMinSamples:   getEnvInt("CATALOG_PRICE_MIN_SAMPLES", 5),
+			PriceTolerancePct: getEnvInt("CATALOG_PRICE_TOLERANCE_PCT", 20),
+			PopularEnabled:    getEnvBool("CATALOG_POPULAR_ENABLED", true),
+			PopularInterval:   getEnvDuration("CATALOG_POPULAR_INTERVAL", time.Hour),
+			PopularMinUsers:   getEnvInt("CATALOG_POPULAR_MIN_USERS", 3),
+		},
+		Audit: AuditConfig{
+			UndoWindow: getEnvDuration("AUDIT_UNDO_WINDOW", 15*time.Minute),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		},
+		Health: HealthConfig{
+			CheckTimeout: getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		},
+		Metrics: MetricsConfig{
+			Port:       getEnv("METRICS_PORT", ""),
+			AllowedIPs: getEnvList("METRICS_ALLOWED_IPS", nil),
+			Username:   getEnv("METRICS_USERNAME", ""),
+			Password:   getEnv("METRICS_PASSWORD", ""),
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:        getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			Workers:        getEnvInt("WEBHOOK_WORKERS", 4),
+			QueueSize:      getEnvInt("WEBHOOK_QUEUE_SIZE", 1024),
+			PerDestination: getEnvInt("WEBHOOK_PER_DESTINATION", 2),
+			MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+			BaseBackoff:    getEnvDuration("WEBHOOK_BASE_BACKOFF", time.Second),
+			MaxBackoff:     getEnvDuration("WEBHOOK_MAX_BACKOFF", 5*time.Minute),
+		},
+		Currency: CurrencyConfig{
+			Rates: getEnvRates("FX_RATES"),
+		},
+		SLO: SLOConfig{
+			Window:            getEnvDuration("SLO_WINDOW", 30*24*time.Hour),
+			RefreshInterval:   getEnvDuration("SLO_REFRESH_INTERVAL", 30*time.Second),
+			ReadAvailability:  getEnvFloat("SLO_READ_AVAILABILITY", 0.999),
+			ReadLatency:       getEnvDuration("SLO_READ_LATENCY", 300*time.Millisecond),
+			WriteAvailability: getEnvFloat("SLO_WRITE_AVAILABILITY", 0.999),
+			WriteLatency:      getEnvDuration("SLO_WRITE_LATENCY", time.Second),
+			LatencyTarget:     getEnvFloat("SLO_LATENCY_TARGET", 0.99),
+		},
+		SMTP: SMTPConfig{
+			Addr:        getEnv("SMTP_ADDR", ""),
+			From:        getEnv("SMTP_FROM", "reports@localhost"),
+			Username:    getEnv("SMTP_USERNAME", ""),
+			Password:    getEnv("SMTP_PASSWORD", ""),
+			TemplateDir: getEnv("SMTP_TEMPLATE_DIR", ""),
 		},
 	}
 
 	if cfg.Swagger.Host == "" {
 		cfg.Swagger.Host = fmt.Sprintf("localhost:%s", cfg.App.Port)
 	}
+	if cfg.Storage.PublicURL == "" {
+		cfg.Storage.PublicURL = fmt.Sprintf("http://localhost:%s", cfg.App.Port)
+	}
 
-	if err := cfg.validate(); err != nil {
-		return Config{}, err
+	if unknown := r.unknownKeys(); len(unknown) > 0 {
+		return Config{}, fmt.Errorf("unknown settings in config file: %s", strings.Join(unknown, ", "))
+	}
+	if problems := append(r.invalid, cfg.validate()...); len(problems) > 0 {
+		sort.Strings(problems)
+		return Config{}, errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
 	}
+	cfg.Settings = r.settings()
 
 	return cfg, nil
 }
 
-func (cfg Config) validate() error {
-	var missing []string
+// validate lists every problem with the resolved values, naming the setting
+// to fix, so a bad deployment is fixed in one round instead of one error at
+// a time.
+func (cfg Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
 
-	if cfg.DB.User == "" {
-		missing = append(missing, "DB_USER")
+	for key, value := range map[string]string{
+		"DB_USER":     cfg.DB.User,
+		"DB_PASSWORD": cfg.DB.Password,
+		"DB_NAME":     cfg.DB.Name,
+	} {
+		if value == "" {
+			add("%s is required", key)
+		}
 	}
-	if cfg.DB.Password == "" {
-		missing = append(missing, "DB_PASSWORD")
+
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			add("%s: %q is not one of %s", key, value, strings.Join(allowed, ", "))
+		}
 	}
-	if cfg.DB.Name == "" {
-		missing = append(missing, "DB_NAME")
+	oneOf("LOG_LEVEL", cfg.Log.Level, "debug", "info", "warn", "error")
+	oneOf("DB_SCHEMA_DRIFT", cfg.DB.SchemaDrift, "off", "warn", "fail")
+
+	for key, value := range map[string]time.Duration{
+		"DB_TIMEOUT_GET":               cfg.DB.Timeouts.Get,
+		"DB_TIMEOUT_LIST":              cfg.DB.Timeouts.List,
+		"DB_TIMEOUT_WRITE":             cfg.DB.Timeouts.Write,
+		"DB_TIMEOUT_SUMMARY":           cfg.DB.Timeouts.Summary,
+		"DB_STATEMENT_TIMEOUT":         cfg.DB.StatementTimeouts.Session,
+		"DB_STATEMENT_TIMEOUT_LIST":    cfg.DB.StatementTimeouts.List,
+		"DB_STATEMENT_TIMEOUT_WRITE":   cfg.DB.StatementTimeouts.Write,
+		"DB_STATEMENT_TIMEOUT_SUMMARY": cfg.DB.StatementTimeouts.Summary,
+		"DB_CONN_MAX_IDLE_TIME":        cfg.DB.ConnMaxIdleTime,
+		"LIST_STREAM_TIMEOUT":          cfg.List.StreamTimeout,
+		"LIST_STREAM_WRITE_TIMEOUT":    cfg.List.StreamWriteTimeout,
+		"DB_CONNECT_BACKOFF":           cfg.DB.ConnectBackoff,
+		"AUTH_JWKS_REFRESH":            cfg.Auth.JWKSRefresh,
+		"LOADSHED_P99_BUDGET":          cfg.LoadShed.P99Budget,
+		"LOADSHED_RETRY_AFTER":         cfg.LoadShed.RetryAfter,
+		"RATE_LIMIT_CACHE_TTL":         cfg.RateLimit.CacheTTL,
+		"DASHBOARD_CACHE_TTL":          cfg.Dashboard.CacheTTL,
+		"IDEMPOTENCY_TTL":              cfg.Idempotency.TTL,
+		"HEALTH_CHECK_TIMEOUT":         cfg.Health.CheckTimeout,
+		"WEBHOOK_TIMEOUT":              cfg.Webhooks.Timeout,
+		"WEBHOOK_BASE_BACKOFF":         cfg.Webhooks.BaseBackoff,
+		"WEBHOOK_MAX_BACKOFF":          cfg.Webhooks.MaxBackoff,
+	} {
+		if value < 0 {
+			add("%s: %s is negative", key, value)
+		}
 	}
 
-	if len(missing) > 0 {
-		return fmt.Errorf("missing required configuration: %s", strings.Join(missing, ", "))
+	for key, value := range map[string]int{
+		"DB_CONNECT_ATTEMPTS":     cfg.DB.ConnectAttempts,
+		"LOADSHED_WINDOW":         cfg.LoadShed.Window,
+		"RATE_LIMIT_PER_MINUTE":   cfg.RateLimit.PerMinute,
+		"WEBHOOK_WORKERS":         cfg.Webhooks.Workers,
+		"WEBHOOK_QUEUE_SIZE":      cfg.Webhooks.QueueSize,
+		"WEBHOOK_PER_DESTINATION": cfg.Webhooks.PerDestination,
+		"WEBHOOK_MAX_ATTEMPTS":    cfg.Webhooks.MaxAttempts,
+	} {
+		if value <= 0 {
+			add("%s: must be positive, got %d", key, value)
+		}
 	}
 
-	return nil
+	if cfg.List.DefaultLimit > 0 && cfg.List.MaxLimit > 0 && cfg.List.DefaultLimit > cfg.List.MaxLimit {
+		add("LIST_DEFAULT_LIMIT: %d exceeds LIST_MAX_LIMIT %d", cfg.List.DefaultLimit, cfg.List.MaxLimit)
+	}
+	if cfg.Webhooks.MaxBackoff > 0 && cfg.Webhooks.BaseBackoff > cfg.Webhooks.MaxBackoff {
+		add("WEBHOOK_BASE_BACKOFF: %s exceeds WEBHOOK_MAX_BACKOFF %s", cfg.Webhooks.BaseBackoff, cfg.Webhooks.MaxBackoff)
+	}
+	for key, value := range map[string]float64{
+		"SLO_READ_AVAILABILITY":  cfg.SLO.ReadAvailability,
+		"SLO_WRITE_AVAILABILITY": cfg.SLO.WriteAvailability,
+		"SLO_LATENCY_TARGET":     cfg.SLO.LatencyTarget,
+	} {
+		if value <= 0 || value >= 1 {
+			add("%s: %v is not between 0 and 1", key, value)
+		}
+	}
+	if (cfg.Metrics.Username == "") != (cfg.Metrics.Password == "") {
+		add("METRICS_USERNAME and METRICS_PASSWORD must be set together")
+	}
+	if port := cfg.Metrics.Port; port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			add("METRICS_PORT: %q is not a port number", port)
+		} else if port == cfg.App.Port {
+			add("METRICS_PORT: %s is the API port; leave it empty to serve metrics there", port)
+		}
+	}
+	for _, entry := range cfg.Metrics.AllowedIPs {
+		if _, err := netip.ParsePrefix(entry); err != nil {
+			if _, err := netip.ParseAddr(entry); err != nil {
+				add("METRICS_ALLOWED_IPS: %q is not an IP address or CIDR range", entry)
+			}
+		}
+	}
+	if cfg.Auth.JWKSURL != "" {
+		if u, err := url.Parse(cfg.Auth.JWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("AUTH_JWKS_URL: %q is not an http(s) URL", cfg.Auth.JWKSURL)
+		}
+	}
+	return problems
 }
 
 func getEnv(key, fallback string) string {
-	value, ok := os.LookupEnv(key)
-	if !ok || value == "" {
+	value, ok := lookup(key)
+	if !ok {
+		noteDefault(key, fallback)
 		return fallback
 	}
 	return value
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	value, ok := lookup(key)
+	if !ok {
+		noteDefault(key, fallback)
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		invalidValue(key, value, "a boolean")
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := lookup(key)
+	if !ok {
+		noteDefault(key, fallback)
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		invalidValue(key, value, "a duration")
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, ok := lookup(key)
+	if !ok {
+		noteDefault(key, fallback)
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		invalidValue(key, value, "an integer")
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, ok := lookup(key)
+	if !ok {
+		noteDefault(key, fallback)
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		invalidValue(key, value, "a number")
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvRates reads an exchange rate table such as "USD=92.5,EUR=99.8".
+func getEnvRates(key string) money.StaticRates {
+	value, ok := lookup(key)
+	if !ok {
+		noteDefault(key, "")
+		return nil
+	}
+	rates, err := money.ParseRates(value)
+	if err != nil {
+		invalidValue(key, value, "a list of CODE=RATE ("+err.Error()+")")
+		return nil
+	}
+	return rates
+}
+
+func getEnvList(key string, fallback []string) []string {
+	value, ok := lookup(key)
+	if !ok {
+		noteDefault(key, strings.Join(fallback, ","))
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
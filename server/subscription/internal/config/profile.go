@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// profiles are the per-environment defaults selected by APP_ENV. They sit
+// between the built-in defaults and the config file, so a profile only
+// lists what differs from development.
+var profiles = map[string]map[string]string{
+	"dev": {
+		"LOG_LEVEL": "debug",
+	},
+	"test": {},
+	"staging": {
+		"DB_SSLMODE":          "require",
+		"RATE_LIMIT_ENABLED":  "true",
+		"DB_CONNECT_ATTEMPTS": "5",
+	},
+	"prod": {
+		"DB_SSLMODE":          "require",
+		"RATE_LIMIT_ENABLED":  "true",
+		"DB_CONNECT_ATTEMPTS": "5",
+		"LOG_LEVEL":           "info",
+	},
+}
+
+// Setting sources, from the lowest precedence to the highest.
+const (
+	SourceDefault = "default"
+	SourceProfile = "profile"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Setting is the effective value of one configuration key and where it came
+// from.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// resolver layers the sources for one Load: environment variables override
+// the config file, which overrides the APP_ENV profile.
+type resolver struct {
+	file    map[string]string
+	profile map[string]string
+	used    map[string]Setting
+	// invalid lists values that failed to parse.
+	invalid []string
+}
+
+var (
+	// loadMu serializes Load, which resolves through the active resolver.
+	loadMu sync.Mutex
+	active *resolver
+)
+
+// newResolver reads the file named by CONFIG_FILE, if any, and selects the
+// profile named by APP_ENV from the environment or the file.
+func newResolver() (*resolver, error) {
+	r := &resolver{file: map[string]string{}, used: map[string]Setting{}}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		var doc map[string]any
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+		if err := flatten("", doc, r.file); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = r.file["APP_ENV"]
+	}
+	if env == "" {
+		env = "dev"
+	}
+	profile, ok := profiles[env]
+	if !ok {
+		return nil, fmt.Errorf("unknown APP_ENV %q, want one of %s", env, strings.Join(profileNames(), ", "))
+	}
+	r.profile = profile
+	return r, nil
+}
+
+// flatten turns nested YAML sections into environment-style keys, so
+//
+//	db:
+//	  host: localhost
+//
+// sets DB_HOST. Keys are always the environment variable names, so sections
+// only group them: REDACT_FIELDS is written at the top level, not under list.
+// Lists become comma-separated values.
+func flatten(prefix string, node map[string]any, out map[string]string) error {
+	for name, value := range node {
+		key := strings.ToUpper(name)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// lookup resolves key through the active layers and records the result.
+// Outside Load only the environment is consulted.
+func lookup(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		active.note(key, value, SourceEnv)
+		return value, true
+	}
+	if active == nil {
+		return "", false
+	}
+	if value, ok := active.file[key]; ok && value != "" {
+		active.note(key, value, SourceFile)
+		return value, true
+	}
+	if value, ok := active.profile[key]; ok {
+		active.note(key, value, SourceProfile)
+		return value, true
+	}
+	return "", false
+}
+
+// noteDefault records that key fell back to its built-in default.
+func noteDefault(key string, value any) {
+	active.note(key, fmt.Sprint(value), SourceDefault)
+}
+
+// invalidValue records that key holds a value that is not want. The setting
+// falls back to its default, but Load fails.
+func invalidValue(key, value, want string) {
+	if active != nil {
+		active.invalid = append(active.invalid, fmt.Sprintf("%s: %q is not %s", key, value, want))
+	}
+}
+
+func (r *resolver) note(key, value, source string) {
+	if r != nil {
+		r.used[key] = Setting{Key: key, Value: value, Source: source}
+	}
+}
+
+// unknownKeys lists config file keys that no setting reads, which are almost
+// always typos.
+func (r *resolver) unknownKeys() []string {
+	var unknown []string
+	for key := range r.file {
+		if _, ok := r.used[key]; !ok && key != "APP_ENV" {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// settings returns every resolved setting sorted by key, with secrets
+// masked.
+func (r *resolver) settings() []Setting {
+	out := make([]Setting, 0, len(r.used))
+	for _, s := range r.used {
+		if isSecret(s.Key) && s.Value != "" {
+			s.Value = "********"
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func isSecret(key string) bool {
+	for _, marker := range []string{"PASSWORD", "SECRET", "TOKEN", "ACCESS_KEY"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func profileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// connector opens every physical connection from a freshly built pq connector
+// so the DSN, and the host name inside it, are resolved again on each dial.
+// After a Patroni or RDS failover moves the primary's DNS record, new
+// connections follow it without a restart.
+type connector struct {
+	dsn      func() string
+	dialer   pq.Dialer
+	attempts int
+	backoff  time.Duration
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	var err error
+	for attempt := 0; attempt < c.attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(c.backoff << (attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, err
+			case <-timer.C:
+			}
+		}
+
+		var pqc *pq.Connector
+		pqc, err = pq.NewConnector(c.dsn())
+		if err != nil {
+			return nil, err
+		}
+		pqc.Dialer(c.dialer)
+
+		var conn driver.Conn
+		if conn, err = pqc.Connect(ctx); err == nil {
+			return conn, nil
+		}
+		if !IsTransient(err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// keepAliveDialer enables TCP keepalives so connections to a primary that
+// vanished without closing its sockets are detected and evicted instead of
+// hanging until the query deadline.
+type keepAliveDialer struct {
+	net.Dialer
+}
+
+func (d keepAliveDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	dialer := d.Dialer
+	dialer.Timeout = timeout
+	return dialer.Dial(network, address)
+}
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
-
-	_ "github.com/lib/pq"
 )
 
 // Config describes connection settings and pool tuning.
@@ -16,19 +18,33 @@ type Config struct {
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime closes connections that sat idle this long, so a pool
+	// does not hand out sockets to a primary that has since failed over.
+	ConnMaxIdleTime time.Duration
+	// ConnectAttempts and ConnectBackoff bound the retries for opening a
+	// single connection while the database is unreachable.
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+	// DSN, when set, is consulted for every new connection instead of URL so
+	// credential or endpoint changes apply without reopening the pool.
+	DSN func() string
+	// PgBouncer makes queries safe behind PgBouncer in transaction pooling
+	// mode, where consecutive round trips of one query may reach different
+	// server connections. See PgBouncerDSN.
+	PgBouncer bool
+	// StatementTimeout, when positive, makes Postgres cancel any statement
+	// running longer on every connection, as a backstop for queries a
+	// context deadline fails to cancel. PgBouncer rejects it as a startup
+	// parameter, so it is not sent in PgBouncer mode.
+	StatementTimeout time.Duration
 }
 
 // New initializes a PostgreSQL connection, configures the pool, and verifies it.
 func New(ctx context.Context, cfg Config) (*sql.DB, error) {
-	if cfg.URL == "" {
+	if cfg.URL == "" && cfg.DSN == nil {
 		return nil, errors.New("postgres url is empty")
 	}
 
-	database, err := sql.Open("postgres", cfg.URL)
-	if err != nil {
-		return nil, fmt.Errorf("open postgres: %w", err)
-	}
-
 	if cfg.MaxOpenConns <= 0 {
 		cfg.MaxOpenConns = 10
 	}
@@ -38,10 +54,38 @@ func New(ctx context.Context, cfg Config) (*sql.DB, error) {
 	if cfg.ConnMaxLifetime == 0 {
 		cfg.ConnMaxLifetime = time.Hour
 	}
+	if cfg.ConnMaxIdleTime == 0 {
+		cfg.ConnMaxIdleTime = 5 * time.Minute
+	}
+	if cfg.ConnectAttempts <= 0 {
+		cfg.ConnectAttempts = 3
+	}
+	if cfg.ConnectBackoff <= 0 {
+		cfg.ConnectBackoff = 200 * time.Millisecond
+	}
+	if cfg.DSN == nil {
+		url := cfg.URL
+		cfg.DSN = func() string { return url }
+	}
+	if cfg.PgBouncer {
+		dsn := cfg.DSN
+		cfg.DSN = func() string { return PgBouncerDSN(dsn()) }
+	} else if cfg.StatementTimeout > 0 {
+		dsn, timeout := cfg.DSN, cfg.StatementTimeout
+		cfg.DSN = func() string { return StatementTimeoutDSN(dsn(), timeout) }
+	}
+
+	database := sql.OpenDB(&connector{
+		dsn:      cfg.DSN,
+		dialer:   &keepAliveDialer{net.Dialer{KeepAlive: 30 * time.Second}},
+		attempts: cfg.ConnectAttempts,
+		backoff:  cfg.ConnectBackoff,
+	})
 
 	database.SetMaxOpenConns(cfg.MaxOpenConns)
 	database.SetMaxIdleConns(cfg.MaxIdleConns)
 	database.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	database.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -53,3 +97,39 @@ func New(ctx context.Context, cfg Config) (*sql.DB, error) {
 
 	return database, nil
 }
+
+// PgBouncerDSN returns dsn with lib/pq sending parameterized queries as
+// Parse, Bind and Execute in a single round trip. By default it describes the
+// unnamed statement first and binds it in a second round trip, which a
+// transaction-pooling PgBouncer may route to a server connection that never
+// saw the statement. Named prepared statements are not used either way.
+func PgBouncerDSN(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+		q := u.Query()
+		q.Set("binary_parameters", "yes")
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn + " binary_parameters=yes")
+}
+
+// StatementTimeoutDSN returns dsn with statement_timeout sent as a startup
+// parameter, so it applies to the whole session.
+func StatementTimeoutDSN(dsn string, timeout time.Duration) string {
+	ms := strconv.FormatInt(timeout.Milliseconds(), 10)
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+		q := u.Query()
+		q.Set("statement_timeout", ms)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn + " statement_timeout=" + ms)
+}
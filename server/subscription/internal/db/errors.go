@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/lib/pq"
+)
+
+// IsTransient reports whether err is the kind of failure a database failover
+// or restart produces, so the operation is worth retrying shortly rather than
+// reporting as a server fault.
+func IsTransient(err error) bool {
+	// A deadline means the caller's budget is spent; retrying would only
+	// overrun it further.
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Class() == "08": // connection_exception
+			return true
+		case pqErr.Code == "57P01", // admin_shutdown
+			pqErr.Code == "57P02", // crash_shutdown
+			pqErr.Code == "57P03", // cannot_connect_now
+			pqErr.Code == "25006": // read_only_sql_transaction: still on a demoted primary
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && !netErr.Timeout()
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	goqu "github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+)
+
+// Sort is one requested ORDER BY key.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// OrderBy builds ORDER BY clauses for one listing from a whitelist of
+// columns, so user input never reaches SQL except as a whitelisted name.
+// Every listing that lets callers choose an order goes through one.
+type OrderBy struct {
+	columns    map[string]bool
+	defaults   []Sort
+	tiebreaker string
+}
+
+// NewOrderBy whitelists columns. defaults is used when no valid sort is
+// requested; tiebreaker, when set, is appended ascending to every clause so
+// pages are stable. All of them must be trusted constants.
+func NewOrderBy(columns []string, defaults []Sort, tiebreaker string) OrderBy {
+	allowed := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		allowed[column] = true
+	}
+	for _, s := range defaults {
+		if !allowed[s.Column] {
+			panic(fmt.Sprintf("db: default sort column %q is not whitelisted", s.Column))
+		}
+	}
+	return OrderBy{columns: allowed, defaults: defaults, tiebreaker: tiebreaker}
+}
+
+// Allows reports whether column is whitelisted.
+func (o OrderBy) Allows(column string) bool {
+	return o.columns[column]
+}
+
+// Parse reads values such as "start_month desc". The column must be
+// whitelisted and the direction, which defaults to ascending, must be asc or
+// desc; anything else is an error.
+func (o OrderBy) Parse(value string) (Sort, error) {
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 0 || len(fields) > 2 {
+		return Sort{}, fmt.Errorf("sort must be in \"column [asc|desc]\" format")
+	}
+
+	column := fields[0]
+	if !o.columns[column] {
+		return Sort{}, fmt.Errorf("column %q is not sortable", column)
+	}
+
+	sort := Sort{Column: column}
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "asc":
+		case "desc":
+			sort.Desc = true
+		default:
+			return Sort{}, fmt.Errorf("sort direction must be asc or desc")
+		}
+	}
+	return sort, nil
+}
+
+// resolve drops keys that are not whitelisted and falls back to the defaults
+// when nothing is left.
+func (o OrderBy) resolve(sorts []Sort) []Sort {
+	valid := make([]Sort, 0, len(sorts))
+	for _, s := range sorts {
+		if o.columns[s.Column] {
+			valid = append(valid, s)
+		}
+	}
+	if len(valid) == 0 {
+		return o.defaults
+	}
+	return valid
+}
+
+// Expressions returns the goqu ORDER BY keys for sorts.
+func (o OrderBy) Expressions(sorts ...Sort) []exp.OrderedExpression {
+	resolved := o.resolve(sorts)
+	order := make([]exp.OrderedExpression, 0, len(resolved)+1)
+	for _, s := range resolved {
+		if s.Desc {
+			order = append(order, goqu.I(s.Column).Desc())
+		} else {
+			order = append(order, goqu.I(s.Column).Asc())
+		}
+	}
+	if o.tiebreaker != "" {
+		order = append(order, goqu.I(o.tiebreaker).Asc())
+	}
+	return order
+}
+
+// SQL returns the ORDER BY list for sorts, without the keywords, for queries
+// written as plain SQL. Identifiers are quoted.
+func (o OrderBy) SQL(sorts ...Sort) string {
+	resolved := o.resolve(sorts)
+	keys := make([]string, 0, len(resolved)+1)
+	for _, s := range resolved {
+		key := pq.QuoteIdentifier(s.Column)
+		if s.Desc {
+			key += " DESC"
+		}
+		keys = append(keys, key)
+	}
+	if o.tiebreaker != "" {
+		keys = append(keys, pq.QuoteIdentifier(o.tiebreaker))
+	}
+	return strings.Join(keys, ", ")
+}
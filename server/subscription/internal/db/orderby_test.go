@@ -0,0 +1,116 @@
+package db
+
+import (
+	"testing"
+
+	goqu "github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+)
+
+func testOrderBy() OrderBy {
+	return NewOrderBy(
+		[]string{"service_name", "start_month", "price_rub"},
+		[]Sort{{Column: "start_month", Desc: true}},
+		"id",
+	)
+}
+
+func TestOrderByParse(t *testing.T) {
+	o := testOrderBy()
+	tests := []struct {
+		value   string
+		want    Sort
+		wantErr bool
+	}{
+		{value: "service_name", want: Sort{Column: "service_name"}},
+		{value: "price_rub asc", want: Sort{Column: "price_rub"}},
+		{value: "start_month desc", want: Sort{Column: "start_month", Desc: true}},
+		{value: "  START_MONTH   DESC ", want: Sort{Column: "start_month", Desc: true}},
+		{value: "Price_Rub Asc", want: Sort{Column: "price_rub"}},
+		{value: "", wantErr: true},
+		{value: "user_id", wantErr: true},
+		{value: "price_rub sideways", wantErr: true},
+		{value: "price_rub desc nulls", wantErr: true},
+		{value: "price_rub; DROP TABLE subscriptions", wantErr: true},
+		{value: `price_rub" DESC, "user_id`, wantErr: true},
+		{value: "(SELECT 1) desc", wantErr: true},
+		{value: "price_rub,user_id", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := o.Parse(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %+v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestOrderByAllows(t *testing.T) {
+	o := testOrderBy()
+	for _, column := range []string{"service_name", "start_month", "price_rub"} {
+		if !o.Allows(column) {
+			t.Errorf("Allows(%q) = false, want true", column)
+		}
+	}
+	for _, column := range []string{"id", "user_id", "Price_Rub", ""} {
+		if o.Allows(column) {
+			t.Errorf("Allows(%q) = true, want false", column)
+		}
+	}
+}
+
+func TestOrderBySQL(t *testing.T) {
+	o := testOrderBy()
+	tests := []struct {
+		name  string
+		sorts []Sort
+		want  string
+	}{
+		{name: "defaults", want: `"start_month" DESC, "id"`},
+		{name: "requested", sorts: []Sort{{Column: "price_rub"}, {Column: "service_name", Desc: true}}, want: `"price_rub", "service_name" DESC, "id"`},
+		{name: "unknown dropped", sorts: []Sort{{Column: "user_id"}, {Column: "price_rub", Desc: true}}, want: `"price_rub" DESC, "id"`},
+		{name: "only unknown", sorts: []Sort{{Column: `price_rub"; DROP TABLE subscriptions; --`}}, want: `"start_month" DESC, "id"`},
+	}
+	for _, tt := range tests {
+		if got := o.SQL(tt.sorts...); got != tt.want {
+			t.Errorf("%s: SQL() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	untied := NewOrderBy([]string{"price_rub"}, []Sort{{Column: "price_rub"}}, "")
+	if got := untied.SQL(); got != `"price_rub"` {
+		t.Errorf("SQL() without tiebreaker = %s, want \"price_rub\"", got)
+	}
+}
+
+func TestOrderByExpressions(t *testing.T) {
+	o := testOrderBy()
+	query, _, err := goqu.Dialect("postgres").From("subscriptions").
+		Order(o.Expressions(Sort{Column: "price_rub", Desc: true}, Sort{Column: "1; DROP TABLE subscriptions"})...).
+		ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT * FROM "subscriptions" ORDER BY "price_rub" DESC, "id" ASC`
+	if query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+}
+
+func TestNewOrderByRejectsUnlistedDefault(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewOrderBy did not panic on a default outside the whitelist")
+		}
+	}()
+	NewOrderBy([]string{"price_rub"}, []Sort{{Column: "user_id"}}, "id")
+}
@@ -0,0 +1,134 @@
+// Package health serves the liveness and readiness probes. /healthz answers
+// 200 as long as the process serves HTTP; /readyz runs every registered
+// dependency check and answers 503 when one fails, so the instance is taken
+// out of rotation without being restarted.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Statuses of a check and of the whole probe.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc reports whether a dependency is usable. It must return once ctx
+// is done.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one dependency check.
+type CheckResult struct {
+	Status     string `json:"status" enums:"ok,fail"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the body of /readyz.
+type Report struct {
+	Status string                 `json:"status" enums:"ok,fail"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Handler serves the probes.
+type Handler struct {
+	timeout time.Duration
+	logger  *slog.Logger
+	checks  []check
+}
+
+// NewHandler creates a Handler that gives the checks of one request timeout
+// to finish, 2s by default.
+func NewHandler(timeout time.Duration, logger *slog.Logger) *Handler {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Handler{timeout: timeout, logger: logger}
+}
+
+// Add registers a readiness check under name. Checks must be added before
+// the routes serve requests.
+func (h *Handler) Add(name string, fn CheckFunc) {
+	h.checks = append(h.checks, check{name: name, fn: fn})
+}
+
+// RegisterRoutes mounts GET /healthz and GET /readyz.
+func (h *Handler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/healthz", h.healthz)
+	router.GET("/readyz", h.readyz)
+}
+
+// healthz godoc
+// @Summary Liveness probe
+// @Description Answers 200 whenever the process serves HTTP. It checks no dependencies, so a database outage does not get the pod restarted.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @ID healthz
+// @Router /healthz [get]
+func (h *Handler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// readyz godoc
+// @Summary Readiness probe
+// @Description Runs every dependency check (Postgres reachable, migrations applied) with a short timeout and reports each one. Answers 503 when any check fails.
+// @Tags health
+// @Produce json
+// @Success 200 {object} Report
+// @Failure 503 {object} Report
+// @ID readyz
+// @Router /readyz [get]
+func (h *Handler) readyz(c *gin.Context) {
+	report := h.Check(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// Check runs every check concurrently and reports the results.
+func (h *Handler) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(h.checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, chk := range h.checks {
+		wg.Go(func() {
+			start := time.Now()
+			err := chk.fn(ctx)
+			result := CheckResult{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+				h.logger.Warn("readiness check failed", "check", chk.name, "error", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[chk.name] = result
+			if err != nil {
+				report.Status = StatusFail
+			}
+		})
+	}
+	wg.Wait()
+	return report
+}
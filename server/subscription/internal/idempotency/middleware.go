@@ -0,0 +1,195 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+)
+
+// Header is the request header carrying the client's key.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set to "true" on responses replayed from a stored key.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength bounds the keys clients may send.
+const maxKeyLength = 255
+
+// maxBodyBytes bounds the bodies read into memory to fingerprint them.
+const maxBodyBytes = 1 << 20
+
+// Keys answers requests retried with the same Idempotency-Key from the
+// stored response of the first one.
+type Keys struct {
+	store   Store
+	ttl     time.Duration
+	logger  *slog.Logger
+	monitor *monitor.Monitor
+}
+
+// NewKeys creates Keys that remember responses for ttl, a day by default.
+func NewKeys(store Store, ttl time.Duration, logger *slog.Logger) *Keys {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Keys{store: store, ttl: ttl, logger: logger}
+}
+
+// ReportTo records every purge on m.
+func (k *Keys) ReportTo(m *monitor.Monitor) {
+	k.monitor = m
+}
+
+// Middleware makes the routes it guards idempotent for requests carrying the
+// header. Keys are scoped to the caller, and a key reused with a different
+// method, path or body is rejected with 422. A retry while the first request
+// is still running gets 409, and a body over 1 MiB gets 413. Responses with a
+// 5xx status are not stored, and neither is a handler panic, so the request
+// can be retried.
+func (k *Keys) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body must be at most 1 MiB"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scope := scopeOf(ctx)
+		fingerprint := fingerprintOf(c.Request.Method, c.FullPath(), body)
+		rec, claimed, err := k.store.Begin(ctx, scope, key, fingerprint, k.ttl)
+		if err != nil {
+			k.logger.Error("idempotency key lookup failed", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check Idempotency-Key"})
+			return
+		}
+
+		if !claimed {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case rec.Status == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+			default:
+				c.Header(ReplayedHeader, "true")
+				c.Data(rec.Status, rec.ContentType, rec.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// The outcome is stored even if the client has gone away, since
+		// that is exactly when it will retry.
+		storeCtx := context.WithoutCancel(ctx)
+		defer func() {
+			// A panicking handler never finishes the request; free the key
+			// so retries are not refused as in progress until it expires.
+			if p := recover(); p != nil {
+				k.release(storeCtx, scope, key)
+				panic(p)
+			}
+		}()
+
+		recorder := &recorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			k.release(storeCtx, scope, key)
+			return
+		}
+		if err := k.store.Complete(storeCtx, scope, key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			k.logger.Error("store idempotent response failed", "error", err)
+		}
+	}
+}
+
+// release frees a claimed key without storing a response.
+func (k *Keys) release(ctx context.Context, scope, key string) {
+	if err := k.store.Release(ctx, scope, key); err != nil {
+		k.logger.Error("release idempotency key failed", "error", err)
+	}
+}
+
+// Run purges expired keys hourly until ctx is cancelled.
+func (k *Keys) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		err := k.monitor.Track("idempotency_purge", func() error {
+			n, err := k.store.Purge(ctx)
+			if err == nil && n > 0 {
+				k.logger.Info("expired idempotency keys purged", "count", n)
+			}
+			return err
+		})
+		if err != nil && ctx.Err() == nil {
+			k.logger.Error("idempotency key purge failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scopeOf keys anonymous callers together and everyone else by user.
+func scopeOf(ctx context.Context) string {
+	if caller, ok := identity.FromContext(ctx); ok {
+		return caller.UserID.String()
+	}
+	return "anonymous"
+}
+
+func fingerprintOf(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder copies the response body while it is written.
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,111 @@
+// Package idempotency replays the stored response of a request retried with
+// the same Idempotency-Key header, so clients on flaky networks can retry a
+// POST without creating duplicates.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Record is the state of one idempotency key. Status is zero while the
+// first request with the key is still running.
+type Record struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Store persists idempotency keys per scope, usually the caller.
+type Store interface {
+	// Begin claims key for a request with fingerprint. When the key is new or
+	// expired it returns claimed true; otherwise it returns the existing
+	// record.
+	Begin(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (rec Record, claimed bool, err error)
+	// Complete stores the response of the request that claimed key.
+	Complete(ctx context.Context, scope, key string, status int, contentType string, body []byte) error
+	// Release forgets key, so a request that failed can be retried.
+	Release(ctx context.Context, scope, key string) error
+	// Purge deletes expired keys and returns how many there were.
+	Purge(ctx context.Context) (int64, error)
+}
+
+// Repository is the postgres implementation of Store.
+type Repository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewRepository wires the DB and logger into a Repository.
+func NewRepository(db *sql.DB, logger *slog.Logger) *Repository {
+	return &Repository{db: db, logger: logger}
+}
+
+func (r *Repository) Begin(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (Record, bool, error) {
+	// An expired key is taken over as if it were new.
+	res, err := r.db.ExecContext(ctx, `
+INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+VALUES ($1, $2, $3, now() + $4 * interval '1 second')
+ON CONFLICT (scope, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = NULL, response_body = NULL,
+    created_at = now(), expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < now()`,
+		scope, key, fingerprint, int64(ttl/time.Second),
+	)
+	if err != nil {
+		return Record{}, false, fmt.Errorf("claim idempotency key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return Record{}, false, fmt.Errorf("claim idempotency key: %w", err)
+	} else if n == 1 {
+		return Record{Fingerprint: fingerprint}, true, nil
+	}
+
+	var rec Record
+	var status sql.NullInt64
+	var contentType sql.NullString
+	err = r.db.QueryRowContext(ctx,
+		`SELECT fingerprint, status_code, content_type, response_body FROM idempotency_keys WHERE scope = $1 AND key = $2`,
+		scope, key,
+	).Scan(&rec.Fingerprint, &status, &contentType, &rec.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released between the insert and the select; the retry will claim it.
+		return Record{}, false, fmt.Errorf("idempotency key %q changed concurrently, retry", key)
+	}
+	if err != nil {
+		return Record{}, false, fmt.Errorf("get idempotency key: %w", err)
+	}
+	rec.Status, rec.ContentType = int(status.Int64), contentType.String
+	return rec, false, nil
+}
+
+func (r *Repository) Complete(ctx context.Context, scope, key string, status int, contentType string, body []byte) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status_code = $3, content_type = $4, response_body = $5 WHERE scope = $1 AND key = $2`,
+		scope, key, status, contentType, body,
+	)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) Release(ctx context.Context, scope, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) Purge(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+	return res.RowsAffected()
+}
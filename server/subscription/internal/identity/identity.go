@@ -0,0 +1,51 @@
+// Package identity carries the authenticated caller through request contexts.
+package identity
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Role names a permission level granted to a caller.
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+// Caller is the identity a request was authenticated as.
+type Caller struct {
+	UserID uuid.UUID
+	Roles  []Role
+}
+
+// HasRole reports whether the caller was granted role.
+func (c Caller) HasRole(role Role) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin reports whether the caller holds the admin role.
+func (c Caller) IsAdmin() bool {
+	return c.HasRole(RoleAdmin)
+}
+
+type contextKey struct{}
+
+// WithCaller returns a copy of ctx carrying caller.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, contextKey{}, caller)
+}
+
+// FromContext returns the caller stored in ctx, if any.
+func FromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(contextKey{}).(Caller)
+	return caller, ok
+}
@@ -0,0 +1,119 @@
+// Package lease runs periodic jobs on one replica at a time. A job's lease
+// lives in the job_leases table: the replica that acquires it runs the job
+// and keeps the lease for one interval, so replicas whose tickers fire later
+// in that interval skip the run. When the holder dies, its lease expires and
+// the next replica to try takes the job over.
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+)
+
+// acquireSQL takes the lease on $1 for $2 when it is free, expired or
+// already held by $2, and returns the previous holder. It returns no row
+// while another replica holds a live lease.
+const acquireSQL = `
+WITH previous AS (
+    SELECT holder FROM job_leases WHERE name = $1
+)
+INSERT INTO job_leases (name, holder, acquired_at, expires_at)
+VALUES ($1, $2, now(), now() + make_interval(secs => $3))
+ON CONFLICT (name) DO UPDATE
+SET holder = EXCLUDED.holder,
+    acquired_at = EXCLUDED.acquired_at,
+    expires_at = EXCLUDED.expires_at
+WHERE job_leases.expires_at <= now() OR job_leases.holder = EXCLUDED.holder
+RETURNING COALESCE((SELECT holder FROM previous), '');
+`
+
+const releaseSQL = `UPDATE job_leases SET expires_at = now() WHERE name = $1 AND holder = $2`
+
+// Leases acquires job leases on behalf of this process. A nil Leases is
+// valid and runs every job unguarded, for single-replica tools.
+type Leases struct {
+	db     *sql.DB
+	holder string
+	logger *slog.Logger
+
+	acquired  *metrics.CounterVec
+	contended *metrics.CounterVec
+	takeovers *metrics.CounterVec
+}
+
+// New creates Leases for this process and registers its metrics on reg.
+func New(db *sql.DB, reg *metrics.Registry, logger *slog.Logger) *Leases {
+	return &Leases{
+		db:     db,
+		holder: holderID(),
+		logger: logger,
+		acquired: reg.Counter("job_lease_acquired_total",
+			"Job runs this replica won the lease for.", "job"),
+		contended: reg.Counter("job_lease_contended_total",
+			"Job runs skipped because another replica held the lease.", "job"),
+		takeovers: reg.Counter("job_lease_takeovers_total",
+			"Leases taken over from another replica after they expired.", "job"),
+	}
+}
+
+// holderID names this process: host name, PID and a random suffix, so a
+// restarted process never mistakes its predecessor's lease for its own.
+func holderID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid()) + "-" + uuid.NewString()[:8]
+}
+
+// Holder returns the ID this process holds leases under.
+func (l *Leases) Holder() string {
+	if l == nil {
+		return ""
+	}
+	return l.holder
+}
+
+// Do runs fn if this replica can take the lease on name for ttl, usually the
+// job's interval, and returns nil without running it while another replica
+// holds the lease. When fn fails the lease is released, so another replica
+// retries on its next tick instead of waiting out the interval.
+func (l *Leases) Do(ctx context.Context, name string, ttl time.Duration, fn func() error) error {
+	if l == nil {
+		return fn()
+	}
+
+	var previous string
+	err := l.db.QueryRowContext(ctx, acquireSQL, name, l.holder, ttl.Seconds()).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		l.contended.Inc(name)
+		l.logger.Debug("job lease held by another replica, skipping run", "job", name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("acquire lease %s: %w", name, err)
+	}
+	l.acquired.Inc(name)
+	if previous != "" && previous != l.holder {
+		l.takeovers.Inc(name)
+		l.logger.Info("took over job lease", "job", name, "previous_holder", previous)
+	}
+
+	if err := fn(); err != nil {
+		if _, relErr := l.db.ExecContext(context.WithoutCancel(ctx), releaseSQL, name, l.holder); relErr != nil {
+			l.logger.Error("release job lease failed", "job", name, "error", relErr)
+		}
+		return err
+	}
+	return nil
+}
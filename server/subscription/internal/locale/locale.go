@@ -0,0 +1,110 @@
+// Package locale formats numbers, dates and money for the people reading an
+// export. Spreadsheets parse what they are given using the reader's locale:
+// Russian Excel expects "199,50" and semicolon-separated CSV, and would read
+// "199.50" as text.
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is how one locale writes values.
+type Format struct {
+	Tag string
+	// Decimal separates the integer and fractional parts of a number.
+	Decimal string
+	// FieldSep separates CSV fields. Locales with a comma as Decimal use a
+	// semicolon, as their spreadsheets do.
+	FieldSep rune
+	// DateLayout and MonthLayout are time layouts for days and months.
+	DateLayout  string
+	MonthLayout string
+	// Currency is the ruble sign written next to amounts; CurrencyFirst puts
+	// it before the number.
+	Currency      string
+	CurrencyFirst bool
+}
+
+// Default is used when no requested locale is supported.
+var Default = formats["en"]
+
+var formats = map[string]Format{
+	"en": {Tag: "en", Decimal: ".", FieldSep: ',', DateLayout: "2006-01-02", MonthLayout: "2006-01", Currency: "RUB ", CurrencyFirst: true},
+	"ru": {Tag: "ru", Decimal: ",", FieldSep: ';', DateLayout: "02.01.2006", MonthLayout: "01.2006", Currency: " ₽"},
+	"de": {Tag: "de", Decimal: ",", FieldSep: ';', DateLayout: "02.01.2006", MonthLayout: "01.2006", Currency: " RUB"},
+	"fr": {Tag: "fr", Decimal: ",", FieldSep: ';', DateLayout: "02/01/2006", MonthLayout: "01/2006", Currency: " RUB"},
+}
+
+// Lookup returns the format for a language tag such as "ru" or "ru-RU". Only
+// the primary language is considered.
+func Lookup(tag string) (Format, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	f, ok := formats[primary]
+	return f, ok
+}
+
+// Negotiate picks the best supported locale from an Accept-Language header,
+// honoring q-values, and falls back to Default.
+func Negotiate(acceptLanguage string) Format {
+	type choice struct {
+		tag string
+		q   float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			choices = append(choices, choice{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+
+	for _, c := range choices {
+		if f, ok := Lookup(c.tag); ok {
+			return f
+		}
+	}
+	return Default
+}
+
+// Number writes v with the given number of decimals and no grouping, so
+// spreadsheets still read it as a number.
+func (f Format) Number(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if f.Decimal != "." {
+		s = strings.Replace(s, ".", f.Decimal, 1)
+	}
+	return s
+}
+
+// Money writes an amount in rubles with two decimals and the currency sign.
+func (f Format) Money(rub float64) string {
+	if f.CurrencyFirst {
+		return f.Currency + f.Number(rub, 2)
+	}
+	return f.Number(rub, 2) + f.Currency
+}
+
+// Date writes the day of t.
+func (f Format) Date(t time.Time) string {
+	return t.Format(f.DateLayout)
+}
+
+// Month writes the month of t.
+func (f Format) Month(t time.Time) string {
+	return t.Format(f.MonthLayout)
+}
@@ -14,6 +14,22 @@ func New(level string) *slog.Logger {
 	return slog.New(handler)
 }
 
+// NewLeveled is like New but returns the LevelVar backing the logger so the
+// level can be changed at runtime with SetLevel.
+func NewLeveled(level string) (*slog.Logger, *slog.LevelVar) {
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(parseLevel(level))
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: levelVar,
+	})
+	return slog.New(handler), levelVar
+}
+
+// SetLevel updates levelVar from a textual level such as "debug".
+func SetLevel(levelVar *slog.LevelVar, level string) {
+	levelVar.Set(parseLevel(level))
+}
+
 func parseLevel(level string) slog.Level {
 	switch strings.ToLower(level) {
 	case "debug":
@@ -0,0 +1,102 @@
+package mailtmpl
+
+// Template names.
+const (
+	Reminder      = "reminder"
+	Digest        = "digest"
+	PriceIncrease = "price-increase"
+)
+
+// ReminderData are the variables of the reminder email, sent ahead of a
+// subscription's next charge.
+type ReminderData struct {
+	ServiceName string
+	RenewsOn    string
+	PriceRUB    int
+}
+
+// DigestData are the variables of the digest email, the scheduled summary
+// or export report.
+type DigestData struct {
+	Frequency string
+	Month     string
+	Active    int
+	TotalRUB  int64
+	// DownloadURL links to the export when it went to blob storage.
+	DownloadURL string
+	// Subscriptions lists every subscription in export reports.
+	Subscriptions []DigestLine
+}
+
+// DigestLine is one subscription in an export digest.
+type DigestLine struct {
+	ServiceName string
+	PriceRUB    int
+	Start       string
+	// End is empty for subscriptions that have not ended.
+	End string
+}
+
+// PriceIncreaseData are the variables of the price increase email, sent
+// when a user's monthly spend rose sharply.
+type PriceIncreaseData struct {
+	Month       string
+	PreviousRUB int64
+	CurrentRUB  int64
+	ChangePct   float64
+}
+
+type builtin struct {
+	description string
+	subject     string
+	body        string
+	sample      any
+}
+
+// builtins are the templates shipped with the service. Deployments override
+// them from the template directory.
+var builtins = map[string]builtin{
+	Reminder: {
+		description: "Reminder ahead of a subscription's next charge",
+		subject:     `{{.ServiceName}} renews on {{.RenewsOn}}`,
+		body: `<p>Your <strong>{{.ServiceName}}</strong> subscription renews on {{.RenewsOn}} for {{.PriceRUB}} RUB.</p>
+<p>If you no longer use it, cancel it before then.</p>
+`,
+		sample: ReminderData{ServiceName: "Yandex Plus", RenewsOn: "2025-04", PriceRUB: 400},
+	},
+	Digest: {
+		description: "Scheduled subscription summary or export report",
+		subject:     `Your {{.Frequency}} subscription report for {{.Month}}`,
+		body: `<h1>Subscription report for {{.Month}}</h1>
+<p>Active subscriptions: {{.Active}}<br>
+Total this month: {{.TotalRUB}} RUB</p>
+{{- if .DownloadURL}}
+<p><a href="{{.DownloadURL}}">Download all subscriptions</a></p>
+{{- else if .Subscriptions}}
+<h2>All subscriptions</h2>
+<ul>
+{{- range .Subscriptions}}
+<li>{{.ServiceName}}: {{.PriceRUB}} RUB, {{.Start}} to {{if .End}}{{.End}}{{else}}ongoing{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+`,
+		sample: DigestData{
+			Frequency: "monthly",
+			Month:     "2025-03",
+			Active:    2,
+			TotalRUB:  1199,
+			Subscriptions: []DigestLine{
+				{ServiceName: "Netflix", PriceRUB: 799, Start: "2024-11"},
+				{ServiceName: "Yandex Plus", PriceRUB: 400, Start: "2025-01", End: "2025-06"},
+			},
+		},
+	},
+	PriceIncrease: {
+		description: "Alert that a user's monthly spend rose sharply",
+		subject:     `Your subscription spend rose {{printf "%.0f" .ChangePct}}% in {{.Month}}`,
+		body: `<p>Your subscriptions cost {{.CurrentRUB}} RUB in {{.Month}}, up {{printf "%.2f" .ChangePct}}% from {{.PreviousRUB}} RUB the month before.</p>
+`,
+		sample: PriceIncreaseData{Month: "2025-03", PreviousRUB: 1000, CurrentRUB: 1600, ChangePct: 60},
+	},
+}
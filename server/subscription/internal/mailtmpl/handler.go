@@ -0,0 +1,94 @@
+package mailtmpl
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler lets operators see the email templates in effect.
+type Handler struct {
+	registry *Registry
+	logger   *slog.Logger
+}
+
+// NewHandler creates a Handler for registry.
+func NewHandler(registry *Registry, logger *slog.Logger) *Handler {
+	return &Handler{registry: registry, logger: logger}
+}
+
+// RegisterRoutes mounts the template endpoints on the admin group.
+func (h *Handler) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/templates", h.list)
+	group.GET("/templates/:name/preview", h.preview)
+}
+
+type templateInfo struct {
+	Name        string `json:"name" example:"digest"`
+	Description string `json:"description"`
+	Overridden  bool   `json:"overridden"`
+}
+
+type previewResponse struct {
+	Name    string `json:"name" example:"digest"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	// Variables is the sample data the preview was rendered with.
+	Variables any `json:"variables"`
+}
+
+// list godoc
+// @Summary Email templates
+// @Description Lists the transactional email templates and whether the deployment overrides their copy
+// @Tags admin
+// @Produce json
+// @Success 200 {array} templateInfo
+// @ID listEmailTemplates
+// @Router /admin/templates [get]
+func (h *Handler) list(c *gin.Context) {
+	templates := h.registry.List()
+	out := make([]templateInfo, 0, len(templates))
+	for _, t := range templates {
+		out = append(out, templateInfo{Name: t.Name, Description: t.Description, Overridden: t.Overridden})
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// preview godoc
+// @Summary Preview email template
+// @Description Renders the template with sample data. The variables in the response are the ones the template can use. format=html returns the body alone, for viewing in a browser.
+// @Tags admin
+// @Produce json
+// @Produce html
+// @Param name path string true "Template name" Enums(digest, price-increase, reminder)
+// @Param format query string false "Response format" Enums(json, html) default(json)
+// @Success 200 {object} previewResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @ID previewEmailTemplate
+// @Router /admin/templates/{name}/preview [get]
+func (h *Handler) preview(c *gin.Context) {
+	t, err := h.registry.Get(c.Param("name"))
+	if err != nil {
+		if errors.Is(err, ErrUnknownTemplate) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	email, err := t.Preview()
+	if err != nil {
+		h.logger.Error("email template preview failed", "template", t.Name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") == "html" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(email.HTML))
+		return
+	}
+	c.JSON(http.StatusOK, previewResponse{Name: t.Name, Subject: email.Subject, HTML: email.HTML, Variables: t.Sample()})
+}
@@ -0,0 +1,166 @@
+// Package mailtmpl renders the transactional emails from named templates, so
+// their copy lives in one place and deployments can change it without a
+// release.
+package mailtmpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// ErrUnknownTemplate is returned for template names the registry does not have.
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// Template is one email: a plain-text subject and an HTML body, both Go
+// templates over the email's data.
+type Template struct {
+	Name        string
+	Description string
+	// Overridden reports whether the copy came from the template directory.
+	Overridden bool
+
+	subject *texttemplate.Template
+	body    *htmltemplate.Template
+	sample  any
+}
+
+// Email is a rendered template.
+type Email struct {
+	Subject string
+	HTML    string
+}
+
+// Registry holds the email templates by name.
+type Registry struct {
+	templates map[string]*Template
+}
+
+// New loads the built-in templates and overrides them from dir, if set. A
+// template is overridden by <name>.subject.txt, <name>.html or both; other
+// files are ignored. Overrides are rendered with sample data once, so a typo
+// in a variable name fails startup rather than an email.
+func New(dir string) (*Registry, error) {
+	r := &Registry{templates: make(map[string]*Template, len(builtins))}
+	for name, b := range builtins {
+		subject, body := b.subject, b.body
+		overridden := false
+		if dir != "" {
+			var err error
+			if subject, overridden, err = readOverride(dir, name+".subject.txt", subject); err != nil {
+				return nil, err
+			}
+			var bodyOverridden bool
+			if body, bodyOverridden, err = readOverride(dir, name+".html", body); err != nil {
+				return nil, err
+			}
+			overridden = overridden || bodyOverridden
+		}
+
+		t, err := parse(name, b, subject, body)
+		if err != nil {
+			return nil, err
+		}
+		t.Overridden = overridden
+		if _, err := t.Render(b.sample); err != nil {
+			return nil, err
+		}
+		r.templates[name] = t
+	}
+	return r, nil
+}
+
+func readOverride(dir, file, fallback string) (string, bool, error) {
+	content, err := os.ReadFile(filepath.Join(dir, file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fallback, false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("read email template: %w", err)
+	}
+	return string(content), true, nil
+}
+
+func parse(name string, b builtin, subject, body string) (*Template, error) {
+	t := &Template{Name: name, Description: b.description, sample: b.sample}
+	var err error
+	if t.subject, err = texttemplate.New(name + ".subject").Option("missingkey=error").Parse(strings.TrimSpace(subject)); err != nil {
+		return nil, fmt.Errorf("parse %s subject: %w", name, err)
+	}
+	if t.body, err = htmltemplate.New(name + ".html").Option("missingkey=error").Parse(body); err != nil {
+		return nil, fmt.Errorf("parse %s body: %w", name, err)
+	}
+	return t, nil
+}
+
+// Render executes the template with data.
+func (t *Template) Render(data any) (Email, error) {
+	var subject, body bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Email{}, fmt.Errorf("render %s subject: %w", t.Name, err)
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return Email{}, fmt.Errorf("render %s body: %w", t.Name, err)
+	}
+	// Header injection is prevented here rather than trusted to every
+	// override.
+	return Email{
+		Subject: strings.NewReplacer("\r", "", "\n", " ").Replace(subject.String()),
+		HTML:    body.String(),
+	}, nil
+}
+
+// Preview renders the template with its sample data.
+func (t *Template) Preview() (Email, error) {
+	return t.Render(t.sample)
+}
+
+// Sample returns the data Preview renders with, which documents the
+// template's variables.
+func (t *Template) Sample() any {
+	return t.sample
+}
+
+// Get returns the template called name.
+func (r *Registry) Get(name string) (*Template, error) {
+	t, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+	return t, nil
+}
+
+// Render renders the template called name with data.
+func (r *Registry) Render(name string, data any) (Email, error) {
+	t, err := r.Get(name)
+	if err != nil {
+		return Email{}, err
+	}
+	return t.Render(data)
+}
+
+// List returns every template, by name.
+func (r *Registry) List() []*Template {
+	out := make([]*Template, 0, len(r.templates))
+	for _, t := range r.templates {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Builtin returns a registry of the built-in templates alone.
+func Builtin() *Registry {
+	r, err := New("")
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
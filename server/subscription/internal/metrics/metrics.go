@@ -0,0 +1,208 @@
+// Package metrics is a small registry of gauges and counters exposed in the
+// Prometheus text format on /metrics.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type kind string
+
+const (
+	kindGauge   kind = "gauge"
+	kindCounter kind = "counter"
+)
+
+// Registry holds every metric family the process exports.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Gauge registers a gauge family with the given label names. Registering the
+// same name twice returns the existing family.
+func (r *Registry) Gauge(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, kindGauge, labels)}
+}
+
+// Counter registers a counter family with the given label names. Registering
+// the same name twice returns the existing family.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, kindCounter, labels)}
+}
+
+func (r *Registry) register(name, help string, k kind, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		if f.kind != k || len(f.labels) != len(labels) {
+			panic(fmt.Sprintf("metrics: %s re-registered with a different shape", name))
+		}
+		return f
+	}
+	f := &family{name: name, help: help, kind: k, labels: labels, values: make(map[string]*sample)}
+	r.families[name] = f
+	return f
+}
+
+// Handler serves every registered family in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		buf := bufio.NewWriter(w)
+		r.write(buf)
+		_ = buf.Flush()
+	})
+}
+
+func (r *Registry) write(w *bufio.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]*family, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		families = append(families, r.families[name])
+	}
+	r.mu.Unlock()
+
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+type sample struct {
+	labelValues []string
+	value       float64
+}
+
+type family struct {
+	name   string
+	help   string
+	kind   kind
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*sample
+}
+
+func (f *family) sample(labelValues []string) *sample {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.values[key]
+	if !ok {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		f.values[key] = s
+	}
+	return s
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.values))
+	for key := range f.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.values[key]
+		w.WriteString(f.name)
+		if len(f.labels) > 0 {
+			w.WriteByte('{')
+			for i, label := range f.labels {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				fmt.Fprintf(w, "%s=\"%s\"", label, labelEscaper.Replace(s.labelValues[i]))
+			}
+			w.WriteByte('}')
+		}
+		w.WriteByte(' ')
+		w.WriteString(formatValue(s.value))
+		w.WriteByte('\n')
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// GaugeVec is a gauge family partitioned by labels.
+type GaugeVec struct {
+	f *family
+}
+
+// Set sets the gauge for the given label values.
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.f.mu.Lock()
+	g.f.sample(labelValues).value = value
+	g.f.mu.Unlock()
+}
+
+// Replace swaps every series of the family for values in one step, so series
+// that disappeared since the last refresh stop being exported.
+func (g *GaugeVec) Replace(values map[string]float64) {
+	if len(g.f.labels) != 1 {
+		panic(fmt.Sprintf("metrics: Replace needs a single-label family, %s has %d", g.f.name, len(g.f.labels)))
+	}
+	next := make(map[string]*sample, len(values))
+	for label, value := range values {
+		next[label] = &sample{labelValues: []string{label}, value: value}
+	}
+	g.f.mu.Lock()
+	g.f.values = next
+	g.f.mu.Unlock()
+}
+
+// CounterVec is a counter family partitioned by labels.
+type CounterVec struct {
+	f *family
+}
+
+// Inc adds one to the counter for the given label values.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the counter.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.f.mu.Lock()
+	c.f.sample(labelValues).value += delta
+	c.f.mu.Unlock()
+}
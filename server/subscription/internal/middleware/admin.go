@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// RequireAdmin lets through callers allowed to read the admin API and requests
+// authenticating with HTTP basic auth whose password is token, which works
+// from a browser. Every other request gets 401; with an empty token only such
+// callers pass.
+func RequireAdmin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if caller, ok := identity.FromContext(c.Request.Context()); ok && rbac.Allows(caller, rbac.ReadAdmin) {
+			c.Next()
+			return
+		}
+		if _, password, ok := c.Request.BasicAuth(); ok && token != "" &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(token)) == 1 {
+			c.Next()
+			return
+		}
+
+		c.Header("WWW-Authenticate", `Basic realm="admin"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin credentials required"})
+	}
+}
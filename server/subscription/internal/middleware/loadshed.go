@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadShedConfig sets the thresholds above which low-priority requests are
+// rejected with 503.
+type LoadShedConfig struct {
+	// MaxInFlight is the concurrency at which shedding starts. Zero disables the check.
+	MaxInFlight int64
+	// P99Budget is the rolling p99 latency at which shedding starts. Zero disables the check.
+	P99Budget time.Duration
+	// Window is how many recent request latencies feed the p99 estimate.
+	Window int
+	// RetryAfter is advertised to shed clients.
+	RetryAfter time.Duration
+}
+
+// LoadShedder tracks in-flight requests and a rolling latency window.
+type LoadShedder struct {
+	cfg      atomic.Pointer[LoadShedConfig]
+	log      *slog.Logger
+	inFlight atomic.Int64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	filled    bool
+}
+
+// NewLoadShedder creates a LoadShedder with the given thresholds.
+func NewLoadShedder(cfg LoadShedConfig, log *slog.Logger) *LoadShedder {
+	if cfg.Window <= 0 {
+		cfg.Window = 512
+	}
+	s := &LoadShedder{
+		log:       log,
+		latencies: make([]time.Duration, cfg.Window),
+	}
+	s.SetThresholds(cfg)
+	return s
+}
+
+// SetThresholds updates MaxInFlight, P99Budget and RetryAfter at runtime. The
+// window size is fixed at construction.
+func (s *LoadShedder) SetThresholds(cfg LoadShedConfig) {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = 5 * time.Second
+	}
+	s.cfg.Store(&cfg)
+}
+
+// Track measures every request. It must be registered before any handler
+// that should contribute to the latency and concurrency figures.
+func (s *LoadShedder) Track() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.inFlight.Add(1)
+		// Deferred so a panicking handler, recovered further out, still
+		// releases its slot.
+		defer s.inFlight.Add(-1)
+		start := time.Now()
+		c.Next()
+		s.record(time.Since(start))
+	}
+}
+
+// Shed rejects the request with 503 and Retry-After while the service is over
+// budget. Attach it only to low-priority routes so CRUD stays responsive.
+func (s *LoadShedder) Shed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reason := s.overloaded()
+		if reason == "" {
+			c.Next()
+			return
+		}
+
+		s.log.Warn("request shed",
+			"path", c.FullPath(),
+			"reason", reason,
+			"in_flight", s.inFlight.Load(),
+		)
+		c.Header("Retry-After", strconv.Itoa(int(s.cfg.Load().RetryAfter.Seconds())))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":  "service is overloaded, retry later",
+			"reason": reason,
+		})
+	}
+}
+
+func (s *LoadShedder) overloaded() string {
+	cfg := s.cfg.Load()
+	if cfg.MaxInFlight > 0 && s.inFlight.Load() > cfg.MaxInFlight {
+		return "concurrency"
+	}
+	if cfg.P99Budget > 0 && s.p99() > cfg.P99Budget {
+		return "latency"
+	}
+	return ""
+}
+
+func (s *LoadShedder) record(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies[s.next] = latency
+	s.next = (s.next + 1) % len(s.latencies)
+	if s.next == 0 {
+		s.filled = true
+	}
+}
+
+func (s *LoadShedder) p99() time.Duration {
+	s.mu.Lock()
+	n := s.next
+	if s.filled {
+		n = len(s.latencies)
+	}
+	samples := make([]time.Duration, n)
+	copy(samples, s.latencies[:n])
+	s.mu.Unlock()
+
+	if len(samples) == 0 {
+		return 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[(len(samples)*99)/100]
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAccess decides who may scrape /metrics. With neither field set the
+// endpoint is open.
+type MetricsAccess struct {
+	// AllowedIPs are addresses or CIDR ranges allowed without credentials.
+	AllowedIPs []string
+	// Username and Password, when set, let any other client in with HTTP
+	// basic auth.
+	Username string
+	Password string
+}
+
+// ParseAllowlist parses addresses and CIDR ranges; a bare address allows
+// just itself.
+func ParseAllowlist(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ProtectMetrics lets through scrapers from an allowed address or with the
+// configured credentials. The allowlist matches the connecting address, not
+// X-Forwarded-For, which any client can set. Others get 401 when credentials
+// would let them in and 403 otherwise.
+func ProtectMetrics(access MetricsAccess) (gin.HandlerFunc, error) {
+	allowed, err := ParseAllowlist(access.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+	withAuth := access.Username != "" || access.Password != ""
+	if len(allowed) == 0 && !withAuth {
+		return func(c *gin.Context) { c.Next() }, nil
+	}
+
+	return func(c *gin.Context) {
+		if addr, err := netip.ParseAddr(c.RemoteIP()); err == nil {
+			addr = addr.Unmap()
+			for _, prefix := range allowed {
+				if prefix.Contains(addr) {
+					c.Next()
+					return
+				}
+			}
+		}
+		if !withAuth {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "metrics are not available from this address"})
+			return
+		}
+		if user, password, ok := c.Request.BasicAuth(); ok &&
+			subtle.ConstantTimeCompare([]byte(user), []byte(access.Username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(access.Password)) == 1 {
+			c.Next()
+			return
+		}
+		c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "metrics credentials required"})
+	}, nil
+}
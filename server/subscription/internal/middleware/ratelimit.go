@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+)
+
+// Rate limit headers set on every response.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+const rateLimitWindow = time.Minute
+
+// LimitSource looks up per-user overrides of the default limit.
+type LimitSource interface {
+	RequestsPerMinute(ctx context.Context, userID uuid.UUID) (limit int, ok bool, err error)
+}
+
+// RateLimiter counts requests per caller in fixed one-minute windows.
+// Authenticated callers are counted by user ID and may have an override;
+// anonymous callers are counted by the connecting address and get the default.
+type RateLimiter struct {
+	perMinute atomic.Int64
+	overrides LimitSource
+	log       *slog.Logger
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	swept   time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter creates a RateLimiter allowing perMinute requests per caller
+// unless overrides, which may be nil, says otherwise.
+func NewRateLimiter(perMinute int, overrides LimitSource, log *slog.Logger) *RateLimiter {
+	l := &RateLimiter{overrides: overrides, log: log, windows: make(map[string]*rateWindow)}
+	l.SetDefault(perMinute)
+	return l
+}
+
+// SetDefault updates the default limit at runtime.
+func (l *RateLimiter) SetDefault(perMinute int) {
+	l.perMinute.Store(int64(perMinute))
+}
+
+// Limit counts the request and answers 429 with Retry-After once the caller
+// is over its limit. Requests for which exempt returns true are neither
+// counted nor limited.
+func (l *RateLimiter) Limit(exempt func(*gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if exempt != nil && exempt(c) {
+			c.Next()
+			return
+		}
+
+		key, limit := l.limitFor(c)
+		count, reset := l.take(key, time.Now())
+		remaining := max(limit-count, 0)
+
+		c.Header(RateLimitLimitHeader, strconv.Itoa(limit))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(remaining))
+		c.Header(RateLimitResetHeader, strconv.FormatInt(reset.Unix(), 10))
+		if count <= limit {
+			c.Next()
+			return
+		}
+
+		retryAfter := max(int(time.Until(reset).Seconds()), 1)
+		l.log.Info("rate limit exceeded", "key", key, "limit", limit, "path", c.FullPath())
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": "rate limit exceeded, retry later",
+		})
+	}
+}
+
+// limitFor returns the counting key and limit for the request. A failed
+// override lookup falls back to the default rather than failing the request.
+func (l *RateLimiter) limitFor(c *gin.Context) (string, int) {
+	limit := int(l.perMinute.Load())
+	caller, ok := identity.FromContext(c.Request.Context())
+	if !ok {
+		// RemoteIP ignores X-Forwarded-For, which any client can rotate to
+		// dodge the limit.
+		return "ip:" + c.RemoteIP(), limit
+	}
+
+	if l.overrides != nil {
+		override, found, err := l.overrides.RequestsPerMinute(c.Request.Context(), caller.UserID)
+		switch {
+		case err != nil:
+			l.log.Warn("rate limit override lookup failed", "user_id", caller.UserID, "error", err)
+		case found:
+			limit = override
+		}
+	}
+	return "user:" + caller.UserID.String(), limit
+}
+
+// take counts one request against key and returns the count so far in the
+// current window along with the window's end.
+func (l *RateLimiter) take(key string, now time.Time) (int, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := now.Truncate(rateLimitWindow)
+	if start.After(l.swept) {
+		// Windows from earlier minutes can no longer limit anyone.
+		for k, w := range l.windows {
+			if w.start.Before(start) {
+				delete(l.windows, k)
+			}
+		}
+		l.swept = start
+	}
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &rateWindow{start: start}
+		l.windows[key] = w
+	}
+	w.count++
+	return w.count, start.Add(rateLimitWindow)
+}
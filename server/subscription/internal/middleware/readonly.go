@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnly answers every mutating request with 405, for deployments that
+// serve reads only, e.g. replicas pointed at a read-only database. GET, HEAD
+// and OPTIONS always pass, as do requests for which exempt returns true
+// (read-only POST endpoints, admin endpoints that only change process state).
+func ReadOnly(exempt func(*gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) || (exempt != nil && exempt(c)) {
+			c.Next()
+			return
+		}
+
+		c.Header("Allow", "GET, HEAD, OPTIONS")
+		c.AbortWithStatusJSON(http.StatusMethodNotAllowed, gin.H{
+			"error":     "this deployment is read-only",
+			"read_only": true,
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/migrations"
+)
+
+// versionLayout is the timestamp prefix of migration file names.
+const versionLayout = "20060102150405"
+
+var (
+	fileNamePattern = regexp.MustCompile(`^(\d{14})_([a-z0-9]+(?:_[a-z0-9]+)*)\.sql$`)
+	namePattern     = regexp.MustCompile(`^[a-z0-9]+(?:_[a-z0-9]+)*$`)
+)
+
+var newMigrationTemplate = template.Must(template.New("migration").Parse(`-- +goose Up
+-- +goose StatementBegin
+-- {{.Name}}: describe the change and why it is needed.
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Undo {{.Name}}.
+-- +goose StatementEnd
+`))
+
+// Create writes a new migration skeleton named name into dir, versioned with
+// now so it sorts after every existing migration. It returns the file path.
+func Create(dir, name string, now time.Time) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("migration name %q must be lower_snake_case", name)
+	}
+	latest, err := latestVersion(os.DirFS(dir))
+	if err != nil {
+		return "", err
+	}
+	version := now.UTC().Format(versionLayout)
+	if version <= latest {
+		return "", fmt.Errorf("version %s does not sort after the latest migration %s; check the clock", version, latest)
+	}
+
+	var body bytes.Buffer
+	if err := newMigrationTemplate.Execute(&body, struct{ Name string }{name}); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, version+"_"+name+".sql")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("create migration: %w", err)
+	}
+	if _, err := f.Write(body.Bytes()); err != nil {
+		f.Close()
+		return "", fmt.Errorf("write migration: %w", err)
+	}
+	return path, f.Close()
+}
+
+// Validate checks the migration files in fsys: every name is
+// <14-digit version>_<lower_snake_name>.sql, versions are unique, and each
+// file has a Goose Up section.
+func Validate(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("read migrations: %w", err)
+	}
+
+	var problems []string
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			problems = append(problems, fmt.Sprintf("%s: name must be <yyyymmddhhmmss>_<lower_snake_name>.sql", entry.Name()))
+			continue
+		}
+		if _, err := time.Parse(versionLayout, match[1]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: version is not a valid timestamp", entry.Name()))
+		}
+		if other, ok := seen[match[1]]; ok {
+			problems = append(problems, fmt.Sprintf("%s: version already used by %s", entry.Name(), other))
+		}
+		seen[match[1]] = entry.Name()
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return fmt.Errorf("read %s: %w", entry.Name(), err)
+		}
+		if !bytes.Contains(body, []byte("-- +goose Up")) {
+			problems = append(problems, fmt.Sprintf("%s: missing \"-- +goose Up\"", entry.Name()))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("invalid migrations:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// ValidateEmbedded validates the migrations compiled into the binary.
+func ValidateEmbedded() error {
+	if err := Validate(migrations.Files); err != nil {
+		return err
+	}
+	strict, err := fs.Sub(migrations.Strict, "strict")
+	if err != nil {
+		return err
+	}
+	return Validate(strict)
+}
+
+func latestVersion(fsys fs.FS) (string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "", fmt.Errorf("read migrations: %w", err)
+	}
+	latest := ""
+	for _, entry := range entries {
+		if match := fileNamePattern.FindStringSubmatch(entry.Name()); match != nil && match[1] > latest {
+			latest = match[1]
+		}
+	}
+	return latest, nil
+}
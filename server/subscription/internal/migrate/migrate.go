@@ -4,14 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 
 	"github.com/pressly/goose/v3"
 
 	"github.com/beheryahmed1991/subscription-service.git/migrations"
 )
 
-// Up runs embedded Goose migrations.
+const strictTableName = "goose_strict_db_version"
+
+// Up validates and runs the embedded Goose migrations.
 func Up(ctx context.Context, db *sql.DB) error {
+	if err := ValidateEmbedded(); err != nil {
+		return err
+	}
 	goose.SetBaseFS(migrations.Files)
 	goose.SetVerbose(false)
 
@@ -20,3 +26,42 @@ func Up(ctx context.Context, db *sql.DB) error {
 	}
 	return nil
 }
+
+// Strict applies the optional constraint migration set. It keeps its own
+// version table so it can be enabled or rolled back independently of Up.
+func Strict(ctx context.Context, db *sql.DB) error {
+	defaultTable := goose.TableName()
+	goose.SetBaseFS(migrations.Strict)
+	goose.SetTableName(strictTableName)
+	goose.SetVerbose(false)
+	defer goose.SetTableName(defaultTable)
+
+	if err := goose.RunContext(ctx, "up", db, "strict"); err != nil {
+		return fmt.Errorf("goose up strict: %w", err)
+	}
+	return nil
+}
+
+// CheckApplied returns an error unless the database is at the latest
+// embedded migration.
+func CheckApplied(ctx context.Context, db *sql.DB) error {
+	latest, err := latestVersion(migrations.Files)
+	if err != nil {
+		return err
+	}
+	want, err := strconv.ParseInt(latest, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse migration version %q: %w", latest, err)
+	}
+	// Read the version table directly: goose would create it when missing,
+	// which a probe must not do, least of all against a read-only replica.
+	var current int64
+	query := `SELECT COALESCE(MAX(version_id), 0) FROM ` + goose.TableName() + ` WHERE is_applied`
+	if err := db.QueryRowContext(ctx, query).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if current < want {
+		return fmt.Errorf("schema at version %d, migrations go up to %d", current, want)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/beheryahmed1991/subscription-service.git/migrations"
+)
+
+// ErrNoManifest is returned by CheckSchema when the embedded manifest has not
+// been generated yet.
+var ErrNoManifest = errors.New("schema manifest is empty")
+
+// Schema maps each column ("column <table>.<name>") to its type and each
+// index ("index <table>.<name>") to its definition. Nullability is left out
+// because the optional strict set changes it.
+type Schema map[string]string
+
+const manifestHeader = `# Expected schema after all migrations, checked at startup.
+# Generated by go run ./cmd/schema-manifest against a fresh database; do not edit.
+`
+
+const inspectColumnsSQL = `
+SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod)
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema()
+  AND c.relkind IN ('r', 'p')
+  AND a.attnum > 0
+  AND NOT a.attisdropped
+  AND c.relname NOT IN ($1, $2);
+`
+
+const inspectIndexesSQL = `
+SELECT tablename, indexname, indexdef
+FROM pg_indexes
+WHERE schemaname = current_schema()
+  AND tablename NOT IN ($1, $2);
+`
+
+// Inspect reads the live schema of the current database schema, leaving out
+// Goose's version tables.
+func Inspect(ctx context.Context, db *sql.DB) (Schema, error) {
+	schema := Schema{}
+	if err := inspect(ctx, db, schema, "column", inspectColumnsSQL); err != nil {
+		return nil, fmt.Errorf("inspect columns: %w", err)
+	}
+	if err := inspect(ctx, db, schema, "index", inspectIndexesSQL); err != nil {
+		return nil, fmt.Errorf("inspect indexes: %w", err)
+	}
+	return schema, nil
+}
+
+func inspect(ctx context.Context, db *sql.DB, schema Schema, kind, query string) error {
+	rows, err := db.QueryContext(ctx, query, "goose_db_version", strictTableName)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name, value string
+		if err := rows.Scan(&table, &name, &value); err != nil {
+			return err
+		}
+		schema[kind+" "+table+"."+name] = value
+	}
+	return rows.Err()
+}
+
+// ParseSchema reads a manifest written by Schema.Write.
+func ParseSchema(r io.Reader) (Schema, error) {
+	schema := Schema{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, " ", 3)
+		if len(fields) != 3 || (fields[0] != "column" && fields[0] != "index") {
+			return nil, fmt.Errorf("schema manifest line %d: want \"column|index <table>.<name> <value>\"", line)
+		}
+		schema[fields[0]+" "+fields[1]] = fields[2]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read schema manifest: %w", err)
+	}
+	return schema, nil
+}
+
+// Write renders s as a manifest, one sorted object per line.
+func (s Schema) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, manifestHeader); err != nil {
+		return err
+	}
+	for _, key := range s.keys() {
+		if _, err := fmt.Fprintf(w, "%s %s\n", key, s[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drift lists the differences between the expected schema and actual, one
+// human-readable line each, in a stable order.
+func Drift(expected, actual Schema) []string {
+	var drift []string
+	for _, key := range expected.keys() {
+		got, ok := actual[key]
+		switch {
+		case !ok:
+			drift = append(drift, "missing "+key)
+		case got != expected[key]:
+			drift = append(drift, fmt.Sprintf("%s is %q, expected %q", key, got, expected[key]))
+		}
+	}
+	for _, key := range actual.keys() {
+		if _, ok := expected[key]; !ok {
+			drift = append(drift, "unexpected "+key)
+		}
+	}
+	return drift
+}
+
+// CheckSchema compares the live schema with the manifest embedded from
+// migrations/schema.txt.
+func CheckSchema(ctx context.Context, db *sql.DB) ([]string, error) {
+	expected, err := ParseSchema(strings.NewReader(migrations.Schema))
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) == 0 {
+		return nil, ErrNoManifest
+	}
+	actual, err := Inspect(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return Drift(expected, actual), nil
+}
+
+func (s Schema) keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
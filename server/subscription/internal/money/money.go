@@ -0,0 +1,135 @@
+// Package money knows the currencies subscriptions may be priced in and
+// converts their amounts to rubles, the currency every total is kept in.
+package money
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Base is the currency totals, summaries and the ledger are kept in.
+const Base = "RUB"
+
+// Currency is an ISO 4217 currency. Exponent is the number of minor units
+// digits: 2 for kopecks and cents, 0 for yen.
+type Currency struct {
+	Code     string
+	Exponent int
+}
+
+// supported lists the currencies subscriptions may be priced in.
+var supported = map[string]Currency{
+	"RUB": {Code: "RUB", Exponent: 2},
+	"USD": {Code: "USD", Exponent: 2},
+	"EUR": {Code: "EUR", Exponent: 2},
+	"GBP": {Code: "GBP", Exponent: 2},
+	"CHF": {Code: "CHF", Exponent: 2},
+	"CNY": {Code: "CNY", Exponent: 2},
+	"KZT": {Code: "KZT", Exponent: 2},
+	"BYN": {Code: "BYN", Exponent: 2},
+	"UAH": {Code: "UAH", Exponent: 2},
+	"TRY": {Code: "TRY", Exponent: 2},
+	"AMD": {Code: "AMD", Exponent: 2},
+	"GEL": {Code: "GEL", Exponent: 2},
+	"JPY": {Code: "JPY", Exponent: 0},
+}
+
+// Lookup returns the supported currency with the given code, ignoring case
+// and surrounding space.
+func Lookup(code string) (Currency, bool) {
+	c, ok := supported[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
+
+// Codes returns the supported currency codes, sorted.
+func Codes() []string {
+	codes := make([]string, 0, len(supported))
+	for code := range supported {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Minor converts whole units to minor units.
+func (c Currency) Minor(major int64) int64 {
+	return major * pow10(c.Exponent)
+}
+
+// Major converts minor units to whole units.
+func (c Currency) Major(minor int64) float64 {
+	return float64(minor) / float64(pow10(c.Exponent))
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
+
+// ErrNoRate is returned for a currency without an exchange rate.
+var ErrNoRate = errors.New("no exchange rate")
+
+// Rates provides exchange rates. Rate returns how many rubles one whole unit
+// of the currency is worth.
+type Rates interface {
+	Rate(ctx context.Context, code string) (float64, error)
+}
+
+// StaticRates is a fixed rate table keyed by currency code. The base
+// currency is always 1 and need not be listed, so a nil table converts
+// rubles only.
+type StaticRates map[string]float64
+
+func (r StaticRates) Rate(_ context.Context, code string) (float64, error) {
+	if code == Base {
+		return 1, nil
+	}
+	rate, ok := r[code]
+	if !ok {
+		return 0, fmt.Errorf("%w for %s", ErrNoRate, code)
+	}
+	return rate, nil
+}
+
+// ParseRates parses a rate table such as "USD=92.5,EUR=99.8". Codes must be
+// supported currencies and rates positive.
+func ParseRates(s string) (StaticRates, error) {
+	rates := StaticRates{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate %q: want CODE=RATE", entry)
+		}
+		c, ok := Lookup(code)
+		if !ok {
+			return nil, fmt.Errorf("rate %q: unsupported currency", entry)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("rate %q: want a positive number", entry)
+		}
+		rates[c.Code] = rate
+	}
+	return rates, nil
+}
+
+// ToRUB converts minor units of c to whole rubles, rounded to the nearest.
+func ToRUB(ctx context.Context, rates Rates, c Currency, minor int64) (int, error) {
+	rate, err := rates.Rate(ctx, c.Code)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Round(c.Major(minor) * rate)), nil
+}
@@ -0,0 +1,209 @@
+// Package monitor makes the background subsystems observable. Jobs report
+// each pass through Track and queues expose their depth through Observe, so
+// a job that silently stopped succeeding shows up as an old last-success
+// timestamp on /metrics and /admin/info instead of going unnoticed.
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+)
+
+// refreshInterval is how often observed values are sampled for /metrics.
+const refreshInterval = 15 * time.Second
+
+// Subsystem is the state of one background subsystem.
+type Subsystem struct {
+	Name        string     `json:"name"`
+	Running     int        `json:"running"`
+	Runs        int64      `json:"runs"`
+	Failures    int64      `json:"failures"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	// Values holds the observed gauges, such as queue depths.
+	Values map[string]float64 `json:"values,omitempty"`
+}
+
+// Info is the state of every subsystem, served at GET /admin/info.
+type Info struct {
+	StartedAt  time.Time   `json:"started_at"`
+	Goroutines int         `json:"goroutines"`
+	Subsystems []Subsystem `json:"subsystems"`
+}
+
+type observer struct {
+	subsystem, name string
+	fn              func() float64
+}
+
+// Monitor collects the state of the background subsystems. A nil Monitor is
+// valid and records nothing, so jobs work without one.
+type Monitor struct {
+	startedAt time.Time
+
+	mu         sync.Mutex
+	subsystems map[string]*Subsystem
+	observers  []observer
+
+	running     *metrics.GaugeVec
+	lastSuccess *metrics.GaugeVec
+	lastFailure *metrics.GaugeVec
+	failures    *metrics.CounterVec
+	values      *metrics.GaugeVec
+	goroutines  *metrics.GaugeVec
+}
+
+// New creates a Monitor and registers its metrics on reg.
+func New(reg *metrics.Registry) *Monitor {
+	return &Monitor{
+		startedAt:  time.Now().UTC(),
+		subsystems: make(map[string]*Subsystem),
+		running: reg.Gauge("subsystem_runs_in_progress",
+			"Passes of a background subsystem currently running.", "subsystem"),
+		lastSuccess: reg.Gauge("subsystem_last_success_timestamp_seconds",
+			"Unix time a background subsystem last completed a pass without error.", "subsystem"),
+		lastFailure: reg.Gauge("subsystem_last_failure_timestamp_seconds",
+			"Unix time a background subsystem last failed a pass.", "subsystem"),
+		failures: reg.Counter("subsystem_failures_total",
+			"Failed passes of a background subsystem.", "subsystem"),
+		values: reg.Gauge("subsystem_value",
+			"Observed state of a background subsystem, such as a queue depth.", "subsystem", "name"),
+		goroutines: reg.Gauge("process_goroutines",
+			"Goroutines in the process."),
+	}
+}
+
+func (m *Monitor) subsystem(name string) *Subsystem {
+	s, ok := m.subsystems[name]
+	if !ok {
+		s = &Subsystem{Name: name}
+		m.subsystems[name] = s
+	}
+	return s
+}
+
+// Track runs one pass of the subsystem name and records its outcome.
+func (m *Monitor) Track(name string, fn func() error) error {
+	if m == nil {
+		return fn()
+	}
+
+	m.mu.Lock()
+	s := m.subsystem(name)
+	s.Running++
+	m.running.Set(float64(s.Running), name)
+	m.mu.Unlock()
+
+	err := fn()
+	now := time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.Running--
+	s.Runs++
+	m.running.Set(float64(s.Running), name)
+	if err != nil {
+		s.Failures++
+		s.LastFailure = &now
+		s.LastError = err.Error()
+		m.failures.Inc(name)
+		m.lastFailure.Set(float64(now.Unix()), name)
+	} else {
+		s.LastSuccess = &now
+		m.lastSuccess.Set(float64(now.Unix()), name)
+	}
+	return err
+}
+
+// Observe samples fn as the value name of subsystem, e.g. a queue depth.
+func (m *Monitor) Observe(subsystem, name string, fn func() float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subsystem(subsystem)
+	m.observers = append(m.observers, observer{subsystem: subsystem, name: name, fn: fn})
+}
+
+// Run samples the observed values until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		m.Info()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Info samples the observed values and returns every subsystem sorted by
+// name.
+func (m *Monitor) Info() Info {
+	m.mu.Lock()
+	observers := append([]observer(nil), m.observers...)
+	m.mu.Unlock()
+
+	// Observers may take their own locks, so they run outside m.mu.
+	sampled := make([]float64, len(observers))
+	for i, o := range observers {
+		sampled[i] = o.fn()
+	}
+	goroutines := float64(runtime.NumGoroutine())
+	m.goroutines.Set(goroutines)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, o := range observers {
+		s := m.subsystem(o.subsystem)
+		if s.Values == nil {
+			s.Values = make(map[string]float64)
+		}
+		s.Values[o.name] = sampled[i]
+		m.values.Set(sampled[i], o.subsystem, o.name)
+	}
+
+	info := Info{StartedAt: m.startedAt, Goroutines: int(goroutines), Subsystems: make([]Subsystem, 0, len(m.subsystems))}
+	for _, s := range m.subsystems {
+		out := *s
+		if s.Values != nil {
+			out.Values = make(map[string]float64, len(s.Values))
+			for k, v := range s.Values {
+				out.Values[k] = v
+			}
+		}
+		info.Subsystems = append(info.Subsystems, out)
+	}
+	sort.Slice(info.Subsystems, func(i, j int) bool { return info.Subsystems[i].Name < info.Subsystems[j].Name })
+	return info
+}
+
+// RegisterRoutes mounts GET /info on the admin group.
+func (m *Monitor) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/info", m.get)
+}
+
+// get godoc
+// @Summary Background subsystem status
+// @Description Runs, failures, last success and failure times and observed values such as queue depths for every background subsystem
+// @Tags admin
+// @Produce json
+// @Success 200 {object} Info
+// @ID getSubsystemInfo
+// @Router /admin/info [get]
+func (m *Monitor) get(c *gin.Context) {
+	c.JSON(http.StatusOK, m.Info())
+}
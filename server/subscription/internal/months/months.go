@@ -0,0 +1,98 @@
+// Package months holds the month arithmetic billing relies on. Subscriptions
+// are charged per calendar month, so every computation first reduces times to
+// the first day of their month in UTC. The SQL helpers produce expressions
+// that follow the same rules, so summaries computed in Go and in PostgreSQL
+// agree.
+package months
+
+import (
+	"fmt"
+	"time"
+)
+
+// Normalize returns the first day of t's month at midnight UTC. The month is
+// read from t's own wall clock, so 2025-03-01T00:30+03:00 is March even
+// though it is still February in UTC.
+func Normalize(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Between counts the months from start to end, both included, after
+// normalizing them. It is 0 when end is before start.
+func Between(start, end time.Time) int {
+	start, end = Normalize(start), Normalize(end)
+	if end.Before(start) {
+		return 0
+	}
+	return (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month()) + 1
+}
+
+// LastCharged returns the last month a subscription ending at end is charged
+// for. An exclusive end ("until March starts") stops one month earlier.
+func LastCharged(end time.Time, inclusive bool) time.Time {
+	end = Normalize(end)
+	if inclusive {
+		return end
+	}
+	return end.AddDate(0, -1, 0)
+}
+
+// Clamp intersects a subscription running from start to end (nil for
+// ongoing) with the period from periodStart to periodEnd, either of which may
+// be nil for unbounded. An open end on both sides stops at now's month. It
+// reports false when the ranges do not overlap.
+func Clamp(start time.Time, end, periodStart, periodEnd *time.Time, now time.Time) (time.Time, time.Time, bool) {
+	from := Normalize(start)
+	if periodStart != nil {
+		from = latest(from, Normalize(*periodStart))
+	}
+
+	var to time.Time
+	switch {
+	case end != nil && periodEnd != nil:
+		to = earliest(Normalize(*end), Normalize(*periodEnd))
+	case end != nil:
+		to = Normalize(*end)
+	case periodEnd != nil:
+		to = Normalize(*periodEnd)
+	default:
+		to = Normalize(now)
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// BetweenSQL is the PostgreSQL counterpart of Between for two date
+// expressions already on the first of their month. Both must be trusted SQL.
+func BetweenSQL(start, end string) string {
+	return fmt.Sprintf(`(
+        (DATE_PART('year', %[2]s) - DATE_PART('year', %[1]s)) * 12 +
+        (DATE_PART('month', %[2]s) - DATE_PART('month', %[1]s)) + 1
+    )::bigint`, start, end)
+}
+
+// LastChargedSQL is the PostgreSQL counterpart of LastCharged for an end
+// month column and a boolean inclusive expression. NULL ends stay NULL.
+func LastChargedSQL(end, inclusive string) string {
+	return fmt.Sprintf(`CASE
+            WHEN %[1]s IS NULL OR %[2]s THEN %[1]s
+            ELSE (%[1]s - interval '1 month')::date
+        END`, end, inclusive)
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,109 @@
+package months_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/testutil"
+)
+
+func TestMonthCases(t *testing.T) {
+	for _, c := range testutil.MonthCases {
+		t.Run(c.Name, func(t *testing.T) {
+			last := months.LastCharged(c.End, c.Inclusive)
+			if got := months.Between(c.Start, last); got != c.Want {
+				t.Errorf("Between(%s, LastCharged(%s, %t)) = %d, want %d", c.Start, c.End, c.Inclusive, got, c.Want)
+			}
+		})
+	}
+}
+
+func TestLastCharged(t *testing.T) {
+	tests := []struct {
+		name      string
+		end       time.Time
+		inclusive bool
+		want      time.Time
+	}{
+		{name: "inclusive", end: date(2025, 3, 15), inclusive: true, want: date(2025, 3, 1)},
+		{name: "exclusive", end: date(2025, 3, 15), want: date(2025, 2, 1)},
+		{name: "exclusive across year", end: date(2025, 1, 1), want: date(2024, 12, 1)},
+		{name: "inclusive at year end", end: date(2024, 12, 31), inclusive: true, want: date(2024, 12, 1)},
+	}
+	for _, tt := range tests {
+		if got := months.LastCharged(tt.end, tt.inclusive); !got.Equal(tt.want) {
+			t.Errorf("%s: LastCharged(%s, %t) = %s, want %s", tt.name, tt.end, tt.inclusive, got, tt.want)
+		}
+	}
+}
+
+func TestClamp(t *testing.T) {
+	now := date(2025, 6, 20)
+	ptr := func(t time.Time) *time.Time { return &t }
+	tests := []struct {
+		name        string
+		start       time.Time
+		end         *time.Time
+		periodStart *time.Time
+		periodEnd   *time.Time
+		wantFrom    time.Time
+		wantTo      time.Time
+		wantOK      bool
+	}{
+		{name: "open ends stop at now", start: date(2025, 1, 10), wantFrom: date(2025, 1, 1), wantTo: date(2025, 6, 1), wantOK: true},
+		{name: "open period", start: date(2025, 1, 1), end: ptr(date(2025, 3, 1)), wantFrom: date(2025, 1, 1), wantTo: date(2025, 3, 1), wantOK: true},
+		{name: "open subscription", start: date(2024, 1, 1), periodStart: ptr(date(2024, 11, 1)), periodEnd: ptr(date(2025, 2, 1)), wantFrom: date(2024, 11, 1), wantTo: date(2025, 2, 1), wantOK: true},
+		{name: "earlier end wins", start: date(2024, 1, 1), end: ptr(date(2024, 12, 1)), periodEnd: ptr(date(2025, 2, 1)), wantFrom: date(2024, 1, 1), wantTo: date(2024, 12, 1), wantOK: true},
+		{name: "no overlap", start: date(2025, 4, 1), periodEnd: ptr(date(2025, 3, 1))},
+	}
+	for _, tt := range tests {
+		from, to, ok := months.Clamp(tt.start, tt.end, tt.periodStart, tt.periodEnd, now)
+		if ok != tt.wantOK || !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+			t.Errorf("%s: Clamp = %s, %s, %t; want %s, %s, %t", tt.name, from, to, ok, tt.wantFrom, tt.wantTo, tt.wantOK)
+		}
+	}
+}
+
+// TestSQLParity checks BetweenSQL and LastChargedSQL against the same cases
+// on a real PostgreSQL. Set TEST_DATABASE_DSN to run it.
+func TestSQLParity(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The SQL helpers expect months already normalized, as stored. The Go
+	// side clamps an end before the start to 0; queries filter those rows.
+	query := `SELECT GREATEST(` + months.BetweenSQL("$1::date", months.LastChargedSQL("$2::date", "$3::boolean")) + `, 0),
+	    ` + months.LastChargedSQL("$2::date", "$3::boolean")
+	for _, c := range testutil.MonthCases {
+		t.Run(c.Name, func(t *testing.T) {
+			var count int
+			var last time.Time
+			start, end := months.Normalize(c.Start), months.Normalize(c.End)
+			if err := conn.QueryRow(query, start, end, c.Inclusive).Scan(&count, &last); err != nil {
+				t.Fatal(err)
+			}
+			if count != c.Want {
+				t.Errorf("BetweenSQL = %d, want %d", count, c.Want)
+			}
+			if want := months.LastCharged(c.End, c.Inclusive); !last.Equal(want) {
+				t.Errorf("LastChargedSQL = %s, LastCharged = %s", last, want)
+			}
+		})
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
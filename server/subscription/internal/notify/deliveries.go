@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// deliveryLogTimeout bounds each write to the delivery log, so a slow log
+// cannot stall the workers.
+const deliveryLogTimeout = 5 * time.Second
+
+// DeliveryStatus is where a logged delivery stands.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is queued or waiting for a retry.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered got a 2xx response.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed ran out of attempts or was dropped.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// DeliveryUpdate is the outcome of one step of a delivery. Attempted is false
+// when the message was dropped without being sent; ResponseCode is zero when
+// no response was received.
+type DeliveryUpdate struct {
+	Status       DeliveryStatus
+	Attempted    bool
+	ResponseCode int
+	Error        string
+}
+
+// DeliveryLog keeps a record of webhook deliveries for integrators to inspect.
+type DeliveryLog interface {
+	CreateDelivery(ctx context.Context, msg Message) (uuid.UUID, error)
+	UpdateDelivery(ctx context.Context, id uuid.UUID, update DeliveryUpdate) error
+}
+
+// recordAttempt writes the outcome of j to the delivery log, if it is logged.
+// Failures are only reported: the log must never affect delivery.
+func (p *Pool) recordAttempt(j job, status DeliveryStatus, code int, attempted bool, err error) {
+	if p.log == nil || j.msg.DeliveryID == uuid.Nil {
+		return
+	}
+	update := DeliveryUpdate{Status: status, Attempted: attempted, ResponseCode: code}
+	if err != nil {
+		update.Error = err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryLogTimeout)
+	defer cancel()
+	if err := p.log.UpdateDelivery(ctx, j.msg.DeliveryID, update); err != nil {
+		p.logger.Warn("delivery log update failed", "delivery_id", j.msg.DeliveryID, "error", err)
+	}
+}
+
+type responseCodeKey struct{}
+
+// withResponseCode returns a context in which senders can report the status
+// code they received, and where to read it afterwards.
+func withResponseCode(ctx context.Context) (context.Context, *int) {
+	code := new(int)
+	return context.WithValue(ctx, responseCodeKey{}, code), code
+}
+
+func setResponseCode(ctx context.Context, code int) {
+	if ptr, ok := ctx.Value(responseCodeKey{}).(*int); ok {
+		*ptr = code
+	}
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailSender delivers the payload as an email over SMTP, plain text unless
+// the message says otherwise.
+type EmailSender struct {
+	// Addr is the SMTP server as host:port.
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func (s EmailSender) Send(ctx context.Context, msg Message) error {
+	if s.Addr == "" {
+		return errors.New("smtp server is not configured")
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("parse smtp address: %w", err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	// net/smtp has no context support; run it aside and give up on the
+	// attempt when ctx ends. The pool retries with backoff.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.Addr, auth, s.From, []string{msg.Destination}, s.compose(msg))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s EmailSender) compose(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.Destination)
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", "", "\n", "").Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	contentType := msg.ContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+	b.WriteString("\r\n")
+	b.Write(msg.Payload)
+	return []byte(b.String())
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Delivery channels understood by Mux.
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// Mux routes each message to the Sender registered for its Channel, so one
+// pool can serve every channel.
+type Mux map[string]Sender
+
+func (m Mux) Send(ctx context.Context, msg Message) error {
+	sender, ok := m[msg.Channel]
+	if !ok {
+		return fmt.Errorf("no sender for channel %q", msg.Channel)
+	}
+	return sender.Send(ctx, msg)
+}
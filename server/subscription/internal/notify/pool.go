@@ -0,0 +1,340 @@
+// Package notify delivers outbound notifications (webhooks, emails) through a
+// bounded worker pool so one slow destination cannot block the others.
+package notify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrQueueFull is returned by Enqueue when the pool cannot accept more work.
+var ErrQueueFull = errors.New("notification queue is full")
+
+// ErrPoolClosed is returned by Enqueue after Stop has been called.
+var ErrPoolClosed = errors.New("notification pool is closed")
+
+// Message is a single delivery. Destination identifies the receiving endpoint
+// (a URL or an email address) and is the key for per-destination limits.
+// Channel selects the sender when the pool delivers through a Mux; Subject is
+// used by channels that have one. WebhookID names the registered webhook the
+// message is for; such messages are recorded in the pool's DeliveryLog, under
+// DeliveryID once assigned.
+type Message struct {
+	Channel     string
+	Destination string
+	Kind        string
+	Subject     string
+	// ContentType is the MIME type of an email payload; empty means plain
+	// text.
+	ContentType string
+	Payload     []byte
+	WebhookID   uuid.UUID
+	DeliveryID  uuid.UUID
+}
+
+// Sender performs one delivery attempt.
+type Sender interface {
+	Send(context.Context, Message) error
+}
+
+// SenderFunc adapts a function to Sender.
+type SenderFunc func(context.Context, Message) error
+
+func (f SenderFunc) Send(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// PoolConfig tunes concurrency, timeouts and retries.
+type PoolConfig struct {
+	Workers         int
+	QueueSize       int
+	PerDestination  int
+	AttemptTimeout  time.Duration
+	MaxAttempts     int
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+	SaturationDelay time.Duration
+}
+
+// Stats is a point-in-time snapshot of the pool.
+type Stats struct {
+	QueueDepth int   `json:"queue_depth"`
+	InFlight   int64 `json:"in_flight"`
+	Delivered  int64 `json:"delivered"`
+	Failed     int64 `json:"failed"`
+	Retried    int64 `json:"retried"`
+}
+
+type job struct {
+	msg     Message
+	attempt int
+}
+
+// Pool runs deliveries on a fixed number of workers.
+type Pool struct {
+	cfg    PoolConfig
+	sender Sender
+	logger *slog.Logger
+	queue  chan job
+	log    DeliveryLog
+
+	mu      sync.Mutex
+	slots   map[string]int
+	closing bool
+	closed  bool
+
+	wg        sync.WaitGroup
+	pending   sync.WaitGroup
+	inFlight  atomic.Int64
+	delivered atomic.Int64
+	failed    atomic.Int64
+	retried   atomic.Int64
+}
+
+// NewPool creates a Pool that delivers messages through sender.
+func NewPool(cfg PoolConfig, sender Sender, logger *slog.Logger) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.PerDestination <= 0 {
+		cfg.PerDestination = 2
+	}
+	if cfg.AttemptTimeout <= 0 {
+		cfg.AttemptTimeout = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.SaturationDelay <= 0 {
+		cfg.SaturationDelay = 100 * time.Millisecond
+	}
+
+	return &Pool{
+		cfg:    cfg,
+		sender: sender,
+		logger: logger,
+		queue:  make(chan job, cfg.QueueSize),
+		slots:  make(map[string]int),
+	}
+}
+
+// Start launches the workers. They stop once ctx is cancelled or Stop is called.
+func (p *Pool) Start(ctx context.Context) {
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+}
+
+// LogDeliveries records webhook messages and the outcome of every attempt in
+// log. It must be called before Start.
+func (p *Pool) LogDeliveries(log DeliveryLog) {
+	p.log = log
+}
+
+// Enqueue schedules msg for delivery without blocking the caller on the
+// delivery itself. Webhook messages without a DeliveryID get one from the
+// delivery log first; if that fails the message is still sent, unlogged.
+func (p *Pool) Enqueue(msg Message) error {
+	p.mu.Lock()
+	closing := p.closing
+	p.mu.Unlock()
+	if closing {
+		return ErrPoolClosed
+	}
+
+	if p.log != nil && msg.WebhookID != uuid.Nil && msg.DeliveryID == uuid.Nil {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryLogTimeout)
+		id, err := p.log.CreateDelivery(ctx, msg)
+		cancel()
+		if err != nil {
+			p.logger.Warn("delivery log unavailable", "webhook_id", msg.WebhookID, "error", err)
+		} else {
+			msg.DeliveryID = id
+		}
+	}
+
+	err := p.push(job{msg: msg})
+	if err != nil {
+		p.recordAttempt(job{msg: msg}, DeliveryFailed, 0, false, err)
+	}
+	return err
+}
+
+// Stop rejects new messages and waits for queued and retrying deliveries to
+// finish, up to ctx's deadline.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	p.closing = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.pending.Wait()
+		p.mu.Lock()
+		p.closed = true
+		close(p.queue)
+		p.mu.Unlock()
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current queue depth and delivery counters.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		QueueDepth: len(p.queue),
+		InFlight:   p.inFlight.Load(),
+		Delivered:  p.delivered.Load(),
+		Failed:     p.failed.Load(),
+		Retried:    p.retried.Load(),
+	}
+}
+
+func (p *Pool) push(j job) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+	select {
+	case p.queue <- j:
+		p.pending.Add(1)
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// pushLater re-enqueues j after delay. It is only called while j is still
+// being processed, so the pending count cannot drop to zero in between and Stop
+// does not close the queue underneath it.
+func (p *Pool) pushLater(j job, delay time.Duration) {
+	p.pending.Add(1)
+	time.AfterFunc(delay, func() {
+		defer p.pending.Done()
+		if err := p.push(j); err != nil {
+			p.failed.Add(1)
+			p.recordAttempt(j, DeliveryFailed, 0, false, err)
+			p.logger.Error("notification dropped",
+				"destination", j.msg.Destination,
+				"kind", j.msg.Kind,
+				"error", err,
+			)
+		}
+	})
+}
+
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j, ok := <-p.queue:
+			if !ok {
+				return
+			}
+			p.process(ctx, j)
+			p.pending.Done()
+		}
+	}
+}
+
+func (p *Pool) process(ctx context.Context, j job) {
+	if !p.acquire(j.msg.Destination) {
+		p.pushLater(j, p.cfg.SaturationDelay)
+		return
+	}
+	defer p.release(j.msg.Destination)
+
+	p.inFlight.Add(1)
+	attemptCtx, cancel := context.WithTimeout(ctx, p.cfg.AttemptTimeout)
+	attemptCtx, code := withResponseCode(attemptCtx)
+	err := p.sender.Send(attemptCtx, j.msg)
+	cancel()
+	p.inFlight.Add(-1)
+
+	if err == nil {
+		p.delivered.Add(1)
+		p.recordAttempt(j, DeliveryDelivered, *code, true, nil)
+		return
+	}
+
+	j.attempt++
+	if j.attempt >= p.cfg.MaxAttempts || ctx.Err() != nil {
+		p.failed.Add(1)
+		p.recordAttempt(j, DeliveryFailed, *code, true, err)
+		p.logger.Error("notification delivery failed",
+			"destination", j.msg.Destination,
+			"kind", j.msg.Kind,
+			"attempts", j.attempt,
+			"error", err,
+		)
+		return
+	}
+
+	p.retried.Add(1)
+	p.recordAttempt(j, DeliveryPending, *code, true, err)
+	p.logger.Warn("notification delivery retry",
+		"destination", j.msg.Destination,
+		"kind", j.msg.Kind,
+		"attempt", j.attempt,
+		"error", err,
+	)
+	p.pushLater(j, p.backoff(j.attempt))
+}
+
+// backoff returns an exponential delay with full jitter.
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.cfg.BaseBackoff << (attempt - 1)
+	if delay <= 0 || delay > p.cfg.MaxBackoff {
+		delay = p.cfg.MaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(delay)) + 1)
+}
+
+func (p *Pool) acquire(destination string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.slots[destination] >= p.cfg.PerDestination {
+		return false
+	}
+	p.slots[destination]++
+	return true
+}
+
+func (p *Pool) release(destination string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.slots[destination]--
+	if p.slots[destination] <= 0 {
+		delete(p.slots, destination)
+	}
+}
@@ -0,0 +1,187 @@
+// Package query binds URL query parameters to typed structs, so handlers
+// declare their parameters once and every malformed value is rejected with
+// the same kind of error instead of being parsed, defaulted or ignored by
+// hand.
+package query
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error reports one query parameter that is missing or malformed.
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Param + ": " + e.Message
+}
+
+// field is the parsed `query` tag of one struct field:
+//
+//	Page  int        `query:"page,default=1,min=1"`
+//	Order string     `query:"order,enum=asc|desc"`
+//	User  *uuid.UUID `query:"user_id"`
+//
+// Options are default, min and max (for integers and floats), enum
+// (case-insensitive, stored lowercased) and required.
+type field struct {
+	name     string
+	def      string
+	min, max *float64
+	enum     []string
+	required bool
+}
+
+var textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// Bind fills dst, a pointer to a struct, from values. Fields without a query
+// tag are left alone. Empty and blank values count as absent: pointer fields
+// stay nil and other fields take their default, or keep their current value
+// when there is none. Supported types are strings, bools, ints, floats,
+// time.Duration, encoding.TextUnmarshaler implementations and pointers to
+// them. The first bad parameter is returned as an *Error.
+func Bind(values url.Values, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		panic("query: Bind needs a pointer to a struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("query")
+		if !ok || tag == "-" {
+			continue
+		}
+		f, err := parseTag(tag)
+		if err != nil {
+			panic(fmt.Sprintf("query: field %s: %v", t.Field(i).Name, err))
+		}
+
+		raw := strings.TrimSpace(values.Get(f.name))
+		if raw == "" {
+			if f.required {
+				return &Error{Param: f.name, Message: "is required"}
+			}
+			if f.def == "" {
+				continue
+			}
+			raw = f.def
+		}
+		if err := f.set(v.Field(i), raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseTag(tag string) (field, error) {
+	parts := strings.Split(tag, ",")
+	f := field{name: parts[0]}
+	for _, opt := range parts[1:] {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "default":
+			f.def = value
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return field{}, fmt.Errorf("bad %s %q", key, value)
+			}
+			if key == "min" {
+				f.min = &n
+			} else {
+				f.max = &n
+			}
+		case "enum":
+			f.enum = strings.Split(strings.ToLower(value), "|")
+		case "required":
+			f.required = true
+		default:
+			return field{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return f, nil
+}
+
+// set parses raw into target, allocating it first when it is a pointer.
+func (f field) set(target reflect.Value, raw string) error {
+	if target.Kind() == reflect.Pointer {
+		value := reflect.New(target.Type().Elem())
+		if err := f.set(value.Elem(), raw); err != nil {
+			return err
+		}
+		target.Set(value)
+		return nil
+	}
+
+	if target.Addr().Type().Implements(textUnmarshaler) {
+		if err := target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return &Error{Param: f.name, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch {
+	case target.Type() == reflect.TypeFor[time.Duration]():
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return &Error{Param: f.name, Message: "must be a duration such as 30s or 5m"}
+		}
+		target.SetInt(int64(d))
+	case target.Kind() == reflect.String:
+		if f.enum != nil {
+			raw = strings.ToLower(raw)
+			if !slices.Contains(f.enum, raw) {
+				return &Error{Param: f.name, Message: "must be one of " + strings.Join(f.enum, ", ")}
+			}
+		}
+		target.SetString(raw)
+	case target.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return &Error{Param: f.name, Message: "must be true or false"}
+		}
+		target.SetBool(b)
+	case target.CanInt():
+		n, err := strconv.ParseInt(raw, 10, target.Type().Bits())
+		if err != nil {
+			return &Error{Param: f.name, Message: "must be an integer"}
+		}
+		if err := f.checkRange(float64(n)); err != nil {
+			return err
+		}
+		target.SetInt(n)
+	case target.CanFloat():
+		n, err := strconv.ParseFloat(raw, target.Type().Bits())
+		if err != nil {
+			return &Error{Param: f.name, Message: "must be a number"}
+		}
+		if err := f.checkRange(n); err != nil {
+			return err
+		}
+		target.SetFloat(n)
+	default:
+		panic(fmt.Sprintf("query: unsupported type %s for %q", target.Type(), f.name))
+	}
+	return nil
+}
+
+func (f field) checkRange(n float64) error {
+	if f.min != nil && n < *f.min {
+		return &Error{Param: f.name, Message: "must be at least " + strconv.FormatFloat(*f.min, 'f', -1, 64)}
+	}
+	if f.max != nil && n > *f.max {
+		return &Error{Param: f.name, Message: "must be at most " + strconv.FormatFloat(*f.max, 'f', -1, 64)}
+	}
+	return nil
+}
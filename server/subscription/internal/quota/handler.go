@@ -0,0 +1,133 @@
+package quota
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler lets operators manage rate limit overrides.
+type Handler struct {
+	store  Store
+	logger *slog.Logger
+}
+
+// NewHandler creates a Handler backed by store. Pass the Cache the rate
+// limiter reads from so changes apply without waiting for it to expire.
+func NewHandler(store Store, logger *slog.Logger) *Handler {
+	return &Handler{store: store, logger: logger}
+}
+
+// RegisterRoutes mounts /quotas/:user_id on the admin group.
+func (h *Handler) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/quotas/:user_id", h.get)
+	group.PUT("/quotas/:user_id", h.set)
+	group.DELETE("/quotas/:user_id", h.delete)
+}
+
+type setQuotaRequest struct {
+	RequestsPerMinute int `json:"requests_per_minute" binding:"required,gt=0"`
+}
+
+// get godoc
+// @Summary Get rate limit override
+// @Description The user's requests-per-minute override. Users without one get the configured default.
+// @Tags admin
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} Quota
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @ID getUserQuota
+// @Router /admin/quotas/{user_id} [get]
+func (h *Handler) get(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	q, err := h.store.Get(c.Request.Context(), userID)
+	if err != nil {
+		h.fail(c, "failed to get quota", userID, err)
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
+// set godoc
+// @Summary Set rate limit override
+// @Description Give the user a requests-per-minute limit other than the configured default
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param request body setQuotaRequest true "Limit"
+// @Success 200 {object} Quota
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @ID setUserQuota
+// @Router /admin/quotas/{user_id} [put]
+func (h *Handler) set(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req setQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := h.store.Set(c.Request.Context(), userID, req.RequestsPerMinute)
+	if err != nil {
+		h.fail(c, "failed to set quota", userID, err)
+		return
+	}
+	h.logger.Info("quota set", "user_id", userID, "requests_per_minute", q.RequestsPerMinute)
+	c.JSON(http.StatusOK, q)
+}
+
+// delete godoc
+// @Summary Remove rate limit override
+// @Description Return the user to the configured default limit
+// @Tags admin
+// @Param user_id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @ID deleteUserQuota
+// @Router /admin/quotas/{user_id} [delete]
+func (h *Handler) delete(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(c.Request.Context(), userID); err != nil {
+		h.fail(c, "failed to delete quota", userID, err)
+		return
+	}
+	h.logger.Info("quota removed", "user_id", userID)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) fail(c *gin.Context, msg string, userID uuid.UUID, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no quota override for this user"})
+		return
+	}
+	h.logger.Error(msg, "user_id", userID, "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func parseUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
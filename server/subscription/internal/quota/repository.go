@@ -0,0 +1,173 @@
+// Package quota stores per-user overrides of the API rate limit.
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Quota is a user's rate limit override.
+type Quota struct {
+	UserID            uuid.UUID `json:"user_id"`
+	RequestsPerMinute int       `json:"requests_per_minute"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Store reads and writes quota overrides. Get returns sql.ErrNoRows for users
+// without one.
+type Store interface {
+	Get(ctx context.Context, userID uuid.UUID) (Quota, error)
+	Set(ctx context.Context, userID uuid.UUID, requestsPerMinute int) (Quota, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+// Repository is the postgres implementation of Store.
+type Repository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewRepository wires the DB and logger into a Repository.
+func NewRepository(db *sql.DB, logger *slog.Logger) *Repository {
+	return &Repository{db: db, logger: logger}
+}
+
+func (r *Repository) Get(ctx context.Context, userID uuid.UUID) (Quota, error) {
+	var q Quota
+	err := r.db.QueryRowContext(ctx,
+		`SELECT user_id, requests_per_minute, updated_at FROM user_quotas WHERE user_id = $1`,
+		userID,
+	).Scan(&q.UserID, &q.RequestsPerMinute, &q.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Quota{}, err
+		}
+		return Quota{}, fmt.Errorf("get quota: %w", err)
+	}
+	return q, nil
+}
+
+func (r *Repository) Set(ctx context.Context, userID uuid.UUID, requestsPerMinute int) (Quota, error) {
+	var q Quota
+	err := r.db.QueryRowContext(ctx, `
+INSERT INTO user_quotas (user_id, requests_per_minute)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET requests_per_minute = EXCLUDED.requests_per_minute, updated_at = now()
+RETURNING user_id, requests_per_minute, updated_at`,
+		userID, requestsPerMinute,
+	).Scan(&q.UserID, &q.RequestsPerMinute, &q.UpdatedAt)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("set quota failed", "user_id", userID, "error", err)
+		}
+		return Quota{}, fmt.Errorf("set quota: %w", err)
+	}
+	return q, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, userID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM user_quotas WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete quota: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Cache answers limit lookups from memory for up to ttl, so the rate limiter
+// does not query the database on every request. Writes through the cache are
+// visible immediately; writes made elsewhere show up once the entry expires.
+type Cache struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]cacheEntry
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	limit   int
+	found   bool
+	expires time.Time
+}
+
+// NewCache wraps store with a read cache holding entries for ttl.
+func NewCache(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl, now: time.Now, entries: make(map[uuid.UUID]cacheEntry)}
+}
+
+// RequestsPerMinute returns userID's override, or false when there is none.
+func (c *Cache) RequestsPerMinute(ctx context.Context, userID uuid.UUID) (int, bool, error) {
+	now := c.now()
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.limit, entry.found, nil
+	}
+
+	q, err := c.store.Get(ctx, userID)
+	switch {
+	case err == nil:
+		entry = cacheEntry{limit: q.RequestsPerMinute, found: true}
+	case errors.Is(err, sql.ErrNoRows):
+		entry = cacheEntry{}
+	default:
+		return 0, false, err
+	}
+	entry.expires = now.Add(c.ttl)
+
+	c.mu.Lock()
+	c.evictExpired(now)
+	c.entries[userID] = entry
+	c.mu.Unlock()
+	return entry.limit, entry.found, nil
+}
+
+func (c *Cache) Get(ctx context.Context, userID uuid.UUID) (Quota, error) {
+	return c.store.Get(ctx, userID)
+}
+
+func (c *Cache) Set(ctx context.Context, userID uuid.UUID, requestsPerMinute int) (Quota, error) {
+	q, err := c.store.Set(ctx, userID, requestsPerMinute)
+	c.forget(userID)
+	return q, err
+}
+
+func (c *Cache) Delete(ctx context.Context, userID uuid.UUID) error {
+	err := c.store.Delete(ctx, userID)
+	c.forget(userID)
+	return err
+}
+
+func (c *Cache) forget(userID uuid.UUID) {
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.mu.Unlock()
+}
+
+// evictExpired drops stale entries, at most once per ttl, so users who
+// stopped calling do not stay in memory. The caller holds c.mu.
+func (c *Cache) evictExpired(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for id, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, id)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package rbac maps caller roles to what they may do beyond their own data.
+// Every caller may manage its own subscriptions; permissions grant access to
+// other users' data and to the admin API.
+//
+//	user     own data only
+//	support  read any user's data and the admin API
+//	admin    everything
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+)
+
+// Permission names an action beyond the caller's own data.
+type Permission string
+
+const (
+	// ReadAnyUser reads any user's subscriptions, summaries and settings.
+	ReadAnyUser Permission = "users:read"
+	// WriteAnyUser changes any user's subscriptions and settings.
+	WriteAnyUser Permission = "users:write"
+	// ReadAdmin calls the read-only admin endpoints.
+	ReadAdmin Permission = "admin:read"
+	// WriteAdmin calls every admin endpoint.
+	WriteAdmin Permission = "admin:write"
+)
+
+// grants lists the permissions of each role. Roles not listed grant none.
+var grants = map[identity.Role][]Permission{
+	identity.RoleSupport: {ReadAnyUser, ReadAdmin},
+	identity.RoleAdmin:   {ReadAnyUser, WriteAnyUser, ReadAdmin, WriteAdmin},
+}
+
+// Allows reports whether one of caller's roles grants perm.
+func Allows(caller identity.Caller, perm Permission) bool {
+	for _, role := range caller.Roles {
+		if slices.Contains(grants[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the caller in ctx holds perm. Anonymous requests, which
+// only reach handlers while authentication is disabled, hold every
+// permission.
+func Can(ctx context.Context, perm Permission) bool {
+	caller, ok := identity.FromContext(ctx)
+	return !ok || Allows(caller, perm)
+}
+
+// CanAccessUser reports whether the caller in ctx may act on userID's data:
+// its own, or anyone's when it holds perm.
+func CanAccessUser(ctx context.Context, userID uuid.UUID, perm Permission) bool {
+	caller, ok := identity.FromContext(ctx)
+	return !ok || caller.UserID == userID || Allows(caller, perm)
+}
+
+// Require rejects callers without perm with 403.
+func Require(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Can(c.Request.Context(), perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing permission " + string(perm)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin guards the admin API: reads need ReadAdmin and everything
+// else WriteAdmin.
+func RequireAdmin() gin.HandlerFunc {
+	read, write := Require(ReadAdmin), Require(WriteAdmin)
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			read(c)
+		default:
+			write(c)
+		}
+	}
+}
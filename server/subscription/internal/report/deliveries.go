@@ -0,0 +1,193 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+)
+
+// Delivery is one logged webhook message. Webhooks are report schedules
+// delivering to the webhook channel, so WebhookID is a schedule ID.
+type Delivery struct {
+	ID            uuid.UUID             `json:"id"`
+	WebhookID     uuid.UUID             `json:"webhook_id"`
+	Destination   string                `json:"destination"`
+	Kind          string                `json:"kind"`
+	Status        notify.DeliveryStatus `json:"status" enums:"pending,delivered,failed"`
+	Attempts      int                   `json:"attempts"`
+	ResponseCodes []int64               `json:"response_codes"`
+	LastError     *string               `json:"last_error,omitempty"`
+	Payload       json.RawMessage       `json:"payload" swaggertype:"object"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+// DeliveryStore reads and writes the webhook delivery log.
+type DeliveryStore interface {
+	notify.DeliveryLog
+	GetDelivery(ctx context.Context, id uuid.UUID) (Delivery, error)
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]Delivery, error)
+	// ResetDelivery puts a finished delivery back to pending for a retry.
+	// It returns sql.ErrNoRows when the delivery does not exist and
+	// ErrDeliveryPending when it is still pending.
+	ResetDelivery(ctx context.Context, id uuid.UUID) (Delivery, error)
+}
+
+// ErrDeliveryPending is returned when retrying a delivery that has not
+// finished yet.
+var ErrDeliveryPending = errors.New("delivery is still pending")
+
+const deliveryColumns = `id, webhook_id, destination, kind, status, attempts, response_codes, last_error, payload, created_at, updated_at`
+
+func scanDelivery(row rowScanner, d *Delivery) error {
+	var payload []byte
+	err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.Destination,
+		&d.Kind,
+		&d.Status,
+		&d.Attempts,
+		pq.Array(&d.ResponseCodes),
+		&d.LastError,
+		&payload,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	d.Payload = payload
+	if d.ResponseCodes == nil {
+		d.ResponseCodes = []int64{}
+	}
+	return err
+}
+
+// DeliveryRepository is the postgres implementation of DeliveryStore.
+type DeliveryRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewDeliveryRepository wires the DB and logger into a DeliveryRepository.
+func NewDeliveryRepository(db *sql.DB, logger *slog.Logger) *DeliveryRepository {
+	return &DeliveryRepository{db: db, logger: logger}
+}
+
+// CreateDelivery logs msg as pending. Payloads that are not JSON are stored
+// as a JSON string so the snapshot is never lost.
+func (r *DeliveryRepository) CreateDelivery(ctx context.Context, msg notify.Message) (uuid.UUID, error) {
+	payload := msg.Payload
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(string(payload))
+	}
+
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+INSERT INTO webhook_deliveries (webhook_id, destination, kind, payload)
+VALUES ($1, $2, $3, $4)
+RETURNING id`,
+		msg.WebhookID, msg.Destination, msg.Kind, payload,
+	).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert webhook delivery: %w", err)
+	}
+	return id, nil
+}
+
+func (r *DeliveryRepository) UpdateDelivery(ctx context.Context, id uuid.UUID, update notify.DeliveryUpdate) error {
+	var code interface{}
+	if update.ResponseCode != 0 {
+		code = update.ResponseCode
+	}
+	var lastError interface{}
+	if update.Error != "" {
+		lastError = update.Error
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + CASE WHEN $3 THEN 1 ELSE 0 END,
+    response_codes = CASE WHEN $4::int IS NULL THEN response_codes ELSE array_append(response_codes, $4::int) END,
+    last_error = COALESCE($5, CASE WHEN $2 = 'delivered' THEN NULL ELSE last_error END),
+    updated_at = now()
+WHERE id = $1`,
+		id, update.Status, update.Attempted, code, lastError,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *DeliveryRepository) GetDelivery(ctx context.Context, id uuid.UUID) (Delivery, error) {
+	var d Delivery
+	err := scanDelivery(r.db.QueryRowContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id,
+	), &d)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Delivery{}, err
+		}
+		return Delivery{}, fmt.Errorf("get webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// ListDeliveries returns the webhook's most recent deliveries first.
+func (r *DeliveryRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]Delivery, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY created_at DESC, id LIMIT $2`,
+		webhookID, limit,
+	)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("list webhook deliveries failed", "webhook_id", webhookID, "error", err)
+		}
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		var d Delivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *DeliveryRepository) ResetDelivery(ctx context.Context, id uuid.UUID) (Delivery, error) {
+	var d Delivery
+	err := scanDelivery(r.db.QueryRowContext(ctx, `
+UPDATE webhook_deliveries
+SET status = 'pending', updated_at = now()
+WHERE id = $1 AND status <> 'pending'
+RETURNING `+deliveryColumns, id,
+	), &d)
+	if err == nil {
+		return d, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Delivery{}, fmt.Errorf("reset webhook delivery: %w", err)
+	}
+
+	// Nothing was updated: tell a missing delivery from a pending one.
+	if _, err := r.GetDelivery(ctx, id); err != nil {
+		return Delivery{}, err
+	}
+	return Delivery{}, ErrDeliveryPending
+}
@@ -0,0 +1,215 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// maxSchedulesPerUser keeps one account from flooding the scheduler.
+const maxSchedulesPerUser = 10
+
+// Handler exposes HTTP handlers for report schedules.
+type Handler struct {
+	store  Store
+	logger *slog.Logger
+}
+
+func NewHandler(store Store, logger *slog.Logger) *Handler {
+	return &Handler{store: store, logger: logger}
+}
+
+// RegisterRoutes mounts the report schedule endpoints under /users/:id.
+func (h *Handler) RegisterRoutes(router *gin.Engine) {
+	group := router.Group("/users/:id/report-schedules")
+	group.GET("", h.list)
+	group.POST("", h.create)
+	group.DELETE("/:scheduleID", h.delete)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type createScheduleRequest struct {
+	Frequency   Frequency `json:"frequency" binding:"required" enums:"weekly,monthly"`
+	Kind        Kind      `json:"kind" binding:"required" enums:"summary,export"`
+	Channel     string    `json:"channel" binding:"required" enums:"email,webhook"`
+	Destination string    `json:"destination" binding:"required"`
+}
+
+// userParam parses the :id path parameter and checks the caller may act on
+// it. It writes the error response and returns false otherwise.
+func userParam(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	perm := rbac.WriteAnyUser
+	if c.Request.Method == http.MethodGet {
+		perm = rbac.ReadAnyUser
+	}
+	if !rbac.CanAccessUser(c.Request.Context(), userID, perm) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this user's data"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// list godoc
+// @Summary List report schedules
+// @Tags reports
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} Schedule
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID listReportSchedules
+// @Router /users/{id}/report-schedules [get]
+func (h *Handler) list(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.store.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to list report schedules", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// create godoc
+// @Summary Create report schedule
+// @Description Deliver a weekly or monthly summary or full export by email or webhook
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body createScheduleRequest true "Schedule"
+// @Success 201 {object} Schedule
+// @Failure 400 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID createReportSchedule
+// @Router /users/{id}/report-schedules [post]
+func (h *Handler) create(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+
+	var req createScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Frequency = Frequency(strings.ToLower(string(req.Frequency)))
+	req.Kind = Kind(strings.ToLower(string(req.Kind)))
+	req.Channel = strings.ToLower(req.Channel)
+	req.Destination = strings.TrimSpace(req.Destination)
+
+	switch {
+	case !req.Frequency.Valid():
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frequency must be weekly or monthly"})
+		return
+	case !req.Kind.Valid():
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be summary or export"})
+		return
+	case !validChannel(req.Channel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be email or webhook"})
+		return
+	}
+	if err := validateDestination(c.Request.Context(), req.Channel, req.Destination); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := h.store.ListByUser(ctx, userID)
+	if err != nil {
+		h.logger.Error("failed to list report schedules", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(existing) >= maxSchedulesPerUser {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "report schedule limit reached"})
+		return
+	}
+
+	schedule, err := h.store.Create(ctx, CreateParams{
+		UserID:      userID,
+		Frequency:   req.Frequency,
+		Kind:        req.Kind,
+		Channel:     req.Channel,
+		Destination: req.Destination,
+		FirstRunAt:  req.Frequency.next(time.Now().UTC(), time.Now().UTC()),
+	})
+	if err != nil {
+		h.logger.Error("failed to create report schedule", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// delete godoc
+// @Summary Delete report schedule
+// @Tags reports
+// @Param id path string true "User ID"
+// @Param scheduleID path string true "Schedule ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID deleteReportSchedule
+// @Router /users/{id}/report-schedules/{scheduleID} [delete]
+func (h *Handler) delete(c *gin.Context) {
+	userID, ok := userParam(c)
+	if !ok {
+		return
+	}
+	scheduleID, err := uuid.Parse(c.Param("scheduleID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return
+	}
+
+	if err := h.store.Delete(c.Request.Context(), userID, scheduleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "report schedule not found"})
+			return
+		}
+		h.logger.Error("failed to delete report schedule", "id", scheduleID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func validateDestination(ctx context.Context, channel, destination string) error {
+	switch channel {
+	case notify.ChannelEmail:
+		addr, err := mail.ParseAddress(destination)
+		if err != nil || addr.Address != destination {
+			return errors.New("destination must be a bare email address")
+		}
+	case notify.ChannelWebhook:
+		return notify.CheckWebhookURL(ctx, destination)
+	}
+	return nil
+}
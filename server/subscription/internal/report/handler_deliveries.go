@@ -0,0 +1,181 @@
+package report
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// DeliveryHandler exposes the webhook delivery log so integrators can debug
+// failed callbacks themselves.
+type DeliveryHandler struct {
+	schedules  Store
+	deliveries DeliveryStore
+	out        Enqueuer
+	logger     *slog.Logger
+}
+
+// NewDeliveryHandler creates a DeliveryHandler. out re-sends retried
+// deliveries; when nil, retries answer 503.
+func NewDeliveryHandler(schedules Store, deliveries DeliveryStore, out Enqueuer, logger *slog.Logger) *DeliveryHandler {
+	return &DeliveryHandler{schedules: schedules, deliveries: deliveries, out: out, logger: logger}
+}
+
+// RegisterRoutes mounts the delivery log endpoints under /webhooks.
+func (h *DeliveryHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/webhooks/:id/deliveries", h.list)
+	router.POST("/webhooks/deliveries/:id/retry", h.retry)
+}
+
+// webhook loads the webhook schedule id and checks the caller owns it. It
+// writes the error response and returns false otherwise.
+func (h *DeliveryHandler) webhook(c *gin.Context, id uuid.UUID) (Schedule, bool) {
+	schedule, err := h.schedules.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return Schedule{}, false
+		}
+		h.logger.Error("failed to get webhook", "webhook_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return Schedule{}, false
+	}
+	if schedule.Channel != notify.ChannelWebhook {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return Schedule{}, false
+	}
+	if !rbac.CanAccessUser(c.Request.Context(), schedule.UserID, rbac.ReadAnyUser) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this webhook"})
+		return Schedule{}, false
+	}
+	return schedule, true
+}
+
+// list godoc
+// @Summary List webhook deliveries
+// @Description Recent deliveries of a webhook report schedule, newest first, with attempt counts, response codes and payload snapshots
+// @Tags reports
+// @Produce json
+// @Param id path string true "Webhook (report schedule) ID"
+// @Param limit query int false "Maximum rows (<=200)" default(50)
+// @Success 200 {array} Delivery
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID listWebhookDeliveries
+// @Router /webhooks/{id}/deliveries [get]
+func (h *DeliveryHandler) list(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+	limit := defaultDeliveryLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(limit, maxDeliveryLimit)
+	}
+	if _, ok := h.webhook(c, webhookID); !ok {
+		return
+	}
+
+	deliveries, err := h.deliveries.ListDeliveries(c.Request.Context(), webhookID, limit)
+	if err != nil {
+		h.logger.Error("failed to list webhook deliveries", "webhook_id", webhookID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// retry godoc
+// @Summary Retry webhook delivery
+// @Description Send a finished delivery again, with its original payload, to the webhook's current URL
+// @Tags reports
+// @Produce json
+// @Param id path string true "Delivery ID"
+// @Success 202 {object} Delivery
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @ID retryWebhookDelivery
+// @Router /webhooks/deliveries/{id}/retry [post]
+func (h *DeliveryHandler) retry(c *gin.Context) {
+	deliveryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+	if h.out == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications are disabled"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	delivery, err := h.deliveries.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+			return
+		}
+		h.logger.Error("failed to get webhook delivery", "delivery_id", deliveryID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	schedule, ok := h.webhook(c, delivery.WebhookID)
+	if !ok {
+		return
+	}
+
+	delivery, err = h.deliveries.ResetDelivery(ctx, deliveryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDeliveryPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		default:
+			h.logger.Error("failed to reset webhook delivery", "delivery_id", deliveryID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	err = h.out.Enqueue(notify.Message{
+		Channel:     notify.ChannelWebhook,
+		Destination: schedule.Destination,
+		Kind:        delivery.Kind,
+		Payload:     delivery.Payload,
+		WebhookID:   schedule.ID,
+		DeliveryID:  delivery.ID,
+	})
+	if err != nil {
+		h.logger.Error("failed to enqueue webhook retry", "delivery_id", deliveryID, "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("webhook delivery retried", "delivery_id", deliveryID, "webhook_id", schedule.ID)
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,79 @@
+// Package report delivers scheduled subscription reports through the
+// notification pool.
+package report
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+)
+
+// Frequency is how often a schedule fires.
+type Frequency string
+
+const (
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+)
+
+// Valid reports whether f is a supported frequency.
+func (f Frequency) Valid() bool {
+	return f == Weekly || f == Monthly
+}
+
+// next returns the first run time after now that stays on the schedule's
+// cadence. Runs missed while the service was down collapse into one.
+func (f Frequency) next(from, now time.Time) time.Time {
+	for !from.After(now) {
+		if f == Weekly {
+			from = from.AddDate(0, 0, 7)
+		} else {
+			from = from.AddDate(0, 1, 0)
+		}
+	}
+	return from
+}
+
+// Kind selects what a report contains.
+type Kind string
+
+const (
+	// KindSummary is the month-to-date total and the active subscriptions count.
+	KindSummary Kind = "summary"
+	// KindExport is the full list of the user's subscriptions.
+	KindExport Kind = "export"
+)
+
+// Valid reports whether k is a supported kind.
+func (k Kind) Valid() bool {
+	return k == KindSummary || k == KindExport
+}
+
+// Schedule is a stored report subscription.
+type Schedule struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Frequency   Frequency  `json:"frequency"`
+	Kind        Kind       `json:"kind"`
+	Channel     string     `json:"channel"`
+	Destination string     `json:"destination"`
+	NextRunAt   time.Time  `json:"next_run_at"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreateParams is the validated input for a new schedule.
+type CreateParams struct {
+	UserID      uuid.UUID
+	Frequency   Frequency
+	Kind        Kind
+	Channel     string
+	Destination string
+	FirstRunAt  time.Time
+}
+
+func validChannel(channel string) bool {
+	return channel == notify.ChannelEmail || channel == notify.ChannelWebhook
+}
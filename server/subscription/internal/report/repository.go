@@ -0,0 +1,231 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	goqu "github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/google/uuid"
+)
+
+// Store persists report schedules.
+type Store interface {
+	Create(context.Context, CreateParams) (Schedule, error)
+	Get(context.Context, uuid.UUID) (Schedule, error)
+	ListByUser(context.Context, uuid.UUID) ([]Schedule, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]Schedule, error)
+	Release(context.Context, Schedule) error
+}
+
+var scheduleColumns = []interface{}{
+	"id", "user_id", "frequency", "kind", "channel", "destination", "next_run_at", "last_run_at", "created_at",
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSchedule(row rowScanner, s *Schedule) error {
+	return row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.Frequency,
+		&s.Kind,
+		&s.Channel,
+		&s.Destination,
+		&s.NextRunAt,
+		&s.LastRunAt,
+		&s.CreatedAt,
+	)
+}
+
+// Repository is the goqu-backed implementation of Store.
+type Repository struct {
+	db      *sql.DB
+	logger  *slog.Logger
+	builder *goqu.Database
+}
+
+// NewRepository wires the DB and logger into a Repository.
+func NewRepository(db *sql.DB, logger *slog.Logger) *Repository {
+	return &Repository{db: db, logger: logger, builder: goqu.New("postgres", db)}
+}
+
+func (r *Repository) Create(ctx context.Context, params CreateParams) (Schedule, error) {
+	stmt := r.builder.Insert("report_schedules").Rows(goqu.Record{
+		"user_id":     params.UserID,
+		"frequency":   params.Frequency,
+		"kind":        params.Kind,
+		"channel":     params.Channel,
+		"destination": params.Destination,
+		"next_run_at": params.FirstRunAt,
+	}).Returning(scheduleColumns...)
+
+	query, args, err := stmt.ToSQL()
+	if err != nil {
+		return Schedule{}, fmt.Errorf("build insert report schedule: %w", err)
+	}
+
+	var s Schedule
+	if err := scanSchedule(r.db.QueryRowContext(ctx, query, args...), &s); err != nil {
+		if r.logger != nil {
+			r.logger.Error("insert report schedule failed", "error", err)
+		}
+		return Schedule{}, fmt.Errorf("insert report schedule: %w", err)
+	}
+	return s, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (Schedule, error) {
+	query, args, err := r.builder.From("report_schedules").Select(scheduleColumns...).
+		Where(goqu.C("id").Eq(id)).
+		ToSQL()
+	if err != nil {
+		return Schedule{}, fmt.Errorf("build get report schedule: %w", err)
+	}
+
+	var s Schedule
+	if err := scanSchedule(r.db.QueryRowContext(ctx, query, args...), &s); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Schedule{}, err
+		}
+		return Schedule{}, fmt.Errorf("get report schedule: %w", err)
+	}
+	return s, nil
+}
+
+func (r *Repository) ListByUser(ctx context.Context, userID uuid.UUID) ([]Schedule, error) {
+	ds := r.builder.From("report_schedules").Select(scheduleColumns...).
+		Where(goqu.C("user_id").Eq(userID)).
+		Order(goqu.C("created_at").Asc(), goqu.C("id").Asc())
+
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("build list report schedules: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list report schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []Schedule{}
+	for rows.Next() {
+		var s Schedule
+		if err := scanSchedule(rows, &s); err != nil {
+			return nil, fmt.Errorf("scan report schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	ds := r.builder.Delete("report_schedules").Where(
+		goqu.C("id").Eq(id),
+		goqu.C("user_id").Eq(userID),
+	)
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return fmt.Errorf("build delete report schedule: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("delete report schedule: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimDue locks up to limit schedules whose run time has passed, moves each
+// to its next run and returns them as they were before the move. Rows locked
+// by another replica are skipped, so every run is claimed once. A run that
+// cannot be rendered or enqueued is handed back with Release; once enqueued,
+// delivery failures are retried by the notification pool, not by re-running
+// the schedule.
+func (r *Repository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]Schedule, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query, args, err := r.builder.From("report_schedules").Select(scheduleColumns...).
+		Where(goqu.C("next_run_at").Lte(now)).
+		Order(goqu.C("next_run_at").Asc()).
+		Limit(uint(limit)).
+		ForUpdate(exp.SkipLocked).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("build claim report schedules: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("claim report schedules: %w", err)
+	}
+	var due []Schedule
+	for rows.Next() {
+		var s Schedule
+		if err := scanSchedule(rows, &s); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan report schedule: %w", err)
+		}
+		due = append(due, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, s := range due {
+		query, args, err := r.builder.Update("report_schedules").Set(goqu.Record{
+			"last_run_at": now,
+			"next_run_at": s.Frequency.next(s.NextRunAt, now),
+		}).Where(goqu.C("id").Eq(s.ID)).ToSQL()
+		if err != nil {
+			return nil, fmt.Errorf("build advance report schedule: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, fmt.Errorf("advance report schedule %s: %w", s.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return due, nil
+}
+
+// Release puts a claimed schedule back to its run time before the claim, so
+// the next poll picks it up again.
+func (r *Repository) Release(ctx context.Context, s Schedule) error {
+	query, args, err := r.builder.Update("report_schedules").Set(goqu.Record{
+		"last_run_at": s.LastRunAt,
+		"next_run_at": s.NextRunAt,
+	}).Where(goqu.C("id").Eq(s.ID)).ToSQL()
+	if err != nil {
+		return fmt.Errorf("build release report schedule: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("release report schedule %s: %w", s.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/mailtmpl"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/notify"
+	"github.com/beheryahmed1991/subscription-service.git/internal/storage"
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+// claimBatch bounds how many schedules one tick renders.
+const claimBatch = 50
+
+// Enqueuer is the part of notify.Pool the scheduler needs.
+type Enqueuer interface {
+	Enqueue(notify.Message) error
+}
+
+// Scheduler renders due reports and hands them to the notification pool.
+type Scheduler struct {
+	store    Store
+	subs     subscription.Service
+	out      Enqueuer
+	interval time.Duration
+	logger   *slog.Logger
+
+	blobs     storage.Blob
+	linkTTL   time.Duration
+	monitor   *monitor.Monitor
+	templates *mailtmpl.Registry
+}
+
+// NewScheduler creates a Scheduler that polls store every interval.
+func NewScheduler(store Store, subs subscription.Service, out Enqueuer, interval time.Duration, logger *slog.Logger) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Scheduler{store: store, subs: subs, out: out, interval: interval, logger: logger, templates: mailtmpl.Builtin()}
+}
+
+// UseTemplates renders report emails from templates instead of the
+// built-in copy.
+func (s *Scheduler) UseTemplates(templates *mailtmpl.Registry) {
+	s.templates = templates
+}
+
+// StoreExports uploads export reports to blobs and sends a download link
+// valid for linkTTL instead of the subscriptions themselves.
+func (s *Scheduler) StoreExports(blobs storage.Blob, linkTTL time.Duration) {
+	s.blobs = blobs
+	s.linkTTL = linkTTL
+}
+
+// ReportTo records every poll on m.
+func (s *Scheduler) ReportTo(m *monitor.Monitor) {
+	s.monitor = m
+}
+
+// Run polls for due schedules until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.monitor.Track("report_scheduler", func() error { return s.tick(ctx, time.Now().UTC()) })
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error("report scheduler run failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick delivers every due schedule. Failed deliveries are logged one by one,
+// released for the next tick and only counted in the returned error, so one
+// bad schedule does not stop the others.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) error {
+	failed := 0
+	for {
+		due, err := s.store.ClaimDue(ctx, now, claimBatch)
+		if err != nil {
+			return fmt.Errorf("claim report schedules: %w", err)
+		}
+		batchFailed := 0
+		for _, schedule := range due {
+			if err := s.deliver(ctx, schedule, now); err != nil {
+				batchFailed++
+				s.logger.Error("report delivery failed",
+					"schedule_id", schedule.ID,
+					"user_id", schedule.UserID,
+					"error", err,
+				)
+				if err := s.store.Release(context.WithoutCancel(ctx), schedule); err != nil {
+					s.logger.Error("failed to release report schedule", "schedule_id", schedule.ID, "error", err)
+				}
+			}
+		}
+		failed += batchFailed
+		// Released schedules are due again; claiming more now would pick
+		// them straight back up.
+		if len(due) < claimBatch || batchFailed > 0 {
+			break
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d report deliveries failed", failed)
+	}
+	return nil
+}
+
+func (s *Scheduler) deliver(ctx context.Context, schedule Schedule, now time.Time) error {
+	rep, err := s.render(ctx, schedule, now)
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	if schedule.Kind == KindExport && s.blobs != nil {
+		if err := s.upload(ctx, &rep); err != nil {
+			return fmt.Errorf("upload export: %w", err)
+		}
+	}
+
+	email, err := s.templates.Render(mailtmpl.Digest, rep.digest())
+	if err != nil {
+		return err
+	}
+	msg := notify.Message{
+		Channel:     schedule.Channel,
+		Destination: schedule.Destination,
+		Kind:        "report." + string(schedule.Kind),
+		Subject:     email.Subject,
+	}
+	if schedule.Channel == notify.ChannelEmail {
+		msg.ContentType, msg.Payload = "text/html; charset=utf-8", []byte(email.HTML)
+	} else if msg.Payload, err = json.Marshal(rep); err != nil {
+		return fmt.Errorf("encode: %w", err)
+	} else {
+		msg.WebhookID = schedule.ID
+	}
+
+	if err := s.out.Enqueue(msg); err != nil {
+		return fmt.Errorf("enqueue: %w", err)
+	}
+	s.logger.Info("report enqueued", "schedule_id", schedule.ID, "kind", schedule.Kind, "channel", schedule.Channel)
+	return nil
+}
+
+// Report is the rendered content of one scheduled run.
+type Report struct {
+	ScheduleID    string                              `json:"schedule_id"`
+	UserID        string                              `json:"user_id"`
+	Kind          Kind                                `json:"kind"`
+	Frequency     Frequency                           `json:"frequency"`
+	GeneratedAt   time.Time                           `json:"generated_at"`
+	Month         types.Month                         `json:"month" swaggertype:"string"`
+	TotalPrice    int64                               `json:"total_price"`
+	Active        int                                 `json:"active_subscriptions"`
+	Subscriptions []subscription.ArchivedSubscription `json:"subscriptions,omitempty"`
+	// DownloadURL replaces Subscriptions when exports go to blob storage.
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+func (s *Scheduler) render(ctx context.Context, schedule Schedule, now time.Time) (Report, error) {
+	month := months.Normalize(now)
+	rep := Report{
+		ScheduleID:  schedule.ID.String(),
+		UserID:      schedule.UserID.String(),
+		Kind:        schedule.Kind,
+		Frequency:   schedule.Frequency,
+		GeneratedAt: now,
+		Month:       types.NewMonth(month),
+	}
+
+	total, err := s.subs.SumByPeriod(ctx, subscription.SumFilter{
+		StartMonth: &month,
+		EndMonth:   &month,
+		UserID:     &schedule.UserID,
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	rep.TotalPrice = total
+
+	err = s.subs.Export(ctx, schedule.UserID, func(sub subscription.Subscription) error {
+		if !sub.StartMonth.After(month) && (sub.EndMonth == nil || !sub.EndMonth.Before(month)) {
+			rep.Active++
+		}
+		if schedule.Kind == KindExport {
+			rep.Subscriptions = append(rep.Subscriptions, subscription.Archive(sub))
+		}
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	return rep, nil
+}
+
+// upload stores rep's subscriptions under
+// exports/<user>/<schedule>/<timestamp>.json and replaces them with a link.
+func (s *Scheduler) upload(ctx context.Context, rep *Report) error {
+	body, err := json.Marshal(rep.Subscriptions)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("exports/%s/%s/%s.json", rep.UserID, rep.ScheduleID, rep.GeneratedAt.Format("20060102T150405Z"))
+	if err := s.blobs.Put(ctx, key, bytes.NewReader(body), "application/json"); err != nil {
+		return err
+	}
+	url, err := s.blobs.SignedURL(ctx, key, s.linkTTL)
+	if err != nil {
+		return err
+	}
+	rep.Subscriptions = nil
+	rep.DownloadURL = url
+	return nil
+}
+
+// digest is the data of the report's email.
+func (r Report) digest() mailtmpl.DigestData {
+	data := mailtmpl.DigestData{
+		Frequency:   string(r.Frequency),
+		Month:       r.Month.Format("2006-01"),
+		Active:      r.Active,
+		TotalRUB:    r.TotalPrice,
+		DownloadURL: r.DownloadURL,
+	}
+	for _, sub := range r.Subscriptions {
+		line := mailtmpl.DigestLine{ServiceName: sub.ServiceName, PriceRUB: sub.PriceRUB, Start: sub.StartMonth.Format("2006-01")}
+		if sub.EndMonth != nil {
+			line.End = sub.EndMonth.Format("2006-01")
+		}
+		data.Subscriptions = append(data.Subscriptions, line)
+	}
+	return data
+}
@@ -0,0 +1,60 @@
+package slo
+
+import "time"
+
+// bucket counts the requests that finished within one bucketWidth.
+type bucket struct {
+	minute int64
+	total  int64
+	failed int64
+	slow   int64
+}
+
+// ring is the per-minute request history of one class, covering the SLO
+// window. Buckets are reused in place as time moves on, so a bucket whose
+// minute is out of date counts as empty.
+type ring struct {
+	buckets []bucket
+}
+
+func newRing(size int) *ring {
+	return &ring{buckets: make([]bucket, size)}
+}
+
+func minuteOf(t time.Time) int64 {
+	return t.Unix() / int64(bucketWidth/time.Second)
+}
+
+func (r *ring) add(now time.Time, failed, slow bool) {
+	minute := minuteOf(now)
+	b := &r.buckets[minute%int64(len(r.buckets))]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	if failed {
+		b.failed++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// sum adds up the requests, and those bad reports as missing the objective,
+// over the window ending at now.
+func (r *ring) sum(now time.Time, window time.Duration, bad func(bucket) int64) (total, missed int64) {
+	last := minuteOf(now)
+	n := int64(window / bucketWidth)
+	if n > int64(len(r.buckets)) {
+		n = int64(len(r.buckets))
+	}
+	for minute := last - n + 1; minute <= last; minute++ {
+		b := r.buckets[minute%int64(len(r.buckets))]
+		if b.minute != minute {
+			continue
+		}
+		total += b.total
+		missed += bad(b)
+	}
+	return total, missed
+}
@@ -0,0 +1,329 @@
+// Package slo tracks availability and latency objectives per route class and
+// turns them into error budget burn rates. Paging on burn rate instead of raw
+// error counts means a short spike on a quiet night and a slow leak that eats
+// the month's budget are both caught, at the urgency they deserve.
+package slo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+)
+
+// Route classes with their own objectives.
+const (
+	ClassRead  = "read"
+	ClassWrite = "write"
+)
+
+// Indicators measured for every class.
+const (
+	Availability = "availability"
+	Latency      = "latency"
+)
+
+// Alert severities, following the multiwindow burn rate alerts of the SRE
+// workbook: a page needs a fast burn confirmed by a short window, a ticket a
+// slower burn sustained for hours.
+const (
+	SeverityPage   = "page"
+	SeverityTicket = "ticket"
+)
+
+// bucketWidth is the resolution of the request history.
+const bucketWidth = time.Minute
+
+// alertRule fires when both windows burn at least Burn times faster than the
+// budget allows.
+type alertRule struct {
+	severity    string
+	long, short time.Duration
+	burn        float64
+}
+
+var alertRules = []alertRule{
+	{severity: SeverityPage, long: time.Hour, short: 5 * time.Minute, burn: 14.4},
+	{severity: SeverityTicket, long: 6 * time.Hour, short: 30 * time.Minute, burn: 6},
+}
+
+// burnWindows are the windows reported by Report and exported on /metrics.
+var burnWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// Objective is the target for one route class. A request counts against
+// availability when it fails with a 5xx status, and against latency when it
+// succeeds but takes longer than Latency.
+type Objective struct {
+	Class string
+	// Availability is the share of requests that must not fail, e.g. 0.999.
+	Availability float64
+	// Latency is the threshold a share LatencyTarget of requests must meet.
+	Latency       time.Duration
+	LatencyTarget float64
+}
+
+// Config sets the objectives and the period their budgets cover.
+type Config struct {
+	Objectives []Objective
+	// Window is the budget period, e.g. 30 days.
+	Window time.Duration
+	// RefreshInterval is how often the /metrics gauges are recomputed.
+	RefreshInterval time.Duration
+}
+
+// Classifier maps a request to a route class, or "" to leave it out.
+type Classifier func(*gin.Context) string
+
+// Tracker records requests per class and computes burn rates from them.
+type Tracker struct {
+	cfg        Config
+	objectives map[string]Objective
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	history map[string]*ring
+	// firing is only touched by Refresh, which Run calls from one goroutine.
+	firing map[string]bool
+
+	requests  *metrics.CounterVec
+	errors    *metrics.CounterVec
+	burnRate  *metrics.GaugeVec
+	remaining *metrics.GaugeVec
+	alerting  *metrics.GaugeVec
+}
+
+// NewTracker creates a Tracker for cfg and registers its metrics on reg.
+func NewTracker(cfg Config, reg *metrics.Registry, logger *slog.Logger) *Tracker {
+	if cfg.Window < bucketWidth {
+		cfg.Window = 30 * 24 * time.Hour
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 30 * time.Second
+	}
+	t := &Tracker{
+		cfg:        cfg,
+		objectives: make(map[string]Objective, len(cfg.Objectives)),
+		logger:     logger,
+		history:    make(map[string]*ring, len(cfg.Objectives)),
+		firing:     make(map[string]bool),
+		requests: reg.Counter("slo_requests_total",
+			"Requests measured against an SLO, by route class.", "class"),
+		errors: reg.Counter("slo_errors_total",
+			"Requests that missed an SLO, by route class and indicator.", "class", "sli"),
+		burnRate: reg.Gauge("slo_burn_rate",
+			"Error budget burn rate over a trailing window; 1 spends the budget exactly over the SLO window.", "class", "sli", "window"),
+		remaining: reg.Gauge("slo_error_budget_remaining",
+			"Share of the error budget left over the SLO window.", "class", "sli"),
+		alerting: reg.Gauge("slo_alert",
+			"1 while a burn rate alert is firing.", "class", "sli", "severity"),
+	}
+	size := int(cfg.Window / bucketWidth)
+	for _, o := range cfg.Objectives {
+		t.objectives[o.Class] = o
+		t.history[o.Class] = newRing(size)
+	}
+	return t
+}
+
+// Middleware measures every request classify assigns to a class with an
+// objective. Register it before handlers that may be slow or fail.
+func (t *Tracker) Middleware(classify Classifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		class := classify(c)
+		if class == "" {
+			return
+		}
+		t.Record(class, c.Writer.Status(), time.Since(start), time.Now())
+	}
+}
+
+// Record counts one request of class that finished at now.
+func (t *Tracker) Record(class string, status int, elapsed time.Duration, now time.Time) {
+	o, ok := t.objectives[class]
+	if !ok {
+		return
+	}
+	failed := status >= http.StatusInternalServerError
+	slow := !failed && elapsed > o.Latency
+
+	t.requests.Inc(class)
+	if failed {
+		t.errors.Inc(class, Availability)
+	}
+	if slow {
+		t.errors.Inc(class, Latency)
+	}
+
+	t.mu.Lock()
+	t.history[class].add(now, failed, slow)
+	t.mu.Unlock()
+}
+
+// Indicator is the state of one SLI of a class.
+type Indicator struct {
+	Target float64 `json:"target"`
+	// Requests and Bad cover the whole SLO window.
+	Requests int64 `json:"requests"`
+	Bad      int64 `json:"bad"`
+	// BudgetRemaining is the share of the error budget left; it goes negative
+	// once the objective is missed.
+	BudgetRemaining float64 `json:"budget_remaining"`
+	// BurnRates maps a trailing window ("1h") to how many times faster than
+	// allowed the budget is being spent.
+	BurnRates map[string]float64 `json:"burn_rates"`
+	// Alerts lists the severities currently firing.
+	Alerts []string `json:"alerts"`
+}
+
+// ClassReport is the state of every SLI of a route class.
+type ClassReport struct {
+	Class        string    `json:"class"`
+	LatencyMS    int64     `json:"latency_threshold_ms"`
+	Availability Indicator `json:"availability"`
+	Latency      Indicator `json:"latency"`
+}
+
+// Report is the SLO state of every class at one moment.
+type Report struct {
+	Window      string        `json:"window"`
+	Classes     []ClassReport `json:"classes"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// Report computes budgets and burn rates as of now.
+func (t *Tracker) Report(now time.Time) Report {
+	report := Report{Window: t.cfg.Window.String(), GeneratedAt: now.UTC(), Classes: []ClassReport{}}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	classes := make([]string, 0, len(t.objectives))
+	for class := range t.objectives {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	for _, class := range classes {
+		o, history := t.objectives[class], t.history[class]
+		report.Classes = append(report.Classes, ClassReport{
+			Class:        class,
+			LatencyMS:    o.Latency.Milliseconds(),
+			Availability: indicator(history, now, t.cfg.Window, o.Availability, func(b bucket) int64 { return b.failed }),
+			Latency:      indicator(history, now, t.cfg.Window, o.LatencyTarget, func(b bucket) int64 { return b.slow }),
+		})
+	}
+	return report
+}
+
+func indicator(history *ring, now time.Time, window time.Duration, target float64, bad func(bucket) int64) Indicator {
+	budget := 1 - target
+	burn := func(d time.Duration) float64 {
+		total, missed := history.sum(now, d, bad)
+		if total == 0 || budget <= 0 {
+			return 0
+		}
+		return float64(missed) / float64(total) / budget
+	}
+
+	ind := Indicator{Target: target, BurnRates: make(map[string]float64, len(burnWindows)), Alerts: []string{}}
+	ind.Requests, ind.Bad = history.sum(now, window, bad)
+	ind.BudgetRemaining = 1
+	if ind.Requests > 0 && budget > 0 {
+		ind.BudgetRemaining = 1 - float64(ind.Bad)/(float64(ind.Requests)*budget)
+	}
+	for _, d := range burnWindows {
+		ind.BurnRates[windowLabel(d)] = round(burn(d))
+	}
+	for _, rule := range alertRules {
+		if burn(rule.long) >= rule.burn && burn(rule.short) >= rule.burn {
+			ind.Alerts = append(ind.Alerts, rule.severity)
+		}
+	}
+	ind.BudgetRemaining = round(ind.BudgetRemaining)
+	return ind
+}
+
+// Run refreshes the /metrics gauges until ctx is cancelled.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		t.Refresh(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recomputes the burn rate, budget and alert gauges, logging alerts
+// as they start and stop firing.
+func (t *Tracker) Refresh(now time.Time) {
+	for _, class := range t.Report(now).Classes {
+		for sli, ind := range map[string]Indicator{Availability: class.Availability, Latency: class.Latency} {
+			t.remaining.Set(ind.BudgetRemaining, class.Class, sli)
+			for window, rate := range ind.BurnRates {
+				t.burnRate.Set(rate, class.Class, sli, window)
+			}
+			for _, rule := range alertRules {
+				firing := slices.Contains(ind.Alerts, rule.severity)
+				key := class.Class + "/" + sli + "/" + rule.severity
+				if firing != t.firing[key] {
+					t.firing[key] = firing
+					if firing {
+						t.logger.Warn("slo burn rate alert firing", "class", class.Class, "sli", sli,
+							"severity", rule.severity, "burn_rates", ind.BurnRates, "budget_remaining", ind.BudgetRemaining)
+					} else {
+						t.logger.Info("slo burn rate alert resolved", "class", class.Class, "sli", sli, "severity", rule.severity)
+					}
+				}
+				value := 0.0
+				if firing {
+					value = 1
+				}
+				t.alerting.Set(value, class.Class, sli, rule.severity)
+			}
+		}
+	}
+}
+
+// RegisterRoutes mounts GET /slo on the admin group.
+func (t *Tracker) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/slo", t.get)
+}
+
+// get godoc
+// @Summary SLO status
+// @Description Error budget and burn rates per route class. A page alert fires when the 1h and 5m burn rates are both at least 14.4, a ticket when the 6h and 30m rates are both at least 6.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} Report
+// @ID getSLO
+// @Router /admin/slo [get]
+func (t *Tracker) get(c *gin.Context) {
+	c.JSON(http.StatusOK, t.Report(time.Now()))
+}
+
+func windowLabel(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
@@ -0,0 +1,90 @@
+// Package storage keeps binary objects (exports, backups, attachments) in a
+// blob store chosen by configuration, so features share one implementation
+// instead of each writing files their own way.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Blob stores objects under slash-separated keys.
+type Blob interface {
+	// Put stores r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the object under key. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// SignedURL returns a URL that downloads the object without further
+	// credentials until ttl has passed.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Delete removes the object under key. Missing keys are not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Backends understood by New.
+const (
+	BackendNone  = ""
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Config selects and configures a backend. S3 settings also fit MinIO and
+// other S3-compatible servers.
+type Config struct {
+	Backend string
+
+	// LocalDir is the directory the local backend writes under. LocalURL is
+	// the public base URL of this service and LocalSecret signs download
+	// links served by Local.RegisterRoutes.
+	LocalDir    string
+	LocalURL    string
+	LocalSecret string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses the bucket in the path rather than the host
+	// name, as MinIO expects.
+	S3PathStyle bool
+}
+
+// New builds the configured backend. It returns nil, nil when storage is not
+// configured, so features can check for it.
+func New(cfg Config) (Blob, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case BackendNone:
+		return nil, nil
+	case BackendLocal:
+		return NewLocal(cfg.LocalDir, cfg.LocalURL, cfg.LocalSecret)
+	case BackendS3:
+		return NewS3(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// cleanKey rejects keys that could escape the store's namespace.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if key == "" || cleaned != key || strings.HasPrefix(key, "/") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return cleaned, nil
+}
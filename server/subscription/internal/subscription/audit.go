@@ -0,0 +1,207 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goqu "github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+)
+
+// Audit actions.
+const (
+	ActionTransfer = "transfer"
+	ActionUpdate   = "update"
+	ActionUndo     = "undo"
+)
+
+// AuditEntry records one change to a subscription. Before and After are the
+// full records around the change; either is nil for creations and deletions.
+// ID and OccurredAt are set by the database.
+type AuditEntry struct {
+	ID             int64
+	OccurredAt     time.Time
+	SubscriptionID uuid.UUID
+	Action         string
+	ActorID        *uuid.UUID
+	Before         *Subscription
+	After          *Subscription
+}
+
+// RecordAudit appends entry to the audit log. Call it inside the transaction
+// that makes the change so the log and the data cannot disagree.
+func (r *Repository) RecordAudit(ctx context.Context, entry AuditEntry) error {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Write)
+	defer cancel()
+
+	before, err := auditJSON(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := auditJSON(entry.After)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := r.builder.Insert("audit_log").Rows(goqu.Record{
+		"subscription_id": entry.SubscriptionID,
+		"action":          entry.Action,
+		"actor_id":        entry.ActorID,
+		"before":          before,
+		"after":           after,
+	}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("build insert audit entry: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		if r.logger != nil {
+			r.logger.Error("insert audit entry failed", "subscription_id", entry.SubscriptionID, "error", err)
+		}
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// LatestAudit returns the most recent audit entry of a subscription, or
+// sql.ErrNoRows when it has none.
+func (r *Repository) LatestAudit(ctx context.Context, id uuid.UUID) (AuditEntry, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	query, args, err := r.builder.
+		From("audit_log").
+		Select(auditColumns...).
+		Where(goqu.C("subscription_id").Eq(id)).
+		Order(goqu.C("occurred_at").Desc(), goqu.C("id").Desc()).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("build select audit entry: %w", err)
+	}
+
+	entry, err := scanAudit(r.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return AuditEntry{}, err
+	}
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("select audit entry: %w", err)
+	}
+	return entry, nil
+}
+
+// AuditTrail returns every audit entry of a subscription, oldest first.
+func (r *Repository) AuditTrail(ctx context.Context, id uuid.UUID) ([]AuditEntry, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.List)
+	defer cancel()
+
+	query, args, err := r.builder.
+		From("audit_log").
+		Select(auditColumns...).
+		Where(goqu.C("subscription_id").Eq(id)).
+		Order(goqu.C("occurred_at").Asc(), goqu.C("id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("build select audit trail: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select audit trail: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		entry, err := scanAudit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit trail: %w", err)
+	}
+	return entries, nil
+}
+
+// UserAuditTrail calls fn for every audit entry of the subscriptions userID
+// owns, oldest first, reading rows as they arrive. Like Stream it sets no
+// default deadline.
+func (r *Repository) UserAuditTrail(ctx context.Context, userID uuid.UUID, fn func(AuditEntry) error) error {
+	owned := r.builder.From("subscriptions").Select("id").Where(goqu.C("user_id").Eq(userID))
+	query, args, err := r.builder.
+		From("audit_log").
+		Select(auditColumns...).
+		Where(goqu.C("subscription_id").In(owned)).
+		Order(goqu.C("occurred_at").Asc(), goqu.C("id").Asc()).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("build select user audit trail: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("select user audit trail: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAudit(rows)
+		if err != nil {
+			return fmt.Errorf("scan audit entry: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate user audit trail: %w", err)
+	}
+	return nil
+}
+
+// auditColumns is the column list audit reads return, in scan order.
+var auditColumns = []interface{}{"id", "occurred_at", "subscription_id", "action", "actor_id", "before", "after"}
+
+func scanAudit(row rowScanner) (AuditEntry, error) {
+	var entry AuditEntry
+	var before, after []byte
+	err := row.Scan(&entry.ID, &entry.OccurredAt, &entry.SubscriptionID, &entry.Action, &entry.ActorID, &before, &after)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	if entry.Before, err = auditSnapshot(before); err != nil {
+		return AuditEntry{}, err
+	}
+	if entry.After, err = auditSnapshot(after); err != nil {
+		return AuditEntry{}, err
+	}
+	return entry, nil
+}
+
+func auditSnapshot(raw []byte) (*Subscription, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var sub Subscription
+	if err := json.Unmarshal(raw, &sub); err != nil {
+		return nil, fmt.Errorf("decode audit snapshot: %w", err)
+	}
+	return &sub, nil
+}
+
+func auditJSON(sub *Subscription) (interface{}, error) {
+	if sub == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(sub)
+	if err != nil {
+		return nil, fmt.Errorf("encode audit snapshot: %w", err)
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,22 @@
+package subscription
+
+import (
+	"strings"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/billing"
+)
+
+// Billing periods. A subscription's price is charged once per period.
+const (
+	BillingWeekly    = billing.Weekly
+	BillingMonthly   = billing.Monthly
+	BillingQuarterly = billing.Quarterly
+	BillingYearly    = billing.Yearly
+)
+
+func validateBillingPeriod(period string) error {
+	if !billing.Valid(period) {
+		return &ValidationError{Field: "billing_period", Message: "must be one of " + strings.Join(billing.Periods(), ", ")}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package subscription
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+)
+
+// CoalescingStore collapses identical concurrent SumByPeriod calls into one
+// query. A dashboard that fans out the same summary to many widgets then
+// costs a single database round trip. Every other call goes straight to the
+// wrapped Store.
+type CoalescingStore struct {
+	Store
+	group    singleflight.Group
+	requests *metrics.CounterVec
+}
+
+// NewCoalescingStore wraps store, counting coalesced calls on reg.
+func NewCoalescingStore(store Store, reg *metrics.Registry) *CoalescingStore {
+	return &CoalescingStore{
+		Store: store,
+		requests: reg.Counter("subscription_summary_requests_total",
+			"SumByPeriod calls by whether they ran a query (leader) or shared another call's result (coalesced).",
+			"result"),
+	}
+}
+
+// SumByPeriod runs one query per distinct normalized filter at a time. The
+// shared query does not inherit any caller's cancellation, so one client
+// going away does not fail the others; each caller still stops waiting when
+// its own ctx is done.
+func (s *CoalescingStore) SumByPeriod(ctx context.Context, filter SumFilter) (int64, error) {
+	ch := s.group.DoChan(sumFilterKey(filter), func() (any, error) {
+		return s.Store.SumByPeriod(context.WithoutCancel(ctx), filter)
+	})
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case res := <-ch:
+		if res.Shared {
+			s.requests.Inc("coalesced")
+		} else {
+			s.requests.Inc("leader")
+		}
+		if res.Err != nil {
+			return 0, res.Err
+		}
+		return res.Val.(int64), nil
+	}
+}
+
+// sumFilterKey normalizes filter the way the summary query interprets it, so
+// filters that only differ in day-of-month or service name case share a key.
+func sumFilterKey(filter SumFilter) string {
+	var b strings.Builder
+	if filter.StartMonth != nil {
+		b.WriteString(months.Normalize(*filter.StartMonth).Format(layoutYearMonth))
+	}
+	b.WriteByte('|')
+	if filter.EndMonth != nil {
+		b.WriteString(months.Normalize(*filter.EndMonth).Format(layoutYearMonth))
+	}
+	b.WriteByte('|')
+	if filter.UserID != nil {
+		b.WriteString(filter.UserID.String())
+	}
+	b.WriteByte('|')
+	if filter.ServiceName != nil {
+		b.WriteString(strings.ToLower(strings.TrimSpace(*filter.ServiceName)))
+	}
+	return b.String()
+}
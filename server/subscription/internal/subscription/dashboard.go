@@ -0,0 +1,265 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+const (
+	dashboardTopServices = 5
+	dashboardRenewals    = 3
+)
+
+// dashboardServicesSQL totals the spend of user $2 in month $1 per service,
+// largest first. The rows also yield the month's total and active count.
+var dashboardServicesSQL = `
+WITH subs AS (` + runningSubscriptionsSQL(3, 1) + `)
+SELECT MIN(service_name), ROUND(SUM(monthly_rub))::text, COUNT(*)
+FROM subs
+WHERE user_id = $2::uuid
+  AND start_month <= $1::date
+  AND (end_month IS NULL OR end_month >= $1::date)
+GROUP BY LOWER(service_name)
+ORDER BY SUM(monthly_rub) DESC, LOWER(service_name);
+`
+
+// dashboardRenewalsSQL lists the next charges of user $2 from month $1 on:
+// that month for subscriptions that continue into it, the start month for
+// ones that have not started yet.
+var dashboardRenewalsSQL = `
+WITH subs AS (` + chargedSubscriptionsSQL(4) + `)
+SELECT subs.id, s.slug, subs.service_name, subs.price_rub, GREATEST(subs.start_month, $1::date) AS renews_on
+FROM subs
+JOIN subscriptions s ON s.id = subs.id
+WHERE subs.user_id = $2::uuid
+  AND (subs.end_month IS NULL OR subs.end_month >= GREATEST(subs.start_month, $1::date))
+ORDER BY renews_on, subs.price_rub DESC, subs.id
+LIMIT $3;
+`
+
+// ServiceSpend is one service's share of a month's spend.
+type ServiceSpend struct {
+	ServiceName string `json:"service_name"`
+	TotalPrice  int64  `json:"total_price"`
+	Count       int64  `json:"count"`
+}
+
+// Renewal is an upcoming charge.
+type Renewal struct {
+	ID          uuid.UUID   `json:"id"`
+	Slug        string      `json:"slug,omitempty"`
+	ServiceName string      `json:"service_name"`
+	PriceRUB    int         `json:"price_rub"`
+	RenewsOn    types.Month `json:"renews_on" swaggertype:"string" example:"2025-04-01T00:00:00Z"`
+}
+
+// Dashboard summarizes a user's account for the current month.
+type Dashboard struct {
+	Month            types.Month    `json:"month" swaggertype:"string" example:"2025-03-01T00:00:00Z"`
+	TotalPrice       int64          `json:"total_price"`
+	ActiveCount      int64          `json:"active_count"`
+	TopServices      []ServiceSpend `json:"top_services"`
+	UpcomingRenewals []Renewal      `json:"upcoming_renewals"`
+	GeneratedAt      time.Time      `json:"generated_at"`
+}
+
+// Dashboard assembles userID's dashboard for month in two queries.
+func (r *Repository) Dashboard(ctx context.Context, userID uuid.UUID, month time.Time) (Dashboard, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+	r, release, err := r.bounded(ctx, r.statements.Summary)
+	if err != nil {
+		return Dashboard{}, err
+	}
+	defer release()
+
+	month = months.Normalize(month)
+	d := Dashboard{
+		Month:            types.NewMonth(month),
+		TopServices:      []ServiceSpend{},
+		UpcomingRenewals: []Renewal{},
+	}
+
+	rows, err := r.db.QueryContext(ctx, dashboardServicesSQL, month, userID, r.endInclusive)
+	if err != nil {
+		return Dashboard{}, fmt.Errorf("dashboard services: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			s   ServiceSpend
+			raw string
+		)
+		if err := rows.Scan(&s.ServiceName, &raw, &s.Count); err != nil {
+			return Dashboard{}, fmt.Errorf("scan dashboard service: %w", err)
+		}
+		if s.TotalPrice, err = parseTotal(raw); err != nil {
+			return Dashboard{}, err
+		}
+		d.TotalPrice += s.TotalPrice
+		d.ActiveCount += s.Count
+		if len(d.TopServices) < dashboardTopServices {
+			d.TopServices = append(d.TopServices, s)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return Dashboard{}, fmt.Errorf("rows error: %w", err)
+	}
+
+	next := month.AddDate(0, 1, 0)
+	rows, err = r.db.QueryContext(ctx, dashboardRenewalsSQL, next, userID, dashboardRenewals, r.endInclusive)
+	if err != nil {
+		return Dashboard{}, fmt.Errorf("dashboard renewals: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			ren      Renewal
+			renewsOn time.Time
+		)
+		if err := rows.Scan(&ren.ID, &ren.Slug, &ren.ServiceName, &ren.PriceRUB, &renewsOn); err != nil {
+			return Dashboard{}, fmt.Errorf("scan dashboard renewal: %w", err)
+		}
+		ren.RenewsOn = types.NewMonth(renewsOn)
+		d.UpcomingRenewals = append(d.UpcomingRenewals, ren)
+	}
+	if err := rows.Err(); err != nil {
+		return Dashboard{}, fmt.Errorf("rows error: %w", err)
+	}
+	return d, nil
+}
+
+// DashboardSource builds dashboards.
+type DashboardSource interface {
+	Dashboard(ctx context.Context, userID uuid.UUID, month time.Time) (Dashboard, error)
+}
+
+// Dashboards serves GET /me/dashboard, caching each user's dashboard for a
+// short while since dashboards poll it.
+type Dashboards struct {
+	source DashboardSource
+	ttl    time.Duration
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]Dashboard
+	swept   time.Time
+}
+
+// NewDashboards creates a Dashboards caching for ttl; zero disables caching.
+func NewDashboards(source DashboardSource, ttl time.Duration, logger *slog.Logger) *Dashboards {
+	return &Dashboards{source: source, ttl: ttl, logger: logger, entries: make(map[uuid.UUID]Dashboard)}
+}
+
+// RegisterRoutes mounts GET /me/dashboard.
+func (d *Dashboards) RegisterRoutes(router *gin.Engine) {
+	router.GET("/me/dashboard", d.get)
+}
+
+// get godoc
+// @Summary Account dashboard
+// @Description Current-month total, active count, top 5 services by spend and the next 3 renewals for the caller. Without authentication, callers name the user with user_id.
+// @Tags users
+// @Produce json
+// @Param user_id query string false "User ID, for anonymous callers"
+// @Success 200 {object} Dashboard
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID getDashboard
+// @Router /me/dashboard [get]
+func (d *Dashboards) get(c *gin.Context) {
+	var userID uuid.UUID
+	if caller, ok := identity.FromContext(c.Request.Context()); ok {
+		userID = caller.UserID
+	}
+	if value := c.Query("user_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		if !authorizeUser(c, parsed, rbac.ReadAnyUser) {
+			return
+		}
+		userID = parsed
+	}
+	if userID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	now := time.Now().UTC()
+	if dash, ok := d.cached(userID, now); ok {
+		c.JSON(http.StatusOK, dash)
+		return
+	}
+
+	dash, err := d.source.Dashboard(c.Request.Context(), userID, now)
+	if err != nil {
+		d.logger.Error("failed to build dashboard", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	dash.GeneratedAt = now
+	d.store(userID, dash, now)
+	c.JSON(http.StatusOK, dash)
+}
+
+// Invalidate drops the cached dashboard of the user an event touched, or all
+// of them when the event does not name one. It is a bus handler.
+func (d *Dashboards) Invalidate(_ context.Context, e Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e.UserID == uuid.Nil {
+		clear(d.entries)
+		return nil
+	}
+	delete(d.entries, e.UserID)
+	if from, ok := e.Data["from_user_id"].(uuid.UUID); ok {
+		delete(d.entries, from)
+	}
+	return nil
+}
+
+func (d *Dashboards) cached(userID uuid.UUID, now time.Time) (Dashboard, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dash, ok := d.entries[userID]
+	if !ok || now.Sub(dash.GeneratedAt) >= d.ttl || !dash.Month.Equal(months.Normalize(now)) {
+		return Dashboard{}, false
+	}
+	return dash, true
+}
+
+func (d *Dashboards) store(userID uuid.UUID, dash Dashboard, now time.Time) {
+	if d.ttl <= 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.swept) >= d.ttl {
+		for id, entry := range d.entries {
+			if now.Sub(entry.GeneratedAt) >= d.ttl {
+				delete(d.entries, id)
+			}
+		}
+		d.swept = now
+	}
+	d.entries[userID] = dash
+}
@@ -0,0 +1,195 @@
+package subscription
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+// SubscriptionResponse is the API representation of a subscription. It is
+// kept apart from Subscription so schema changes do not leak into the
+// contract: every field is always present (end_month is null rather than
+// omitted) except the bookkeeping timestamps, which only support and admins see, and
+// the warnings a create or update raised.
+type SubscriptionResponse struct {
+	ID                uuid.UUID    `json:"id"`
+	Slug              string       `json:"slug"`
+	ServiceName       string       `json:"service_name"`
+	PriceRUB          int          `json:"price_rub"`
+	Currency          string       `json:"currency" example:"USD"`
+	AmountMinor       int64        `json:"amount_minor" example:"999"`
+	BillingPeriod     string       `json:"billing_period" enums:"weekly,monthly,quarterly,yearly"`
+	PlanID            *int64       `json:"plan_id"`
+	UserID            uuid.UUID    `json:"user_id"`
+	StartMonth        types.Month  `json:"start_month" swaggertype:"string" example:"2025-03-01T00:00:00Z"`
+	EndMonth          *types.Month `json:"end_month" swaggertype:"string" example:"2025-12-01T00:00:00Z"`
+	EndMonthInclusive *bool        `json:"end_month_inclusive"`
+	Status            string       `json:"status" enums:"active,paused,cancelled,expired"`
+	Tags              []string     `json:"tags"`
+	Locked            bool         `json:"locked"`
+	Version           int64        `json:"version"`
+	CreatedAt         *time.Time   `json:"created_at,omitempty"`
+	UpdatedAt         *time.Time   `json:"updated_at,omitempty"`
+	Warnings          []Warning    `json:"warnings,omitempty"`
+}
+
+// responseView decides which optional fields a response carries and how the
+// status is derived.
+type responseView struct {
+	timestamps   bool
+	endInclusive bool
+}
+
+// view returns the view for the caller in ctx. Anonymous requests keep the
+// full view, matching the redaction policy, while authentication is disabled.
+func (h *Handler) view(ctx context.Context) responseView {
+	caller, ok := identity.FromContext(ctx)
+	return responseView{
+		timestamps:   !ok || rbac.Allows(caller, rbac.ReadAnyUser),
+		endInclusive: *h.config().EndMonthInclusive,
+	}
+}
+
+func (v responseView) subscription(sub Subscription) SubscriptionResponse {
+	resp := SubscriptionResponse{
+		ID:                sub.ID,
+		Slug:              sub.Slug,
+		ServiceName:       sub.ServiceName,
+		PriceRUB:          sub.PriceRUB,
+		Currency:          sub.Currency,
+		AmountMinor:       sub.AmountMinor,
+		BillingPeriod:     sub.BillingPeriod,
+		PlanID:            sub.PlanID,
+		UserID:            sub.UserID,
+		StartMonth:        types.NewMonth(sub.StartMonth),
+		EndMonth:          types.MonthPtr(sub.EndMonth),
+		EndMonthInclusive: sub.EndMonthInclusive,
+		Status:            sub.CurrentStatus(time.Now().UTC(), v.endInclusive),
+		Tags:              sub.Tags,
+		Locked:            sub.Locked,
+		Version:           sub.Version,
+	}
+	if resp.Tags == nil {
+		resp.Tags = []string{}
+	}
+	if v.timestamps {
+		createdAt, updatedAt := sub.CreatedAt, sub.UpdatedAt
+		resp.CreatedAt, resp.UpdatedAt = &createdAt, &updatedAt
+	}
+	return resp
+}
+
+func (v responseView) subscriptions(subs []Subscription) []SubscriptionResponse {
+	out := make([]SubscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		out = append(out, v.subscription(sub))
+	}
+	return out
+}
+
+// ArchivedSubscription is the exported form of a subscription, used by
+// takeout archives and export reports. Unlike SubscriptionResponse it keeps
+// every field, so an archive can be restored as it was.
+type ArchivedSubscription struct {
+	ID                uuid.UUID    `json:"id"`
+	Slug              string       `json:"slug,omitempty"`
+	ServiceName       string       `json:"service_name"`
+	PriceRUB          int          `json:"price_rub"`
+	Currency          string       `json:"currency,omitempty"`
+	AmountMinor       int64        `json:"amount_minor,omitempty"`
+	BillingPeriod     string       `json:"billing_period,omitempty"`
+	PlanID            *int64       `json:"plan_id,omitempty"`
+	UserID            uuid.UUID    `json:"user_id"`
+	StartMonth        types.Month  `json:"start_month" swaggertype:"string" example:"2025-03-01T00:00:00Z"`
+	EndMonth          *types.Month `json:"end_month,omitempty" swaggertype:"string" example:"2025-12-01T00:00:00Z"`
+	EndMonthInclusive *bool        `json:"end_month_inclusive,omitempty"`
+	Status            string       `json:"status,omitempty"`
+	Tags              []string     `json:"tags,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+	Version           int64        `json:"version"`
+}
+
+// Archive converts sub to its exported form.
+func Archive(sub Subscription) ArchivedSubscription {
+	return ArchivedSubscription{
+		ID:                sub.ID,
+		Slug:              sub.Slug,
+		ServiceName:       sub.ServiceName,
+		PriceRUB:          sub.PriceRUB,
+		Currency:          sub.Currency,
+		AmountMinor:       sub.AmountMinor,
+		BillingPeriod:     sub.BillingPeriod,
+		PlanID:            sub.PlanID,
+		UserID:            sub.UserID,
+		StartMonth:        types.NewMonth(sub.StartMonth),
+		EndMonth:          types.MonthPtr(sub.EndMonth),
+		EndMonthInclusive: sub.EndMonthInclusive,
+		Status:            sub.Status,
+		Tags:              sub.Tags,
+		CreatedAt:         sub.CreatedAt,
+		UpdatedAt:         sub.UpdatedAt,
+		Version:           sub.Version,
+	}
+}
+
+// ArchivedAuditEntry is one recorded change in a takeout archive's history.
+type ArchivedAuditEntry struct {
+	OccurredAt     time.Time             `json:"occurred_at"`
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	Action         string                `json:"action" enums:"update,transfer,undo"`
+	ActorID        *uuid.UUID            `json:"actor_id,omitempty"`
+	Before         *ArchivedSubscription `json:"before,omitempty"`
+	After          *ArchivedSubscription `json:"after,omitempty"`
+}
+
+// ArchiveAudit converts entry to its exported form.
+func ArchiveAudit(entry AuditEntry) ArchivedAuditEntry {
+	archived := ArchivedAuditEntry{
+		OccurredAt:     entry.OccurredAt,
+		SubscriptionID: entry.SubscriptionID,
+		Action:         entry.Action,
+		ActorID:        entry.ActorID,
+	}
+	if entry.Before != nil {
+		before := Archive(*entry.Before)
+		archived.Before = &before
+	}
+	if entry.After != nil {
+		after := Archive(*entry.After)
+		archived.After = &after
+	}
+	return archived
+}
+
+// Subscription converts an archived entry back.
+func (a ArchivedSubscription) Subscription() Subscription {
+	sub := Subscription{
+		ID:                a.ID,
+		Slug:              a.Slug,
+		ServiceName:       a.ServiceName,
+		PriceRUB:          a.PriceRUB,
+		Currency:          a.Currency,
+		AmountMinor:       a.AmountMinor,
+		BillingPeriod:     a.BillingPeriod,
+		PlanID:            a.PlanID,
+		UserID:            a.UserID,
+		StartMonth:        a.StartMonth.Time,
+		EndMonthInclusive: a.EndMonthInclusive,
+		Status:            a.Status,
+		Tags:              a.Tags,
+		CreatedAt:         a.CreatedAt,
+		UpdatedAt:         a.UpdatedAt,
+		Version:           a.Version,
+	}
+	if a.EndMonth != nil {
+		end := a.EndMonth.Time
+		sub.EndMonth = &end
+	}
+	return sub
+}
@@ -0,0 +1,97 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrConstraintViolation is returned when the database rejects a write because
+// it breaks a table constraint.
+var ErrConstraintViolation = errors.New("constraint violation")
+
+// ErrRejected is returned when a ValidationHook refuses an operation.
+var ErrRejected = errors.New("rejected by validation hook")
+
+// ErrInvalidInput is matched by every ValidationError.
+var ErrInvalidInput = errors.New("invalid input")
+
+// ValidationError reports a business rule broken by the input of an
+// operation, independently of the transport that carried it.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// ErrAlreadyExists is returned when a client-supplied ID is already taken.
+var ErrAlreadyExists = errors.New("subscription already exists")
+
+// ErrForbidden is returned when the caller may not perform an operation on a
+// subscription it does not own.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrLocked is returned when a locked subscription would be deleted or
+// changed without unlocking it.
+var ErrLocked = errors.New("subscription is locked")
+
+// ErrInvalidTransition is returned when a status change is not allowed from
+// the subscription's current status.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrNothingToUndo is returned when a subscription has no recent change that
+// can be undone.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrUndoConflict is matched by UndoConflictError.
+var ErrUndoConflict = errors.New("undo conflicts with a newer change")
+
+// UndoConflictError lists the fields an undo would revert that have changed
+// again since the change being undone.
+type UndoConflictError struct {
+	Fields []string
+}
+
+func (e *UndoConflictError) Error() string {
+	return ErrUndoConflict.Error() + ": " + strings.Join(e.Fields, ", ")
+}
+
+func (e *UndoConflictError) Is(target error) bool {
+	return target == ErrUndoConflict
+}
+
+// ErrTotalOverflow is returned when an aggregate does not fit an int64.
+var ErrTotalOverflow = errors.New("total exceeds the supported range")
+
+// constraintError translates integrity-constraint failures from postgres into
+// ErrConstraintViolation. Other errors are returned unchanged.
+func constraintError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		if pqErr.Constraint == "subscriptions_pkey" {
+			return ErrAlreadyExists
+		}
+		return err
+	case "check_violation", "not_null_violation", "foreign_key_violation":
+		if pqErr.Constraint != "" {
+			return fmt.Errorf("%w: %s", ErrConstraintViolation, pqErr.Constraint)
+		}
+		return fmt.Errorf("%w: %s", ErrConstraintViolation, pqErr.Message)
+	default:
+		return err
+	}
+}
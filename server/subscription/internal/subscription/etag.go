@@ -0,0 +1,47 @@
+package subscription
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// collectionETag derives a weak ETag for one page of a list. Besides the
+// collection version it covers everything that shapes the page: paging,
+// ordering and who is asking, since redaction and timestamps depend on the
+// caller.
+func collectionETag(ctx context.Context, version CollectionVersion, opts ListOptions) string {
+	caller, ok := identity.FromContext(ctx)
+	key := fmt.Sprintf("%d|%d|%d|%d|%s|%t|%t|%s|%t|%s",
+		version.Count, version.LastModified.UnixNano(),
+		opts.Limit, opts.Offset, opts.Sort.Column, opts.Sort.Desc,
+		ok, caller.UserID, rbac.Allows(caller, rbac.ReadAnyUser),
+		opts.Status,
+	)
+	// Subscriptions expire as months pass without any write, so a status
+	// filter is only current within the month.
+	if opts.Status != "" {
+		key += "|" + time.Now().UTC().Format("2006-01")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the
+// weak comparison RFC 9110 prescribes for GET.
+func etagMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package subscription
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+)
+
+// Event types emitted by the service.
+const (
+	EventCreated     = "subscription.created"
+	EventUpdated     = "subscription.updated"
+	EventDeleted     = "subscription.deleted"
+	EventTransferred = "subscription.transferred"
+)
+
+// Event announces a completed change to other parts of the system.
+type Event struct {
+	Type           string    `json:"type"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	// UserID owns the subscription after the change, or owned it before a
+	// deletion.
+	UserID  uuid.UUID      `json:"user_id"`
+	ActorID *uuid.UUID     `json:"actor_id,omitempty"`
+	At      time.Time      `json:"at"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// EventSink receives events after the change they describe has committed.
+// Emit must not block for long; sinks that deliver remotely should queue.
+type EventSink interface {
+	Emit(context.Context, Event)
+}
+
+// EventSinkFunc adapts a function to EventSink.
+type EventSinkFunc func(context.Context, Event)
+
+func (f EventSinkFunc) Emit(ctx context.Context, e Event) {
+	f(ctx, e)
+}
+
+// LogEvents is an EventSink that writes events to logger.
+func LogEvents(logger *slog.Logger) EventSink {
+	return EventSinkFunc(func(_ context.Context, e Event) {
+		logger.Info("event emitted",
+			"type", e.Type,
+			"subscription_id", e.SubscriptionID,
+			"data", e.Data,
+		)
+	})
+}
+
+// MultiSink delivers every event to each of sinks in order.
+func MultiSink(sinks ...EventSink) EventSink {
+	return EventSinkFunc(func(ctx context.Context, e Event) {
+		for _, sink := range sinks {
+			sink.Emit(ctx, e)
+		}
+	})
+}
+
+// CountEvents returns a bus handler counting events by type on
+// subscription_events_total.
+func CountEvents(reg *metrics.Registry) func(context.Context, Event) error {
+	events := reg.Counter("subscription_events_total", "Subscription changes by event type.", "type")
+	return func(_ context.Context, e Event) error {
+		events.Inc(e.Type)
+		return nil
+	}
+}
+
+type discardEvents struct{}
+
+func (discardEvents) Emit(context.Context, Event) {}
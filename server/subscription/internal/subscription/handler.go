@@ -8,26 +8,87 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/db"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/query"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
 )
 
 const (
-	layoutYearMonth     = "2006-01"
-	layoutMonthYear     = "01-2006"
-	layoutFullDate      = "2006-01-02"
-	defaultDayComponent = 1
-	defaultPage         = 1
-	defaultLimit        = 2
-	maxLimit            = 100
+	layoutYearMonth = "2006-01"
+	layoutMonthYear = "01-2006"
+	layoutFullDate  = "2006-01-02"
+	defaultPage     = 1
+	defaultLimit    = 2
+	maxLimit        = 100
+	maxBatchUsers   = 100
+	maxBatchIDs     = 100
 )
 
 // Handler exposes HTTP handlers for subscription resources.
 type Handler struct {
 	svc    Service
 	logger *slog.Logger
+	cfg    atomic.Pointer[HandlerConfig]
+
+	prices            PriceCatalog
+	priceMinSamples   int
+	priceTolerancePct int
+
+	deletes *bulkDeletes
+	// presets backs the summary preset parameter and endpoints when set.
+	presets PresetStore
+	// idempotent guards create when Idempotency-Key support is enabled.
+	idempotent []gin.HandlerFunc
+}
+
+// HandlerConfig carries per-deployment defaults for the HTTP layer. Zero
+// values fall back to the built-in defaults.
+type HandlerConfig struct {
+	DefaultSort  Sort
+	DefaultLimit int
+	MaxLimit     int
+	Redaction    RedactionPolicy
+	// UndoWindow is how old an update may be and still be undone.
+	UndoWindow time.Duration
+	// EndMonthInclusive is the deployment default for end months without
+	// their own setting, used to tell expired subscriptions. Nil means
+	// inclusive, as in the summaries.
+	EndMonthInclusive *bool
+	// StreamTimeout bounds a whole stream response, and StreamWriteTimeout
+	// each row written, so clients that stop reading do not hold a
+	// connection and its query open. Zero disables a limit.
+	StreamTimeout      time.Duration
+	StreamWriteTimeout time.Duration
+}
+
+func (cfg HandlerConfig) withDefaults() HandlerConfig {
+	if cfg.DefaultSort.Column == "" {
+		cfg.DefaultSort = DefaultSort
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = maxLimit
+	}
+	if cfg.DefaultLimit <= 0 {
+		cfg.DefaultLimit = defaultLimit
+	}
+	if cfg.DefaultLimit > cfg.MaxLimit {
+		cfg.DefaultLimit = cfg.MaxLimit
+	}
+	if cfg.UndoWindow <= 0 {
+		cfg.UndoWindow = defaultUndoWindow
+	}
+	if cfg.EndMonthInclusive == nil {
+		inclusive := true
+		cfg.EndMonthInclusive = &inclusive
+	}
+	return cfg
 }
 
 type errorResponse struct {
@@ -35,48 +96,166 @@ type errorResponse struct {
 }
 
 type summaryResponse struct {
-	TotalPrice int `json:"total_price"`
+	TotalPrice int64 `json:"total_price"`
+}
+
+// projectedSummaryResponse is summaryResponse plus the projection fields,
+// which are only present when projected=true.
+type projectedSummaryResponse struct {
+	TotalPrice int64 `json:"total_price"`
+	SpendProjection
+}
+
+type batchSummaryRequest struct {
+	UserIDs     []string `json:"user_ids" binding:"required,min=1"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	ServiceName string   `json:"service_name"`
+}
+
+type userTotal struct {
+	UserID     uuid.UUID `json:"user_id"`
+	TotalPrice int64     `json:"total_price"`
+}
+
+type batchSummaryResponse struct {
+	Totals []userTotal `json:"totals"`
+}
+
+type tagSummaryResponse struct {
+	TagMode TagMode    `json:"tag_mode" enums:"each,primary"`
+	Totals  []TagTotal `json:"totals"`
+}
+
+type timeSeriesResponse struct {
+	Granularity Granularity       `json:"granularity" enums:"month,quarter,year"`
+	Points      []TimeSeriesPoint `json:"points"`
+}
+
+type batchGetRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1"`
+}
+
+type batchGetResponse struct {
+	Items   []SubscriptionResponse `json:"items"`
+	Missing []uuid.UUID            `json:"missing"`
 }
 
 type listResponse struct {
-	Items []Subscription `json:"items"`
-	Page  int            `json:"page"`
-	Limit int            `json:"limit"`
-	Total int            `json:"total"`
+	Items []SubscriptionResponse `json:"items"`
+	Page  int                    `json:"page"`
+	Limit int                    `json:"limit"`
+	Total int                    `json:"total"`
+}
+
+func NewHandler(service Service, logger *slog.Logger, cfg HandlerConfig) *Handler {
+	h := &Handler{svc: service, logger: logger, deletes: newBulkDeletes()}
+	h.UpdateConfig(cfg)
+	return h
+}
+
+// UpdateConfig swaps the handler defaults at runtime, e.g. on config reload.
+func (h *Handler) UpdateConfig(cfg HandlerConfig) {
+	cfg = cfg.withDefaults()
+	h.cfg.Store(&cfg)
+}
+
+// UseIdempotency guards POST /subscriptions with mw, which replays the stored
+// response of requests retried with the same Idempotency-Key.
+func (h *Handler) UseIdempotency(mw gin.HandlerFunc) {
+	h.idempotent = []gin.HandlerFunc{mw}
 }
 
-func NewHandler(service Service, logger *slog.Logger) *Handler {
-	return &Handler{svc: service, logger: logger}
+func (h *Handler) config() HandlerConfig {
+	return *h.cfg.Load()
 }
 
-func (h *Handler) RegisterRoutes(router *gin.Engine) {
+// RegisterRoutes mounts the subscription endpoints. The lowPriority middleware
+// is applied only to the expensive aggregate routes, so it can shed them under
+// load while CRUD stays available.
+func (h *Handler) RegisterRoutes(router *gin.Engine, lowPriority ...gin.HandlerFunc) {
 	group := router.Group("/subscriptions")
-	group.POST("", h.create)
+	group.POST("", append(h.idempotent, h.create)...)
 	group.GET("", h.list)
-	group.GET("/summary", h.summary)
+	group.PATCH("", h.bulkUpdate)
+	group.GET("/stream", h.stream)
+	group.POST("/search", h.search)
+	group.POST("/batch-get", h.batchGet)
+	group.POST("/bulk-delete", h.previewBulkDelete)
+	group.POST("/bulk-delete/confirm", h.confirmBulkDelete)
+	group.GET("/bulk-delete/:job_id", h.getBulkDelete)
+	group.PUT("/by-key/:user_id/:service_name/:start_month", h.upsert)
+
+	summary := group.Group("/summary", lowPriority...)
+	summary.GET("", h.summary)
+	summary.POST("/batch", h.summaryBatch)
+	summary.GET("/timeseries", h.summaryTimeSeries)
+
 	group.GET("/:id", h.getByID)
 	group.PATCH("/:id", h.update)
 	group.DELETE("/:id", h.delete)
+	group.POST("/:id/transfer", h.transfer)
+	group.POST("/:id/pause", h.pause)
+	group.POST("/:id/resume", h.resume)
+	group.POST("/:id/cancel", h.cancel)
+	group.POST("/:id/undo", h.undo)
+	group.GET("/:id/timeline", h.timeline)
+	if h.prices != nil {
+		group.GET("/:id/price-check", h.priceCheck)
+	}
+
+	users := router.Group("/users")
+	users.GET("/:id/takeout", h.exportTakeout)
+	users.POST("/:id/takeout", h.restoreTakeout)
+	if h.presets != nil {
+		users.GET("/:id/summary-presets", h.listPresets)
+		users.GET("/:id/summary-presets/:name", h.getPreset)
+		users.PUT("/:id/summary-presets/:name", h.savePreset)
+		users.DELETE("/:id/summary-presets/:name", h.deletePreset)
+	}
 }
 
 type createSubscriptionRequest struct {
+	// ID is optional; clients that create records offline send their own
+	// (v4 or v7) UUID so retried syncs cannot create duplicates.
+	ID          *string `json:"id" example:"0193a4f2-7c1e-7d3a-9b1f-2f6c8e4d5a10"`
 	ServiceName string  `json:"service_name" binding:"required"`
-	PriceRUB    int     `json:"price" binding:"required,min=0"`
-	UserID      string  `json:"user_id" binding:"required"`
-	StartMonth  string  `json:"start_date" binding:"required"`
-	EndMonth    *string `json:"end_date"`
+	// PriceRUB is the price in whole units of Currency; AmountMinor, when
+	// set, gives it in minor units instead, for prices such as 9.99 USD.
+	PriceRUB    *int   `json:"price" binding:"omitempty,min=0"`
+	Currency    string `json:"currency" example:"RUB"`
+	AmountMinor *int64 `json:"amount_minor" binding:"omitempty,min=0"`
+	// BillingPeriod is how often the price is charged; omitted means
+	// monthly.
+	BillingPeriod string `json:"billing_period" enums:"weekly,monthly,quarterly,yearly"`
+	// PlanID is a plan from GET /services/{name}/plans of this service.
+	PlanID     *int64  `json:"plan_id"`
+	UserID     string  `json:"user_id" binding:"required"`
+	StartMonth string  `json:"start_date" binding:"required"`
+	EndMonth   *string `json:"end_date"`
+	// EndInclusive controls whether end_date itself is charged; omitted means
+	// the deployment default.
+	EndInclusive *bool `json:"end_month_inclusive"`
+	// Tags are stored lowercased, at most 20 of up to 32 characters.
+	Tags []string `json:"tags" example:"work,video"`
 }
 
 // create godoc
 // @Summary Create subscription
-// @Description Create a new subscription entry
+// @Description Create a new subscription entry. The ID may be supplied by the client; an ID already in use returns 409. Callers other than admins may only create subscriptions for themselves; another user_id returns 403. The price is given in currency (default RUB) as whole units in price or minor units in amount_minor, and converted to price_rub at the configured exchange rate. Rules that inform without blocking add to warnings. A request retried with the same Idempotency-Key gets the first response back with Idempotent-Replayed: true; reusing a key for a different payload returns 422, and a retry while the first request is still running returns 409, and a keyed body over 1 MiB returns 413.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Client-chosen key, at most 255 characters, that makes retries safe"
 // @Param request body createSubscriptionRequest true "Subscription payload"
-// @Success 201 {object} Subscription
+// @Success 201 {object} SubscriptionResponse
 // @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 413 {object} errorResponse
+// @Failure 422 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @ID createSubscription
 // @Router /subscriptions [post]
 func (h *Handler) create(c *gin.Context) {
 	var req createSubscriptionRequest
@@ -86,6 +265,11 @@ func (h *Handler) create(c *gin.Context) {
 		return
 	}
 
+	if req.PriceRUB == nil && req.AmountMinor == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price or amount_minor is required"})
+		return
+	}
+
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
 		h.logger.Info("invalid user id", "user_id", req.UserID)
@@ -93,6 +277,14 @@ func (h *Handler) create(c *gin.Context) {
 		return
 	}
 
+	var subID uuid.UUID
+	if req.ID != nil {
+		if subID, err = uuid.Parse(*req.ID); err != nil || subID == uuid.Nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+	}
+
 	startMonth, err := parseMonth(req.StartMonth)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -106,59 +298,230 @@ func (h *Handler) create(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if parsed.Before(startMonth) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date cannot be before start_date"})
-			return
-		}
 		end = &parsed
 	}
 
-	sub, err := h.svc.Create(c.Request.Context(), CreateParams{
-		ServiceName: strings.TrimSpace(req.ServiceName),
-		PriceRUB:    req.PriceRUB,
-		UserID:      userID,
-		StartMonth:  startMonth,
-		EndMonth:    end,
+	var price int
+	if req.PriceRUB != nil {
+		price = *req.PriceRUB
+	}
+
+	ctx, warnings := CollectWarnings(c.Request.Context())
+	sub, err := h.svc.Create(ctx, CreateParams{
+		ID:                subID,
+		ServiceName:       req.ServiceName,
+		PriceRUB:          price,
+		UserID:            userID,
+		StartMonth:        startMonth,
+		EndMonth:          end,
+		EndMonthInclusive: req.EndInclusive,
+		Currency:          req.Currency,
+		AmountMinor:       req.AmountMinor,
+		BillingPeriod:     req.BillingPeriod,
+		PlanID:            req.PlanID,
+		Tags:              req.Tags,
 	})
 	if err != nil {
-		h.logger.Error("failed to create subscription", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrAlreadyExists) {
+			h.logger.Info("subscription id already taken", "id", subID)
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected) {
+			h.logger.Info("subscription create rejected", "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+			return
+		}
+		h.serverError(c, "failed to create subscription", err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, sub)
+	resp := h.view(c.Request.Context()).subscription(sub)
+	resp.Warnings = warnings()
+	h.respond(c, http.StatusCreated, resp)
 }
 
 // list godoc
 // @Summary List subscriptions
-// @Description List subscriptions ordered by creation date with pagination
+// @Description List subscriptions with pagination, ordered by sort_by and order or else the configured default sort, with the ID breaking ties. Malformed or out-of-range parameters are rejected with 400. Callers other than support and admins only see their own subscriptions. Responses carry an ETag; send it back in If-None-Match to get 304 when nothing changed.
 // @Tags subscriptions
 // @Produce json
 // @Param page query int false "Page number (>=1)" default(1)
 // @Param limit query int false "Items per page (<=100)" default(20)
+// @Param sort_by query string false "Column to sort by" Enums(price_rub, start_month, end_month, service_name, created_at, updated_at)
+// @Param order query string false "Sort direction, ascending unless desc" Enums(asc, desc)
+// @Param status query string false "Only subscriptions currently in this status" Enums(active, paused, cancelled, expired)
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} listResponse
+// @Success 304 "Not modified since the given ETag"
+// @Failure 400 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @ID listSubscriptions
 // @Router /subscriptions [get]
 func (h *Handler) list(c *gin.Context) {
-	page := parsePositiveInt(c.DefaultQuery("page", "1"), defaultPage)
-	limit := parsePositiveInt(c.DefaultQuery("limit", fmt.Sprintf("%d", defaultLimit)), defaultLimit)
-	if limit > maxLimit {
-		limit = maxLimit
+	var q listQuery
+	if err := query.Bind(c.Request.URL.Query(), &q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
+	cfg := h.config()
+	page, limit := q.Page, q.Limit
+	if limit == 0 {
+		limit = cfg.DefaultLimit
+	}
+	if limit > cfg.MaxLimit {
+		limit = cfg.MaxLimit
+	}
+
+	sort, err := q.sort(cfg.DefaultSort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
 	opts := ListOptions{
 		Limit:  limit,
 		Offset: (page - 1) * limit,
+		Sort:   sort,
+		UserID: callerScope(ctx, rbac.ReadAnyUser),
+		Status: q.Status,
 	}
 
-	subs, total, err := h.svc.List(c.Request.Context(), opts)
+	// The version is read before the page, so a concurrent write can only
+	// make the ETag stale, never make stale data look current.
+	version, err := h.svc.ListVersion(ctx, opts)
 	if err != nil {
-		h.logger.Error("failed to list subscriptions", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.serverError(c, "failed to check subscriptions version", err)
 		return
 	}
-	c.JSON(http.StatusOK, listResponse{
-		Items: subs,
+	etag := collectionETag(ctx, version, opts)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	subs, total, err := h.svc.List(ctx, opts)
+	if err != nil {
+		h.serverError(c, "failed to list subscriptions", err)
+		return
+	}
+	h.respond(c, http.StatusOK, listResponse{
+		Items: h.view(ctx).subscriptions(subs),
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
+
+// listQuery holds the query parameters of the list endpoint. A zero Limit
+// means the configured default.
+type listQuery struct {
+	Page   int    `query:"page,default=1,min=1"`
+	Limit  int    `query:"limit,min=1"`
+	SortBy string `query:"sort_by"`
+	Order  string `query:"order,enum=asc|desc"`
+	Status string `query:"status,enum=active|paused|cancelled|expired"`
+}
+
+// sort applies sort_by and order. sort_by must be a sortable column and sorts
+// ascending unless order is desc; order alone changes the direction of
+// fallback.
+func (q listQuery) sort(fallback Sort) (Sort, error) {
+	sort := fallback
+	if column := strings.ToLower(q.SortBy); column != "" {
+		if !listOrdering.Allows(column) {
+			return Sort{}, fmt.Errorf("sort_by: column %q is not sortable", column)
+		}
+		sort = Sort{Column: column}
+	}
+	switch q.Order {
+	case "asc":
+		sort.Desc = false
+	case "desc":
+		sort.Desc = true
+	}
+	return sort, nil
+}
+
+type searchRequest struct {
+	Filter *SearchNode `json:"filter"`
+	Sort   string      `json:"sort" example:"price_rub desc"`
+	Page   int         `json:"page"`
+	Limit  int         `json:"limit"`
+}
+
+// search godoc
+// @Summary Search subscriptions
+// @Description Filter subscriptions with a JSON document of conditions combined with and/or/not. status matches the current status, expired included; tags eq matches subscriptions carrying the tag and in any of the tags. Text comparisons ignore case. Callers other than support and admins only match their own subscriptions.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body searchRequest true "Search document"
+// @Success 200 {object} listResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID searchSubscriptions
+// @Router /subscriptions/search [post]
+func (h *Handler) search(c *gin.Context) {
+	var req searchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Info("invalid search payload", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg := h.config()
+	page := req.Page
+	if page <= 0 {
+		page = defaultPage
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = cfg.DefaultLimit
+	}
+	if limit > cfg.MaxLimit {
+		limit = cfg.MaxLimit
+	}
+
+	sort := cfg.DefaultSort
+	if req.Sort != "" {
+		parsed, err := ParseSort(req.Sort)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sort = parsed
+	}
+
+	subs, total, err := h.svc.Search(c.Request.Context(), SearchQuery{
+		Filter: req.Filter,
+		Sort:   sort,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+		UserID: callerScope(c.Request.Context(), rbac.ReadAnyUser),
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidFilter) {
+			h.logger.Info("invalid search filter", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.serverError(c, "failed to search subscriptions", err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, listResponse{
+		Items: h.view(c.Request.Context()).subscriptions(subs),
 		Page:  page,
 		Limit: limit,
 		Total: total,
@@ -170,19 +533,20 @@ func (h *Handler) list(c *gin.Context) {
 // @Description Get subscription by ID
 // @Tags subscriptions
 // @Produce json
-// @Param id path string true "Subscription ID"
-// @Success 200 {object} Subscription
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Success 200 {object} SubscriptionResponse
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @ID getSubscription
 // @Router /subscriptions/{id} [get]
 func (h *Handler) getByID(c *gin.Context) {
-	id := c.Param("id")
-	if _, err := uuid.Parse(id); err != nil {
-		h.logger.Info("invalid subscription id", "id", id)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	subID, ok := h.subscriptionID(c)
+	if !ok {
 		return
 	}
+	id := subID.String()
 
 	sub, err := h.svc.GetByID(c.Request.Context(), id)
 	if err != nil {
@@ -192,41 +556,142 @@ func (h *Handler) getByID(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 			return
 		}
-		h.logger.Error("failed to get subscription", "id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.serverError(c, "failed to get subscription", err, "id", id)
 		return
 	}
 
-	c.JSON(http.StatusOK, sub)
+	h.respond(c, http.StatusOK, h.view(c.Request.Context()).subscription(sub))
+}
+
+// batchGet godoc
+// @Summary Get several subscriptions
+// @Description Load up to 100 subscriptions by ID in one call. Items follow the order of the request; IDs that match nothing are listed under missing.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body batchGetRequest true "Subscription IDs"
+// @Success 200 {object} batchGetResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID batchGetSubscriptions
+// @Router /subscriptions/batch-get [post]
+func (h *Handler) batchGet(c *gin.Context) {
+	var req batchGetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Info("invalid batch get payload", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.IDs) > maxBatchIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d ids are allowed", maxBatchIDs)})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	seen := make(map[uuid.UUID]bool, len(req.IDs))
+	for _, raw := range req.IDs {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id: " + raw})
+			return
+		}
+		if seen[parsed] {
+			continue
+		}
+		seen[parsed] = true
+		ids = append(ids, parsed)
+	}
+
+	subs, missing, err := h.svc.GetByIDs(c.Request.Context(), ids)
+	if err != nil {
+		h.serverError(c, "failed to get subscriptions", err, "count", len(ids))
+		return
+	}
+	h.respond(c, http.StatusOK, batchGetResponse{
+		Items:   h.view(c.Request.Context()).subscriptions(subs),
+		Missing: missing,
+	})
 }
 
 type updateSubscriptionRequest struct {
 	ServiceName *string `json:"service_name"`
 	PriceRUB    *int    `json:"price"`
-	StartMonth  *string `json:"start_date"`
-	EndMonth    *string `json:"end_date"`
+	Currency    *string `json:"currency"`
+	AmountMinor *int64  `json:"amount_minor"`
+	// BillingPeriod changes how often the price is charged.
+	BillingPeriod *string `json:"billing_period" enums:"weekly,monthly,quarterly,yearly"`
+	// PlanID changes the catalog plan; 0 clears it.
+	PlanID       *int64  `json:"plan_id"`
+	StartMonth   *string `json:"start_date"`
+	EndMonth     *string `json:"end_date"`
+	EndInclusive *bool   `json:"end_month_inclusive"`
+	// Locked protects the subscription from deletion and changes until it
+	// is set back to false.
+	Locked *bool `json:"locked"`
+	// Tags replaces the tags; an empty list clears them.
+	Tags *[]string `json:"tags"`
+}
+
+// params converts the request into UpdateParams for subscription id.
+func (req updateSubscriptionRequest) params(id uuid.UUID) (UpdateParams, error) {
+	params := UpdateParams{
+		ID:                id,
+		ServiceName:       req.ServiceName,
+		PriceRUB:          req.PriceRUB,
+		Currency:          req.Currency,
+		AmountMinor:       req.AmountMinor,
+		BillingPeriod:     req.BillingPeriod,
+		PlanID:            req.PlanID,
+		EndMonthInclusive: req.EndInclusive,
+		Locked:            req.Locked,
+		Tags:              req.Tags,
+	}
+
+	if req.StartMonth != nil {
+		start, err := parseMonth(*req.StartMonth)
+		if err != nil {
+			return UpdateParams{}, err
+		}
+		params.StartMonth = &start
+	}
+
+	if req.EndMonth != nil {
+		params.EndMonthSet = true
+		if strings.TrimSpace(*req.EndMonth) != "" {
+			end, err := parseMonth(*req.EndMonth)
+			if err != nil {
+				return UpdateParams{}, err
+			}
+			params.EndMonth = &end
+		}
+	}
+	return params, nil
 }
 
 // update godoc
 // @Summary Update subscription
-// @Description Partially update subscription fields
+// @Description Partially update subscription fields. Locked subscriptions only accept updates that set locked to false. A new price is in the subscription's currency unless currency changes it; changing only the currency keeps the amount. Rules that inform without blocking add to warnings.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id path string true "Subscription ID"
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
 // @Param request body updateSubscriptionRequest true "Fields to update"
-// @Success 200 {object} Subscription
+// @Success 200 {object} SubscriptionResponse
 // @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @ID updateSubscription
 // @Router /subscriptions/{id} [patch]
 func (h *Handler) update(c *gin.Context) {
-	idParam := c.Param("id")
-	subID, err := uuid.Parse(idParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	subID, ok := h.subscriptionID(c)
+	if !ok {
 		return
 	}
+	idParam := subID.String()
 
 	var req updateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -235,82 +700,147 @@ func (h *Handler) update(c *gin.Context) {
 		return
 	}
 
-	params := UpdateParams{ID: subID}
-
-	if req.ServiceName != nil {
-		trimmed := strings.TrimSpace(*req.ServiceName)
-		params.ServiceName = &trimmed
+	params, err := req.params(subID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if req.PriceRUB != nil {
-		if *req.PriceRUB < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
+	ctx, warnings := CollectWarnings(c.Request.Context())
+	sub, err := h.svc.Update(ctx, params)
+	if err != nil {
+		// Previously compared using == which fails for wrapped errors.
+		if errors.Is(err, sql.ErrNoRows) {
+			h.logger.Info("subscription not found for update", "id", idParam)
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 			return
 		}
-		params.PriceRUB = req.PriceRUB
-	}
-
-	if req.StartMonth != nil {
-		start, err := parseMonth(*req.StartMonth)
-		if err != nil {
+		if errors.Is(err, ErrInvalidInput) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		params.StartMonth = &start
+		if h.lockError(c, err) {
+			return
+		}
+		if errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected) {
+			h.logger.Info("subscription update rejected", "id", idParam, "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+			return
+		}
+		h.serverError(c, "failed to update subscription", err, "id", idParam)
+		return
 	}
 
-	if req.EndMonth != nil {
-		params.EndMonthSet = true
-		if strings.TrimSpace(*req.EndMonth) == "" {
-			params.EndMonth = nil
-		} else {
-			end, err := parseMonth(*req.EndMonth)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-			if params.StartMonth != nil && end.Before(*params.StartMonth) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "end_date cannot be before start_date"})
-				return
-			}
-			params.EndMonth = &end
-		}
+	resp := h.view(c.Request.Context()).subscription(sub)
+	resp.Warnings = warnings()
+	h.respond(c, http.StatusOK, resp)
+}
+
+// bulkUpdate godoc
+// @Summary Update matching subscriptions
+// @Description Apply the same partial update to every subscription matching the filters, e.g. set end_date on all subscriptions to a service that shut down. All matches change in one transaction or none do; at most 1000 may match.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param service_name query string false "Service name, case-insensitive"
+// @Param user_id query string false "User ID (UUID)"
+// @Param request body updateSubscriptionRequest true "Fields to update"
+// @Success 200 {object} BulkResult
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID bulkUpdateSubscriptions
+// @Router /subscriptions [patch]
+func (h *Handler) bulkUpdate(c *gin.Context) {
+	filter, ok := bulkFilter(c)
+	if !ok {
+		return
 	}
 
-	sub, err := h.svc.Update(c.Request.Context(), params)
+	var req updateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	change, err := req.params(uuid.Nil)
 	if err != nil {
-		// Previously compared using == which fails for wrapped errors.
-		if errors.Is(err, sql.ErrNoRows) {
-			h.logger.Info("subscription not found for update", "id", idParam)
-			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.UpdateWhere(c.Request.Context(), filter, change)
+	if err != nil {
+		if errors.Is(err, ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if h.lockError(c, err) {
+			return
+		}
+		if errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected) {
+			h.logger.Info("bulk update rejected", "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
 			return
 		}
-		h.logger.Error("failed to update subscription", "id", idParam, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.serverError(c, "failed to bulk update subscriptions", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, sub)
+	h.logger.Info("subscriptions bulk updated", "count", result.Count)
+	c.JSON(http.StatusOK, result)
+}
+
+// bulkFilter reads the service_name and user_id query filters of the bulk
+// endpoints. Non-admin callers are limited to their own subscriptions. On
+// failure it writes the response and returns false.
+func bulkFilter(c *gin.Context) (BulkFilter, bool) {
+	var filter BulkFilter
+	if name := strings.TrimSpace(c.Query("service_name")); name != "" {
+		filter.ServiceName = &name
+	}
+	if value := c.Query("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return BulkFilter{}, false
+		}
+		if !authorizeUser(c, userID, rbac.WriteAnyUser) {
+			return BulkFilter{}, false
+		}
+		filter.UserID = &userID
+	} else {
+		filter.UserID = callerScope(c.Request.Context(), rbac.WriteAnyUser)
+	}
+	if filter.ServiceName == nil && filter.UserID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "service_name or user_id is required"})
+		return BulkFilter{}, false
+	}
+	return filter, true
 }
 
 // delete godoc
 // @Summary Delete subscription
-// @Description Delete subscription by ID
+// @Description Delete subscription by ID. Locked subscriptions return 423 until unlocked.
 // @Tags subscriptions
 // @Produce json
-// @Param id path string true "Subscription ID"
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
 // @Success 204 {string} string "No Content"
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @ID deleteSubscription
 // @Router /subscriptions/{id} [delete]
 func (h *Handler) delete(c *gin.Context) {
-	id := c.Param("id")
-	if _, err := uuid.Parse(id); err != nil {
-		h.logger.Info("invalid subscription id for delete", "id", id)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	subID, ok := h.subscriptionID(c)
+	if !ok {
 		return
 	}
+	id := subID.String()
 
 	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
 		// Previously compared using == which fails for wrapped errors.
@@ -319,8 +849,15 @@ func (h *Handler) delete(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
 			return
 		}
-		h.logger.Error("failed to delete subscription", "id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if h.lockError(c, err) {
+			return
+		}
+		if errors.Is(err, ErrRejected) {
+			h.logger.Info("subscription delete rejected", "id", id, "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+			return
+		}
+		h.serverError(c, "failed to delete subscription", err, "id", id)
 		return
 	}
 
@@ -329,72 +866,314 @@ func (h *Handler) delete(c *gin.Context) {
 
 // summary godoc
 // @Summary Sum subscriptions
-// @Description Calculate total subscription cost within optional filters
+// @Description Calculate total subscription cost within optional filters. Prices billed other than monthly are prorated over the months they cover, so a yearly price counts one twelfth per month. group_by=tag returns a tagSummaryResponse instead, with one total per tag and a null tag for untagged subscriptions; tag_mode=each counts a subscription in all of its tags, primary only in its first. Callers other than support and admins are limited to their own subscriptions; another user_id returns 403.
 // @Tags subscriptions
 // @Produce json
 // @Param start query string false "Start month (YYYY-MM or MM-YYYY)"
 // @Param end query string false "End month (YYYY-MM or MM-YYYY)"
 // @Param user_id query string false "User ID (UUID)"
 // @Param service_name query string false "Service name"
-// @Success 200 {object} summaryResponse
+// @Param preset query string false "Saved summary preset of the user, see /users/{id}/summary-presets"
+// @Param projected query bool false "Also return spend to date and the projected total through end (requires end)"
+// @Param group_by query string false "Return one total per tag" Enums(tag)
+// @Param tag_mode query string false "Tags a subscription counts toward with group_by=tag" Enums(each, primary) default(each)
+// @Success 200 {object} projectedSummaryResponse
 // @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @ID getSummary
 // @Router /subscriptions/summary [get]
 func (h *Handler) summary(c *gin.Context) {
-	var (
-		startMonth *time.Time
-		endMonth   *time.Time
-		userID     *uuid.UUID
-		service    *string
-		err        error
-	)
-
-	if start := c.Query("start"); start != "" {
-		if startMonth, err = parseMonthPtr(start); err != nil {
-			h.logger.Info("invalid start date", "value", start)
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
+	filter, ok := h.bindSumFilter(c)
+	if !ok {
+		return
 	}
-	if end := c.Query("end"); end != "" {
-		if endMonth, err = parseMonthPtr(end); err != nil {
-			h.logger.Info("invalid end date", "value", end)
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+	var q struct {
+		Projected bool   `query:"projected"`
+		GroupBy   string `query:"group_by,enum=tag"`
+		TagMode   string `query:"tag_mode,default=each,enum=each|primary"`
+	}
+	if err := query.Bind(c.Request.URL.Query(), &q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.GroupBy == "tag" {
+		if q.Projected {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "projected cannot be combined with group_by"})
 			return
 		}
+		h.summaryByTag(c, filter, TagMode(q.TagMode))
+		return
+	}
+	if q.Projected {
+		h.summaryProjected(c, filter)
+		return
+	}
+
+	total, err := h.svc.SumByPeriod(c.Request.Context(), filter)
+	if err != nil {
+		h.serverError(c, "failed to summarize subscriptions", err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, gin.H{"total_price": total})
+}
+
+func (h *Handler) summaryProjected(c *gin.Context, filter SumFilter) {
+	if filter.EndMonth == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end is required when projected=true"})
+		return
 	}
-	if startMonth != nil && endMonth != nil && endMonth.Before(*startMonth) {
+
+	projection, err := h.svc.SumProjected(c.Request.Context(), filter)
+	if err != nil {
+		h.serverError(c, "failed to project subscription spend", err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, projectedSummaryResponse{
+		TotalPrice:      projection.ProjectedTotal,
+		SpendProjection: projection,
+	})
+}
+
+func (h *Handler) summaryByTag(c *gin.Context, filter SumFilter, mode TagMode) {
+	totals, err := h.svc.SumByTags(c.Request.Context(), filter, mode)
+	if err != nil {
+		h.serverError(c, "failed to summarize subscriptions per tag", err)
+		return
+	}
+	h.respond(c, http.StatusOK, tagSummaryResponse{TagMode: mode, Totals: totals})
+}
+
+// summaryTimeSeries godoc
+// @Summary Subscription cost over time
+// @Description Calculate subscription cost per calendar bucket within optional filters. Callers other than support and admins are limited to their own subscriptions; another user_id returns 403.
+// @Tags subscriptions
+// @Produce json
+// @Param start query string false "Start month (YYYY-MM or MM-YYYY)"
+// @Param end query string false "End month (YYYY-MM or MM-YYYY)"
+// @Param user_id query string false "User ID (UUID)"
+// @Param service_name query string false "Service name"
+// @Param preset query string false "Saved summary preset of the user, see /users/{id}/summary-presets"
+// @Param granularity query string false "Bucket size" Enums(month, quarter, year) default(month)
+// @Success 200 {object} timeSeriesResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID getSummaryTimeSeries
+// @Router /subscriptions/summary/timeseries [get]
+func (h *Handler) summaryTimeSeries(c *gin.Context) {
+	var q struct {
+		Granularity string `query:"granularity,default=month,enum=month|quarter|year"`
+	}
+	if err := query.Bind(c.Request.URL.Query(), &q); err != nil {
+		h.logger.Info("invalid granularity", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	granularity := Granularity(q.Granularity)
+
+	filter, ok := h.bindSumFilter(c)
+	if !ok {
+		return
+	}
+
+	points, err := h.svc.SumTimeSeries(c.Request.Context(), filter, granularity)
+	if err != nil {
+		h.serverError(c, "failed to build subscription time series", err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, timeSeriesResponse{Granularity: granularity, Points: points})
+}
+
+// sumQuery holds the query parameters shared by the summary endpoints.
+type sumQuery struct {
+	Start       *monthParam `query:"start"`
+	End         *monthParam `query:"end"`
+	UserID      *uuid.UUID  `query:"user_id"`
+	ServiceName *string     `query:"service_name"`
+	// Preset names a saved preset of the user whose subscriptions are
+	// summed; the other parameters override its filters.
+	Preset string `query:"preset"`
+}
+
+// monthParam is a month query parameter in any layout parseMonth accepts.
+type monthParam struct {
+	time.Time
+}
+
+func (m *monthParam) UnmarshalText(text []byte) error {
+	t, err := parseMonth(string(text))
+	m.Time = t
+	return err
+}
+
+func (m *monthParam) month() *time.Time {
+	if m == nil {
+		return nil
+	}
+	return &m.Time
+}
+
+// bindSumFilter parses the shared summary query parameters. It writes a 400
+// response and returns false when any of them is invalid.
+func (h *Handler) bindSumFilter(c *gin.Context) (SumFilter, bool) {
+	var q sumQuery
+	if err := query.Bind(c.Request.URL.Query(), &q); err != nil {
+		h.logger.Info("invalid summary query", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return SumFilter{}, false
+	}
+
+	filter := SumFilter{
+		StartMonth:  q.Start.month(),
+		EndMonth:    q.End.month(),
+		UserID:      q.UserID,
+		ServiceName: q.ServiceName,
+	}
+	if filter.UserID == nil {
+		filter.UserID = callerScope(c.Request.Context(), rbac.ReadAnyUser)
+	} else if !authorizeUser(c, *filter.UserID, rbac.ReadAnyUser) {
+		return SumFilter{}, false
+	}
+	if q.Preset != "" && !h.applyPreset(c, q.Preset, &filter) {
+		return SumFilter{}, false
+	}
+	if filter.StartMonth != nil && filter.EndMonth != nil && filter.EndMonth.Before(*filter.StartMonth) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be after start"})
+		return SumFilter{}, false
+	}
+	return filter, true
+}
+
+// summaryBatch godoc
+// @Summary Sum subscriptions per user
+// @Description Calculate total subscription cost for several users in one call. Callers other than support and admins may only name themselves.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body batchSummaryRequest true "Users and period"
+// @Success 200 {object} batchSummaryResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID getSummaryBatch
+// @Router /subscriptions/summary/batch [post]
+func (h *Handler) summaryBatch(c *gin.Context) {
+	var req batchSummaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Info("invalid batch summary payload", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.UserIDs) > maxBatchUsers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d user_ids are allowed", maxBatchUsers)})
 		return
 	}
 
-	if user := c.Query("user_id"); user != "" {
-		parsed, err := uuid.Parse(user)
+	filter := BatchSumFilter{UserIDs: make([]uuid.UUID, 0, len(req.UserIDs))}
+	seen := make(map[uuid.UUID]bool, len(req.UserIDs))
+	for _, raw := range req.UserIDs {
+		parsed, err := uuid.Parse(raw)
 		if err != nil {
-			h.logger.Info("invalid user_id filter", "user_id", user)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			h.logger.Info("invalid user_id in batch", "user_id", raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id: " + raw})
+			return
+		}
+		if seen[parsed] {
+			continue
+		}
+		if !authorizeUser(c, parsed, rbac.ReadAnyUser) {
 			return
 		}
-		userID = &parsed
+		seen[parsed] = true
+		filter.UserIDs = append(filter.UserIDs, parsed)
 	}
 
-	if name := strings.TrimSpace(c.Query("service_name")); name != "" {
-		service = &name
+	var err error
+	if req.Start != "" {
+		if filter.StartMonth, err = parseMonthPtr(req.Start); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.End != "" {
+		if filter.EndMonth, err = parseMonthPtr(req.End); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if filter.StartMonth != nil && filter.EndMonth != nil && filter.EndMonth.Before(*filter.StartMonth) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be after start"})
+		return
+	}
+	if name := strings.TrimSpace(req.ServiceName); name != "" {
+		filter.ServiceName = &name
 	}
 
-	total, err := h.svc.SumByPeriod(c.Request.Context(), SumFilter{
-		StartMonth:  startMonth,
-		EndMonth:    endMonth,
-		UserID:      userID,
-		ServiceName: service,
-	})
+	totals, err := h.svc.SumByUsers(c.Request.Context(), filter)
 	if err != nil {
-		h.logger.Error("failed to summarize subscriptions", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.serverError(c, "failed to summarize subscriptions per user", err)
+		return
+	}
+
+	resp := batchSummaryResponse{Totals: make([]userTotal, 0, len(filter.UserIDs))}
+	for _, id := range filter.UserIDs {
+		resp.Totals = append(resp.Totals, userTotal{UserID: id, TotalPrice: totals[id]})
+	}
+	h.respond(c, http.StatusOK, resp)
+}
+
+// rejectionBody renders a 422 for err and names the violated rule, if any.
+func rejectionBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+	var violation *RuleViolation
+	if errors.As(err, &violation) {
+		body["rule"] = violation.Rule
+	}
+	return body
+}
+
+// unavailableRetryAfter is what clients are told to wait when the database is
+// failing over.
+const unavailableRetryAfter = "2"
+
+// serverError logs err and writes the response for an unexpected failure.
+// Errors the database produces while failing over become a 503 with
+// Retry-After so clients back off briefly instead of treating them as bugs.
+func (h *Handler) serverError(c *gin.Context, msg string, err error, args ...any) {
+	if errors.Is(err, ErrTotalOverflow) {
+		h.logger.Warn(msg, append(args, "error", err)...)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error() + "; narrow the filter"})
 		return
 	}
+	if db.IsTransient(err) {
+		h.logger.Warn(msg, append(args, "error", err, "transient", true)...)
+		c.Header("Retry-After", unavailableRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database temporarily unavailable, retry shortly"})
+		return
+	}
+	h.logger.Error(msg, append(args, "error", err)...)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
 
-	c.JSON(http.StatusOK, gin.H{"total_price": total})
+// lockError writes 423 for ErrLocked and 403 for ErrForbidden, reporting
+// whether err was one of them.
+func (h *Handler) lockError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrLocked):
+		c.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
 }
 
 func parseMonth(value string) (time.Time, error) {
@@ -404,14 +1183,14 @@ func parseMonth(value string) (time.Time, error) {
 	}
 
 	if t, err := time.Parse(layoutYearMonth, value); err == nil {
-		return normalizeMonth(t), nil
+		return months.Normalize(t), nil
 	}
 	if t, err := time.Parse(layoutMonthYear, value); err == nil {
-		return normalizeMonth(t), nil
+		return months.Normalize(t), nil
 	}
 	// Allow full date inputs and truncate.
 	if t, err := time.Parse(layoutFullDate, value); err == nil {
-		return normalizeMonth(t), nil
+		return months.Normalize(t), nil
 	}
 
 	return time.Time{}, fmt.Errorf("date must be in YYYY-MM or MM-YYYY format")
@@ -425,10 +1204,6 @@ func parseMonthPtr(value string) (*time.Time, error) {
 	return &t, nil
 }
 
-func normalizeMonth(t time.Time) time.Time {
-	return time.Date(t.Year(), t.Month(), defaultDayComponent, 0, 0, 0, 0, time.UTC)
-}
-
 func parsePositiveInt(value string, fallback int) int {
 	n, err := strconv.Atoi(strings.TrimSpace(value))
 	if err != nil || n <= 0 {
@@ -0,0 +1,63 @@
+package subscription
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+)
+
+const defaultAnalyticsMonths = 12
+
+// RegisterAdminRoutes mounts the operator-facing endpoints on the admin group.
+func (h *Handler) RegisterAdminRoutes(group *gin.RouterGroup) {
+	group.GET("/analytics/spend-distribution", h.spendDistribution)
+}
+
+// spendDistribution godoc
+// @Summary Spend distribution
+// @Description Percentiles (p50/p90/p99) of per-user monthly spend over a period, plus a per-month trend
+// @Tags admin
+// @Produce json
+// @Param start query string false "Start month (YYYY-MM or MM-YYYY), defaults to 11 months before end"
+// @Param end query string false "End month (YYYY-MM or MM-YYYY), defaults to the current month"
+// @Success 200 {object} SpendDistribution
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID getSpendDistribution
+// @Router /admin/analytics/spend-distribution [get]
+func (h *Handler) spendDistribution(c *gin.Context) {
+	end := months.Normalize(time.Now().UTC())
+	if value := c.Query("end"); value != "" {
+		parsed, err := parseMonth(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, -(defaultAnalyticsMonths - 1), 0)
+	if value := c.Query("start"); value != "" {
+		parsed, err := parseMonth(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		start = parsed
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be after start"})
+		return
+	}
+
+	dist, err := h.svc.SpendDistribution(c.Request.Context(), start, end)
+	if err != nil {
+		h.serverError(c, "failed to compute spend distribution", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dist)
+}
@@ -0,0 +1,318 @@
+package subscription
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+const (
+	// maxBulkDelete bounds how many subscriptions one confirmed delete removes.
+	maxBulkDelete = 10000
+	// bulkDeleteBatch is how many deletions run between progress updates.
+	bulkDeleteBatch = 100
+	// bulkDeleteSample is how many matches a preview shows.
+	bulkDeleteSample = 10
+	// bulkDeleteTokenTTL is how long a preview can be confirmed.
+	bulkDeleteTokenTTL = 10 * time.Minute
+	// bulkDeleteJobsKept is how many finished jobs stay queryable.
+	bulkDeleteJobsKept = 100
+	// maxBulkDeleteErrors caps the errors a job reports.
+	maxBulkDeleteErrors = 20
+)
+
+// Bulk delete job states.
+const (
+	BulkDeleteRunning = "running"
+	BulkDeleteDone    = "done"
+)
+
+// BulkDeletePreview is what a filter-wide delete would remove. Nothing is
+// deleted until Token is confirmed.
+type BulkDeletePreview struct {
+	Count     int                    `json:"count"`
+	Sample    []SubscriptionResponse `json:"sample"`
+	Token     string                 `json:"token"`
+	ExpiresAt time.Time              `json:"expires_at"`
+}
+
+// BulkDeleteJob is the progress of a confirmed bulk delete. Subscriptions
+// that are locked, rejected by a rule or already gone are skipped, not
+// failed.
+type BulkDeleteJob struct {
+	ID         uuid.UUID  `json:"id"`
+	State      string     `json:"state" enums:"running,done"`
+	Total      int        `json:"total"`
+	Deleted    int        `json:"deleted"`
+	Skipped    int        `json:"skipped"`
+	Failed     int        `json:"failed"`
+	Errors     []string   `json:"errors"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// owner is the caller who confirmed the delete, nil when anonymous.
+	owner *uuid.UUID
+}
+
+type confirmBulkDeleteRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// pendingDelete is a preview awaiting confirmation. The IDs are fixed at
+// preview time, so rows that start matching later are never deleted.
+type pendingDelete struct {
+	ids       []uuid.UUID
+	owner     *uuid.UUID
+	expiresAt time.Time
+}
+
+// bulkDeletes holds the outstanding previews and recent jobs. Both live in
+// the memory of one process: a restart drops unconfirmed tokens and stops
+// running jobs, whose remaining rows can be previewed and confirmed again,
+// and other replicas know neither.
+type bulkDeletes struct {
+	mu      sync.Mutex
+	pending map[string]pendingDelete
+	jobs    map[uuid.UUID]*BulkDeleteJob
+	order   []uuid.UUID
+}
+
+func newBulkDeletes() *bulkDeletes {
+	return &bulkDeletes{pending: make(map[string]pendingDelete), jobs: make(map[uuid.UUID]*BulkDeleteJob)}
+}
+
+// take removes and returns the preview for token if it has not expired and
+// belongs to the caller.
+func (b *bulkDeletes) take(token string, owner *uuid.UUID, now time.Time) (pendingDelete, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for t, p := range b.pending {
+		if now.After(p.expiresAt) {
+			delete(b.pending, t)
+		}
+	}
+	p, ok := b.pending[token]
+	if !ok || !sameOwner(p.owner, owner) {
+		return pendingDelete{}, false
+	}
+	delete(b.pending, token)
+	return p, true
+}
+
+func sameOwner(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (b *bulkDeletes) start(total int, owner *uuid.UUID, now time.Time) *BulkDeleteJob {
+	job := &BulkDeleteJob{ID: uuid.New(), State: BulkDeleteRunning, Total: total, Errors: []string{}, StartedAt: now, owner: owner}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.jobs[job.ID] = job
+	b.order = append(b.order, job.ID)
+	for len(b.order) > bulkDeleteJobsKept {
+		oldest := b.jobs[b.order[0]]
+		if oldest.State == BulkDeleteRunning {
+			break
+		}
+		delete(b.jobs, b.order[0])
+		b.order = b.order[1:]
+	}
+	return job
+}
+
+func (b *bulkDeletes) snapshot(id uuid.UUID) (BulkDeleteJob, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job, ok := b.jobs[id]
+	if !ok {
+		return BulkDeleteJob{}, false
+	}
+	out := *job
+	out.Errors = append([]string(nil), job.Errors...)
+	return out, true
+}
+
+// callerID is the authenticated caller's ID, or nil for anonymous requests.
+func callerID(ctx context.Context) *uuid.UUID {
+	if caller, ok := identity.FromContext(ctx); ok {
+		return &caller.UserID
+	}
+	return nil
+}
+
+// previewBulkDelete godoc
+// @Summary Preview a filter-wide delete
+// @Description First step of a bulk delete: returns how many subscriptions match, a sample and a confirmation token valid for 10 minutes. Nothing is deleted until the token is confirmed. At most 10000 may match. Tokens and jobs are kept in the memory of the replica that issued them, so the confirmation and progress requests must reach the same one (e.g. through sticky sessions); elsewhere they return 404.
+// @Tags subscriptions
+// @Produce json
+// @Param service_name query string false "Service name, case-insensitive"
+// @Param user_id query string false "User ID (UUID)"
+// @Success 200 {object} BulkDeletePreview
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID previewBulkDelete
+// @Router /subscriptions/bulk-delete [post]
+func (h *Handler) previewBulkDelete(c *gin.Context) {
+	filter, ok := bulkFilter(c)
+	if !ok {
+		return
+	}
+
+	matches, err := h.svc.Matching(c.Request.Context(), filter, maxBulkDelete+1)
+	if err != nil {
+		if errors.Is(err, ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.serverError(c, "failed to preview bulk delete", err)
+		return
+	}
+	if len(matches) > maxBulkDelete {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "filter matches more than 10000 subscriptions"})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(matches))
+	for _, sub := range matches {
+		ids = append(ids, sub.ID)
+	}
+	now := time.Now().UTC()
+	preview := BulkDeletePreview{
+		Count:     len(matches),
+		Sample:    h.view(c.Request.Context()).subscriptions(matches[:min(len(matches), bulkDeleteSample)]),
+		Token:     rand.Text(),
+		ExpiresAt: now.Add(bulkDeleteTokenTTL),
+	}
+
+	h.deletes.mu.Lock()
+	h.deletes.pending[preview.Token] = pendingDelete{ids: ids, owner: callerID(c.Request.Context()), expiresAt: preview.ExpiresAt}
+	h.deletes.mu.Unlock()
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// confirmBulkDelete godoc
+// @Summary Confirm a filter-wide delete
+// @Description Second step of a bulk delete: starts deleting the subscriptions of the preview in the background. A token works once, only for the caller who requested the preview, and only on the replica that issued it; other replicas return 404.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body confirmBulkDeleteRequest true "Token from the preview"
+// @Success 202 {object} BulkDeleteJob
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @ID confirmBulkDelete
+// @Router /subscriptions/bulk-delete/confirm [post]
+func (h *Handler) confirmBulkDelete(c *gin.Context) {
+	var req confirmBulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	pending, ok := h.deletes.take(req.Token, callerID(c.Request.Context()), now)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown or expired confirmation token"})
+		return
+	}
+
+	job := h.deletes.start(len(pending.ids), pending.owner, now)
+	h.logger.Warn("bulk delete started", "job_id", job.ID, "count", job.Total)
+	// The job outlives the request but keeps its values, so events still
+	// name the caller.
+	go h.runBulkDelete(context.WithoutCancel(c.Request.Context()), job, pending.ids)
+
+	snapshot, _ := h.deletes.snapshot(job.ID)
+	c.Header("Location", "/subscriptions/bulk-delete/"+job.ID.String())
+	c.JSON(http.StatusAccepted, snapshot)
+}
+
+// getBulkDelete godoc
+// @Summary Bulk delete progress
+// @Description Progress of a confirmed bulk delete. Only the caller who confirmed it, support and admins can see a job; others get 404. Jobs are kept in the memory of the replica running them, so other replicas and restarts return 404 as well.
+// @Tags subscriptions
+// @Produce json
+// @Param job_id path string true "Job ID"
+// @Success 200 {object} BulkDeleteJob
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @ID getBulkDelete
+// @Router /subscriptions/bulk-delete/{job_id} [get]
+func (h *Handler) getBulkDelete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+		return
+	}
+	ctx := c.Request.Context()
+	job, ok := h.deletes.snapshot(id)
+	// Other callers' jobs are reported missing rather than forbidden, so job
+	// IDs do not leak.
+	if !ok || !(sameOwner(job.owner, callerID(ctx)) || rbac.Can(ctx, rbac.ReadAnyUser)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bulk delete job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// runBulkDelete deletes ids one by one through the service, so locks, hooks
+// and events apply as for single deletes, publishing progress after every
+// batch.
+func (h *Handler) runBulkDelete(ctx context.Context, job *BulkDeleteJob, ids []uuid.UUID) {
+	for start := 0; start < len(ids); start += bulkDeleteBatch {
+		var deleted, skipped, failed int
+		var errs []string
+		for _, id := range ids[start:min(start+bulkDeleteBatch, len(ids))] {
+			err := h.svc.Delete(ctx, id.String())
+			switch {
+			case err == nil:
+				deleted++
+			case errors.Is(err, sql.ErrNoRows):
+				// Deleted by someone else since the preview.
+				skipped++
+			case errors.Is(err, ErrLocked), errors.Is(err, ErrRejected):
+				skipped++
+				errs = append(errs, id.String()+": "+err.Error())
+			default:
+				failed++
+				errs = append(errs, id.String()+": "+err.Error())
+				h.logger.Error("bulk delete failed for subscription", "job_id", job.ID, "id", id, "error", err)
+			}
+		}
+
+		h.deletes.mu.Lock()
+		job.Deleted += deleted
+		job.Skipped += skipped
+		job.Failed += failed
+		for _, e := range errs {
+			if len(job.Errors) < maxBulkDeleteErrors {
+				job.Errors = append(job.Errors, e)
+			}
+		}
+		h.deletes.mu.Unlock()
+	}
+
+	finished := time.Now().UTC()
+	h.deletes.mu.Lock()
+	job.State = BulkDeleteDone
+	job.FinishedAt = &finished
+	h.deletes.mu.Unlock()
+	h.logger.Warn("bulk delete finished", "job_id", job.ID, "deleted", job.Deleted, "skipped", job.Skipped, "failed", job.Failed)
+}
@@ -0,0 +1,261 @@
+package subscription
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+// UseSummaryPresets enables the preset summary parameter and the
+// /users/{id}/summary-presets endpoints.
+func (h *Handler) UseSummaryPresets(store PresetStore) {
+	h.presets = store
+}
+
+// summaryPresetRequest is the body of a preset save. Omitted fields leave
+// the filter open.
+type summaryPresetRequest struct {
+	Start       *monthParam `json:"start" swaggertype:"string" example:"2025-01"`
+	End         *monthParam `json:"end" swaggertype:"string" example:"2025-12"`
+	ServiceName *string     `json:"service_name"`
+}
+
+type summaryPresetResponse struct {
+	Name        string       `json:"name" example:"work"`
+	Start       *types.Month `json:"start" swaggertype:"string" example:"2025-01-01T00:00:00Z"`
+	End         *types.Month `json:"end" swaggertype:"string" example:"2025-12-01T00:00:00Z"`
+	ServiceName *string      `json:"service_name"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func presetResponse(p SummaryPreset) summaryPresetResponse {
+	return summaryPresetResponse{
+		Name:        p.Name,
+		Start:       types.MonthPtr(p.StartMonth),
+		End:         types.MonthPtr(p.EndMonth),
+		ServiceName: p.ServiceName,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+// applyPreset loads the preset called name and fills the filters left unset
+// from it. The preset belongs to the user being summed: user_id when given,
+// else the caller. It writes the error response and returns false when the
+// preset cannot be used.
+func (h *Handler) applyPreset(c *gin.Context, name string, filter *SumFilter) bool {
+	if h.presets == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "summary presets are not enabled"})
+		return false
+	}
+	owner := filter.UserID
+	if owner == nil {
+		caller, ok := identity.FromContext(c.Request.Context())
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required with preset"})
+			return false
+		}
+		owner = &caller.UserID
+	}
+
+	preset, err := h.presets.GetPreset(c.Request.Context(), *owner, strings.ToLower(name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "summary preset not found"})
+			return false
+		}
+		h.serverError(c, "failed to load summary preset", err, "user_id", *owner, "preset", name)
+		return false
+	}
+	filter.UserID = owner
+	preset.apply(filter)
+	return true
+}
+
+// presetUser parses the :id path parameter and checks the caller may read
+// it, or change it for writes.
+func presetUser(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	perm := rbac.WriteAnyUser
+	if c.Request.Method == http.MethodGet {
+		perm = rbac.ReadAnyUser
+	}
+	return userID, authorizeUser(c, userID, perm)
+}
+
+// listPresets godoc
+// @Summary List summary presets
+// @Description List the summary filters the user saved, by name.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} summaryPresetResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID listSummaryPresets
+// @Router /users/{id}/summary-presets [get]
+func (h *Handler) listPresets(c *gin.Context) {
+	userID, ok := presetUser(c)
+	if !ok {
+		return
+	}
+
+	presets, err := h.presets.ListPresets(c.Request.Context(), userID)
+	if err != nil {
+		h.serverError(c, "failed to list summary presets", err, "user_id", userID)
+		return
+	}
+	resp := make([]summaryPresetResponse, 0, len(presets))
+	for _, p := range presets {
+		resp = append(resp, presetResponse(p))
+	}
+	h.respond(c, http.StatusOK, resp)
+}
+
+// getPreset godoc
+// @Summary Get summary preset
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param name path string true "Preset name"
+// @Success 200 {object} summaryPresetResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID getSummaryPreset
+// @Router /users/{id}/summary-presets/{name} [get]
+func (h *Handler) getPreset(c *gin.Context) {
+	userID, ok := presetUser(c)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(c.Param("name"))
+
+	preset, err := h.presets.GetPreset(c.Request.Context(), userID, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "summary preset not found"})
+			return
+		}
+		h.serverError(c, "failed to get summary preset", err, "user_id", userID, "preset", name)
+		return
+	}
+	h.respond(c, http.StatusOK, presetResponse(preset))
+}
+
+// savePreset godoc
+// @Summary Save summary preset
+// @Description Save summary filters under a name for GET /subscriptions/summary?preset=name. Names are lowercase letters, digits, - and _, up to 40 characters. Saving an existing name replaces its filters (200); a new name returns 201.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param name path string true "Preset name"
+// @Param request body summaryPresetRequest true "Filters"
+// @Success 200 {object} summaryPresetResponse
+// @Success 201 {object} summaryPresetResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID saveSummaryPreset
+// @Router /users/{id}/summary-presets/{name} [put]
+func (h *Handler) savePreset(c *gin.Context) {
+	userID, ok := presetUser(c)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(c.Param("name"))
+	if !presetName.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be lowercase letters, digits, - or _, up to 40 characters"})
+		return
+	}
+
+	var req summaryPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Info("invalid summary preset payload", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	preset := SummaryPreset{UserID: userID, Name: name, StartMonth: req.Start.month(), EndMonth: req.End.month()}
+	if req.ServiceName != nil {
+		if service := strings.TrimSpace(*req.ServiceName); service != "" {
+			preset.ServiceName = &service
+		}
+	}
+	if preset.StartMonth != nil && preset.EndMonth != nil && preset.EndMonth.Before(*preset.StartMonth) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end must be after start"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := h.presets.ListPresets(ctx, userID)
+	if err != nil {
+		h.serverError(c, "failed to list summary presets", err, "user_id", userID)
+		return
+	}
+	replaces := slices.ContainsFunc(existing, func(p SummaryPreset) bool { return p.Name == name })
+	if !replaces && len(existing) >= maxPresetsPerUser {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("at most %d summary presets are allowed", maxPresetsPerUser)})
+		return
+	}
+
+	saved, created, err := h.presets.SavePreset(ctx, preset)
+	if err != nil {
+		h.serverError(c, "failed to save summary preset", err, "user_id", userID, "preset", name)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	h.respond(c, status, presetResponse(saved))
+}
+
+// deletePreset godoc
+// @Summary Delete summary preset
+// @Tags users
+// @Param id path string true "User ID"
+// @Param name path string true "Preset name"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID deleteSummaryPreset
+// @Router /users/{id}/summary-presets/{name} [delete]
+func (h *Handler) deletePreset(c *gin.Context) {
+	userID, ok := presetUser(c)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(c.Param("name"))
+
+	if err := h.presets.DeletePreset(c.Request.Context(), userID, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "summary preset not found"})
+			return
+		}
+		h.serverError(c, "failed to delete summary preset", err, "user_id", userID, "preset", name)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,153 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/billing"
+	"github.com/beheryahmed1991/subscription-service.git/internal/catalog"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// defaultPriceTolerancePct is how far, in percent, a price may stray from
+// the catalog reference before it is flagged.
+const defaultPriceTolerancePct = 20
+
+// PriceCatalog looks up reference prices by service name.
+type PriceCatalog interface {
+	PriceOf(ctx context.Context, name string) (catalog.Price, bool, error)
+}
+
+// Price check verdicts.
+const (
+	PriceUnknown = "unknown"
+	PriceOK      = "ok"
+	PriceBelow   = "below_reference"
+	PriceAbove   = "above_reference"
+)
+
+// PriceCheck compares a subscription's price with the catalog. Catalog prices
+// are monthly, so a price billed per week, quarter or year is compared as its
+// monthly equivalent. Every price is in rubles.
+type PriceCheck struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	PriceRUB       int       `json:"price_rub"`
+	BillingPeriod  string    `json:"billing_period" enums:"weekly,monthly,quarterly,yearly"`
+	// MonthlyPriceRUB is PriceRUB spread over the months of its billing
+	// period, the figure compared with the reference.
+	MonthlyPriceRUB int `json:"monthly_price_rub"`
+	// Catalog is null when the service is not in the catalog.
+	Catalog *catalog.Price `json:"catalog"`
+	// ReferencePrice is the list price when curated, otherwise the average
+	// of enough running subscriptions.
+	ReferencePrice  *int     `json:"reference_price_rub"`
+	ReferenceSource string   `json:"reference_source,omitempty" enums:"list,average"`
+	DifferencePct   *float64 `json:"difference_pct"`
+	Verdict         string   `json:"verdict" enums:"unknown,ok,below_reference,above_reference"`
+	// LikelyOutdated is set when the price is below the provider's list
+	// price by more than the tolerance, which usually means the provider
+	// raised prices and the entry was never updated.
+	LikelyOutdated bool `json:"likely_outdated"`
+}
+
+// UsePriceCatalog enables GET /subscriptions/{id}/price-check. minSamples is
+// how many running subscriptions an average needs before it is trusted, and
+// tolerancePct how far a price may differ from the reference.
+func (h *Handler) UsePriceCatalog(prices PriceCatalog, minSamples, tolerancePct int) {
+	if tolerancePct <= 0 {
+		tolerancePct = defaultPriceTolerancePct
+	}
+	h.prices = prices
+	h.priceMinSamples = minSamples
+	h.priceTolerancePct = tolerancePct
+}
+
+// priceCheck godoc
+// @Summary Compare a subscription's price with the catalog
+// @Description Compares the price with the service's catalog list price, or failing that with the average of running subscriptions, and flags entries that are likely outdated
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Success 200 {object} PriceCheck
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID checkSubscriptionPrice
+// @Router /subscriptions/{id}/price-check [get]
+func (h *Handler) priceCheck(c *gin.Context) {
+	subID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+	id := subID.String()
+
+	sub, err := h.svc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			return
+		}
+		h.serverError(c, "failed to get subscription", err, "id", id)
+		return
+	}
+	if !authorizeUser(c, sub.UserID, rbac.ReadAnyUser) {
+		return
+	}
+
+	price, found, err := h.prices.PriceOf(c.Request.Context(), sub.ServiceName)
+	if err != nil {
+		h.serverError(c, "failed to look up catalog price", err, "id", id)
+		return
+	}
+
+	check := PriceCheck{
+		SubscriptionID: sub.ID,
+		ServiceName:    sub.ServiceName,
+		PriceRUB:       sub.PriceRUB,
+		BillingPeriod:  sub.BillingPeriod,
+		// Rows from before billing periods are monthly.
+		MonthlyPriceRUB: billing.MonthlyPrice(sub.PriceRUB, sub.BillingPeriod),
+		Verdict:         PriceUnknown,
+	}
+	if found {
+		check.Catalog = &price
+		h.comparePrice(&check, price)
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+func (h *Handler) comparePrice(check *PriceCheck, price catalog.Price) {
+	switch {
+	case price.ListPrice != nil:
+		check.ReferencePrice, check.ReferenceSource = price.ListPrice, "list"
+	case price.AveragePrice != nil && price.Samples >= h.priceMinSamples:
+		check.ReferencePrice, check.ReferenceSource = price.AveragePrice, "average"
+	}
+	if check.ReferencePrice == nil || *check.ReferencePrice == 0 {
+		return
+	}
+
+	reference := float64(*check.ReferencePrice)
+	diff := math.Round((float64(check.MonthlyPriceRUB)-reference)/reference*10000) / 100
+	check.DifferencePct = &diff
+
+	tolerance := float64(h.priceTolerancePct)
+	switch {
+	case diff < -tolerance:
+		check.Verdict = PriceBelow
+		check.LikelyOutdated = check.ReferenceSource == "list"
+	case diff > tolerance:
+		check.Verdict = PriceAbove
+	default:
+		check.Verdict = PriceOK
+	}
+}
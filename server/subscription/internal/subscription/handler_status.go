@@ -0,0 +1,102 @@
+package subscription
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pause godoc
+// @Summary Pause subscription
+// @Description Pause an active subscription. A paused subscription keeps its dates, but cost summaries leave out the months from this one until it is resumed; earlier months stay charged. Allowed for the owner or an admin.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID pauseSubscription
+// @Router /subscriptions/{id}/pause [post]
+func (h *Handler) pause(c *gin.Context) {
+	h.changeStatus(c, StatusPaused)
+}
+
+// resume godoc
+// @Summary Resume subscription
+// @Description Make a paused subscription active again. It is charged again from the current month. Allowed for the owner or an admin.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID resumeSubscription
+// @Router /subscriptions/{id}/resume [post]
+func (h *Handler) resume(c *gin.Context) {
+	h.changeStatus(c, StatusActive)
+}
+
+// cancel godoc
+// @Summary Cancel subscription
+// @Description Cancel an active or paused subscription for good. Unless it already ends earlier, it is ended with the current month, or where it starts if it has not started yet. Cancelled and expired subscriptions cannot change status again. Allowed for the owner or an admin.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID cancelSubscription
+// @Router /subscriptions/{id}/cancel [post]
+func (h *Handler) cancel(c *gin.Context) {
+	h.changeStatus(c, StatusCancelled)
+}
+
+func (h *Handler) changeStatus(c *gin.Context, to string) {
+	subID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+	idParam := subID.String()
+
+	sub, err := h.svc.ChangeStatus(c.Request.Context(), subID, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		case errors.Is(err, ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case h.lockError(c, err):
+		case errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected):
+			h.logger.Info("subscription status change rejected", "id", idParam, "status", to, "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+		default:
+			h.serverError(c, "failed to change subscription status", err, "id", idParam, "status", to)
+		}
+		return
+	}
+
+	h.logger.Info("subscription status changed", "id", idParam, "status", to)
+	h.respond(c, http.StatusOK, h.view(c.Request.Context()).subscription(sub))
+}
@@ -0,0 +1,134 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// stream godoc
+// @Summary Stream subscriptions
+// @Description Stream matching subscriptions as newline-delimited JSON, one object per line, flushed per row. Callers other than support and admins only see their own subscriptions. A failure mid-stream, including running past the configured stream timeout, ends it with a line holding only an "error" field.
+// @Tags subscriptions
+// @Produce application/x-ndjson
+// @Param user_id query string false "User ID (UUID)"
+// @Param sort query string false "Sort, e.g. \"price_rub desc\"; defaults to the configured list sort"
+// @Param status query string false "Only subscriptions currently in this status" Enums(active, paused, cancelled, expired)
+// @Param limit query int false "Maximum number of rows; unlimited by default"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID streamSubscriptions
+// @Router /subscriptions/stream [get]
+func (h *Handler) stream(c *gin.Context) {
+	opts := ListOptions{Sort: h.config().DefaultSort}
+
+	if user := c.Query("user_id"); user != "" {
+		parsed, err := uuid.Parse(user)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		if !authorizeUser(c, parsed, rbac.ReadAnyUser) {
+			return
+		}
+		opts.UserID = &parsed
+	} else {
+		opts.UserID = callerScope(c.Request.Context(), rbac.ReadAnyUser)
+	}
+	if value := c.Query("sort"); value != "" {
+		sort, err := ParseSort(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		opts.Sort = sort
+	}
+	if value := c.Query("status"); value != "" {
+		if !slices.Contains(searchStatuses, value) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of " + strings.Join(searchStatuses, ", ")})
+			return
+		}
+		opts.Status = value
+	}
+	if value := c.Query("limit"); value != "" {
+		opts.Limit = parsePositiveInt(value, 0)
+		if opts.Limit == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+	}
+
+	cfg := h.config()
+	ctx := c.Request.Context()
+	if cfg.StreamTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.StreamTimeout)
+		defer cancel()
+	}
+	view := h.view(ctx)
+	render := h.renderer(c)
+	enc := json.NewEncoder(c.Writer)
+	rc := http.NewResponseController(c.Writer)
+	if cfg.StreamWriteTimeout > 0 {
+		// The connection may serve further requests after this one.
+		defer rc.SetWriteDeadline(time.Time{})
+	}
+
+	written := 0
+	err := h.svc.Stream(ctx, opts, func(sub Subscription) error {
+		payload, err := render(view.subscription(sub))
+		if err != nil {
+			return err
+		}
+		if written == 0 {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+		// A client that stops reading fails the write once the deadline
+		// passes instead of blocking the stream. Writers that cannot take a
+		// deadline fall back to the total timeout.
+		if cfg.StreamWriteTimeout > 0 {
+			err := rc.SetWriteDeadline(time.Now().Add(cfg.StreamWriteTimeout))
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		// Encode terminates every value with a newline.
+		if err := enc.Encode(payload); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		written++
+		return nil
+	})
+	if err != nil {
+		if written == 0 {
+			h.serverError(c, "failed to stream subscriptions", err)
+			return
+		}
+		// Rows and the status are already out, so the failure is reported as
+		// a final line carrying only an error field.
+		h.logger.Error("subscription stream aborted", "written", written, "error", err)
+		if cfg.StreamWriteTimeout > 0 {
+			_ = rc.SetWriteDeadline(time.Now().Add(cfg.StreamWriteTimeout))
+		}
+		_ = enc.Encode(gin.H{"error": "stream aborted"})
+		c.Abort()
+		return
+	}
+	if written == 0 {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,410 @@
+package subscription
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/locale"
+	"github.com/beheryahmed1991/subscription-service.git/internal/money"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+const (
+	takeoutVersion = 1
+	// maxTakeoutItems caps how many subscriptions a single archive may carry,
+	// in either direction.
+	maxTakeoutItems = 10000
+	// maxTakeoutBytes caps the size of an uploaded archive.
+	maxTakeoutBytes = 16 << 20
+	// takeoutFlushEvery is how many subscriptions are written between
+	// flushes, so large archives reach the client in chunks instead of
+	// piling up in buffers.
+	takeoutFlushEvery = 200
+)
+
+// takeoutArchive documents the archive layout. Handlers stream it rather than
+// building it in memory.
+type takeoutArchive struct {
+	Version       int                    `json:"version"`
+	UserID        uuid.UUID              `json:"user_id"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Subscriptions []ArchivedSubscription `json:"subscriptions"`
+	// History is the audit log of the subscriptions, oldest first. It is
+	// for the owner's records; restores ignore it.
+	History []ArchivedAuditEntry `json:"history"`
+}
+
+// authorizeUser writes a 403 and returns false when the caller may not act
+// on userID's data: it is not userID and its role lacks perm. Anonymous
+// requests are let through, since they only get here when authentication is
+// disabled.
+func authorizeUser(c *gin.Context, userID uuid.UUID, perm rbac.Permission) bool {
+	if rbac.CanAccessUser(c.Request.Context(), userID, perm) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to access this user's data"})
+	return false
+}
+
+// exportTakeout godoc
+// @Summary Export account data
+// @Description Stream a JSON archive of every subscription owned by the user, with the audit history of their changes. format=csv streams a read-only spreadsheet copy of the subscriptions alone instead, with numbers, dates and the field separator in the locale from the locale parameter or Accept-Language (e.g. "199,00", "₽" and semicolons for ru).
+// @Tags users
+// @Produce json
+// @Produce text/csv
+// @Param id path string true "User ID"
+// @Param format query string false "Archive format" Enums(json, csv) default(json)
+// @Param locale query string false "CSV locale, overrides Accept-Language" Enums(en, ru, de, fr)
+// @Param Accept-Language header string false "Preferred CSV locales"
+// @Success 200 {object} takeoutArchive
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID exportTakeout
+// @Router /users/{id}/takeout [get]
+func (h *Handler) exportTakeout(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if !authorizeUser(c, userID, rbac.ReadAnyUser) {
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	_, total, err := h.svc.List(ctx, ListOptions{Limit: 1, UserID: &userID})
+	if err != nil {
+		h.serverError(c, "failed to count subscriptions for takeout", err, "user_id", userID)
+		return
+	}
+	if total > maxTakeoutItems {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("archive would exceed %d subscriptions", maxTakeoutItems)})
+		return
+	}
+
+	exportedAt := time.Now().UTC()
+	if format == "csv" {
+		h.writeTakeoutCSV(c, userID, exportedAt, requestLocale(c))
+		return
+	}
+	h.writeTakeoutJSON(c, userID, exportedAt)
+}
+
+// writeTakeoutJSON streams the restorable JSON archive.
+func (h *Handler) writeTakeoutJSON(c *gin.Context, userID uuid.UUID, exportedAt time.Time) {
+	ctx := c.Request.Context()
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="takeout-%s-%s.json"`,
+		userID, exportedAt.Format("20060102T150405Z")))
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, `{"version":%d,"user_id":%q,"exported_at":%q,"subscriptions":[`,
+		takeoutVersion, userID.String(), exportedAt.Format(time.RFC3339))
+
+	written := 0
+	err := h.svc.Export(ctx, userID, func(sub Subscription) error {
+		if written >= maxTakeoutItems {
+			return fmt.Errorf("archive exceeded %d subscriptions", maxTakeoutItems)
+		}
+		raw, err := json.Marshal(Archive(sub))
+		if err != nil {
+			return err
+		}
+		if written > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(raw); err != nil {
+			return err
+		}
+		written++
+		if written%takeoutFlushEvery == 0 {
+			w.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		_, err = io.WriteString(w, `],"history":[`)
+	}
+	if err == nil {
+		err = h.writeTakeoutHistory(c, userID)
+	}
+	if err != nil {
+		// The status line is already out; truncating the body is the only
+		// signal left, and it leaves the archive unparseable on purpose.
+		h.logger.Error("takeout export aborted", "user_id", userID, "written", written, "error", err)
+		c.Abort()
+		return
+	}
+
+	io.WriteString(w, "]}")
+}
+
+// writeTakeoutHistory streams the elements of the archive's history array.
+func (h *Handler) writeTakeoutHistory(c *gin.Context, userID uuid.UUID) error {
+	w := c.Writer
+	written := 0
+	return h.svc.ExportHistory(c.Request.Context(), userID, func(entry AuditEntry) error {
+		raw, err := json.Marshal(ArchiveAudit(entry))
+		if err != nil {
+			return err
+		}
+		if written > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(raw); err != nil {
+			return err
+		}
+		written++
+		if written%takeoutFlushEvery == 0 {
+			w.Flush()
+		}
+		return nil
+	})
+}
+
+// writeTakeoutCSV streams a spreadsheet-friendly copy of the archive with
+// numbers, dates and the field separator in the reader's locale. It is for
+// reading only; restores need the JSON archive.
+func (h *Handler) writeTakeoutCSV(c *gin.Context, userID uuid.UUID, exportedAt time.Time, loc locale.Format) {
+	ctx := c.Request.Context()
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Language", loc.Tag)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="takeout-%s-%s.csv"`,
+		userID, exportedAt.Format("20060102T150405Z")))
+	c.Status(http.StatusOK)
+
+	// The byte order mark makes Excel read the file as UTF-8.
+	io.WriteString(c.Writer, "\uFEFF")
+	w := csv.NewWriter(c.Writer)
+	w.Comma = loc.FieldSep
+	w.Write([]string{"id", "service_name", "price", "currency", "start_month", "end_month", "end_month_inclusive", "locked", "created_at"})
+
+	written := 0
+	err := h.svc.Export(ctx, userID, func(sub Subscription) error {
+		if written >= maxTakeoutItems {
+			return fmt.Errorf("archive exceeded %d subscriptions", maxTakeoutItems)
+		}
+		end, inclusive := "", ""
+		if sub.EndMonth != nil {
+			end = loc.Month(*sub.EndMonth)
+		}
+		if sub.EndMonthInclusive != nil {
+			inclusive = strconv.FormatBool(*sub.EndMonthInclusive)
+		}
+		// Prices are written as charged; rubles keep the locale's sign.
+		price, currency := loc.Number(float64(sub.PriceRUB), 2), strings.TrimSpace(loc.Currency)
+		if cur, ok := money.Lookup(sub.Currency); ok {
+			price = loc.Number(cur.Major(sub.AmountMinor), cur.Exponent)
+			if cur.Code != money.Base {
+				currency = cur.Code
+			}
+		}
+		if err := w.Write([]string{
+			sub.ID.String(),
+			sub.ServiceName,
+			price,
+			currency,
+			loc.Month(sub.StartMonth),
+			end,
+			inclusive,
+			strconv.FormatBool(sub.Locked),
+			loc.Date(sub.CreatedAt),
+		}); err != nil {
+			return err
+		}
+		written++
+		if written%takeoutFlushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	if err != nil {
+		h.logger.Error("takeout export aborted", "user_id", userID, "written", written, "error", err)
+		c.Abort()
+		return
+	}
+	w.Flush()
+}
+
+// requestLocale picks the export locale from the locale query parameter,
+// then Accept-Language.
+func requestLocale(c *gin.Context) locale.Format {
+	if tag := c.Query("locale"); tag != "" {
+		if f, ok := locale.Lookup(tag); ok {
+			return f
+		}
+	}
+	return locale.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// restoreTakeout godoc
+// @Summary Restore account data
+// @Description Recreate subscriptions from a takeout archive. Existing records are skipped.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param archive body takeoutArchive true "Takeout archive"
+// @Success 200 {object} RestoreResult
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 413 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID restoreTakeout
+// @Router /users/{id}/takeout [post]
+func (h *Handler) restoreTakeout(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if !authorizeUser(c, userID, rbac.WriteAnyUser) {
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxTakeoutBytes)
+	result, err := h.svc.Restore(c.Request.Context(), userID, decodeTakeout(json.NewDecoder(body)))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		var malformed *takeoutFormatError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("archive exceeds %d bytes", maxTakeoutBytes)})
+		case errors.As(err, &malformed) || errors.Is(err, ErrInvalidInput):
+			h.logger.Info("invalid takeout archive", "user_id", userID, "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected):
+			h.logger.Info("takeout restore rejected", "user_id", userID, "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+		default:
+			h.serverError(c, "failed to restore takeout", err, "user_id", userID)
+		}
+		return
+	}
+
+	h.logger.Info("takeout restored", "user_id", userID, "restored", result.Restored, "skipped", result.Skipped)
+	c.JSON(http.StatusOK, result)
+}
+
+// takeoutFormatError marks archives that do not follow the takeout layout.
+type takeoutFormatError struct {
+	err error
+}
+
+func (e *takeoutFormatError) Error() string { return "invalid archive: " + e.err.Error() }
+func (e *takeoutFormatError) Unwrap() error { return e.err }
+
+// decodeTakeout yields the archived subscriptions one at a time so the body is
+// never held in memory as a whole. Keys other than version and subscriptions
+// are skipped, and a history after the subscriptions is not read at all, so
+// long histories neither fill memory nor count against the size limit.
+func decodeTakeout(dec *json.Decoder) iter.Seq2[Subscription, error] {
+	return func(yield func(Subscription, error) bool) {
+		fail := func(err error) {
+			var tooLarge *http.MaxBytesError
+			if !errors.As(err, &tooLarge) {
+				err = &takeoutFormatError{err: err}
+			}
+			yield(Subscription{}, err)
+		}
+
+		if err := expectDelim(dec, '{'); err != nil {
+			fail(err)
+			return
+		}
+		subscriptionsRead := false
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				fail(err)
+				return
+			}
+			switch tok {
+			case "version":
+				var version int
+				if err := dec.Decode(&version); err != nil {
+					fail(err)
+					return
+				}
+				if version != takeoutVersion {
+					fail(fmt.Errorf("unsupported version %d", version))
+					return
+				}
+			case "subscriptions":
+				if err := expectDelim(dec, '['); err != nil {
+					fail(err)
+					return
+				}
+				for n := 0; dec.More(); n++ {
+					if n >= maxTakeoutItems {
+						fail(fmt.Errorf("more than %d subscriptions", maxTakeoutItems))
+						return
+					}
+					var archived ArchivedSubscription
+					if err := dec.Decode(&archived); err != nil {
+						fail(err)
+						return
+					}
+					if !yield(archived.Subscription(), nil) {
+						return
+					}
+				}
+				if err := expectDelim(dec, ']'); err != nil {
+					fail(err)
+					return
+				}
+				subscriptionsRead = true
+			case "history":
+				if subscriptionsRead {
+					return
+				}
+				fallthrough
+			default:
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					fail(err)
+					return
+				}
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
@@ -0,0 +1,598 @@
+package subscription_test
+
+import (
+	"database/sql"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+	"github.com/beheryahmed1991/subscription-service.git/internal/testutil"
+)
+
+var (
+	subscriptionSchema = testutil.Schema{
+		"id":                  "string",
+		"slug":                "string",
+		"service_name":        "string",
+		"price_rub":           "number",
+		"currency":            "string",
+		"amount_minor":        "number",
+		"billing_period":      "string",
+		"plan_id":             "null",
+		"user_id":             "string",
+		"start_month":         "string",
+		"end_month":           "null",
+		"end_month_inclusive": "null",
+		"status":              "string",
+		"tags":                "array",
+		"locked":              "bool",
+		"version":             "number",
+		"created_at":          "string",
+		"updated_at":          "string",
+	}
+	listSchema  = testutil.Schema{"items": "array", "page": "number", "limit": "number", "total": "number"}
+	errorSchema = testutil.Schema{"error": "string"}
+)
+
+// asCaller serves the subscription routes to requests authenticated as
+// caller.
+func asCaller(caller identity.Caller) func(subscription.Service) http.Handler {
+	return func(svc subscription.Service) http.Handler {
+		router := testutil.SubscriptionRouter(svc)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			router.ServeHTTP(w, r.WithContext(identity.WithCaller(r.Context(), caller)))
+		})
+	}
+}
+
+func returns(sub subscription.Subscription, err error) func(subscription.UpdateParams) (subscription.Subscription, error) {
+	return func(subscription.UpdateParams) (subscription.Subscription, error) { return sub, err }
+}
+
+func TestHandlerContracts(t *testing.T) {
+	sub := testutil.NewSubscriptionBuilder().Active().Build()
+	path := "/subscriptions/" + sub.ID.String()
+	notFound := fmt.Errorf("select subscription: %w", sql.ErrNoRows)
+	missingID := uuid.New()
+
+	testutil.RunContracts(t, testutil.SubscriptionRouter, []testutil.Contract{
+		{
+			Name:   "create",
+			Method: http.MethodPost, Path: "/subscriptions",
+			Body: testutil.NewSubscriptionBuilder().RequestBody(),
+			Setup: func(m *testutil.ServiceMock) {
+				m.CreateFunc = func(subscription.CreateParams) (subscription.Subscription, error) { return sub, nil }
+			},
+			Status: http.StatusCreated,
+			Schema: subscriptionSchema,
+			Calls:  []string{"Create"},
+		},
+		{
+			Name:   "create without price",
+			Method: http.MethodPost, Path: "/subscriptions",
+			Body:   map[string]any{"service_name": "Netflix", "user_id": uuid.NewString(), "start_date": "2025-03"},
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "create with invalid user",
+			Method: http.MethodPost, Path: "/subscriptions",
+			Body:   map[string]any{"service_name": "Netflix", "price": 599, "user_id": "nobody", "start_date": "2025-03"},
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "create rejected by validation",
+			Method: http.MethodPost, Path: "/subscriptions",
+			Body: testutil.NewSubscriptionBuilder().RequestBody(),
+			Setup: func(m *testutil.ServiceMock) {
+				m.CreateFunc = func(subscription.CreateParams) (subscription.Subscription, error) {
+					return subscription.Subscription{}, &subscription.ValidationError{Field: "service_name", Message: "is too long"}
+				}
+			},
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{"Create"},
+		},
+		{
+			Name:   "create with taken id",
+			Method: http.MethodPost, Path: "/subscriptions",
+			Body: testutil.NewSubscriptionBuilder().RequestBody(),
+			Setup: func(m *testutil.ServiceMock) {
+				m.CreateFunc = func(subscription.CreateParams) (subscription.Subscription, error) {
+					return subscription.Subscription{}, subscription.ErrAlreadyExists
+				}
+			},
+			Status: http.StatusConflict,
+			Schema: errorSchema,
+			Calls:  []string{"Create"},
+		},
+		{
+			Name:   "create for another user",
+			Method: http.MethodPost, Path: "/subscriptions",
+			Body: testutil.NewSubscriptionBuilder().RequestBody(),
+			Setup: func(m *testutil.ServiceMock) {
+				m.CreateFunc = func(subscription.CreateParams) (subscription.Subscription, error) {
+					return subscription.Subscription{}, subscription.ErrForbidden
+				}
+			},
+			Status: http.StatusForbidden,
+			Schema: errorSchema,
+			Calls:  []string{"Create"},
+		},
+		{
+			Name:   "get",
+			Method: http.MethodGet, Path: path,
+			Setup: func(m *testutil.ServiceMock) {
+				m.GetByIDFunc = func(string) (subscription.Subscription, error) { return sub, nil }
+			},
+			Status: http.StatusOK,
+			Schema: subscriptionSchema,
+			Calls:  []string{"GetByID"},
+		},
+		{
+			Name:   "get missing",
+			Method: http.MethodGet, Path: path,
+			Setup: func(m *testutil.ServiceMock) {
+				m.GetByIDFunc = func(string) (subscription.Subscription, error) { return subscription.Subscription{}, notFound }
+			},
+			Status: http.StatusNotFound,
+			Schema: errorSchema,
+			Calls:  []string{"GetByID"},
+		},
+		{
+			Name:   "get invalid id",
+			Method: http.MethodGet, Path: "/subscriptions/not%20an%20id",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "list",
+			Method: http.MethodGet, Path: "/subscriptions?page=2&limit=5&sort_by=price_rub&order=desc",
+			Setup: func(m *testutil.ServiceMock) {
+				m.ListFunc = func(subscription.ListOptions) ([]subscription.Subscription, int, error) {
+					return []subscription.Subscription{sub}, 6, nil
+				}
+			},
+			Status: http.StatusOK,
+			Schema: listSchema,
+			Calls:  []string{"ListVersion", "List"},
+		},
+		{
+			Name:   "list by unsortable column",
+			Method: http.MethodGet, Path: "/subscriptions?sort_by=user_id",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "update",
+			Method: http.MethodPatch, Path: path,
+			Body:   map[string]any{"price": 799},
+			Setup:  func(m *testutil.ServiceMock) { m.UpdateFunc = returns(sub, nil) },
+			Status: http.StatusOK,
+			Schema: subscriptionSchema,
+			Calls:  []string{"Update"},
+		},
+		{
+			Name:   "update with invalid month",
+			Method: http.MethodPatch, Path: path,
+			Body:   map[string]any{"start_date": "March"},
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "update missing",
+			Method: http.MethodPatch, Path: path,
+			Body:   map[string]any{"price": 799},
+			Setup:  func(m *testutil.ServiceMock) { m.UpdateFunc = returns(subscription.Subscription{}, notFound) },
+			Status: http.StatusNotFound,
+			Schema: errorSchema,
+			Calls:  []string{"Update"},
+		},
+		{
+			Name:   "update someone else's",
+			Method: http.MethodPatch, Path: path,
+			Body: map[string]any{"price": 799},
+			Setup: func(m *testutil.ServiceMock) {
+				m.UpdateFunc = returns(subscription.Subscription{}, subscription.ErrForbidden)
+			},
+			Status: http.StatusForbidden,
+			Schema: errorSchema,
+			Calls:  []string{"Update"},
+		},
+		{
+			Name:   "update locked",
+			Method: http.MethodPatch, Path: path,
+			Body: map[string]any{"price": 799},
+			Setup: func(m *testutil.ServiceMock) {
+				m.UpdateFunc = returns(subscription.Subscription{}, subscription.ErrLocked)
+			},
+			Status: http.StatusLocked,
+			Schema: errorSchema,
+			Calls:  []string{"Update"},
+		},
+		{
+			Name:   "delete",
+			Method: http.MethodDelete, Path: path,
+			Status: http.StatusNoContent,
+			Calls:  []string{"Delete"},
+		},
+		{
+			Name:   "delete missing",
+			Method: http.MethodDelete, Path: path,
+			Setup:  func(m *testutil.ServiceMock) { m.DeleteFunc = func(string) error { return notFound } },
+			Status: http.StatusNotFound,
+			Schema: errorSchema,
+			Calls:  []string{"Delete"},
+		},
+		{
+			Name:   "delete locked",
+			Method: http.MethodDelete, Path: path,
+			Setup:  func(m *testutil.ServiceMock) { m.DeleteFunc = func(string) error { return subscription.ErrLocked } },
+			Status: http.StatusLocked,
+			Schema: errorSchema,
+			Calls:  []string{"Delete"},
+		},
+		{
+			Name:   "summary",
+			Method: http.MethodGet, Path: "/subscriptions/summary?start=2025-01&end=2025-06",
+			Setup: func(m *testutil.ServiceMock) {
+				m.SumByPeriodFunc = func(subscription.SumFilter) (int64, error) { return 3594, nil }
+			},
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"total_price": "number"},
+			Calls:  []string{"SumByPeriod"},
+		},
+		{
+			Name:   "summary per tag",
+			Method: http.MethodGet, Path: "/subscriptions/summary?group_by=tag&tag_mode=primary",
+			Setup: func(m *testutil.ServiceMock) {
+				m.SumByTagsFunc = func(_ subscription.SumFilter, mode subscription.TagMode) ([]subscription.TagTotal, error) {
+					if mode != subscription.TagModePrimary {
+						return nil, fmt.Errorf("tag mode %q", mode)
+					}
+					work := "work"
+					return []subscription.TagTotal{{Tag: &work, TotalPrice: 1200}, {TotalPrice: 300}}, nil
+				}
+			},
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"tag_mode": "string", "totals": "array"},
+			Calls:  []string{"SumByTags"},
+		},
+		{
+			Name:   "summary per tag with unknown mode",
+			Method: http.MethodGet, Path: "/subscriptions/summary?group_by=tag&tag_mode=first",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "summary per tag projected",
+			Method: http.MethodGet, Path: "/subscriptions/summary?group_by=tag&projected=true&end=2026-01",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "summary grouped by service",
+			Method: http.MethodGet, Path: "/subscriptions/summary?group_by=service",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "summary with end before start",
+			Method: http.MethodGet, Path: "/subscriptions/summary?start=2025-06&end=2025-01",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "batch get",
+			Method: http.MethodPost, Path: "/subscriptions/batch-get",
+			Body: map[string]any{"ids": []string{sub.ID.String(), missingID.String(), sub.ID.String()}},
+			Setup: func(m *testutil.ServiceMock) {
+				m.GetByIDsFunc = func([]uuid.UUID) ([]subscription.Subscription, []uuid.UUID, error) {
+					return []subscription.Subscription{sub}, []uuid.UUID{missingID}, nil
+				}
+			},
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"items": "array", "missing": "array"},
+			Calls:  []string{"GetByIDs"},
+		},
+		{
+			Name:   "batch get with invalid id",
+			Method: http.MethodPost, Path: "/subscriptions/batch-get",
+			Body:   map[string]any{"ids": []string{"nobody"}},
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "transfer",
+			Method: http.MethodPost, Path: path + "/transfer",
+			Body: map[string]any{"to_user_id": uuid.NewString()},
+			Setup: func(m *testutil.ServiceMock) {
+				m.TransferFunc = func(uuid.UUID, uuid.UUID) (subscription.Subscription, error) { return sub, nil }
+			},
+			Status: http.StatusOK,
+			Schema: subscriptionSchema,
+			Calls:  []string{"Transfer"},
+		},
+		{
+			Name:   "transfer to invalid user",
+			Method: http.MethodPost, Path: path + "/transfer",
+			Body:   map[string]any{"to_user_id": "nobody"},
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "transfer missing",
+			Method: http.MethodPost, Path: path + "/transfer",
+			Body: map[string]any{"to_user_id": uuid.NewString()},
+			Setup: func(m *testutil.ServiceMock) {
+				m.TransferFunc = func(uuid.UUID, uuid.UUID) (subscription.Subscription, error) {
+					return subscription.Subscription{}, notFound
+				}
+			},
+			Status: http.StatusNotFound,
+			Schema: errorSchema,
+			Calls:  []string{"Transfer"},
+		},
+		{
+			Name:   "transfer without write access",
+			Method: http.MethodPost, Path: path + "/transfer",
+			Body: map[string]any{"to_user_id": uuid.NewString()},
+			Setup: func(m *testutil.ServiceMock) {
+				m.TransferFunc = func(uuid.UUID, uuid.UUID) (subscription.Subscription, error) {
+					return subscription.Subscription{}, subscription.ErrForbidden
+				}
+			},
+			Status: http.StatusForbidden,
+			Schema: errorSchema,
+			Calls:  []string{"Transfer"},
+		},
+		{
+			Name:   "transfer locked",
+			Method: http.MethodPost, Path: path + "/transfer",
+			Body: map[string]any{"to_user_id": uuid.NewString()},
+			Setup: func(m *testutil.ServiceMock) {
+				m.TransferFunc = func(uuid.UUID, uuid.UUID) (subscription.Subscription, error) {
+					return subscription.Subscription{}, subscription.ErrLocked
+				}
+			},
+			Status: http.StatusLocked,
+			Schema: errorSchema,
+			Calls:  []string{"Transfer"},
+		},
+		{
+			Name:   "timeline",
+			Method: http.MethodGet, Path: path + "/timeline?limit=1",
+			Setup: func(m *testutil.ServiceMock) {
+				m.TimelineFunc = func(uuid.UUID) ([]subscription.TimelineItem, error) {
+					return []subscription.TimelineItem{{At: sub.CreatedAt, Kind: "created"}, {At: sub.CreatedAt, Kind: "started"}}, nil
+				}
+			},
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"items": "array", "page": "number", "limit": "number", "total": "number"},
+			Calls:  []string{"Timeline"},
+		},
+		{
+			Name:   "timeline missing",
+			Method: http.MethodGet, Path: path + "/timeline",
+			Setup: func(m *testutil.ServiceMock) {
+				m.TimelineFunc = func(uuid.UUID) ([]subscription.TimelineItem, error) { return nil, notFound }
+			},
+			Status: http.StatusNotFound,
+			Schema: errorSchema,
+			Calls:  []string{"Timeline"},
+		},
+		{
+			Name:   "timeline with oversized page",
+			Method: http.MethodGet, Path: path + "/timeline?limit=500",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "bulk delete preview",
+			Method: http.MethodPost, Path: "/subscriptions/bulk-delete?service_name=Netflix",
+			Setup: func(m *testutil.ServiceMock) {
+				m.MatchingFunc = func(subscription.BulkFilter, int) ([]subscription.Subscription, error) {
+					return []subscription.Subscription{sub}, nil
+				}
+			},
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"count": "number", "sample": "array", "token": "string", "expires_at": "string"},
+			Calls:  []string{"Matching"},
+		},
+		{
+			Name:   "bulk delete preview without filter",
+			Method: http.MethodPost, Path: "/subscriptions/bulk-delete",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "bulk delete confirm with unknown token",
+			Method: http.MethodPost, Path: "/subscriptions/bulk-delete/confirm",
+			Body:   map[string]string{"token": "unknown"},
+			Status: http.StatusNotFound,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "bulk delete job with invalid id",
+			Method: http.MethodGet, Path: "/subscriptions/bulk-delete/nope",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "bulk delete job unknown",
+			Method: http.MethodGet, Path: "/subscriptions/bulk-delete/" + uuid.NewString(),
+			Status: http.StatusNotFound,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "takeout export",
+			Method: http.MethodGet, Path: "/users/" + sub.UserID.String() + "/takeout",
+			Setup: func(m *testutil.ServiceMock) {
+				m.ExportFunc = func(_ uuid.UUID, fn func(subscription.Subscription) error) error { return fn(sub) }
+			},
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"version": "number", "user_id": "string", "exported_at": "string", "subscriptions": "array", "history": "array"},
+			Calls:  []string{"List", "Export", "ExportHistory"},
+		},
+		{
+			Name:   "takeout export in unknown format",
+			Method: http.MethodGet, Path: "/users/" + sub.UserID.String() + "/takeout?format=xml",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "takeout restore",
+			Method: http.MethodPost, Path: "/users/" + sub.UserID.String() + "/takeout",
+			Body: map[string]any{"version": 1, "subscriptions": []any{}},
+			Setup: func(m *testutil.ServiceMock) {
+				m.RestoreFunc = func(uuid.UUID, iter.Seq2[subscription.Subscription, error]) (subscription.RestoreResult, error) {
+					return subscription.RestoreResult{Restored: 1}, nil
+				}
+			},
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"restored": "number", "skipped": "number"},
+			Calls:  []string{"Restore"},
+		},
+		{
+			Name:   "takeout restore for invalid user",
+			Method: http.MethodPost, Path: "/users/nobody/takeout",
+			Status: http.StatusBadRequest,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+	})
+
+	caller := identity.Caller{UserID: uuid.New(), Roles: []identity.Role{identity.RoleUser}}
+	testutil.RunContracts(t, asCaller(caller), []testutil.Contract{
+		{
+			Name:   "summary of another user",
+			Method: http.MethodGet, Path: "/subscriptions/summary?user_id=" + uuid.NewString(),
+			Status: http.StatusForbidden,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "summary of own subscriptions",
+			Method: http.MethodGet, Path: "/subscriptions/summary?user_id=" + caller.UserID.String(),
+			Status: http.StatusOK,
+			Schema: testutil.Schema{"total_price": "number"},
+			Calls:  []string{"SumByPeriod"},
+		},
+		{
+			Name:   "bulk delete preview for another user",
+			Method: http.MethodPost, Path: "/subscriptions/bulk-delete?user_id=" + uuid.NewString(),
+			Status: http.StatusForbidden,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "takeout export of another user",
+			Method: http.MethodGet, Path: "/users/" + uuid.NewString() + "/takeout",
+			Status: http.StatusForbidden,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+		{
+			Name:   "takeout restore for another user",
+			Method: http.MethodPost, Path: "/users/" + uuid.NewString() + "/takeout",
+			Body:   map[string]any{"version": 1, "subscriptions": []any{}},
+			Status: http.StatusForbidden,
+			Schema: errorSchema,
+			Calls:  []string{},
+		},
+	})
+}
+
+func TestCreatePassesRequestToService(t *testing.T) {
+	b := testutil.NewSubscriptionBuilder().
+		Between(testutil.MonthsAgo(3), testutil.MonthsAgo(-2)).
+		EndInclusive(false)
+	body := b.RequestBody()
+	body["id"] = b.Build().ID.String()
+
+	mock := &testutil.ServiceMock{}
+	rec := testutil.DoJSON(testutil.SubscriptionRouter(mock), http.MethodPost, "/subscriptions", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 1 || calls[0].Method != "Create" {
+		t.Fatalf("service calls = %v, want one Create", mock.Methods())
+	}
+	if got, want := calls[0].Args[0], b.CreateParams(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Create params = %+v, want %+v", got, want)
+	}
+}
+
+func TestBulkDeleteJobVisibleToOwner(t *testing.T) {
+	owner := identity.Caller{UserID: uuid.New(), Roles: []identity.Role{identity.RoleUser}}
+	other := identity.Caller{UserID: uuid.New(), Roles: []identity.Role{identity.RoleUser}}
+	support := identity.Caller{UserID: uuid.New(), Roles: []identity.Role{identity.RoleSupport}}
+
+	sub := testutil.NewSubscriptionBuilder().WithUser(owner.UserID).Build()
+	mock := &testutil.ServiceMock{
+		MatchingFunc: func(subscription.BulkFilter, int) ([]subscription.Subscription, error) {
+			return []subscription.Subscription{sub}, nil
+		},
+	}
+	router := testutil.SubscriptionRouter(mock)
+	do := func(caller identity.Caller, method, path string, body any) *httptest.ResponseRecorder {
+		req := testutil.JSONRequest(method, path, body)
+		return testutil.Do(router, req.WithContext(identity.WithCaller(req.Context(), caller)))
+	}
+
+	rec := do(owner, http.MethodPost, "/subscriptions/bulk-delete", nil)
+	preview, err := testutil.DecodeJSON[subscription.BulkDeletePreview](rec)
+	if err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("preview: %d %v", rec.Code, err)
+	}
+	if rec := do(other, http.MethodPost, "/subscriptions/bulk-delete/confirm", map[string]string{"token": preview.Token}); rec.Code != http.StatusNotFound {
+		t.Fatalf("confirm by another user: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	rec = do(owner, http.MethodPost, "/subscriptions/bulk-delete/confirm", map[string]string{"token": preview.Token})
+	job, err := testutil.DecodeJSON[subscription.BulkDeleteJob](rec)
+	if err != nil || rec.Code != http.StatusAccepted {
+		t.Fatalf("confirm: %d %v", rec.Code, err)
+	}
+
+	path := "/subscriptions/bulk-delete/" + job.ID.String()
+	for _, tt := range []struct {
+		name   string
+		caller identity.Caller
+		status int
+	}{
+		{name: "owner", caller: owner, status: http.StatusOK},
+		{name: "support", caller: support, status: http.StatusOK},
+		{name: "another user", caller: other, status: http.StatusNotFound},
+	} {
+		if rec := do(tt.caller, http.MethodGet, path, nil); rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d; body %s", tt.name, rec.Code, tt.status, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package subscription
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/query"
+)
+
+type timelineResponse struct {
+	Items []TimelineItem `json:"items"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+	Total int            `json:"total"`
+}
+
+// timelineQuery holds the paging parameters of the timeline endpoint.
+type timelineQuery struct {
+	Page  int `query:"page,default=1,min=1"`
+	Limit int `query:"limit,default=50,min=1,max=100"`
+}
+
+// timeline godoc
+// @Summary Subscription timeline
+// @Description Chronological feed of a subscription's life for its detail page: creation, every audited change with the fields it touched (price changes, lock and unlock, transfers, undos), and the months it started and ended. Paged oldest first.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Param page query int false "Page number (>=1)" default(1)
+// @Param limit query int false "Items per page (<=100)" default(50)
+// @Success 200 {object} timelineResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID getSubscriptionTimeline
+// @Router /subscriptions/{id}/timeline [get]
+func (h *Handler) timeline(c *gin.Context) {
+	subID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+	var q timelineQuery
+	if err := query.Bind(c.Request.URL.Query(), &q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.svc.Timeline(c.Request.Context(), subID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			return
+		}
+		h.serverError(c, "failed to build subscription timeline", err, "id", subID)
+		return
+	}
+
+	total := len(items)
+	from := min((q.Page-1)*q.Limit, total)
+	to := min(from+q.Limit, total)
+	h.respond(c, http.StatusOK, timelineResponse{
+		Items: items[from:to],
+		Page:  q.Page,
+		Limit: q.Limit,
+		Total: total,
+	})
+}
@@ -0,0 +1,76 @@
+package subscription
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type transferRequest struct {
+	ToUserID string `json:"to_user_id" binding:"required"`
+}
+
+// transfer godoc
+// @Summary Transfer subscription
+// @Description Hand a subscription over to another user. Allowed for the current owner or an admin. Callers who cannot see the subscription get 404; support staff who can see but not change it get 403.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Param request body transferRequest true "New owner"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID transferSubscription
+// @Router /subscriptions/{id}/transfer [post]
+func (h *Handler) transfer(c *gin.Context) {
+	subID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+	idParam := subID.String()
+
+	var req transferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Info("invalid transfer payload", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	toUserID, err := uuid.Parse(req.ToUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to_user_id"})
+		return
+	}
+
+	sub, err := h.svc.Transfer(c.Request.Context(), subID, toUserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		case errors.Is(err, ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrLocked):
+			c.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrForbidden):
+			h.logger.Info("subscription transfer forbidden", "id", idParam)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected):
+			h.logger.Info("subscription transfer rejected", "id", idParam, "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+		default:
+			h.serverError(c, "failed to transfer subscription", err, "id", idParam)
+		}
+		return
+	}
+
+	h.logger.Info("subscription transferred", "id", idParam, "to_user_id", toUserID)
+	h.respond(c, http.StatusOK, h.view(c.Request.Context()).subscription(sub))
+}
@@ -0,0 +1,67 @@
+package subscription
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUndoWindow is how long an update can be undone when the handler
+// config sets no window.
+const defaultUndoWindow = 15 * time.Minute
+
+// undo godoc
+// @Summary Undo the last update
+// @Description Revert the fields changed by the most recent update of a subscription, if it happened within the configured window (15 minutes by default). Fails with 409 when the last change was not an update, is too old, or when a reverted field has changed again since.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID or slug"
+// @Param user_id query string false "Owner to resolve a slug against, defaults to the caller"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID undoSubscriptionUpdate
+// @Router /subscriptions/{id}/undo [post]
+func (h *Handler) undo(c *gin.Context) {
+	subID, ok := h.subscriptionID(c)
+	if !ok {
+		return
+	}
+	idParam := subID.String()
+
+	ctx, warnings := CollectWarnings(c.Request.Context())
+	sub, err := h.svc.Undo(ctx, subID, h.config().UndoWindow)
+	if err != nil {
+		var conflict *UndoConflictError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		case errors.As(err, &conflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "fields": conflict.Fields})
+		case errors.Is(err, ErrNothingToUndo):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case h.lockError(c, err):
+		case errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected):
+			h.logger.Info("subscription undo rejected", "id", idParam, "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+		default:
+			h.serverError(c, "failed to undo subscription update", err, "id", idParam)
+		}
+		return
+	}
+
+	h.logger.Info("subscription update undone", "id", idParam)
+	resp := h.view(c.Request.Context()).subscription(sub)
+	resp.Warnings = warnings()
+	h.respond(c, http.StatusOK, resp)
+}
@@ -0,0 +1,137 @@
+package subscription
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// upsertSubscriptionRequest is the body of a by-key upsert. The key fields
+// come from the path.
+type upsertSubscriptionRequest struct {
+	// ID is only used when the upsert creates the subscription.
+	ID          *string `json:"id" example:"0193a4f2-7c1e-7d3a-9b1f-2f6c8e4d5a10"`
+	PriceRUB    *int    `json:"price" binding:"omitempty,min=0"`
+	Currency    string  `json:"currency" example:"RUB"`
+	AmountMinor *int64  `json:"amount_minor" binding:"omitempty,min=0"`
+	// BillingPeriod omitted means monthly.
+	BillingPeriod string `json:"billing_period" enums:"weekly,monthly,quarterly,yearly"`
+	// PlanID omitted means the subscription is on no particular plan.
+	PlanID *int64 `json:"plan_id"`
+	// EndMonth omitted or empty means the subscription has no end.
+	EndMonth     *string `json:"end_date"`
+	EndInclusive *bool   `json:"end_month_inclusive"`
+}
+
+// upsert godoc
+// @Summary Create or update subscription by natural key
+// @Description Idempotent write for sync jobs that do not track IDs. The subscription is identified by owner, service name (case-insensitive) and start month. If none exists it is created (201); otherwise its price, currency, billing period, plan and end date are replaced to match the body (200). Sending the same body again changes nothing, so jobs can re-run without checking first. If several subscriptions share the key, the oldest is updated.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id path string true "Owner (UUID)"
+// @Param service_name path string true "Service name"
+// @Param start_month path string true "Start month, e.g. 2025-03"
+// @Param request body upsertSubscriptionRequest true "Price and end of the subscription"
+// @Success 200 {object} SubscriptionResponse
+// @Success 201 {object} SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 423 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @ID upsertSubscriptionByKey
+// @Router /subscriptions/by-key/{user_id}/{service_name}/{start_month} [put]
+func (h *Handler) upsert(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+	startMonth, err := parseMonth(c.Param("start_month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req upsertSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Info("invalid upsert payload", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PriceRUB == nil && req.AmountMinor == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price or amount_minor is required"})
+		return
+	}
+
+	var subID uuid.UUID
+	if req.ID != nil {
+		if subID, err = uuid.Parse(*req.ID); err != nil || subID == uuid.Nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+		if err := validateClientID(subID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var end *time.Time
+	if req.EndMonth != nil && strings.TrimSpace(*req.EndMonth) != "" {
+		parsed, err := parseMonth(*req.EndMonth)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		end = &parsed
+	}
+
+	var price int
+	if req.PriceRUB != nil {
+		price = *req.PriceRUB
+	}
+
+	ctx, warnings := CollectWarnings(c.Request.Context())
+	sub, created, err := h.svc.Upsert(ctx, CreateParams{
+		ID:                subID,
+		ServiceName:       c.Param("service_name"),
+		PriceRUB:          price,
+		UserID:            userID,
+		StartMonth:        startMonth,
+		EndMonth:          end,
+		EndMonthInclusive: req.EndInclusive,
+		Currency:          req.Currency,
+		AmountMinor:       req.AmountMinor,
+		BillingPeriod:     req.BillingPeriod,
+		PlanID:            req.PlanID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case h.lockError(c, err):
+		case errors.Is(err, ErrConstraintViolation) || errors.Is(err, ErrRejected):
+			h.logger.Info("subscription upsert rejected", "user_id", userID, "error", err)
+			c.JSON(http.StatusUnprocessableEntity, rejectionBody(err))
+		default:
+			h.serverError(c, "failed to upsert subscription", err, "user_id", userID)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	resp := h.view(c.Request.Context()).subscription(sub)
+	resp.Warnings = warnings()
+	h.respond(c, status, resp)
+}
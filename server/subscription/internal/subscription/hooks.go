@@ -0,0 +1,42 @@
+package subscription
+
+import "context"
+
+// ValidationHook lets deployments plug custom business rules into the service
+// without forking it. Returning a non-nil error aborts the operation; the
+// error is wrapped with ErrRejected before it reaches the caller. A *Warning
+// or Warnings lets the operation proceed and is reported back instead.
+type ValidationHook interface {
+	BeforeCreate(context.Context, CreateParams) error
+	BeforeUpdate(context.Context, UpdateParams) error
+	BeforeDelete(ctx context.Context, id string) error
+}
+
+// HookFuncs adapts plain functions to ValidationHook. Nil fields are skipped,
+// so a hook only needs to provide the checks it cares about.
+type HookFuncs struct {
+	Create func(context.Context, CreateParams) error
+	Update func(context.Context, UpdateParams) error
+	Delete func(ctx context.Context, id string) error
+}
+
+func (h HookFuncs) BeforeCreate(ctx context.Context, params CreateParams) error {
+	if h.Create == nil {
+		return nil
+	}
+	return h.Create(ctx, params)
+}
+
+func (h HookFuncs) BeforeUpdate(ctx context.Context, params UpdateParams) error {
+	if h.Update == nil {
+		return nil
+	}
+	return h.Update(ctx, params)
+}
+
+func (h HookFuncs) BeforeDelete(ctx context.Context, id string) error {
+	if h.Delete == nil {
+		return nil
+	}
+	return h.Delete(ctx, id)
+}
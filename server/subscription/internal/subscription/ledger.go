@@ -0,0 +1,94 @@
+package subscription
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/lease"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+)
+
+// ChargeLedger is the part of the store LedgerJob writes through.
+type ChargeLedger interface {
+	LedgerWatermark(ctx context.Context) (*time.Time, error)
+	EarliestStart(ctx context.Context) (*time.Time, error)
+	MaterializeCharges(ctx context.Context, month time.Time) (int64, bool, error)
+}
+
+// LedgerJob closes every finished month into the charges ledger. A month is
+// only materialized once it is over, so its charges never change afterwards.
+type LedgerJob struct {
+	store    ChargeLedger
+	interval time.Duration
+	logger   *slog.Logger
+	monitor  *monitor.Monitor
+	leases   *lease.Leases
+}
+
+// NewLedgerJob creates a LedgerJob that runs every interval, nightly by
+// default.
+func NewLedgerJob(store ChargeLedger, interval time.Duration, logger *slog.Logger) *LedgerJob {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return &LedgerJob{store: store, interval: interval, logger: logger}
+}
+
+// ReportTo records every run on m.
+func (j *LedgerJob) ReportTo(m *monitor.Monitor) {
+	j.monitor = m
+}
+
+// UseLeases runs the job on one replica at a time through l.
+func (j *LedgerJob) UseLeases(l *lease.Leases) {
+	j.leases = l
+}
+
+// Run closes finished months until ctx is cancelled.
+func (j *LedgerJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		err := j.monitor.Track("charges_ledger", func() error {
+			return j.leases.Do(ctx, "charges_ledger", j.interval, func() error { return j.CatchUp(ctx, time.Now().UTC()) })
+		})
+		if err != nil && ctx.Err() == nil {
+			j.logger.Error("charges ledger run failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CatchUp materializes every month after the ledger watermark that ended
+// before now. On an empty ledger it starts at the earliest subscription.
+func (j *LedgerJob) CatchUp(ctx context.Context, now time.Time) error {
+	next, err := j.store.LedgerWatermark(ctx)
+	if err != nil {
+		return err
+	}
+	if next != nil {
+		month := next.AddDate(0, 1, 0)
+		next = &month
+	} else if next, err = j.store.EarliestStart(ctx); err != nil || next == nil {
+		return err
+	}
+
+	current := months.Normalize(now)
+	for month := *next; month.Before(current); month = month.AddDate(0, 1, 0) {
+		written, closed, err := j.store.MaterializeCharges(ctx, month)
+		if err != nil {
+			return err
+		}
+		if closed {
+			j.logger.Info("charges ledger month closed", "month", month.Format(layoutYearMonth), "charges", written)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/metrics"
+	"github.com/beheryahmed1991/subscription-service.git/internal/monitor"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+)
+
+var countActiveSQL = `
+WITH subs AS (` + runningSubscriptionsSQL(2, 1) + `)
+SELECT LOWER(service_name), COUNT(*)
+FROM subs
+WHERE start_month <= $1::date
+  AND (end_month IS NULL OR end_month >= $1::date)
+GROUP BY LOWER(service_name)
+ORDER BY LOWER(service_name);
+`
+
+// ServiceCount is the number of subscriptions to one service.
+type ServiceCount struct {
+	ServiceName string `json:"service_name"`
+	Count       int64  `json:"count"`
+}
+
+// LiveStats is a snapshot of the subscriptions charged in the current month.
+type LiveStats struct {
+	Total       int64          `json:"total"`
+	ByService   []ServiceCount `json:"by_service"`
+	RefreshedAt time.Time      `json:"refreshed_at"`
+}
+
+// CountActive counts the subscriptions charged in month, per service.
+// Service names are compared case-insensitively, as the summary filters do.
+func (r *Repository) CountActive(ctx context.Context, month time.Time) ([]ServiceCount, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, countActiveSQL, months.Normalize(month), r.endInclusive)
+	if err != nil {
+		return nil, fmt.Errorf("count active subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []ServiceCount{}
+	for rows.Next() {
+		var sc ServiceCount
+		if err := rows.Scan(&sc.ServiceName, &sc.Count); err != nil {
+			return nil, fmt.Errorf("scan active count: %w", err)
+		}
+		counts = append(counts, sc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate active counts: %w", err)
+	}
+	return counts, nil
+}
+
+// ActiveCounter is the part of the store LiveCounter polls.
+type ActiveCounter interface {
+	CountActive(ctx context.Context, month time.Time) ([]ServiceCount, error)
+}
+
+// LiveCounter periodically refreshes the active subscription counts and
+// publishes them as gauges, so a sudden drop after a deploy shows up on the
+// dashboards without anyone running a query.
+type LiveCounter struct {
+	store     ActiveCounter
+	interval  time.Duration
+	logger    *slog.Logger
+	byService *metrics.GaugeVec
+	total     *metrics.GaugeVec
+	snapshot  atomic.Pointer[LiveStats]
+	monitor   *monitor.Monitor
+}
+
+// NewLiveCounter creates a LiveCounter that refreshes every interval and
+// registers its gauges on reg.
+func NewLiveCounter(store ActiveCounter, reg *metrics.Registry, interval time.Duration, logger *slog.Logger) *LiveCounter {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &LiveCounter{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+		byService: reg.Gauge("subscriptions_active",
+			"Subscriptions charged in the current month, by service.", "service"),
+		total: reg.Gauge("subscriptions_active_total",
+			"Subscriptions charged in the current month."),
+	}
+}
+
+// ReportTo records every refresh on m.
+func (l *LiveCounter) ReportTo(m *monitor.Monitor) {
+	l.monitor = m
+}
+
+// Run refreshes the counts until ctx is cancelled.
+func (l *LiveCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		err := l.monitor.Track("live_counter", func() error { return l.Refresh(ctx) })
+		if err != nil && ctx.Err() == nil {
+			l.logger.Error("refresh live subscription counts failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recounts the active subscriptions and updates the gauges.
+func (l *LiveCounter) Refresh(ctx context.Context) error {
+	now := time.Now().UTC()
+	counts, err := l.store.CountActive(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	stats := &LiveStats{ByService: counts, RefreshedAt: now}
+	values := make(map[string]float64, len(counts))
+	for _, sc := range counts {
+		stats.Total += sc.Count
+		values[sc.ServiceName] = float64(sc.Count)
+	}
+	l.byService.Replace(values)
+	l.total.Set(float64(stats.Total))
+	l.snapshot.Store(stats)
+	return nil
+}
+
+// Snapshot returns the last refreshed counts, or false before the first
+// refresh has completed.
+func (l *LiveCounter) Snapshot() (LiveStats, bool) {
+	stats := l.snapshot.Load()
+	if stats == nil {
+		return LiveStats{}, false
+	}
+	return *stats, true
+}
+
+// RegisterRoutes mounts GET /stats/live on the admin group.
+func (l *LiveCounter) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/stats/live", l.get)
+}
+
+// get godoc
+// @Summary Live subscription counts
+// @Description Subscriptions charged in the current month, total and per service, as of the last periodic refresh
+// @Tags admin
+// @Produce json
+// @Success 200 {object} LiveStats
+// @Failure 503 {object} errorResponse
+// @ID getLiveStats
+// @Router /admin/stats/live [get]
+func (l *LiveCounter) get(c *gin.Context) {
+	stats, ok := l.Snapshot()
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "live counts are not available yet"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -4,37 +4,125 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
 )
 
 // Subscription mirrors the database schema for the subscriptions table.
 type Subscription struct {
-	ID          uuid.UUID  `json:"id"`
-	ServiceName string     `json:"service_name"`
-	PriceRUB    int        `json:"price_rub"`
-	UserID      uuid.UUID  `json:"user_id"`
-	StartMonth  time.Time  `json:"start_month"`
-	EndMonth    *time.Time `json:"end_month,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID                uuid.UUID  `json:"id"`
+	Slug              string     `json:"slug"`
+	ServiceName       string     `json:"service_name"`
+	PriceRUB          int        `json:"price_rub"`
+	Currency          string     `json:"currency"`
+	AmountMinor       int64      `json:"amount_minor"`
+	BillingPeriod     string     `json:"billing_period"`
+	PlanID            *int64     `json:"plan_id,omitempty"`
+	Status            string     `json:"status"`
+	Tags              []string   `json:"tags"`
+	UserID            uuid.UUID  `json:"user_id"`
+	StartMonth        time.Time  `json:"start_month"`
+	EndMonth          *time.Time `json:"end_month,omitempty"`
+	EndMonthInclusive *bool      `json:"end_month_inclusive,omitempty"`
+	Locked            bool       `json:"locked"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	Version           int64      `json:"version"`
 }
 
 // CreateParams represents validated data needed to insert a subscription.
 type CreateParams struct {
-	ServiceName string
-	PriceRUB    int
-	UserID      uuid.UUID
-	StartMonth  time.Time
-	EndMonth    *time.Time
+	ID                uuid.UUID
+	ServiceName       string
+	PriceRUB          int
+	UserID            uuid.UUID
+	StartMonth        time.Time
+	EndMonth          *time.Time
+	EndMonthInclusive *bool
+	// Currency and AmountMinor give the price as charged; the service derives
+	// PriceRUB from them. An empty Currency means rubles, and a nil AmountMinor
+	// takes PriceRUB as whole units of Currency.
+	Currency    string
+	AmountMinor *int64
+	// BillingPeriod is how often the price is charged; empty means monthly.
+	BillingPeriod string
+	// PlanID names the catalog plan the subscription is on. The plan must
+	// belong to the subscription's service.
+	PlanID *int64
+	// Status is only set by restores; new subscriptions start active.
+	Status string
+	// Tags label the subscription; the service lowercases them and drops
+	// repeats.
+	Tags []string
+}
+
+// SpendProjection separates what has been charged up to the current month
+// from what the period will cost in total if nothing changes.
+type SpendProjection struct {
+	ActualToDate   int64 `json:"actual_to_date"`
+	ProjectedTotal int64 `json:"projected_total"`
+}
+
+// RestoreResult reports what a takeout restore did.
+type RestoreResult struct {
+	Restored int `json:"restored"`
+	Skipped  int `json:"skipped"`
+}
+
+// CollectionVersion identifies the state of a set of subscriptions cheaply:
+// any insert, update or delete changes the count or the latest updated_at.
+type CollectionVersion struct {
+	Count        int64
+	LastModified time.Time
 }
 
 // UpdateParams carries mutable fields for an existing subscription.
 type UpdateParams struct {
-	ID          uuid.UUID
+	ID                uuid.UUID
+	ServiceName       *string
+	PriceRUB          *int
+	StartMonth        *time.Time
+	EndMonth          *time.Time
+	EndMonthSet       bool
+	EndMonthInclusive *bool
+	// UserID changes the owner. Only ownership transfers set it.
+	UserID *uuid.UUID
+	// Locked sets or clears deletion protection. Only the owner or an admin
+	// may change it.
+	Locked *bool
+	// Currency and AmountMinor change the price as charged, like their
+	// CreateParams namesakes. PriceRUB alone is taken as whole units of the
+	// subscription's currency; the service then fills in all three.
+	Currency    *string
+	AmountMinor *int64
+	// BillingPeriod changes how often the price is charged.
+	BillingPeriod *string
+	// PlanID changes the catalog plan; 0 clears it.
+	PlanID *int64
+	// Status moves the subscription in its lifecycle. Only ChangeStatus and
+	// undo set it, after checking the transition.
+	Status *string
+	// Tags replaces the subscription's tags; an empty slice clears them.
+	Tags *[]string
+}
+
+// changesFields reports whether p changes anything besides the lock.
+func (p UpdateParams) changesFields() bool {
+	return p.ServiceName != nil || p.PriceRUB != nil || p.Currency != nil || p.AmountMinor != nil || p.BillingPeriod != nil || p.PlanID != nil || p.Status != nil || p.Tags != nil ||
+		p.StartMonth != nil || p.EndMonthSet || p.EndMonthInclusive != nil || p.UserID != nil
+}
+
+// BulkFilter selects the subscriptions a bulk update applies to. Service
+// names match case-insensitively. At least one field must be set.
+type BulkFilter struct {
 	ServiceName *string
-	PriceRUB    *int
-	StartMonth  *time.Time
-	EndMonth    *time.Time
-	EndMonthSet bool
+	UserID      *uuid.UUID
+}
+
+// BulkResult reports the subscriptions a bulk update changed.
+type BulkResult struct {
+	Count int         `json:"count"`
+	IDs   []uuid.UUID `json:"ids"`
 }
 
 // SumFilter describes filters for aggregation queries.
@@ -44,3 +132,84 @@ type SumFilter struct {
 	UserID      *uuid.UUID
 	ServiceName *string
 }
+
+// BatchSumFilter describes a per-user aggregation over several users at once.
+type BatchSumFilter struct {
+	StartMonth  *time.Time
+	EndMonth    *time.Time
+	UserIDs     []uuid.UUID
+	ServiceName *string
+}
+
+// Granularity is the calendar bucket size used by time-series summaries.
+type Granularity string
+
+const (
+	GranularityMonth   Granularity = "month"
+	GranularityQuarter Granularity = "quarter"
+	GranularityYear    Granularity = "year"
+)
+
+// Valid reports whether g is one of the supported bucket sizes.
+func (g Granularity) Valid() bool {
+	switch g {
+	case GranularityMonth, GranularityQuarter, GranularityYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// TagMode decides which tags a subscription's cost counts toward when
+// summing per tag.
+type TagMode string
+
+const (
+	// TagModeEach counts a subscription in every one of its tags, so the
+	// totals of several tags can add up to more than the overall spend.
+	TagModeEach TagMode = "each"
+	// TagModePrimary counts a subscription only in its first tag.
+	TagModePrimary TagMode = "primary"
+)
+
+// Valid reports whether m is one of the supported modes.
+func (m TagMode) Valid() bool {
+	return m == TagModeEach || m == TagModePrimary
+}
+
+// TagTotal is the cost of the subscriptions counted toward Tag. A nil Tag
+// collects the untagged ones.
+type TagTotal struct {
+	Tag        *string `json:"tag"`
+	TotalPrice int64   `json:"total_price"`
+}
+
+// TimeSeriesPoint is the total cost of one calendar bucket.
+type TimeSeriesPoint struct {
+	Period     types.Month `json:"period" swaggertype:"string" example:"2025-03-01T00:00:00Z"`
+	TotalPrice int64       `json:"total_price"`
+}
+
+// SpendStats summarizes per-user monthly spend.
+type SpendStats struct {
+	Users int     `json:"users"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Mean  float64 `json:"mean"`
+	Total int64   `json:"total"`
+}
+
+// MonthlySpendStats is SpendStats for a single month.
+type MonthlySpendStats struct {
+	Month types.Month `json:"month" swaggertype:"string" example:"2025-03-01T00:00:00Z"`
+	SpendStats
+}
+
+// SpendDistribution is the spend distribution over a period plus its trend.
+type SpendDistribution struct {
+	Start   types.Month         `json:"start" swaggertype:"string" example:"2025-01-01T00:00:00Z"`
+	End     types.Month         `json:"end" swaggertype:"string" example:"2025-12-01T00:00:00Z"`
+	Overall SpendStats          `json:"overall"`
+	Trend   []MonthlySpendStats `json:"trend"`
+}
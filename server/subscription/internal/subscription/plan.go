@@ -0,0 +1,59 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PlanService returns the name of the catalog service planID belongs to, or
+// sql.ErrNoRows when there is no such plan.
+func (r *Repository) PlanService(ctx context.Context, planID int64) (string, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	var name string
+	err := r.db.QueryRowContext(ctx, `SELECT service_name FROM service_plans WHERE id = $1`, planID).Scan(&name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("select plan service: %w", err)
+	}
+	return name, err
+}
+
+// checkPlan rejects a plan that does not exist or belongs to a service other
+// than serviceName. A nil or zero plan is no plan.
+func checkPlan(ctx context.Context, repo Store, serviceName string, planID *int64) error {
+	if planID == nil || *planID == 0 {
+		return nil
+	}
+	planService, err := repo.PlanService(ctx, *planID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &ValidationError{Field: "plan_id", Message: "does not exist"}
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(planService, serviceName) {
+		return &ValidationError{Field: "plan_id", Message: fmt.Sprintf("is a plan of %s, not %s", planService, serviceName)}
+	}
+	return nil
+}
+
+// checkPlanUpdate checks the plan current ends up on after params: a new
+// plan must belong to the service, and so must the current plan when the
+// service is renamed.
+func checkPlanUpdate(ctx context.Context, repo Store, current Subscription, params UpdateParams) error {
+	if params.PlanID == nil && params.ServiceName == nil {
+		return nil
+	}
+	name, plan := current.ServiceName, current.PlanID
+	if params.ServiceName != nil {
+		name = *params.ServiceName
+	}
+	if params.PlanID != nil {
+		plan = params.PlanID
+	}
+	return checkPlan(ctx, repo, name, plan)
+}
@@ -0,0 +1,175 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+)
+
+// maxPresetsPerUser keeps saved filters a convenience rather than storage.
+const maxPresetsPerUser = 20
+
+// presetName is the form preset names take; they appear in query strings.
+var presetName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+// SummaryPreset is a named set of summary filters saved by a user. Unset
+// fields leave the matching filter open. Presets always apply to their
+// owner's subscriptions.
+type SummaryPreset struct {
+	UserID      uuid.UUID
+	Name        string
+	StartMonth  *time.Time
+	EndMonth    *time.Time
+	ServiceName *string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// apply fills the filters left unset from the preset, so request
+// parameters override the saved ones.
+func (p SummaryPreset) apply(filter *SumFilter) {
+	if filter.StartMonth == nil {
+		filter.StartMonth = p.StartMonth
+	}
+	if filter.EndMonth == nil {
+		filter.EndMonth = p.EndMonth
+	}
+	if filter.ServiceName == nil {
+		filter.ServiceName = p.ServiceName
+	}
+}
+
+// PresetStore persists summary presets.
+type PresetStore interface {
+	ListPresets(ctx context.Context, userID uuid.UUID) ([]SummaryPreset, error)
+	GetPreset(ctx context.Context, userID uuid.UUID, name string) (SummaryPreset, error)
+	// SavePreset creates the preset or replaces the one with its name, and
+	// reports whether it was created.
+	SavePreset(ctx context.Context, preset SummaryPreset) (SummaryPreset, bool, error)
+	DeletePreset(ctx context.Context, userID uuid.UUID, name string) error
+}
+
+var presetColumns = []interface{}{
+	"user_id", "name", "start_month", "end_month", "service_name", "created_at", "updated_at",
+}
+
+func scanPreset(row rowScanner, p *SummaryPreset) error {
+	return row.Scan(&p.UserID, &p.Name, &p.StartMonth, &p.EndMonth, &p.ServiceName, &p.CreatedAt, &p.UpdatedAt)
+}
+
+func (r *Repository) ListPresets(ctx context.Context, userID uuid.UUID) ([]SummaryPreset, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.List)
+	defer cancel()
+
+	query, args, err := r.builder.From("summary_presets").Select(presetColumns...).
+		Where(goqu.C("user_id").Eq(userID)).
+		Order(goqu.C("name").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("build list summary presets: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list summary presets: %w", err)
+	}
+	defer rows.Close()
+
+	presets := []SummaryPreset{}
+	for rows.Next() {
+		var p SummaryPreset
+		if err := scanPreset(rows, &p); err != nil {
+			return nil, fmt.Errorf("scan summary preset: %w", err)
+		}
+		presets = append(presets, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return presets, nil
+}
+
+func (r *Repository) GetPreset(ctx context.Context, userID uuid.UUID, name string) (SummaryPreset, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	query, args, err := r.builder.From("summary_presets").Select(presetColumns...).
+		Where(goqu.C("user_id").Eq(userID), goqu.C("name").Eq(name)).
+		ToSQL()
+	if err != nil {
+		return SummaryPreset{}, fmt.Errorf("build get summary preset: %w", err)
+	}
+
+	var p SummaryPreset
+	if err := scanPreset(r.db.QueryRowContext(ctx, query, args...), &p); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SummaryPreset{}, err
+		}
+		return SummaryPreset{}, fmt.Errorf("get summary preset: %w", err)
+	}
+	return p, nil
+}
+
+func (r *Repository) SavePreset(ctx context.Context, preset SummaryPreset) (SummaryPreset, bool, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Write)
+	defer cancel()
+
+	stmt := r.builder.Insert("summary_presets").Rows(goqu.Record{
+		"user_id":      preset.UserID,
+		"name":         preset.Name,
+		"start_month":  preset.StartMonth,
+		"end_month":    preset.EndMonth,
+		"service_name": preset.ServiceName,
+	}).OnConflict(goqu.DoUpdate("user_id, name", goqu.Record{
+		"start_month":  goqu.L("EXCLUDED.start_month"),
+		"end_month":    goqu.L("EXCLUDED.end_month"),
+		"service_name": goqu.L("EXCLUDED.service_name"),
+		"updated_at":   goqu.L("now()"),
+	})).Returning(append(presetColumns, goqu.L("xmax = 0"))...)
+
+	query, args, err := stmt.ToSQL()
+	if err != nil {
+		return SummaryPreset{}, false, fmt.Errorf("build save summary preset: %w", err)
+	}
+
+	var (
+		p       SummaryPreset
+		created bool
+	)
+	row := r.db.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(&p.UserID, &p.Name, &p.StartMonth, &p.EndMonth, &p.ServiceName, &p.CreatedAt, &p.UpdatedAt, &created); err != nil {
+		return SummaryPreset{}, false, fmt.Errorf("save summary preset: %w", err)
+	}
+	return p, created, nil
+}
+
+func (r *Repository) DeletePreset(ctx context.Context, userID uuid.UUID, name string) error {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Write)
+	defer cancel()
+
+	query, args, err := r.builder.Delete("summary_presets").
+		Where(goqu.C("user_id").Eq(userID), goqu.C("name").Eq(name)).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("build delete summary preset: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("delete summary preset: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
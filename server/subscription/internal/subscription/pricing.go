@@ -0,0 +1,67 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/money"
+)
+
+// priceCreate fills in the currency, the amount in minor units and the ruble
+// price of a validated create.
+func (s *service) priceCreate(ctx context.Context, params *CreateParams) error {
+	if params.Currency == "" {
+		params.Currency = money.Base
+	}
+	cur, _ := money.Lookup(params.Currency)
+	amount := cur.Minor(int64(params.PriceRUB))
+	if params.AmountMinor != nil {
+		amount = *params.AmountMinor
+	}
+	rub, err := s.toRUB(ctx, cur, amount)
+	if err != nil {
+		return err
+	}
+	params.Currency, params.AmountMinor, params.PriceRUB = cur.Code, &amount, rub
+	return nil
+}
+
+// priceUpdate does the same for an update of current that touches the price
+// or its currency. Changing only the currency keeps the amount and converts
+// it anew.
+func (s *service) priceUpdate(ctx context.Context, current Subscription, params *UpdateParams) error {
+	if params.PriceRUB == nil && params.Currency == nil && params.AmountMinor == nil {
+		return nil
+	}
+	code := current.Currency
+	if params.Currency != nil {
+		code = *params.Currency
+	}
+	cur, ok := money.Lookup(code)
+	if !ok {
+		return &ValidationError{Field: "currency", Message: "is no longer supported"}
+	}
+	amount := current.AmountMinor
+	switch {
+	case params.AmountMinor != nil:
+		amount = *params.AmountMinor
+	case params.PriceRUB != nil:
+		amount = cur.Minor(int64(*params.PriceRUB))
+	}
+	rub, err := s.toRUB(ctx, cur, amount)
+	if err != nil {
+		return err
+	}
+	params.Currency, params.AmountMinor, params.PriceRUB = &cur.Code, &amount, &rub
+	return nil
+}
+
+// toRUB converts at today's rate. A currency without a rate is the client's
+// problem to fix, not a server error.
+func (s *service) toRUB(ctx context.Context, cur money.Currency, amount int64) (int, error) {
+	rub, err := money.ToRUB(ctx, s.rates, cur, amount)
+	if errors.Is(err, money.ErrNoRate) {
+		return 0, &ValidationError{Field: "currency", Message: "has no exchange rate configured"}
+	}
+	return rub, err
+}
@@ -0,0 +1,92 @@
+package subscription
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// ownerField marks a JSON object as belonging to a user.
+const ownerField = "user_id"
+
+// RedactionPolicy lists response fields hidden from callers that neither own a
+// record nor may read any user's data. It is applied to every JSON object in a
+// response that carries a user_id, so new endpoints inherit it as long as they
+// respond through Handler.respond.
+type RedactionPolicy struct {
+	Fields []string
+}
+
+func (p RedactionPolicy) apply(caller identity.Caller, payload any) (any, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode response: %w", err)
+	}
+
+	// Numbers stay json.Number so IDs and amounts beyond 2^53 survive the
+	// round trip exactly.
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	p.walk(caller, doc)
+	return doc, nil
+}
+
+func (p RedactionPolicy) walk(caller identity.Caller, node any) {
+	switch v := node.(type) {
+	case map[string]any:
+		if owner, ok := v[ownerField].(string); ok && owner != caller.UserID.String() {
+			for _, field := range p.Fields {
+				delete(v, field)
+			}
+		}
+		for _, child := range v {
+			p.walk(caller, child)
+		}
+	case []any:
+		for _, child := range v {
+			p.walk(caller, child)
+		}
+	}
+}
+
+// renderer returns a function that applies the caller's redaction policy to
+// one payload, for handlers that write responses incrementally.
+func (h *Handler) renderer(c *gin.Context) func(any) (any, error) {
+	policy := h.config().Redaction
+	caller, ok := identity.FromContext(c.Request.Context())
+	if !ok || rbac.Allows(caller, rbac.ReadAnyUser) || len(policy.Fields) == 0 {
+		return func(payload any) (any, error) { return payload, nil }
+	}
+	return func(payload any) (any, error) { return policy.apply(caller, payload) }
+}
+
+// respond writes payload as JSON after applying the redaction policy for the
+// authenticated caller. Anonymous requests and callers who may read any
+// user's data receive the full payload.
+func (h *Handler) respond(c *gin.Context, status int, payload any) {
+	policy := h.config().Redaction
+	caller, ok := identity.FromContext(c.Request.Context())
+	if !ok || rbac.Allows(caller, rbac.ReadAnyUser) || len(policy.Fields) == 0 {
+		c.JSON(status, payload)
+		return
+	}
+
+	redacted, err := policy.apply(caller, payload)
+	if err != nil {
+		h.logger.Error("failed to redact response", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render response"})
+		return
+	}
+	c.JSON(status, redacted)
+}
@@ -6,85 +6,476 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
 	goqu "github.com/doug-martin/goqu/v9"
 	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/db"
+	"github.com/lib/pq"
 )
 
 // Store describes the contract for subscription persistence.
 type Store interface {
+	InTx(context.Context, func(Store) error) error
+	GetByIDForUpdate(context.Context, string) (Subscription, error)
+	LockMatching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error)
+	LockByKey(context.Context, NaturalKey) (Subscription, error)
+	PlanService(ctx context.Context, planID int64) (string, error)
+	FindMatching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error)
 	Create(context.Context, CreateParams) (Subscription, error)
 	GetByID(context.Context, string) (Subscription, error)
+	GetByIDs(context.Context, []uuid.UUID) ([]Subscription, error)
+	GetBySlug(ctx context.Context, userID uuid.UUID, slug string) (Subscription, error)
 	List(context.Context, ListOptions) ([]Subscription, int, error)
+	ListVersion(context.Context, ListOptions) (CollectionVersion, error)
+	Stream(context.Context, ListOptions, func(Subscription) error) error
 	Update(context.Context, UpdateParams) (Subscription, error)
 	Delete(context.Context, string) error
-	SumByPeriod(context.Context, SumFilter) (int, error)
+	RecordAudit(context.Context, AuditEntry) error
+	LatestAudit(ctx context.Context, id uuid.UUID) (AuditEntry, error)
+	AuditTrail(ctx context.Context, id uuid.UUID) ([]AuditEntry, error)
+	UserAuditTrail(ctx context.Context, userID uuid.UUID, fn func(AuditEntry) error) error
+	SumByPeriod(context.Context, SumFilter) (int64, error)
+	SumByUsers(context.Context, BatchSumFilter) (map[uuid.UUID]int64, error)
+	SumByTags(context.Context, SumFilter, TagMode) ([]TagTotal, error)
+	SumTimeSeries(context.Context, SumFilter, Granularity) ([]TimeSeriesPoint, error)
+	Search(context.Context, SearchQuery) ([]Subscription, int, error)
+	SpendDistribution(ctx context.Context, start, end time.Time) (SpendDistribution, error)
+	// EndMonthInclusive is the deployment default for subscriptions that do
+	// not set end_month_inclusive themselves.
+	EndMonthInclusive() bool
 }
 
-// ListOptions controls pagination for List.
+// ListOptions controls pagination, filtering and ordering for List.
 type ListOptions struct {
 	Limit  int
 	Offset int
+	Sort   Sort
+	UserID *uuid.UUID
+	// Status filters on the current status, see Subscription.CurrentStatus.
+	Status string
+}
+
+// Sort describes an ORDER BY clause over one of the sortable columns.
+type Sort = db.Sort
+
+// DefaultSort keeps the historical newest-first ordering.
+var DefaultSort = Sort{Column: "created_at", Desc: true}
+
+// listOrdering whitelists the columns List, Stream and Search may order by.
+// The ID breaks ties so pages are stable.
+var listOrdering = db.NewOrderBy(
+	[]string{"created_at", "updated_at", "start_month", "end_month", "price_rub", "service_name"},
+	[]Sort{DefaultSort},
+	"id",
+)
+
+// ParseSort parses values such as "start_month desc" and validates the column
+// against the whitelist. The direction defaults to ascending.
+func ParseSort(value string) (Sort, error) {
+	return listOrdering.Parse(value)
+}
+
+// subscriptionColumns is the column list every read returns, in scan order.
+var subscriptionColumns = []interface{}{
+	"id", "slug", "service_name", "price_rub", "currency", "amount_minor", "billing_period", "plan_id", "status", "tags", "user_id",
+	"start_month", "end_month", "end_month_inclusive", "locked", "created_at", "updated_at", "version",
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner, sub *Subscription) error {
+	return row.Scan(
+		&sub.ID,
+		&sub.Slug,
+		&sub.ServiceName,
+		&sub.PriceRUB,
+		&sub.Currency,
+		&sub.AmountMinor,
+		&sub.BillingPeriod,
+		&sub.PlanID,
+		&sub.Status,
+		pq.Array(&sub.Tags),
+		&sub.UserID,
+		&sub.StartMonth,
+		&sub.EndMonth,
+		&sub.EndMonthInclusive,
+		&sub.Locked,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+		&sub.Version,
+	)
+}
+
+// dbtx is the query surface shared by *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 // Repository is the goqu-backed implementation of Store.
 type Repository struct {
-	db      *sql.DB
+	db      dbtx
+	conn    *sql.DB
+	inTx    bool
 	logger  *slog.Logger
 	builder *goqu.Database
+
+	endInclusive bool
+	ledgerReads  bool
+	timeouts     Timeouts
+	statements   Timeouts
+}
+
+// Timeouts are the default deadlines applied per operation class when the
+// incoming context has none, so callers that forget timeouts cannot run
+// unbounded queries. A zero value disables the default for that class.
+type Timeouts struct {
+	Get     time.Duration
+	List    time.Duration
+	Write   time.Duration
+	Summary time.Duration
+}
+
+// DefaultTimeouts are used unless overridden with WithTimeouts.
+var DefaultTimeouts = Timeouts{
+	Get:     500 * time.Millisecond,
+	List:    2 * time.Second,
+	Write:   2 * time.Second,
+	Summary: 3 * time.Second,
+}
+
+// RepositoryOption customizes a Repository.
+type RepositoryOption func(*Repository)
+
+// WithTimeouts overrides the default per-operation deadlines.
+func WithTimeouts(timeouts Timeouts) RepositoryOption {
+	return func(r *Repository) {
+		r.timeouts = timeouts
+	}
+}
+
+// WithStatementTimeouts has Postgres itself cancel statements of an
+// operation class that run longer than its timeout, for queries a context
+// deadline fails to cancel. Lists and summaries then run in a read-only
+// transaction that sets statement_timeout locally, and InTx applies the
+// write timeout to its transaction. Get is not used: lookups by key are
+// covered by the session-wide limit instead. Zero values, the default,
+// disable a class.
+func WithStatementTimeouts(timeouts Timeouts) RepositoryOption {
+	return func(r *Repository) {
+		r.statements = timeouts
+	}
+}
+
+// WithEndMonthInclusive sets how summaries treat end_month for subscriptions
+// that do not set end_month_inclusive themselves. Inclusive is the default.
+func WithEndMonthInclusive(inclusive bool) RepositoryOption {
+	return func(r *Repository) {
+		r.endInclusive = inclusive
+	}
+}
+
+// EndMonthInclusive reports the default set with WithEndMonthInclusive.
+func (r *Repository) EndMonthInclusive() bool {
+	return r.endInclusive
+}
+
+// WithLedgerReads makes SumByPeriod read closed months from the charges
+// ledger and only compute the months after it from subscriptions.
+func WithLedgerReads(enabled bool) RepositoryOption {
+	return func(r *Repository) {
+		r.ledgerReads = enabled
+	}
 }
 
 // NewRepository wires the DB and logger into a Repository.
-func NewRepository(db *sql.DB, logger *slog.Logger) *Repository {
-	return &Repository{
-		db:      db,
-		logger:  logger,
-		builder: goqu.New("postgres", db),
+func NewRepository(db *sql.DB, logger *slog.Logger, opts ...RepositoryOption) *Repository {
+	r := &Repository{
+		db:           db,
+		conn:         db,
+		logger:       logger,
+		builder:      goqu.New("postgres", db),
+		endInclusive: true,
+		timeouts:     DefaultTimeouts,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// withDeadline applies d to ctx unless the caller already set a deadline.
+func (r *Repository) withDeadline(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// bounded returns a Repository whose statements Postgres cancels after d,
+// and a function that releases it. With d zero, or inside a transaction
+// whose limit was set by InTx, it returns r itself.
+func (r *Repository) bounded(ctx context.Context, d time.Duration) (*Repository, func(), error) {
+	if d <= 0 || r.inTx {
+		return r, func() {}, nil
+	}
+
+	tx, err := r.conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := setStatementTimeout(ctx, tx, d); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	scoped := *r
+	scoped.db = tx
+	scoped.inTx = true
+	// Nothing was written, so rolling back just ends the transaction.
+	return &scoped, func() { tx.Rollback() }, nil
+}
+
+// setStatementTimeout limits the statements of the current transaction to d.
+func setStatementTimeout(ctx context.Context, tx *sql.Tx, d time.Duration) error {
+	ms := strconv.FormatInt(d.Milliseconds(), 10)
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('statement_timeout', $1, true)`, ms); err != nil {
+		return fmt.Errorf("set statement timeout: %w", err)
 	}
+	return nil
+}
+
+// InTx runs fn with a Store bound to a single transaction, committing when fn
+// returns nil and rolling back otherwise. Nested calls reuse the outer
+// transaction.
+func (r *Repository) InTx(ctx context.Context, fn func(Store) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if r.statements.Write > 0 {
+		if err := setStatementTimeout(ctx, tx, r.statements.Write); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	txRepo := *r
+	txRepo.db = tx
+	txRepo.inTx = true
+
+	if err := fn(&txRepo); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && r.logger != nil {
+			r.logger.Error("rollback transaction failed", "error", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByIDForUpdate loads a subscription and locks its row until the
+// surrounding transaction ends. It must be called from within InTx.
+func (r *Repository) GetByIDForUpdate(ctx context.Context, id string) (Subscription, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	if !r.inTx {
+		return Subscription{}, errors.New("GetByIDForUpdate requires a transaction")
+	}
+
+	ds := r.builder.From("subscriptions").Select(subscriptionColumns...).
+		Where(goqu.C("id").Eq(id)).
+		ForUpdate(exp.Wait)
+
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return Subscription{}, fmt.Errorf("build lock subscription: %w", err)
+	}
+
+	var sub Subscription
+	if err := scanSubscription(r.db.QueryRowContext(ctx, query, args...), &sub); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Subscription{}, err
+		}
+		if r.logger != nil {
+			r.logger.Error("lock subscription failed", "id", id, "error", err)
+		}
+		return Subscription{}, fmt.Errorf("lock subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// LockMatching loads up to limit subscriptions matching filter, ordered by
+// ID, and locks their rows until the surrounding transaction ends. It must
+// be called from within InTx.
+func (r *Repository) LockMatching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error) {
+	if !r.inTx {
+		return nil, errors.New("LockMatching requires a transaction")
+	}
+	return r.matching(ctx, r.matchingDataset(filter, limit).ForUpdate(exp.Wait))
+}
+
+// FindMatching loads up to limit subscriptions matching filter, ordered by
+// ID, without locking them.
+func (r *Repository) FindMatching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error) {
+	return r.matching(ctx, r.matchingDataset(filter, limit))
+}
+
+func (r *Repository) matchingDataset(filter BulkFilter, limit int) *goqu.SelectDataset {
+	ds := r.builder.From("subscriptions").Select(subscriptionColumns...).
+		Order(goqu.C("id").Asc()).
+		Limit(uint(limit))
+	if filter.ServiceName != nil {
+		ds = ds.Where(goqu.Func("LOWER", goqu.C("service_name")).Eq(strings.ToLower(*filter.ServiceName)))
+	}
+	if filter.UserID != nil {
+		ds = ds.Where(goqu.C("user_id").Eq(*filter.UserID))
+	}
+	return ds
+}
+
+func (r *Repository) matching(ctx context.Context, ds *goqu.SelectDataset) ([]Subscription, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.List)
+	defer cancel()
+
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("build matching subscriptions: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select matching subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var sub Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return nil, fmt.Errorf("scan subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return subs, nil
 }
 
 func (r *Repository) Create(ctx context.Context, params CreateParams) (Subscription, error) {
-	stmt := r.builder.Insert("subscriptions").Rows(goqu.Record{
-		"service_name": params.ServiceName,
-		"price_rub":    params.PriceRUB,
-		"user_id":      params.UserID,
-		"start_month":  params.StartMonth,
-		"end_month":    params.EndMonth,
-	}).Returning(
-		"id", "service_name", "price_rub", "user_id", "start_month", "end_month", "created_at", "updated_at",
-	)
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Write)
+	defer cancel()
+
+	record := goqu.Record{
+		"service_name":        params.ServiceName,
+		"price_rub":           params.PriceRUB,
+		"currency":            params.Currency,
+		"amount_minor":        params.AmountMinor,
+		"plan_id":             params.PlanID,
+		"user_id":             params.UserID,
+		"start_month":         params.StartMonth,
+		"end_month":           params.EndMonth,
+		"end_month_inclusive": params.EndMonthInclusive,
+		"slug":                nextSlug(params.UserID, slugBase(params.ServiceName)),
+	}
+	if params.BillingPeriod != "" {
+		record["billing_period"] = params.BillingPeriod
+	}
+	if params.Status != "" {
+		record["status"] = params.Status
+	}
+	if len(params.Tags) > 0 {
+		record["tags"] = pq.Array(params.Tags)
+	}
+	// IDs are normally generated by the service; the column default remains as
+	// a fallback for callers that leave it empty.
+	if params.ID != uuid.Nil {
+		record["id"] = params.ID
+	}
+
+	stmt := r.builder.Insert("subscriptions").Rows(record).Returning(subscriptionColumns...)
 
 	query, args, err := stmt.ToSQL()
 	if err != nil {
 		return Subscription{}, fmt.Errorf("build insert subscription: %w", err)
 	}
+	query = syncWritten(query)
 
 	var sub Subscription
-	if err := r.db.QueryRowContext(ctx, query, args...).Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.PriceRUB,
-		&sub.UserID,
-		&sub.StartMonth,
-		&sub.EndMonth,
-		&sub.CreatedAt,
-		&sub.UpdatedAt,
-	); err != nil {
+	for attempt := 1; ; attempt++ {
+		err = scanSubscription(r.db.QueryRowContext(ctx, query, args...), &sub)
+		// A failed statement aborts the surrounding transaction, so a slug
+		// race can only be retried outside one.
+		if err == nil || !isSlugConflict(err) || r.inTx || attempt == slugInsertTries {
+			break
+		}
+	}
+	if err != nil {
 		if r.logger != nil {
 			r.logger.Error("insert subscription failed", "error", err)
 		}
-		return Subscription{}, fmt.Errorf("insert subscription: %w", err)
+		return Subscription{}, fmt.Errorf("insert subscription: %w", constraintError(err))
 	}
 
 	return sub, nil
 }
 
+// syncWritten wraps a statement returning subscription rows so that it also
+// keeps the tables derived from them current, in the same statement:
+//   - subscription_prices gets their price in kopecks, as the price-to-money
+//     backfill filled it;
+//   - subscription_pauses opens a pause from the current month for a row that
+//     became paused and closes the open one of a row that became active again.
+func syncWritten(query string) string {
+	return `WITH written AS (` + query + `),
+synced AS (
+    INSERT INTO subscription_prices (subscription_id, amount_minor, currency)
+    SELECT id, price_rub::bigint * 100, 'RUB' FROM written
+    ON CONFLICT (subscription_id) DO UPDATE
+    SET amount_minor = EXCLUDED.amount_minor
+    WHERE subscription_prices.amount_minor <> EXCLUDED.amount_minor
+),
+paused AS (
+    INSERT INTO subscription_pauses (subscription_id, paused_month)
+    SELECT w.id, date_trunc('month', now())::date FROM written w
+    WHERE w.status = 'paused'
+      AND NOT EXISTS (
+          SELECT 1 FROM subscription_pauses p
+          WHERE p.subscription_id = w.id AND p.resumed_month IS NULL
+      )
+),
+resumed AS (
+    UPDATE subscription_pauses p
+    SET resumed_month = GREATEST(p.paused_month, date_trunc('month', now())::date), resumed_at = now()
+    FROM written w
+    WHERE p.subscription_id = w.id AND w.status = 'active' AND p.resumed_month IS NULL
+)
+SELECT * FROM written`
+}
+
 func (r *Repository) GetByID(ctx context.Context, id string) (Subscription, error) {
-	ds := r.builder.From("subscriptions").Select(
-		"id", "service_name", "price_rub", "user_id", "start_month", "end_month", "created_at", "updated_at",
-	).Where(goqu.C("id").Eq(id))
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	ds := r.builder.From("subscriptions").Select(subscriptionColumns...).Where(goqu.C("id").Eq(id))
 
 	query, args, err := ds.ToSQL()
 	if err != nil {
@@ -92,16 +483,7 @@ func (r *Repository) GetByID(ctx context.Context, id string) (Subscription, erro
 	}
 
 	var sub Subscription
-	if err := r.db.QueryRowContext(ctx, query, args...).Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.PriceRUB,
-		&sub.UserID,
-		&sub.StartMonth,
-		&sub.EndMonth,
-		&sub.CreatedAt,
-		&sub.UpdatedAt,
-	); err != nil {
+	if err := scanSubscription(r.db.QueryRowContext(ctx, query, args...), &sub); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Subscription{}, err
 		}
@@ -114,6 +496,45 @@ func (r *Repository) GetByID(ctx context.Context, id string) (Subscription, erro
 	return sub, nil
 }
 
+// GetByIDs loads the subscriptions among ids in one query, in no particular
+// order. Unknown IDs are left out.
+func (r *Repository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]Subscription, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return []Subscription{}, nil
+	}
+	query, args, err := r.builder.From("subscriptions").Select(subscriptionColumns...).
+		Where(goqu.C("id").In(ids)).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("build get subscriptions: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("get subscriptions failed", "count", len(ids), "error", err)
+		}
+		return nil, fmt.Errorf("select subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := make([]Subscription, 0, len(ids))
+	for rows.Next() {
+		var sub Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return nil, fmt.Errorf("scan subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return subs, nil
+}
+
 func (r *Repository) List(ctx context.Context, opts ListOptions) ([]Subscription, int, error) {
 	limit := opts.Limit
 	if limit <= 0 {
@@ -123,10 +544,127 @@ func (r *Repository) List(ctx context.Context, opts ListOptions) ([]Subscription
 	if offset < 0 {
 		offset = 0
 	}
+	return r.list(ctx, r.listWhere(opts), limit, offset, opts.Sort)
+}
+
+func (r *Repository) listWhere(opts ListOptions) []goqu.Expression {
+	var where []goqu.Expression
+	if opts.UserID != nil {
+		where = append(where, goqu.C("user_id").Eq(*opts.UserID))
+	}
+	if opts.Status != "" {
+		where = append(where, statusWhere(opts.Status, r.endInclusive))
+	}
+	return where
+}
+
+// ListVersion returns the row count and latest updated_at of the rows List
+// would page through, which together change whenever the collection does.
+func (r *Repository) ListVersion(ctx context.Context, opts ListOptions) (CollectionVersion, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.List)
+	defer cancel()
+
+	query, args, err := r.builder.From("subscriptions").
+		Select(goqu.COUNT("*"), goqu.MAX("updated_at")).
+		Where(r.listWhere(opts)...).
+		ToSQL()
+	if err != nil {
+		return CollectionVersion{}, fmt.Errorf("build list version: %w", err)
+	}
+
+	var (
+		version      CollectionVersion
+		lastModified sql.NullTime
+	)
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&version.Count, &lastModified); err != nil {
+		return CollectionVersion{}, fmt.Errorf("list version: %w", err)
+	}
+	if lastModified.Valid {
+		version.LastModified = lastModified.Time
+	}
+	return version, nil
+}
+
+// Stream calls fn for every subscription matching opts, reading rows from a
+// single query as they arrive. Limit caps the row count when positive; Offset
+// is ignored. No default deadline applies, since a stream's length depends on
+// the data; cancel ctx to stop it.
+func (r *Repository) Stream(ctx context.Context, opts ListOptions, fn func(Subscription) error) error {
+	ds := r.builder.From("subscriptions").Select(subscriptionColumns...).
+		Where(r.listWhere(opts)...).
+		Order(listOrdering.Expressions(opts.Sort)...)
+	if opts.Limit > 0 {
+		ds = ds.Limit(uint(opts.Limit))
+	}
+
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return fmt.Errorf("build stream subscriptions: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("stream subscriptions query failed", "error", err)
+		}
+		return fmt.Errorf("stream subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sub Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return fmt.Errorf("scan subscription: %w", err)
+		}
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+	return nil
+}
 
-	listDS := r.builder.From("subscriptions").Select(
-		"id", "service_name", "price_rub", "user_id", "start_month", "end_month", "created_at", "updated_at",
-	).Order(goqu.I("created_at").Desc()).Limit(uint(limit)).Offset(uint(offset))
+// Search lists subscriptions matching a compiled search filter.
+func (r *Repository) Search(ctx context.Context, q SearchQuery) ([]Subscription, int, error) {
+	var where []goqu.Expression
+	if q.Filter != nil {
+		expr, err := compileSearch(*q.Filter, r.endInclusive)
+		if err != nil {
+			return nil, 0, err
+		}
+		where = append(where, expr)
+	}
+	if q.UserID != nil {
+		where = append(where, goqu.C("user_id").Eq(*q.UserID))
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	return r.list(ctx, where, limit, offset, q.Sort)
+}
+
+func (r *Repository) list(ctx context.Context, where []goqu.Expression, limit, offset int, sort Sort) ([]Subscription, int, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.List)
+	defer cancel()
+	r, release, err := r.bounded(ctx, r.statements.List)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer release()
+
+	listDS := r.builder.From("subscriptions").Select(subscriptionColumns...).
+		Where(where...).
+		Order(listOrdering.Expressions(sort)...).
+		Limit(uint(limit)).
+		Offset(uint(offset))
 
 	query, args, err := listDS.ToSQL()
 	if err != nil {
@@ -145,16 +683,7 @@ func (r *Repository) List(ctx context.Context, opts ListOptions) ([]Subscription
 	var subs []Subscription
 	for rows.Next() {
 		var sub Subscription
-		if err := rows.Scan(
-			&sub.ID,
-			&sub.ServiceName,
-			&sub.PriceRUB,
-			&sub.UserID,
-			&sub.StartMonth,
-			&sub.EndMonth,
-			&sub.CreatedAt,
-			&sub.UpdatedAt,
-		); err != nil {
+		if err := scanSubscription(rows, &sub); err != nil {
 			return nil, 0, fmt.Errorf("scan subscription: %w", err)
 		}
 		subs = append(subs, sub)
@@ -163,7 +692,7 @@ func (r *Repository) List(ctx context.Context, opts ListOptions) ([]Subscription
 		return nil, 0, fmt.Errorf("rows error: %w", err)
 	}
 
-	countDS := r.builder.From("subscriptions").Select(goqu.COUNT("*"))
+	countDS := r.builder.From("subscriptions").Select(goqu.COUNT("*")).Where(where...)
 	countQuery, countArgs, err := countDS.ToSQL()
 	if err != nil {
 		return nil, 0, fmt.Errorf("build count subscriptions: %w", err)
@@ -178,6 +707,9 @@ func (r *Repository) List(ctx context.Context, opts ListOptions) ([]Subscription
 }
 
 func (r *Repository) Update(ctx context.Context, params UpdateParams) (Subscription, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Write)
+	defer cancel()
+
 	updates := goqu.Record{}
 
 	if params.ServiceName != nil {
@@ -186,9 +718,43 @@ func (r *Repository) Update(ctx context.Context, params UpdateParams) (Subscript
 	if params.PriceRUB != nil {
 		updates["price_rub"] = *params.PriceRUB
 	}
+	if params.Currency != nil {
+		updates["currency"] = *params.Currency
+	}
+	if params.AmountMinor != nil {
+		updates["amount_minor"] = *params.AmountMinor
+	}
+	if params.BillingPeriod != nil {
+		updates["billing_period"] = *params.BillingPeriod
+	}
+	if params.Status != nil {
+		updates["status"] = *params.Status
+	}
+	if params.Tags != nil {
+		updates["tags"] = pq.Array(*params.Tags)
+	}
+	if params.PlanID != nil {
+		if *params.PlanID == 0 {
+			updates["plan_id"] = nil
+		} else {
+			updates["plan_id"] = *params.PlanID
+		}
+	}
 	if params.StartMonth != nil {
 		updates["start_month"] = *params.StartMonth
 	}
+	if params.EndMonthInclusive != nil {
+		updates["end_month_inclusive"] = *params.EndMonthInclusive
+	}
+	if params.UserID != nil {
+		updates["user_id"] = *params.UserID
+		// Slugs are unique per owner, so a transfer numbers the subscription
+		// among the new owner's, keeping its current base.
+		updates["slug"] = nextSlug(*params.UserID, goqu.L(`regexp_replace(subscriptions.slug, '-[0-9]+$', '')`))
+	}
+	if params.Locked != nil {
+		updates["locked"] = *params.Locked
+	}
 	if params.EndMonthSet {
 		if params.EndMonth != nil {
 			updates["end_month"] = *params.EndMonth
@@ -206,37 +772,32 @@ func (r *Repository) Update(ctx context.Context, params UpdateParams) (Subscript
 	ds := r.builder.Update("subscriptions").
 		Set(updates).
 		Where(goqu.C("id").Eq(params.ID)).
-		Returning("id", "service_name", "price_rub", "user_id", "start_month", "end_month", "created_at", "updated_at")
+		Returning(subscriptionColumns...)
 
 	query, args, err := ds.ToSQL()
 	if err != nil {
 		return Subscription{}, fmt.Errorf("build update subscription: %w", err)
 	}
+	query = syncWritten(query)
 
 	var sub Subscription
-	if err := r.db.QueryRowContext(ctx, query, args...).Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.PriceRUB,
-		&sub.UserID,
-		&sub.StartMonth,
-		&sub.EndMonth,
-		&sub.CreatedAt,
-		&sub.UpdatedAt,
-	); err != nil {
+	if err := scanSubscription(r.db.QueryRowContext(ctx, query, args...), &sub); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Subscription{}, err
 		}
 		if r.logger != nil {
 			r.logger.Error("update subscription failed", "id", params.ID, "error", err)
 		}
-		return Subscription{}, fmt.Errorf("update subscription: %w", err)
+		return Subscription{}, fmt.Errorf("update subscription: %w", constraintError(err))
 	}
 
 	return sub, nil
 }
 
 func (r *Repository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Write)
+	defer cancel()
+
 	ds := r.builder.Delete("subscriptions").Where(goqu.C("id").Eq(id))
 	query, args, err := ds.ToSQL()
 	if err != nil {
@@ -264,127 +825,3 @@ func (r *Repository) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
-
-const sumByPeriodSQL = `
-WITH ranges AS (
-    SELECT
-        s.price_rub,
-        GREATEST(s.start_month, COALESCE($1::date, s.start_month)) AS eff_start,
-        LEAST(
-            COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)),
-            COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
-        ) AS eff_end
-    FROM subscriptions s
-    WHERE ($3::uuid IS NULL OR s.user_id = $3::uuid)
-      AND ($4::text IS NULL OR LOWER(s.service_name) = LOWER($4::text))
-      AND s.start_month <= COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
-      AND COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)) >= COALESCE($1::date, s.start_month)
-)
-SELECT COALESCE(SUM(
-    price_rub *
-    (
-        (DATE_PART('year', eff_end) - DATE_PART('year', eff_start)) * 12 +
-        (DATE_PART('month', eff_end) - DATE_PART('month', eff_start)) + 1
-    )
-), 0)
-FROM ranges
-WHERE eff_end >= eff_start;
-`
-
-func (r *Repository) SumByPeriod(ctx context.Context, filter SumFilter) (int, error) {
-	var (
-		start interface{}
-		end   interface{}
-		user  interface{}
-		name  interface{}
-	)
-
-	if filter.StartMonth != nil {
-		start = normalizeMonth(*filter.StartMonth)
-	}
-	if filter.EndMonth != nil {
-		end = normalizeMonth(*filter.EndMonth)
-	}
-	if filter.UserID != nil {
-		user = *filter.UserID
-	}
-	if filter.ServiceName != nil {
-		name = strings.TrimSpace(*filter.ServiceName)
-		if name == "" {
-			name = nil
-		}
-	}
-
-	var total sql.NullInt64
-	if err := r.db.QueryRowContext(ctx, sumByPeriodSQL, start, end, user, name).Scan(&total); err != nil {
-		return 0, fmt.Errorf("sum subscriptions: %w", err)
-	}
-	if !total.Valid {
-		return 0, nil
-	}
-	return int(total.Int64), nil
-}
-
-func monthsBetween(start, end time.Time) int {
-	start = normalizeMonth(start)
-	end = normalizeMonth(end)
-	if end.Before(start) {
-		return 0
-	}
-	years := end.Year() - start.Year()
-	months := int(end.Month()) - int(start.Month())
-	return years*12 + months + 1
-}
-
-func clampRange(subStart time.Time, subEnd sql.NullTime, periodStart, periodEnd *time.Time) (time.Time, time.Time, bool) {
-	start := normalizeMonth(subStart)
-
-	var subEndNorm *time.Time
-	if subEnd.Valid {
-		t := normalizeMonth(subEnd.Time)
-		subEndNorm = &t
-	}
-
-	if periodStart != nil {
-		ps := normalizeMonth(*periodStart)
-		if ps.After(start) {
-			start = ps
-		}
-	}
-
-	var end time.Time
-	switch {
-	case subEndNorm != nil && periodEnd != nil:
-		pe := normalizeMonth(*periodEnd)
-		if pe.Before(*subEndNorm) {
-			end = pe
-		} else {
-			end = *subEndNorm
-		}
-	case subEndNorm != nil && periodEnd == nil:
-		end = *subEndNorm
-	case subEndNorm == nil && periodEnd != nil:
-		end = normalizeMonth(*periodEnd)
-	default:
-		end = normalizeMonth(time.Now().UTC())
-	}
-
-	if end.Before(start) {
-		return time.Time{}, time.Time{}, false
-	}
-	return start, end, true
-}
-
-func maxTime(a, b time.Time) time.Time {
-	if a.After(b) {
-		return a
-	}
-	return b
-}
-
-func minTime(a, b time.Time) time.Time {
-	if a.Before(b) {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,141 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+)
+
+// materializeChargesSQL writes one charge per subscription charged in month $1
+// and marks the month closed, in one statement. Nothing is written for a
+// month that is already closed.
+var materializeChargesSQL = `
+WITH subs AS (` + runningSubscriptionsSQL(2, 1) + `),
+inserted AS (
+    INSERT INTO charges (subscription_id, month, user_id, service_name, amount)
+    SELECT id, $1::date, user_id, service_name, ROUND(monthly_rub)
+    FROM subs
+    WHERE start_month <= $1::date
+      AND (end_month IS NULL OR end_month >= $1::date)
+      AND NOT EXISTS (SELECT 1 FROM charge_months WHERE month = $1::date)
+    ON CONFLICT (subscription_id, month) DO NOTHING
+    RETURNING 1
+)
+INSERT INTO charge_months (month, charges)
+SELECT $1::date, COUNT(*) FROM inserted
+ON CONFLICT (month) DO NOTHING
+RETURNING charges;
+`
+
+const sumChargesSQL = `
+SELECT COALESCE(SUM(amount), 0)::text
+FROM charges
+WHERE ($1::date IS NULL OR month >= $1::date)
+  AND month <= $2::date
+  AND ($3::uuid IS NULL OR user_id = $3::uuid)
+  AND ($4::text IS NULL OR LOWER(service_name) = LOWER($4::text));
+`
+
+// LedgerWatermark returns the last closed month in the charges ledger, or nil
+// when nothing has been materialized yet.
+func (r *Repository) LedgerWatermark(ctx context.Context) (*time.Time, error) {
+	var month sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(month) FROM charge_months`).Scan(&month); err != nil {
+		return nil, fmt.Errorf("read ledger watermark: %w", err)
+	}
+	if !month.Valid {
+		return nil, nil
+	}
+	watermark := months.Normalize(month.Time)
+	return &watermark, nil
+}
+
+// EarliestStart returns the first month any subscription starts in, or nil
+// when there are none. The ledger job starts from it on an empty ledger.
+func (r *Repository) EarliestStart(ctx context.Context) (*time.Time, error) {
+	var month sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MIN(start_month) FROM subscriptions`).Scan(&month); err != nil {
+		return nil, fmt.Errorf("read earliest start: %w", err)
+	}
+	if !month.Valid {
+		return nil, nil
+	}
+	start := months.Normalize(month.Time)
+	return &start, nil
+}
+
+// MaterializeCharges writes the charges for month and closes it. It reports
+// how many charges were written, and false if the month was already closed.
+func (r *Repository) MaterializeCharges(ctx context.Context, month time.Time) (int64, bool, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+
+	var written int64
+	err := r.db.QueryRowContext(ctx, materializeChargesSQL, months.Normalize(month), r.endInclusive).Scan(&written)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("materialize charges for %s: %w", month.Format(layoutYearMonth), err)
+	}
+	return written, true, nil
+}
+
+// sumWithLedger sums closed months from the charges ledger and computes only
+// the months after the watermark from subscriptions.
+func (r *Repository) sumWithLedger(ctx context.Context, filter SumFilter) (int64, error) {
+	watermark, err := r.LedgerWatermark(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if watermark == nil || (filter.StartMonth != nil && months.Normalize(*filter.StartMonth).After(*watermark)) {
+		return r.sumLive(ctx, filter)
+	}
+
+	if filter.EndMonth != nil && !months.Normalize(*filter.EndMonth).After(*watermark) {
+		return r.sumCharges(ctx, filter, months.Normalize(*filter.EndMonth))
+	}
+	closed, err := r.sumCharges(ctx, filter, *watermark)
+	if err != nil {
+		return 0, err
+	}
+
+	open := filter
+	liveStart := watermark.AddDate(0, 1, 0)
+	open.StartMonth = &liveStart
+	live, err := r.sumLive(ctx, open)
+	if err != nil {
+		return 0, err
+	}
+	if live > math.MaxInt64-closed {
+		return 0, fmt.Errorf("%w: ledger %d + live %d", ErrTotalOverflow, closed, live)
+	}
+	return closed + live, nil
+}
+
+func (r *Repository) sumCharges(ctx context.Context, filter SumFilter, end time.Time) (int64, error) {
+	var start, user, name interface{}
+	if filter.StartMonth != nil {
+		start = months.Normalize(*filter.StartMonth)
+	}
+	if filter.UserID != nil {
+		user = *filter.UserID
+	}
+	if filter.ServiceName != nil {
+		if trimmed := strings.TrimSpace(*filter.ServiceName); trimmed != "" {
+			name = trimmed
+		}
+	}
+
+	var raw string
+	if err := r.db.QueryRowContext(ctx, sumChargesSQL, start, end, user, name).Scan(&raw); err != nil {
+		return 0, fmt.Errorf("sum charges: %w", err)
+	}
+	return parseTotal(raw)
+}
@@ -0,0 +1,517 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/billing"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+// chargedSubscriptionsSQL selects the charged stretches of subscriptions, with
+// end_month rewritten to the last month that is actually charged.
+// Subscriptions whose end is exclusive ("until March starts") stop one month
+// earlier; rows without an explicit end_month_inclusive fall back to the
+// deployment default bound at $n. A subscription is split around its pauses
+// (chargedSegmentsSQL) into one row per stretch, so summaries only leave out
+// the paused months; the rows of one subscription never share a month.
+// Stretches emptied by a pause come out with end_month before start_month.
+// monthly_rub is the price spread over the months of its billing period; cost
+// totals sum it and round once.
+func chargedSubscriptionsSQL(n int) string {
+	return fmt.Sprintf(`
+    SELECT
+        id,
+        user_id,
+        service_name,
+        price_rub,
+        %s AS monthly_rub,
+        GREATEST(start_month, seg.from_month) AS start_month,
+        LEAST(%s, seg.to_month) AS end_month
+    FROM subscriptions
+    CROSS JOIN LATERAL (%s) seg
+`, billing.MonthlyPriceSQL("price_rub", "billing_period"),
+		months.LastChargedSQL("end_month", fmt.Sprintf("COALESCE(end_month_inclusive, $%d::boolean)", n)),
+		chargedSegmentsSQL)
+}
+
+// chargedSegmentsSQL lists the stretches of the subscription in the outer
+// query between its pauses, as from_month and to_month bounds where NULL
+// means unbounded (GREATEST and LEAST skip NULLs). Without pauses it is one
+// unbounded stretch; an open pause has no stretch after it.
+const chargedSegmentsSQL = `
+        SELECT NULL::date AS from_month, NULL::date AS to_month
+        WHERE NOT EXISTS (SELECT 1 FROM subscription_pauses p WHERE p.subscription_id = subscriptions.id)
+        UNION ALL
+        SELECT
+            LAG(p.resumed_month) OVER (ORDER BY p.paused_at),
+            (p.paused_month - interval '1 month')::date
+        FROM subscription_pauses p
+        WHERE p.subscription_id = subscriptions.id
+        UNION ALL
+        SELECT latest.resumed_month, NULL
+        FROM (
+            SELECT p.resumed_month
+            FROM subscription_pauses p
+            WHERE p.subscription_id = subscriptions.id
+            ORDER BY p.paused_at DESC
+            LIMIT 1
+        ) latest
+        WHERE latest.resumed_month IS NOT NULL
+    `
+
+// runningSubscriptionsSQL is chargedSubscriptionsSQL limited to rows that may
+// be charged in the month bound at $m: started by then and not ended before
+// it. The charged end never exceeds the stored one, so callers still apply
+// their exact range check. The filter is split into ongoing and ended rows
+// because an OR over end_month defeats the indexes; each branch has its own
+// (subscriptions_ongoing_idx and subscriptions_period_idx).
+func runningSubscriptionsSQL(n, m int) string {
+	charged := chargedSubscriptionsSQL(n)
+	return fmt.Sprintf(`%[1]s    WHERE end_month IS NULL AND start_month <= $%[2]d::date
+    UNION ALL%[1]s    WHERE end_month >= $%[2]d::date AND start_month <= $%[2]d::date
+`, charged, m)
+}
+
+// chargedMonthsSQL counts the months from eff_start to eff_end inclusive.
+var chargedMonthsSQL = months.BetweenSQL("eff_start", "eff_end")
+
+// Totals are summed as numeric in SQL, which cannot overflow, and returned as
+// text so parseTotal can reject values that do not fit an int64 instead of
+// letting them wrap.
+func parseTotal(raw string) (int64, error) {
+	total, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%w: %s", ErrTotalOverflow, raw)
+		}
+		return 0, fmt.Errorf("parse total %q: %w", raw, err)
+	}
+	return total, nil
+}
+
+var sumByPeriodSQL = `
+WITH subs AS (` + chargedSubscriptionsSQL(5) + `),
+ranges AS (
+    SELECT
+        s.monthly_rub,
+        GREATEST(s.start_month, COALESCE($1::date, s.start_month)) AS eff_start,
+        LEAST(
+            COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)),
+            COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+        ) AS eff_end
+    FROM subs s
+    WHERE ($3::uuid IS NULL OR s.user_id = $3::uuid)
+      AND ($4::text IS NULL OR LOWER(s.service_name) = LOWER($4::text))
+      AND s.start_month <= COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+      AND COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)) >= COALESCE($1::date, s.start_month)
+)
+SELECT COALESCE(ROUND(SUM(monthly_rub * ` + chargedMonthsSQL + `)), 0)::text
+FROM ranges
+WHERE eff_end >= eff_start;
+`
+
+func (r *Repository) SumByPeriod(ctx context.Context, filter SumFilter) (int64, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+	r, release, err := r.bounded(ctx, r.statements.Summary)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	if r.ledgerReads {
+		return r.sumWithLedger(ctx, filter)
+	}
+	return r.sumLive(ctx, filter)
+}
+
+// sumLive computes the total from the subscriptions table.
+func (r *Repository) sumLive(ctx context.Context, filter SumFilter) (int64, error) {
+	var (
+		start interface{}
+		end   interface{}
+		user  interface{}
+		name  interface{}
+	)
+
+	if filter.StartMonth != nil {
+		start = months.Normalize(*filter.StartMonth)
+	}
+	if filter.EndMonth != nil {
+		end = months.Normalize(*filter.EndMonth)
+	}
+	if filter.UserID != nil {
+		user = *filter.UserID
+	}
+	if filter.ServiceName != nil {
+		name = strings.TrimSpace(*filter.ServiceName)
+		if name == "" {
+			name = nil
+		}
+	}
+
+	var raw string
+	if err := r.db.QueryRowContext(ctx, sumByPeriodSQL, start, end, user, name, r.endInclusive).Scan(&raw); err != nil {
+		return 0, fmt.Errorf("sum subscriptions: %w", err)
+	}
+	return parseTotal(raw)
+}
+
+var sumByUsersSQL = `
+WITH subs AS (` + chargedSubscriptionsSQL(5) + `),
+ranges AS (
+    SELECT
+        s.user_id,
+        s.monthly_rub,
+        GREATEST(s.start_month, COALESCE($1::date, s.start_month)) AS eff_start,
+        LEAST(
+            COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)),
+            COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+        ) AS eff_end
+    FROM subs s
+    WHERE s.user_id = ANY($3::uuid[])
+      AND ($4::text IS NULL OR LOWER(s.service_name) = LOWER($4::text))
+      AND s.start_month <= COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+      AND COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)) >= COALESCE($1::date, s.start_month)
+)
+SELECT user_id, COALESCE(ROUND(SUM(monthly_rub * ` + chargedMonthsSQL + `)), 0)::text
+FROM ranges
+WHERE eff_end >= eff_start
+GROUP BY user_id;
+`
+
+// SumByUsers computes one total per requested user in a single grouped query.
+// Users without matching subscriptions are reported with a zero total.
+func (r *Repository) SumByUsers(ctx context.Context, filter BatchSumFilter) (map[uuid.UUID]int64, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+	r, release, err := r.bounded(ctx, r.statements.Summary)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var (
+		start interface{}
+		end   interface{}
+		name  interface{}
+	)
+
+	if filter.StartMonth != nil {
+		start = months.Normalize(*filter.StartMonth)
+	}
+	if filter.EndMonth != nil {
+		end = months.Normalize(*filter.EndMonth)
+	}
+	if filter.ServiceName != nil {
+		name = strings.TrimSpace(*filter.ServiceName)
+		if name == "" {
+			name = nil
+		}
+	}
+
+	totals := make(map[uuid.UUID]int64, len(filter.UserIDs))
+	users := make([]string, 0, len(filter.UserIDs))
+	for _, id := range filter.UserIDs {
+		totals[id] = 0
+		users = append(users, id.String())
+	}
+	if len(users) == 0 {
+		return totals, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, sumByUsersSQL, start, end, pq.Array(users), name, r.endInclusive)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("sum subscriptions by users failed", "error", err)
+		}
+		return nil, fmt.Errorf("sum subscriptions by users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			userID uuid.UUID
+			raw    string
+		)
+		if err := rows.Scan(&userID, &raw); err != nil {
+			return nil, fmt.Errorf("scan user total: %w", err)
+		}
+		if totals[userID], err = parseTotal(raw); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return totals, nil
+}
+
+// sumByTagsSQL totals the cost per tag, counting a subscription in each of
+// its tags or, when $6 is set, only in its first one. Untagged subscriptions
+// come out under a NULL tag. Each tag's total is rounded once, like the
+// overall total.
+var sumByTagsSQL = `
+WITH subs AS (` + chargedSubscriptionsSQL(5) + `),
+ranges AS (
+    SELECT
+        t.tags,
+        s.monthly_rub,
+        GREATEST(s.start_month, COALESCE($1::date, s.start_month)) AS eff_start,
+        LEAST(
+            COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)),
+            COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+        ) AS eff_end
+    FROM subs s
+    JOIN subscriptions t ON t.id = s.id
+    WHERE ($3::uuid IS NULL OR s.user_id = $3::uuid)
+      AND ($4::text IS NULL OR LOWER(s.service_name) = LOWER($4::text))
+      AND s.start_month <= COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+      AND COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)) >= COALESCE($1::date, s.start_month)
+)
+SELECT tag, COALESCE(ROUND(SUM(monthly_rub * ` + chargedMonthsSQL + `)), 0)::text
+FROM ranges
+LEFT JOIN LATERAL unnest(CASE WHEN $6::boolean THEN ranges.tags[1:1] ELSE ranges.tags END) AS tag ON true
+WHERE eff_end >= eff_start
+GROUP BY tag
+ORDER BY SUM(monthly_rub * ` + chargedMonthsSQL + `) DESC, tag NULLS LAST;
+`
+
+// SumByTags computes the cost per tag from the subscriptions table; the
+// ledger does not record tags.
+func (r *Repository) SumByTags(ctx context.Context, filter SumFilter, mode TagMode) ([]TagTotal, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+	r, release, err := r.bounded(ctx, r.statements.Summary)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var (
+		start interface{}
+		end   interface{}
+		user  interface{}
+		name  interface{}
+	)
+
+	if filter.StartMonth != nil {
+		start = months.Normalize(*filter.StartMonth)
+	}
+	if filter.EndMonth != nil {
+		end = months.Normalize(*filter.EndMonth)
+	}
+	if filter.UserID != nil {
+		user = *filter.UserID
+	}
+	if filter.ServiceName != nil {
+		name = strings.TrimSpace(*filter.ServiceName)
+		if name == "" {
+			name = nil
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, sumByTagsSQL, start, end, user, name, r.endInclusive, mode == TagModePrimary)
+	if err != nil {
+		return nil, fmt.Errorf("sum subscriptions by tags: %w", err)
+	}
+	defer rows.Close()
+
+	totals := []TagTotal{}
+	for rows.Next() {
+		var (
+			tag sql.NullString
+			raw string
+		)
+		if err := rows.Scan(&tag, &raw); err != nil {
+			return nil, fmt.Errorf("scan tag total: %w", err)
+		}
+		total := TagTotal{}
+		if tag.Valid {
+			total.Tag = &tag.String
+		}
+		if total.TotalPrice, err = parseTotal(raw); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return totals, nil
+}
+
+var sumTimeSeriesSQL = `
+WITH subs AS (` + chargedSubscriptionsSQL(6) + `),
+ranges AS (
+    SELECT
+        s.monthly_rub,
+        GREATEST(s.start_month, COALESCE($1::date, s.start_month)) AS eff_start,
+        LEAST(
+            COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)),
+            COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+        ) AS eff_end
+    FROM subs s
+    WHERE ($3::uuid IS NULL OR s.user_id = $3::uuid)
+      AND ($4::text IS NULL OR LOWER(s.service_name) = LOWER($4::text))
+      AND s.start_month <= COALESCE($2::date, COALESCE(s.end_month, CURRENT_DATE))
+      AND COALESCE(s.end_month, COALESCE($2::date, CURRENT_DATE)) >= COALESCE($1::date, s.start_month)
+),
+months AS (
+    SELECT monthly_rub, generate_series(eff_start, eff_end, interval '1 month')::date AS month
+    FROM ranges
+    WHERE eff_end >= eff_start
+)
+SELECT date_trunc($5::text, month)::date AS period, ROUND(SUM(monthly_rub))::text
+FROM months
+GROUP BY period
+ORDER BY period;
+`
+
+// SumTimeSeries returns the cost per calendar bucket, bucketing in SQL with
+// date_trunc. Buckets without any charge are omitted.
+func (r *Repository) SumTimeSeries(ctx context.Context, filter SumFilter, granularity Granularity) ([]TimeSeriesPoint, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+	r, release, err := r.bounded(ctx, r.statements.Summary)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var (
+		start interface{}
+		end   interface{}
+		user  interface{}
+		name  interface{}
+	)
+
+	if filter.StartMonth != nil {
+		start = months.Normalize(*filter.StartMonth)
+	}
+	if filter.EndMonth != nil {
+		end = months.Normalize(*filter.EndMonth)
+	}
+	if filter.UserID != nil {
+		user = *filter.UserID
+	}
+	if filter.ServiceName != nil {
+		name = strings.TrimSpace(*filter.ServiceName)
+		if name == "" {
+			name = nil
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, sumTimeSeriesSQL, start, end, user, name, string(granularity), r.endInclusive)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("subscription time series query failed", "error", err)
+		}
+		return nil, fmt.Errorf("sum subscriptions time series: %w", err)
+	}
+	defer rows.Close()
+
+	points := []TimeSeriesPoint{}
+	for rows.Next() {
+		var (
+			point TimeSeriesPoint
+			raw   string
+		)
+		if err := rows.Scan(&point.Period, &raw); err != nil {
+			return nil, fmt.Errorf("scan time series point: %w", err)
+		}
+		if point.TotalPrice, err = parseTotal(raw); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return points, nil
+}
+
+var spendDistributionSQL = `
+WITH subs AS (` + chargedSubscriptionsSQL(3) + `),
+months AS (
+    SELECT generate_series($1::date, $2::date, interval '1 month')::date AS month
+),
+spend AS (
+    SELECT m.month, s.user_id, SUM(s.monthly_rub) AS total
+    FROM months m
+    JOIN subs s
+      ON s.start_month <= m.month
+     AND (s.end_month IS NULL OR s.end_month >= m.month)
+    GROUP BY m.month, s.user_id
+)
+SELECT
+    month,
+    COUNT(*),
+    percentile_cont(0.5) WITHIN GROUP (ORDER BY total::float8),
+    percentile_cont(0.9) WITHIN GROUP (ORDER BY total::float8),
+    percentile_cont(0.99) WITHIN GROUP (ORDER BY total::float8),
+    AVG(total)::float8,
+    ROUND(SUM(total))::text
+FROM spend
+GROUP BY GROUPING SETS ((month), ())
+ORDER BY month NULLS FIRST;
+`
+
+// SpendDistribution computes percentiles of per-user monthly spend over the
+// period, overall and per month. The overall row comes from the empty
+// grouping set and has a NULL month.
+func (r *Repository) SpendDistribution(ctx context.Context, start, end time.Time) (SpendDistribution, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Summary)
+	defer cancel()
+	r, release, err := r.bounded(ctx, r.statements.Summary)
+	if err != nil {
+		return SpendDistribution{}, err
+	}
+	defer release()
+
+	rows, err := r.db.QueryContext(ctx, spendDistributionSQL, start, end, r.endInclusive)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Error("spend distribution query failed", "error", err)
+		}
+		return SpendDistribution{}, fmt.Errorf("spend distribution: %w", err)
+	}
+	defer rows.Close()
+
+	dist := SpendDistribution{Start: types.NewMonth(start), End: types.NewMonth(end), Trend: []MonthlySpendStats{}}
+	for rows.Next() {
+		var (
+			month sql.NullTime
+			stats SpendStats
+			raw   string
+		)
+		if err := rows.Scan(&month, &stats.Users, &stats.P50, &stats.P90, &stats.P99, &stats.Mean, &raw); err != nil {
+			return SpendDistribution{}, fmt.Errorf("scan spend stats: %w", err)
+		}
+		if stats.Total, err = parseTotal(raw); err != nil {
+			return SpendDistribution{}, err
+		}
+		if !month.Valid {
+			dist.Overall = stats
+			continue
+		}
+		dist.Trend = append(dist.Trend, MonthlySpendStats{Month: types.NewMonth(month.Time), SpendStats: stats})
+	}
+	if err := rows.Err(); err != nil {
+		return SpendDistribution{}, fmt.Errorf("rows error: %w", err)
+	}
+
+	return dist, nil
+}
@@ -0,0 +1,43 @@
+package subscription
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		want     int64
+		overflow bool
+	}{
+		{name: "zero", raw: "0", want: 0},
+		{name: "max int64", raw: "9223372036854775807", want: 9223372036854775807},
+		{name: "min int64", raw: "-9223372036854775808", want: -9223372036854775808},
+		{name: "just past max", raw: "9223372036854775808", overflow: true},
+		{name: "negative overflow", raw: "-9223372036854775809", overflow: true},
+		{name: "far past max", raw: "123456789012345678901234567890", overflow: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTotal(tt.raw)
+			if tt.overflow {
+				if !errors.Is(err, ErrTotalOverflow) {
+					t.Fatalf("parseTotal(%s) error = %v, want ErrTotalOverflow", tt.raw, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTotal(%s): %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseTotal(%s) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseTotal("12.5"); err == nil || errors.Is(err, ErrTotalOverflow) {
+		t.Errorf("parseTotal(12.5) error = %v, want a parse error", err)
+	}
+}
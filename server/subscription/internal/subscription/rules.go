@@ -0,0 +1,122 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RuleViolation names the validation rule an operation broke.
+type RuleViolation struct {
+	Rule    string
+	Message string
+}
+
+func (v *RuleViolation) Error() string {
+	return fmt.Sprintf("rule %s: %s", v.Rule, v.Message)
+}
+
+// Rules are deployment-wide sanity limits on subscription data, evaluated as a
+// ValidationHook so violations surface as ErrRejected. Zero values disable a
+// rule.
+type Rules struct {
+	// MaxPrice is the highest accepted monthly price.
+	MaxPrice int
+	// EarliestStart and LatestEnd bound the months a subscription may cover.
+	EarliestStart *time.Time
+	LatestEnd     *time.Time
+	// BannedServices are rejected service names, compared case-insensitively.
+	BannedServices []string
+	// WarnPrice is the monthly price above which a write is accepted with a
+	// price_high warning.
+	WarnPrice int
+	// WarnOnly reports violations as warnings instead of rejecting the write,
+	// e.g. while trying out new limits.
+	WarnOnly bool
+}
+
+// NewRules builds Rules from configuration values. Months use the same
+// formats as the API.
+func NewRules(maxPrice int, earliestStart, latestEnd string, banned []string) (Rules, error) {
+	rules := Rules{MaxPrice: maxPrice, BannedServices: banned}
+	if earliestStart != "" {
+		start, err := parseMonth(earliestStart)
+		if err != nil {
+			return Rules{}, fmt.Errorf("earliest start: %w", err)
+		}
+		rules.EarliestStart = &start
+	}
+	if latestEnd != "" {
+		end, err := parseMonth(latestEnd)
+		if err != nil {
+			return Rules{}, fmt.Errorf("latest end: %w", err)
+		}
+		rules.LatestEnd = &end
+	}
+	if rules.EarliestStart != nil && rules.LatestEnd != nil && rules.LatestEnd.Before(*rules.EarliestStart) {
+		return Rules{}, fmt.Errorf("latest end is before earliest start")
+	}
+	return rules, nil
+}
+
+func (r Rules) BeforeCreate(_ context.Context, params CreateParams) error {
+	return r.result(r.check(&params.ServiceName, &params.PriceRUB, &params.StartMonth, params.EndMonth), &params.PriceRUB)
+}
+
+func (r Rules) BeforeUpdate(_ context.Context, params UpdateParams) error {
+	var end *time.Time
+	if params.EndMonthSet {
+		end = params.EndMonth
+	}
+	return r.result(r.check(params.ServiceName, params.PriceRUB, params.StartMonth, end), params.PriceRUB)
+}
+
+func (r Rules) BeforeDelete(context.Context, string) error {
+	return nil
+}
+
+// result combines a violation with the price warning. Violations reject the
+// write unless WarnOnly is set.
+func (r Rules) result(violation *RuleViolation, price *int) error {
+	var warnings Warnings
+	if violation != nil {
+		if !r.WarnOnly {
+			return violation
+		}
+		warnings = append(warnings, Warning{Rule: violation.Rule, Message: violation.Message})
+	}
+	if price != nil && r.WarnPrice > 0 && *price > r.WarnPrice {
+		warnings = append(warnings, Warning{Rule: "price_high", Message: fmt.Sprintf("price %d is unusually high, above %d", *price, r.WarnPrice)})
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
+
+// check validates the fields being written; nil means the field is unchanged.
+func (r Rules) check(serviceName *string, price *int, start, end *time.Time) *RuleViolation {
+	if serviceName != nil {
+		for _, banned := range r.BannedServices {
+			if strings.EqualFold(strings.TrimSpace(*serviceName), banned) {
+				return &RuleViolation{Rule: "banned_service", Message: fmt.Sprintf("service %q is not allowed", *serviceName)}
+			}
+		}
+	}
+	if price != nil && r.MaxPrice > 0 && *price > r.MaxPrice {
+		return &RuleViolation{Rule: "max_price", Message: fmt.Sprintf("price %d exceeds the maximum of %d", *price, r.MaxPrice)}
+	}
+	if start != nil {
+		if r.EarliestStart != nil && start.Before(*r.EarliestStart) {
+			return &RuleViolation{Rule: "earliest_start", Message: "start_date is before " + r.EarliestStart.Format(layoutYearMonth)}
+		}
+		if r.LatestEnd != nil && start.After(*r.LatestEnd) {
+			return &RuleViolation{Rule: "latest_end", Message: "start_date is after " + r.LatestEnd.Format(layoutYearMonth)}
+		}
+	}
+	if end != nil && r.LatestEnd != nil && end.After(*r.LatestEnd) {
+		return &RuleViolation{Rule: "latest_end", Message: "end_date is after " + r.LatestEnd.Format(layoutYearMonth)}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// callerScope returns the only user whose subscriptions the caller in ctx may
+// access with perm: the caller itself, unless its role grants perm for every
+// user. Anonymous requests, which only reach the service when authentication
+// is disabled, get nil.
+func callerScope(ctx context.Context, perm rbac.Permission) *uuid.UUID {
+	caller, ok := identity.FromContext(ctx)
+	if !ok || rbac.Allows(caller, perm) {
+		return nil
+	}
+	return &caller.UserID
+}
+
+// visible reports whether the caller may read sub. Subscriptions it may not
+// read are reported as not found, so IDs of other users' records do not
+// leak.
+func visible(ctx context.Context, sub Subscription) bool {
+	return rbac.CanAccessUser(ctx, sub.UserID, rbac.ReadAnyUser)
+}
+
+// checkWrite returns sql.ErrNoRows when the caller may not read sub and
+// ErrForbidden when it may read but not change it.
+func checkWrite(ctx context.Context, sub Subscription) error {
+	if !visible(ctx, sub) {
+		return sql.ErrNoRows
+	}
+	if !rbac.CanAccessUser(ctx, sub.UserID, rbac.WriteAnyUser) {
+		return fmt.Errorf("%w: only the owner or an admin can change this subscription", ErrForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package subscription
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	goqu "github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/google/uuid"
+)
+
+const (
+	maxSearchDepth = 8
+	maxSearchNodes = 64
+	maxSearchIn    = 100
+)
+
+// ErrInvalidFilter is returned when a search document cannot be compiled.
+var ErrInvalidFilter = errors.New("invalid search filter")
+
+// SearchQuery is a filter document plus pagination for Search.
+type SearchQuery struct {
+	Filter *SearchNode
+	Sort   Sort
+	Limit  int
+	Offset int
+	// UserID restricts the search to one user's subscriptions.
+	UserID *uuid.UUID
+}
+
+// SearchNode is either a combinator (and/or/not) or a single condition on a
+// field. Exactly one of the two forms must be used per node.
+type SearchNode struct {
+	And   []SearchNode    `json:"and,omitempty"`
+	Or    []SearchNode    `json:"or,omitempty"`
+	Not   *SearchNode     `json:"not,omitempty"`
+	Field string          `json:"field,omitempty" enums:"service_name,price_rub,user_id,start_month,end_month,created_at,updated_at,status,tags"`
+	Op    string          `json:"op,omitempty" enums:"eq,ne,gt,gte,lt,lte,between,in,like,is_null,is_not_null"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindInt
+	kindMonth
+	kindTime
+	kindUUID
+	// kindStatus matches the current status, expired included, like the
+	// list filter.
+	kindStatus
+	// kindTag matches subscriptions carrying a tag.
+	kindTag
+)
+
+type searchField struct {
+	kind     fieldKind
+	nullable bool
+}
+
+// searchFields whitelists the columns a search document may reference.
+var searchFields = map[string]searchField{
+	"service_name": {kind: kindText},
+	"price_rub":    {kind: kindInt},
+	"user_id":      {kind: kindUUID},
+	"start_month":  {kind: kindMonth},
+	"end_month":    {kind: kindMonth, nullable: true},
+	"created_at":   {kind: kindTime},
+	"updated_at":   {kind: kindTime},
+	"status":       {kind: kindStatus},
+	"tags":         {kind: kindTag},
+}
+
+// searchStatuses are the values a status condition accepts.
+var searchStatuses = []string{StatusActive, StatusPaused, StatusCancelled, StatusExpired}
+
+// searchCompiler compiles one search document. Status conditions treat end
+// months without their own setting as charged when defaultInclusive is set.
+type searchCompiler struct {
+	nodes            int
+	defaultInclusive bool
+}
+
+func compileSearch(root SearchNode, defaultInclusive bool) (exp.Expression, error) {
+	c := &searchCompiler{defaultInclusive: defaultInclusive}
+	return c.node(root, 0)
+}
+
+func (c *searchCompiler) node(node SearchNode, depth int) (exp.Expression, error) {
+	c.nodes++
+	if c.nodes > maxSearchNodes {
+		return nil, fmt.Errorf("%w: more than %d conditions", ErrInvalidFilter, maxSearchNodes)
+	}
+	if depth > maxSearchDepth {
+		return nil, fmt.Errorf("%w: nesting deeper than %d", ErrInvalidFilter, maxSearchDepth)
+	}
+
+	forms := 0
+	for _, set := range []bool{len(node.And) > 0, len(node.Or) > 0, node.Not != nil, node.Field != ""} {
+		if set {
+			forms++
+		}
+	}
+	if forms != 1 {
+		return nil, fmt.Errorf("%w: each node needs exactly one of and, or, not, field", ErrInvalidFilter)
+	}
+
+	switch {
+	case len(node.And) > 0:
+		children, err := c.children(node.And, depth)
+		if err != nil {
+			return nil, err
+		}
+		return goqu.And(children...), nil
+	case len(node.Or) > 0:
+		children, err := c.children(node.Or, depth)
+		if err != nil {
+			return nil, err
+		}
+		return goqu.Or(children...), nil
+	case node.Not != nil:
+		child, err := c.node(*node.Not, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return goqu.L("NOT (?)", child), nil
+	default:
+		return c.condition(node)
+	}
+}
+
+func (c *searchCompiler) children(children []SearchNode, depth int) ([]exp.Expression, error) {
+	out := make([]exp.Expression, 0, len(children))
+	for _, child := range children {
+		expr, err := c.node(child, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, expr)
+	}
+	return out, nil
+}
+
+func (c *searchCompiler) condition(node SearchNode) (exp.Expression, error) {
+	field, ok := searchFields[node.Field]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, node.Field)
+	}
+	col := goqu.C(node.Field)
+	op := strings.ToLower(node.Op)
+
+	switch op {
+	case "is_null", "is_not_null":
+		if !field.nullable {
+			return nil, fmt.Errorf("%w: %s is never null", ErrInvalidFilter, node.Field)
+		}
+		if op == "is_null" {
+			return col.IsNull(), nil
+		}
+		return col.IsNotNull(), nil
+	case "in":
+		var raw []json.RawMessage
+		if err := json.Unmarshal(node.Value, &raw); err != nil || len(raw) == 0 {
+			return nil, fmt.Errorf("%w: %s in expects a non-empty array", ErrInvalidFilter, node.Field)
+		}
+		if len(raw) > maxSearchIn {
+			return nil, fmt.Errorf("%w: in accepts at most %d values", ErrInvalidFilter, maxSearchIn)
+		}
+		values := make([]interface{}, 0, len(raw))
+		for _, item := range raw {
+			v, err := decodeSearchValue(field.kind, node.Field, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		if field.kind == kindStatus || field.kind == kindTag {
+			matches := make([]exp.Expression, 0, len(values))
+			for _, v := range values {
+				matches = append(matches, c.matches(field.kind, v.(string)))
+			}
+			return goqu.Or(matches...), nil
+		}
+		return col.In(values...), nil
+	case "between":
+		if field.kind == kindStatus || field.kind == kindTag {
+			return nil, fmt.Errorf("%w: %s does not support between", ErrInvalidFilter, node.Field)
+		}
+		var bounds []json.RawMessage
+		if err := json.Unmarshal(node.Value, &bounds); err != nil || len(bounds) != 2 {
+			return nil, fmt.Errorf("%w: %s between expects [from, to]", ErrInvalidFilter, node.Field)
+		}
+		from, err := decodeSearchValue(field.kind, node.Field, bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := decodeSearchValue(field.kind, node.Field, bounds[1])
+		if err != nil {
+			return nil, err
+		}
+		return col.Between(goqu.Range(from, to)), nil
+	case "like":
+		if field.kind != kindText {
+			return nil, fmt.Errorf("%w: like is only supported on text fields", ErrInvalidFilter)
+		}
+		var pattern string
+		if err := json.Unmarshal(node.Value, &pattern); err != nil {
+			return nil, fmt.Errorf("%w: %s like expects a string", ErrInvalidFilter, node.Field)
+		}
+		return col.ILike(likePattern(pattern)), nil
+	}
+
+	value, err := decodeSearchValue(field.kind, node.Field, node.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "eq", "ne":
+		var expr exp.Expression
+		switch field.kind {
+		case kindStatus, kindTag:
+			expr = c.matches(field.kind, value.(string))
+			if op == "ne" {
+				expr = goqu.L("NOT (?)", expr)
+			}
+			return expr, nil
+		case kindText:
+			if op == "ne" {
+				return goqu.Func("LOWER", col).Neq(strings.ToLower(value.(string))), nil
+			}
+			return goqu.Func("LOWER", col).Eq(strings.ToLower(value.(string))), nil
+		}
+		if op == "ne" {
+			return col.Neq(value), nil
+		}
+		return col.Eq(value), nil
+	case "gt", "gte", "lt", "lte":
+		if field.kind == kindText || field.kind == kindUUID || field.kind == kindStatus || field.kind == kindTag {
+			return nil, fmt.Errorf("%w: %s does not support %s", ErrInvalidFilter, node.Field, op)
+		}
+		switch op {
+		case "gt":
+			return col.Gt(value), nil
+		case "gte":
+			return col.Gte(value), nil
+		case "lt":
+			return col.Lt(value), nil
+		default:
+			return col.Lte(value), nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported operator %q", ErrInvalidFilter, node.Op)
+	}
+}
+
+// matches is the condition for a status or tag value.
+func (c *searchCompiler) matches(kind fieldKind, value string) exp.Expression {
+	if kind == kindStatus {
+		return statusWhere(value, c.defaultInclusive)
+	}
+	return goqu.L("? = ANY(?)", value, goqu.C("tags"))
+}
+
+func decodeSearchValue(kind fieldKind, name string, raw json.RawMessage) (interface{}, error) {
+	switch kind {
+	case kindInt:
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%w: %s expects an integer", ErrInvalidFilter, name)
+		}
+		return v, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return nil, fmt.Errorf("%w: %s expects a string", ErrInvalidFilter, name)
+	}
+
+	switch kind {
+	case kindUUID:
+		id, err := uuid.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s expects a UUID", ErrInvalidFilter, name)
+		}
+		return id, nil
+	case kindMonth:
+		month, err := parseMonth(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFilter, name, err)
+		}
+		return month, nil
+	case kindStatus:
+		if !slices.Contains(searchStatuses, text) {
+			return nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidFilter, name, strings.Join(searchStatuses, ", "))
+		}
+		return text, nil
+	case kindTag:
+		return strings.ToLower(strings.TrimSpace(text)), nil
+	case kindTime:
+		t, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s expects an RFC 3339 timestamp", ErrInvalidFilter, name)
+		}
+		return t, nil
+	default:
+		return text, nil
+	}
+}
+
+// likePattern turns a user pattern using * as wildcard into an ILIKE pattern,
+// escaping the SQL wildcards the user typed literally.
+func likePattern(pattern string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`)
+	return replacer.Replace(pattern)
+}
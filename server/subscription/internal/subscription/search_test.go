@@ -0,0 +1,75 @@
+package subscription
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	goqu "github.com/doug-martin/goqu/v9"
+)
+
+var (
+	sqlLiteral    = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlIdentifier = regexp.MustCompile(`"((?:[^"]|"")*)"`)
+)
+
+// searchColumns are the columns compiled filters may reference: the
+// whitelisted fields plus what status conditions read.
+func searchColumns() map[string]bool {
+	columns := map[string]bool{"end_month_inclusive": true}
+	for name := range searchFields {
+		columns[name] = true
+	}
+	return columns
+}
+
+func FuzzSearchFilter(f *testing.F) {
+	seeds := []string{
+		`{"field":"service_name","op":"eq","value":"Netflix"}`,
+		`{"field":"service_name","op":"like","value":"*flix%_"}`,
+		`{"field":"price_rub","op":"between","value":[100,500]}`,
+		`{"field":"end_month","op":"is_null"}`,
+		`{"field":"start_month","op":"gte","value":"2025-01"}`,
+		`{"field":"user_id","op":"in","value":["60601fee-2bf1-4721-ae6f-7636e79a0cba"]}`,
+		`{"field":"created_at","op":"lt","value":"2025-01-01T00:00:00Z"}`,
+		`{"field":"status","op":"in","value":["active","expired"]}`,
+		`{"field":"tags","op":"ne","value":"family"}`,
+		`{"and":[{"field":"price_rub","op":"gt","value":1},{"not":{"field":"status","op":"eq","value":"paused"}}]}`,
+		`{"or":[{"field":"tags","op":"eq","value":"a\"b'c"},{"field":"service_name","op":"ne","value":"x\" OR 1=1 --"}]}`,
+		`{"field":"price_rub\" OR 1=1 --","op":"eq","value":1}`,
+		`{"field":"password","op":"eq","value":"x"}`,
+		`{"and":[],"field":"price_rub","op":"eq","value":1}`,
+		`{"not":{"not":{"not":{"not":{"not":{"not":{"not":{"not":{"not":{"field":"price_rub","op":"eq","value":1}}}}}}}}}}`,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed), false)
+	}
+
+	columns := searchColumns()
+	f.Fuzz(func(t *testing.T, doc []byte, defaultInclusive bool) {
+		var node SearchNode
+		if err := json.Unmarshal(doc, &node); err != nil {
+			return
+		}
+		expr, err := compileSearch(node, defaultInclusive)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidFilter) {
+				t.Fatalf("compileSearch(%s) = %v, want ErrInvalidFilter", doc, err)
+			}
+			return
+		}
+
+		query, _, err := goqu.Dialect("postgres").From("subscriptions").Where(expr).ToSQL()
+		if err != nil {
+			t.Fatalf("render %s: %v", doc, err)
+		}
+		// Values are interpolated as literals; only identifiers outside
+		// them can name a column.
+		for _, match := range sqlIdentifier.FindAllStringSubmatch(sqlLiteral.ReplaceAllString(query, "''"), -1) {
+			if match[1] != "subscriptions" && !columns[match[1]] {
+				t.Fatalf("filter %s references column %q: %s", doc, match[1], query)
+			}
+		}
+	})
+}
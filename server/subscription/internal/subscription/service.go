@@ -1,46 +1,627 @@
 package subscription
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/money"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
 
 // Service defines the business operations exposed to handlers.
 type Service interface {
 	Create(context.Context, CreateParams) (Subscription, error)
 	GetByID(context.Context, string) (Subscription, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) (found []Subscription, missing []uuid.UUID, err error)
+	GetBySlug(ctx context.Context, userID uuid.UUID, slug string) (Subscription, error)
 	List(context.Context, ListOptions) ([]Subscription, int, error)
+	ListVersion(context.Context, ListOptions) (CollectionVersion, error)
+	Stream(context.Context, ListOptions, func(Subscription) error) error
 	Update(context.Context, UpdateParams) (Subscription, error)
+	Upsert(context.Context, CreateParams) (Subscription, bool, error)
+	UpdateWhere(context.Context, BulkFilter, UpdateParams) (BulkResult, error)
+	Matching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error)
 	Delete(context.Context, string) error
-	SumByPeriod(context.Context, SumFilter) (int, error)
+	Transfer(ctx context.Context, id, toUserID uuid.UUID) (Subscription, error)
+	ChangeStatus(ctx context.Context, id uuid.UUID, to string) (Subscription, error)
+	Undo(ctx context.Context, id uuid.UUID, window time.Duration) (Subscription, error)
+	Timeline(ctx context.Context, id uuid.UUID) ([]TimelineItem, error)
+	SumByPeriod(context.Context, SumFilter) (int64, error)
+	SumProjected(context.Context, SumFilter) (SpendProjection, error)
+	SumByUsers(context.Context, BatchSumFilter) (map[uuid.UUID]int64, error)
+	SumByTags(context.Context, SumFilter, TagMode) ([]TagTotal, error)
+	SumTimeSeries(context.Context, SumFilter, Granularity) ([]TimeSeriesPoint, error)
+	Search(context.Context, SearchQuery) ([]Subscription, int, error)
+	SpendDistribution(ctx context.Context, start, end time.Time) (SpendDistribution, error)
+	Export(ctx context.Context, userID uuid.UUID, fn func(Subscription) error) error
+	ExportHistory(ctx context.Context, userID uuid.UUID, fn func(AuditEntry) error) error
+	Restore(ctx context.Context, userID uuid.UUID, subs iter.Seq2[Subscription, error]) (RestoreResult, error)
 }
 
+// exportPageSize bounds how many rows Export holds in memory at once.
+const exportPageSize = 500
+
+// maxBulkUpdate bounds how many rows one bulk update locks and changes.
+const maxBulkUpdate = 1000
+
 type service struct {
-	repo Store
+	repo   Store
+	hooks  []ValidationHook
+	events EventSink
+	rates  money.Rates
+}
+
+// NewService creates a Service backed by the provided repository. Hooks run in
+// registration order before every mutating operation.
+func NewService(repo Store, hooks ...ValidationHook) Service {
+	return NewServiceWithEvents(repo, discardEvents{}, hooks...)
+}
+
+// NewServiceWithEvents is NewService with events delivered to sink. Only
+// ruble prices can be converted.
+func NewServiceWithEvents(repo Store, sink EventSink, hooks ...ValidationHook) Service {
+	return NewServiceWithRates(repo, sink, money.StaticRates(nil), hooks...)
 }
 
-// NewService creates a Service backed by the provided repository.
-func NewService(repo Store) Service {
-	return &service{repo: repo}
+// NewServiceWithRates is NewServiceWithEvents with prices in other currencies
+// converted to rubles at rates.
+func NewServiceWithRates(repo Store, sink EventSink, rates money.Rates, hooks ...ValidationHook) Service {
+	return &service{repo: repo, hooks: hooks, events: sink, rates: rates}
 }
 
+// Create inserts a subscription. A caller-supplied ID lets offline clients
+// create records locally and sync them later; reusing one fails with
+// ErrAlreadyExists.
 func (s *service) Create(ctx context.Context, params CreateParams) (Subscription, error) {
-	return s.repo.Create(ctx, params)
+	if params.ID != uuid.Nil {
+		if err := validateClientID(params.ID); err != nil {
+			return Subscription{}, err
+		}
+	}
+	sub, err := s.createIn(ctx, s.repo, params)
+	if err != nil {
+		return Subscription{}, err
+	}
+	s.emit(ctx, EventCreated, sub)
+	return sub, nil
+}
+
+// emit announces a change to sub made by the caller in ctx.
+func (s *service) emit(ctx context.Context, eventType string, sub Subscription) {
+	e := Event{Type: eventType, SubscriptionID: sub.ID, UserID: sub.UserID, At: sub.UpdatedAt}
+	if caller, ok := identity.FromContext(ctx); ok {
+		e.ActorID = &caller.UserID
+	}
+	s.events.Emit(ctx, e)
+}
+
+func (s *service) createIn(ctx context.Context, repo Store, params CreateParams) (Subscription, error) {
+	params.ServiceName = strings.TrimSpace(params.ServiceName)
+	tags, err := normalizeTags(params.Tags)
+	if err != nil {
+		return Subscription{}, err
+	}
+	params.Tags = tags
+	if err := validateCreate(params); err != nil {
+		return Subscription{}, err
+	}
+	if !rbac.CanAccessUser(ctx, params.UserID, rbac.WriteAnyUser) {
+		return Subscription{}, fmt.Errorf("%w: only the owner or an admin can add subscriptions for this user", ErrForbidden)
+	}
+	if err := s.priceCreate(ctx, &params); err != nil {
+		return Subscription{}, err
+	}
+	if err := checkPlan(ctx, repo, params.ServiceName, params.PlanID); err != nil {
+		return Subscription{}, err
+	}
+	for _, hook := range s.hooks {
+		if err := hookResult(ctx, hook.BeforeCreate(ctx, params)); err != nil {
+			return Subscription{}, fmt.Errorf("%w: %w", ErrRejected, err)
+		}
+	}
+
+	// Time-ordered UUIDv7 keeps inserts index-local and makes ordering by ID
+	// follow creation order.
+	if params.ID == uuid.Nil {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return Subscription{}, fmt.Errorf("generate subscription id: %w", err)
+		}
+		params.ID = id
+	}
+	return repo.Create(ctx, params)
+}
+
+func (s *service) GetBySlug(ctx context.Context, userID uuid.UUID, slug string) (Subscription, error) {
+	sub, err := s.repo.GetBySlug(ctx, userID, slug)
+	if err == nil && !visible(ctx, sub) {
+		return Subscription{}, sql.ErrNoRows
+	}
+	return sub, err
 }
 
 func (s *service) GetByID(ctx context.Context, id string) (Subscription, error) {
-	return s.repo.GetByID(ctx, id)
+	sub, err := s.repo.GetByID(ctx, id)
+	if err == nil && !visible(ctx, sub) {
+		return Subscription{}, sql.ErrNoRows
+	}
+	return sub, err
+}
+
+// GetByIDs loads several subscriptions at once. found follows the order of
+// ids; missing lists the IDs that matched nothing, in the same order.
+func (s *service) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]Subscription, []uuid.UUID, error) {
+	subs, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uuid.UUID]Subscription, len(subs))
+	for _, sub := range subs {
+		byID[sub.ID] = sub
+	}
+
+	found := make([]Subscription, 0, len(subs))
+	missing := []uuid.UUID{}
+	for _, id := range ids {
+		if sub, ok := byID[id]; ok && visible(ctx, sub) {
+			found = append(found, sub)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing, nil
 }
 
 func (s *service) List(ctx context.Context, opts ListOptions) ([]Subscription, int, error) {
 	return s.repo.List(ctx, opts)
 }
 
+func (s *service) ListVersion(ctx context.Context, opts ListOptions) (CollectionVersion, error) {
+	return s.repo.ListVersion(ctx, opts)
+}
+
+func (s *service) Stream(ctx context.Context, opts ListOptions, fn func(Subscription) error) error {
+	return s.repo.Stream(ctx, opts, fn)
+}
+
 func (s *service) Update(ctx context.Context, params UpdateParams) (Subscription, error) {
-	return s.repo.Update(ctx, params)
+	if params.ServiceName != nil {
+		trimmed := strings.TrimSpace(*params.ServiceName)
+		params.ServiceName = &trimmed
+	}
+	if params.Tags != nil {
+		tags, err := normalizeTags(*params.Tags)
+		if err != nil {
+			return Subscription{}, err
+		}
+		params.Tags = &tags
+	}
+	if err := validateUpdate(params); err != nil {
+		return Subscription{}, err
+	}
+	// The row is locked for the checks that need the stored record: the lock
+	// flag, the date order when the update touches one side of the range, and
+	// the currency a new price is in. Hooks see the converted ruble price.
+	var updated Subscription
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		current, err := tx.GetByIDForUpdate(ctx, params.ID.String())
+		if err != nil {
+			return err
+		}
+		if err := checkWrite(ctx, current); err != nil {
+			return err
+		}
+		if err := checkLock(ctx, current, params); err != nil {
+			return err
+		}
+		if (params.StartMonth == nil) != !params.EndMonthSet {
+			start, end := current.StartMonth, current.EndMonth
+			if params.StartMonth != nil {
+				start = *params.StartMonth
+			}
+			if params.EndMonthSet {
+				end = params.EndMonth
+			}
+			if err := validateRange(start, end); err != nil {
+				return err
+			}
+		}
+		if err := s.priceUpdate(ctx, current, &params); err != nil {
+			return err
+		}
+		if err := checkPlanUpdate(ctx, tx, current, params); err != nil {
+			return err
+		}
+		for _, hook := range s.hooks {
+			if err := hookResult(ctx, hook.BeforeUpdate(ctx, params)); err != nil {
+				return fmt.Errorf("%w: %w", ErrRejected, err)
+			}
+		}
+		if updated, err = tx.Update(ctx, params); err != nil {
+			return err
+		}
+		return tx.RecordAudit(ctx, AuditEntry{
+			SubscriptionID: updated.ID,
+			Action:         ActionUpdate,
+			ActorID:        callerID(ctx),
+			Before:         &current,
+			After:          &updated,
+		})
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	s.emit(ctx, EventUpdated, updated)
+	return updated, nil
+}
+
+// UpdateWhere applies change to every subscription matching filter in one
+// transaction: either all of them change or none do. Each row is checked
+// and passed to the hooks as if it were updated on its own.
+func (s *service) UpdateWhere(ctx context.Context, filter BulkFilter, change UpdateParams) (BulkResult, error) {
+	if filter.ServiceName == nil && filter.UserID == nil {
+		return BulkResult{}, &ValidationError{Field: "filter", Message: "service_name or user_id is required"}
+	}
+	if change.UserID != nil {
+		return BulkResult{}, &ValidationError{Field: "user_id", Message: "cannot be changed in bulk"}
+	}
+	if change.ServiceName != nil {
+		trimmed := strings.TrimSpace(*change.ServiceName)
+		change.ServiceName = &trimmed
+	}
+	if err := validateUpdate(change); err != nil {
+		return BulkResult{}, err
+	}
+
+	var updated []Subscription
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		matches, err := tx.LockMatching(ctx, filter, maxBulkUpdate+1)
+		if err != nil {
+			return err
+		}
+		if len(matches) > maxBulkUpdate {
+			return &ValidationError{Field: "filter", Message: fmt.Sprintf("matches more than %d subscriptions", maxBulkUpdate)}
+		}
+
+		for _, current := range matches {
+			params := change
+			params.ID = current.ID
+			if err := checkLock(ctx, current, params); err != nil {
+				return fmt.Errorf("subscription %s: %w", current.ID, err)
+			}
+			start, end := current.StartMonth, current.EndMonth
+			if params.StartMonth != nil {
+				start = *params.StartMonth
+			}
+			if params.EndMonthSet {
+				end = params.EndMonth
+			}
+			if err := validateRange(start, end); err != nil {
+				return fmt.Errorf("subscription %s: %w", current.ID, err)
+			}
+			if err := s.priceUpdate(ctx, current, &params); err != nil {
+				return fmt.Errorf("subscription %s: %w", current.ID, err)
+			}
+			if err := checkPlanUpdate(ctx, tx, current, params); err != nil {
+				return fmt.Errorf("subscription %s: %w", current.ID, err)
+			}
+			for _, hook := range s.hooks {
+				if err := hookResult(ctx, hook.BeforeUpdate(ctx, params)); err != nil {
+					return fmt.Errorf("%w: subscription %s: %w", ErrRejected, current.ID, err)
+				}
+			}
+			sub, err := tx.Update(ctx, params)
+			if err != nil {
+				return err
+			}
+			if err := tx.RecordAudit(ctx, AuditEntry{
+				SubscriptionID: sub.ID,
+				Action:         ActionUpdate,
+				ActorID:        callerID(ctx),
+				Before:         &current,
+				After:          &sub,
+			}); err != nil {
+				return err
+			}
+			updated = append(updated, sub)
+		}
+		return nil
+	})
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := BulkResult{Count: len(updated), IDs: make([]uuid.UUID, 0, len(updated))}
+	for _, sub := range updated {
+		result.IDs = append(result.IDs, sub.ID)
+		s.emit(ctx, EventUpdated, sub)
+	}
+	return result, nil
+}
+
+// Matching returns up to limit subscriptions matching filter, ordered by ID,
+// without locking them. The filter must not be empty.
+func (s *service) Matching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error) {
+	if filter.ServiceName == nil && filter.UserID == nil {
+		return nil, &ValidationError{Field: "filter", Message: "service_name or user_id is required"}
+	}
+	return s.repo.FindMatching(ctx, filter, limit)
 }
 
 func (s *service) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	var deleted Subscription
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		current, err := tx.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkWrite(ctx, current); err != nil {
+			return err
+		}
+		if current.Locked {
+			return ErrLocked
+		}
+		for _, hook := range s.hooks {
+			if err := hookResult(ctx, hook.BeforeDelete(ctx, id)); err != nil {
+				return fmt.Errorf("%w: %w", ErrRejected, err)
+			}
+		}
+		deleted = current
+		return tx.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	deleted.UpdatedAt = time.Now().UTC()
+	s.emit(ctx, EventDeleted, deleted)
+	return nil
 }
 
-func (s *service) SumByPeriod(ctx context.Context, filter SumFilter) (int, error) {
+// checkLock refuses changes to a locked subscription unless the same update
+// unlocks it, and lets only the owner or a caller allowed to change any
+// user's data change the lock. Anonymous callers are allowed while
+// authentication is disabled.
+func checkLock(ctx context.Context, current Subscription, params UpdateParams) error {
+	if params.Locked != nil {
+		if !rbac.CanAccessUser(ctx, current.UserID, rbac.WriteAnyUser) {
+			return fmt.Errorf("%w: only the owner or an admin can lock or unlock a subscription", ErrForbidden)
+		}
+	}
+	unlocking := params.Locked != nil && !*params.Locked
+	if current.Locked && !unlocking && params.changesFields() {
+		return ErrLocked
+	}
+	return nil
+}
+
+// Transfer hands a subscription over to another user. Only the current owner
+// or an admin may do so; anonymous callers are allowed while authentication
+// is disabled. Callers who may not read the subscription get sql.ErrNoRows,
+// as from Get. The change and its audit entry commit together.
+func (s *service) Transfer(ctx context.Context, id, toUserID uuid.UUID) (Subscription, error) {
+	caller, authenticated := identity.FromContext(ctx)
+	var actor *uuid.UUID
+	if authenticated {
+		actor = &caller.UserID
+	}
+
+	var before, after Subscription
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		var err error
+		if before, err = tx.GetByIDForUpdate(ctx, id.String()); err != nil {
+			return err
+		}
+		if !visible(ctx, before) {
+			return sql.ErrNoRows
+		}
+		if !rbac.CanAccessUser(ctx, before.UserID, rbac.WriteAnyUser) {
+			return fmt.Errorf("%w: only the owner or an admin can transfer a subscription", ErrForbidden)
+		}
+		if before.Locked {
+			return ErrLocked
+		}
+		if before.UserID == toUserID {
+			return fmt.Errorf("%w: subscription already belongs to %s", ErrRejected, toUserID)
+		}
+
+		if after, err = tx.Update(ctx, UpdateParams{ID: id, UserID: &toUserID}); err != nil {
+			return err
+		}
+		return tx.RecordAudit(ctx, AuditEntry{
+			SubscriptionID: id,
+			Action:         ActionTransfer,
+			ActorID:        actor,
+			Before:         &before,
+			After:          &after,
+		})
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	s.events.Emit(ctx, Event{
+		Type:           EventTransferred,
+		SubscriptionID: id,
+		UserID:         after.UserID,
+		ActorID:        actor,
+		At:             after.UpdatedAt,
+		Data: map[string]any{
+			"from_user_id": before.UserID,
+			"to_user_id":   after.UserID,
+		},
+	})
+	return after, nil
+}
+
+func (s *service) SumByPeriod(ctx context.Context, filter SumFilter) (int64, error) {
 	return s.repo.SumByPeriod(ctx, filter)
 }
+
+// SumProjected returns the spend through the current month next to the spend
+// through filter.EndMonth, which must be set. Open-ended subscriptions count
+// for every month up to the end, so the difference is what is still expected.
+func (s *service) SumProjected(ctx context.Context, filter SumFilter) (SpendProjection, error) {
+	if filter.EndMonth == nil {
+		return SpendProjection{}, fmt.Errorf("end month is required for a projection")
+	}
+
+	projected, err := s.repo.SumByPeriod(ctx, filter)
+	if err != nil {
+		return SpendProjection{}, err
+	}
+
+	current := months.Normalize(time.Now().UTC())
+	if !filter.EndMonth.After(current) {
+		return SpendProjection{ActualToDate: projected, ProjectedTotal: projected}, nil
+	}
+	if filter.StartMonth != nil && filter.StartMonth.After(current) {
+		return SpendProjection{ProjectedTotal: projected}, nil
+	}
+
+	toDate := filter
+	toDate.EndMonth = &current
+	actual, err := s.repo.SumByPeriod(ctx, toDate)
+	if err != nil {
+		return SpendProjection{}, err
+	}
+	return SpendProjection{ActualToDate: actual, ProjectedTotal: projected}, nil
+}
+
+func (s *service) SumByUsers(ctx context.Context, filter BatchSumFilter) (map[uuid.UUID]int64, error) {
+	return s.repo.SumByUsers(ctx, filter)
+}
+
+func (s *service) SumByTags(ctx context.Context, filter SumFilter, mode TagMode) ([]TagTotal, error) {
+	if !mode.Valid() {
+		return nil, fmt.Errorf("unsupported tag mode %q", mode)
+	}
+	return s.repo.SumByTags(ctx, filter, mode)
+}
+
+func (s *service) SumTimeSeries(ctx context.Context, filter SumFilter, granularity Granularity) ([]TimeSeriesPoint, error) {
+	if !granularity.Valid() {
+		return nil, fmt.Errorf("unsupported granularity %q", granularity)
+	}
+	return s.repo.SumTimeSeries(ctx, filter, granularity)
+}
+
+func (s *service) Search(ctx context.Context, q SearchQuery) ([]Subscription, int, error) {
+	return s.repo.Search(ctx, q)
+}
+
+func (s *service) SpendDistribution(ctx context.Context, start, end time.Time) (SpendDistribution, error) {
+	start, end = months.Normalize(start), months.Normalize(end)
+	if end.Before(start) {
+		return SpendDistribution{}, fmt.Errorf("end must be after start")
+	}
+	return s.repo.SpendDistribution(ctx, start, end)
+}
+
+// ExportHistory streams the audit entries of every subscription userID owns
+// to fn, oldest first.
+func (s *service) ExportHistory(ctx context.Context, userID uuid.UUID, fn func(AuditEntry) error) error {
+	return s.repo.UserAuditTrail(ctx, userID, fn)
+}
+
+// Export streams every subscription owned by userID to fn, oldest first, one
+// page at a time.
+func (s *service) Export(ctx context.Context, userID uuid.UUID, fn func(Subscription) error) error {
+	opts := ListOptions{
+		Limit:  exportPageSize,
+		Sort:   Sort{Column: "created_at"},
+		UserID: &userID,
+	}
+	for {
+		page, _, err := s.repo.List(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("export page at offset %d: %w", opts.Offset, err)
+		}
+		for _, sub := range page {
+			if err := fn(sub); err != nil {
+				return err
+			}
+		}
+		if len(page) < opts.Limit {
+			return nil
+		}
+		opts.Offset += len(page)
+	}
+}
+
+// Restore recreates subscriptions from an export in a single transaction.
+// Records that already exist for the user are skipped, so restoring the same
+// archive twice is harmless. A record whose ID belongs to another user rejects
+// the whole restore.
+func (s *service) Restore(ctx context.Context, userID uuid.UUID, subs iter.Seq2[Subscription, error]) (RestoreResult, error) {
+	var result RestoreResult
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		for sub, err := range subs {
+			if err != nil {
+				return err
+			}
+
+			if sub.ID != uuid.Nil {
+				existing, err := tx.GetByID(ctx, sub.ID.String())
+				switch {
+				case err == nil && existing.UserID != userID:
+					return fmt.Errorf("%w: subscription %s belongs to another user", ErrRejected, sub.ID)
+				case err == nil:
+					result.Skipped++
+					continue
+				case !errors.Is(err, sql.ErrNoRows):
+					return err
+				}
+			}
+
+			params := CreateParams{
+				ID:                sub.ID,
+				ServiceName:       sub.ServiceName,
+				PriceRUB:          sub.PriceRUB,
+				UserID:            userID,
+				StartMonth:        months.Normalize(sub.StartMonth),
+				EndMonth:          sub.EndMonth,
+				EndMonthInclusive: sub.EndMonthInclusive,
+			}
+			// Archives from before currencies were stored only have rubles.
+			if sub.Currency != "" {
+				params.Currency = sub.Currency
+				params.AmountMinor = &sub.AmountMinor
+			}
+			// A plan removed from the catalog since the export is dropped
+			// rather than failing the restore.
+			if err := checkPlan(ctx, tx, sub.ServiceName, sub.PlanID); err == nil {
+				params.PlanID = sub.PlanID
+			} else if !errors.Is(err, ErrInvalidInput) {
+				return err
+			}
+			params.BillingPeriod = sub.BillingPeriod
+			params.Tags = sub.Tags
+			// Archives from before statuses existed restore as active.
+			if _, ok := statusVerbs[sub.Status]; ok {
+				params.Status = sub.Status
+			}
+			if params.EndMonth != nil {
+				end := months.Normalize(*params.EndMonth)
+				params.EndMonth = &end
+			}
+			if _, err := s.createIn(ctx, tx, params); err != nil {
+				return err
+			}
+			result.Restored++
+		}
+		return nil
+	})
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	return result, nil
+}
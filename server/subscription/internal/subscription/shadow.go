@@ -0,0 +1,285 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShadowConfig tunes a ShadowStore.
+type ShadowConfig struct {
+	// SampleRate is the fraction of reads mirrored to the shadow, in [0, 1].
+	SampleRate float64
+	// Timeout bounds each shadow call, independently of the caller.
+	Timeout time.Duration
+	// MaxInFlight caps concurrent shadow calls; reads beyond it are not
+	// mirrored rather than queued.
+	MaxInFlight int
+	// Writes mirrors Create, Update and Delete too. Only enable it when the
+	// shadow writes to a separate database, or every write happens twice.
+	Writes bool
+}
+
+// ShadowStore serves every call from the primary Store and mirrors it to a
+// shadow Store in the background, logging results that differ. It de-risks a
+// storage-layer rewrite: run the new implementation as the shadow in
+// production and promote it once divergences stop.
+//
+// Transactions, row locks and streams only go to the primary.
+type ShadowStore struct {
+	primary Store
+	shadow  Store
+	cfg     ShadowConfig
+	logger  *slog.Logger
+	slots   chan struct{}
+}
+
+var _ Store = (*ShadowStore)(nil)
+
+// NewShadowStore wraps primary, mirroring calls to shadow.
+func NewShadowStore(primary, shadow Store, cfg ShadowConfig, logger *slog.Logger) *ShadowStore {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 16
+	}
+	return &ShadowStore{
+		primary: primary,
+		shadow:  shadow,
+		cfg:     cfg,
+		logger:  logger,
+		slots:   make(chan struct{}, cfg.MaxInFlight),
+	}
+}
+
+// mirror runs call against the shadow in the background and compares its
+// outcome with the primary's. want and wantErr are the primary's results.
+func mirror[T any](s *ShadowStore, ctx context.Context, op string, write bool, want T, wantErr error, call func(context.Context, Store) (T, error)) {
+	if write && !s.cfg.Writes {
+		return
+	}
+	if !write && rand.Float64() >= s.cfg.SampleRate {
+		return
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.logger.Debug("shadow call skipped: saturated", "op", op)
+		return
+	}
+
+	go func() {
+		defer func() { <-s.slots }()
+
+		shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.cfg.Timeout)
+		defer cancel()
+
+		start := time.Now()
+		got, gotErr := call(shadowCtx, s.shadow)
+		elapsed := time.Since(start)
+
+		switch {
+		case (wantErr == nil) != (gotErr == nil):
+			s.logger.Warn("shadow divergence: error mismatch", "op", op, "primary_error", errString(wantErr), "shadow_error", errString(gotErr))
+		case wantErr != nil:
+			// Both failed; messages differ between drivers and are not compared.
+		default:
+			wantJSON, err1 := json.Marshal(want)
+			gotJSON, err2 := json.Marshal(got)
+			if err1 != nil || err2 != nil || !bytes.Equal(wantJSON, gotJSON) {
+				s.logger.Warn("shadow divergence: result mismatch", "op", op, "primary", string(wantJSON), "shadow", string(gotJSON))
+				return
+			}
+			s.logger.Debug("shadow match", "op", op, "shadow_elapsed", elapsed)
+		}
+	}()
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// page bundles the two results of List and Search for comparison.
+type page struct {
+	Items []Subscription
+	Total int
+}
+
+func (s *ShadowStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return s.primary.InTx(ctx, fn)
+}
+
+func (s *ShadowStore) GetByIDForUpdate(ctx context.Context, id string) (Subscription, error) {
+	return s.primary.GetByIDForUpdate(ctx, id)
+}
+
+func (s *ShadowStore) LockMatching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error) {
+	return s.primary.LockMatching(ctx, filter, limit)
+}
+
+func (s *ShadowStore) LockByKey(ctx context.Context, key NaturalKey) (Subscription, error) {
+	return s.primary.LockByKey(ctx, key)
+}
+
+func (s *ShadowStore) PlanService(ctx context.Context, planID int64) (string, error) {
+	service, err := s.primary.PlanService(ctx, planID)
+	mirror(s, ctx, "plan_service", false, service, err, func(ctx context.Context, st Store) (string, error) {
+		return st.PlanService(ctx, planID)
+	})
+	return service, err
+}
+
+func (s *ShadowStore) EndMonthInclusive() bool {
+	return s.primary.EndMonthInclusive()
+}
+
+func (s *ShadowStore) FindMatching(ctx context.Context, filter BulkFilter, limit int) ([]Subscription, error) {
+	return s.primary.FindMatching(ctx, filter, limit)
+}
+
+func (s *ShadowStore) Stream(ctx context.Context, opts ListOptions, fn func(Subscription) error) error {
+	return s.primary.Stream(ctx, opts, fn)
+}
+
+func (s *ShadowStore) Create(ctx context.Context, params CreateParams) (Subscription, error) {
+	sub, err := s.primary.Create(ctx, params)
+	mirror(s, ctx, "create", true, sub, err, func(ctx context.Context, st Store) (Subscription, error) {
+		return st.Create(ctx, params)
+	})
+	return sub, err
+}
+
+func (s *ShadowStore) GetByID(ctx context.Context, id string) (Subscription, error) {
+	sub, err := s.primary.GetByID(ctx, id)
+	mirror(s, ctx, "get", false, sub, err, func(ctx context.Context, st Store) (Subscription, error) {
+		return st.GetByID(ctx, id)
+	})
+	return sub, err
+}
+
+func (s *ShadowStore) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]Subscription, error) {
+	subs, err := s.primary.GetByIDs(ctx, ids)
+	mirror(s, ctx, "get_batch", false, subs, err, func(ctx context.Context, st Store) ([]Subscription, error) {
+		return st.GetByIDs(ctx, ids)
+	})
+	return subs, err
+}
+
+func (s *ShadowStore) GetBySlug(ctx context.Context, userID uuid.UUID, slug string) (Subscription, error) {
+	sub, err := s.primary.GetBySlug(ctx, userID, slug)
+	mirror(s, ctx, "get_by_slug", false, sub, err, func(ctx context.Context, st Store) (Subscription, error) {
+		return st.GetBySlug(ctx, userID, slug)
+	})
+	return sub, err
+}
+
+func (s *ShadowStore) List(ctx context.Context, opts ListOptions) ([]Subscription, int, error) {
+	subs, total, err := s.primary.List(ctx, opts)
+	mirror(s, ctx, "list", false, page{subs, total}, err, func(ctx context.Context, st Store) (page, error) {
+		subs, total, err := st.List(ctx, opts)
+		return page{subs, total}, err
+	})
+	return subs, total, err
+}
+
+func (s *ShadowStore) ListVersion(ctx context.Context, opts ListOptions) (CollectionVersion, error) {
+	version, err := s.primary.ListVersion(ctx, opts)
+	mirror(s, ctx, "list_version", false, version, err, func(ctx context.Context, st Store) (CollectionVersion, error) {
+		return st.ListVersion(ctx, opts)
+	})
+	return version, err
+}
+
+func (s *ShadowStore) Update(ctx context.Context, params UpdateParams) (Subscription, error) {
+	sub, err := s.primary.Update(ctx, params)
+	mirror(s, ctx, "update", true, sub, err, func(ctx context.Context, st Store) (Subscription, error) {
+		return st.Update(ctx, params)
+	})
+	return sub, err
+}
+
+func (s *ShadowStore) Delete(ctx context.Context, id string) error {
+	err := s.primary.Delete(ctx, id)
+	mirror(s, ctx, "delete", true, struct{}{}, err, func(ctx context.Context, st Store) (struct{}, error) {
+		return struct{}{}, st.Delete(ctx, id)
+	})
+	return err
+}
+
+func (s *ShadowStore) LatestAudit(ctx context.Context, id uuid.UUID) (AuditEntry, error) {
+	return s.primary.LatestAudit(ctx, id)
+}
+
+func (s *ShadowStore) AuditTrail(ctx context.Context, id uuid.UUID) ([]AuditEntry, error) {
+	return s.primary.AuditTrail(ctx, id)
+}
+
+func (s *ShadowStore) UserAuditTrail(ctx context.Context, userID uuid.UUID, fn func(AuditEntry) error) error {
+	return s.primary.UserAuditTrail(ctx, userID, fn)
+}
+
+func (s *ShadowStore) RecordAudit(ctx context.Context, entry AuditEntry) error {
+	err := s.primary.RecordAudit(ctx, entry)
+	mirror(s, ctx, "record_audit", true, struct{}{}, err, func(ctx context.Context, st Store) (struct{}, error) {
+		return struct{}{}, st.RecordAudit(ctx, entry)
+	})
+	return err
+}
+
+func (s *ShadowStore) SumByPeriod(ctx context.Context, filter SumFilter) (int64, error) {
+	total, err := s.primary.SumByPeriod(ctx, filter)
+	mirror(s, ctx, "sum_by_period", false, total, err, func(ctx context.Context, st Store) (int64, error) {
+		return st.SumByPeriod(ctx, filter)
+	})
+	return total, err
+}
+
+func (s *ShadowStore) SumByUsers(ctx context.Context, filter BatchSumFilter) (map[uuid.UUID]int64, error) {
+	totals, err := s.primary.SumByUsers(ctx, filter)
+	mirror(s, ctx, "sum_by_users", false, totals, err, func(ctx context.Context, st Store) (map[uuid.UUID]int64, error) {
+		return st.SumByUsers(ctx, filter)
+	})
+	return totals, err
+}
+
+func (s *ShadowStore) SumByTags(ctx context.Context, filter SumFilter, mode TagMode) ([]TagTotal, error) {
+	totals, err := s.primary.SumByTags(ctx, filter, mode)
+	mirror(s, ctx, "sum_by_tags", false, totals, err, func(ctx context.Context, st Store) ([]TagTotal, error) {
+		return st.SumByTags(ctx, filter, mode)
+	})
+	return totals, err
+}
+
+func (s *ShadowStore) SumTimeSeries(ctx context.Context, filter SumFilter, granularity Granularity) ([]TimeSeriesPoint, error) {
+	points, err := s.primary.SumTimeSeries(ctx, filter, granularity)
+	mirror(s, ctx, "sum_time_series", false, points, err, func(ctx context.Context, st Store) ([]TimeSeriesPoint, error) {
+		return st.SumTimeSeries(ctx, filter, granularity)
+	})
+	return points, err
+}
+
+func (s *ShadowStore) Search(ctx context.Context, q SearchQuery) ([]Subscription, int, error) {
+	subs, total, err := s.primary.Search(ctx, q)
+	mirror(s, ctx, "search", false, page{subs, total}, err, func(ctx context.Context, st Store) (page, error) {
+		subs, total, err := st.Search(ctx, q)
+		return page{subs, total}, err
+	})
+	return subs, total, err
+}
+
+func (s *ShadowStore) SpendDistribution(ctx context.Context, start, end time.Time) (SpendDistribution, error) {
+	dist, err := s.primary.SpendDistribution(ctx, start, end)
+	mirror(s, ctx, "spend_distribution", false, dist, err, func(ctx context.Context, st Store) (SpendDistribution, error) {
+		return st.SpendDistribution(ctx, start, end)
+	})
+	return dist, err
+}
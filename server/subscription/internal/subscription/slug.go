@@ -0,0 +1,138 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	goqu "github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/identity"
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+const (
+	maxSlugBase     = 40
+	fallbackSlug    = "sub"
+	slugConstraint  = "subscriptions_user_slug_key"
+	slugInsertTries = 3
+)
+
+var (
+	slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+	slugPattern    = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*-[0-9]+$`)
+)
+
+// slugBase turns a service name into the readable part of a slug. Names with
+// nothing ASCII-alphanumeric in them (for example, Cyrillic ones) fall back to
+// "sub". It must stay in step with the backfill in the slug migration.
+func slugBase(serviceName string) string {
+	base := slugSeparators.ReplaceAllString(strings.ToLower(serviceName), "-")
+	if len(base) > maxSlugBase {
+		base = base[:maxSlugBase]
+	}
+	base = strings.Trim(base, "-")
+	if base == "" {
+		return fallbackSlug
+	}
+	return base
+}
+
+// IsSlug reports whether ref has the shape of a subscription slug.
+func IsSlug(ref string) bool {
+	return slugPattern.MatchString(ref)
+}
+
+// nextSlug is the SQL for the next free "<base>-<n>" slug of userID. base is
+// a string or an SQL expression. Two concurrent inserts can compute the same
+// value; the unique constraint rejects the second and Create retries it.
+func nextSlug(userID uuid.UUID, base any) exp.LiteralExpression {
+	return goqu.L(`? || '-' || (
+        SELECT COALESCE(MAX(substring(o.slug FROM '[0-9]+$')::bigint), 0) + 1
+        FROM subscriptions o
+        WHERE o.user_id = ? AND o.slug ~ ('^' || ? || '-[0-9]+$'))`,
+		base, userID, base)
+}
+
+func isSlugConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == slugConstraint
+}
+
+// GetBySlug loads the subscription userID addresses as slug.
+func (r *Repository) GetBySlug(ctx context.Context, userID uuid.UUID, slug string) (Subscription, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	query, args, err := r.builder.From("subscriptions").Select(subscriptionColumns...).
+		Where(goqu.C("user_id").Eq(userID), goqu.C("slug").Eq(slug)).
+		ToSQL()
+	if err != nil {
+		return Subscription{}, fmt.Errorf("build get subscription by slug: %w", err)
+	}
+
+	var sub Subscription
+	if err := scanSubscription(r.db.QueryRowContext(ctx, query, args...), &sub); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Subscription{}, err
+		}
+		if r.logger != nil {
+			r.logger.Error("get subscription by slug failed", "slug", slug, "error", err)
+		}
+		return Subscription{}, fmt.Errorf("select subscription by slug: %w", err)
+	}
+	return sub, nil
+}
+
+// subscriptionID resolves the :id path parameter, which is either a UUID or a
+// slug. A slug is looked up among the subscriptions of the user_id query
+// parameter if given, otherwise of the caller. On failure it writes the
+// response and returns false.
+func (h *Handler) subscriptionID(c *gin.Context) (uuid.UUID, bool) {
+	ref := c.Param("id")
+	if id, err := uuid.Parse(ref); err == nil {
+		return id, true
+	}
+	if !IsSlug(ref) {
+		h.logger.Info("invalid subscription id", "id", ref)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return uuid.Nil, false
+	}
+
+	var owner uuid.UUID
+	if value := c.Query("user_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return uuid.Nil, false
+		}
+		if !authorizeUser(c, parsed, rbac.ReadAnyUser) {
+			return uuid.Nil, false
+		}
+		owner = parsed
+	} else if caller, ok := identity.FromContext(c.Request.Context()); ok {
+		owner = caller.UserID
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required to address a subscription by slug"})
+		return uuid.Nil, false
+	}
+
+	sub, err := h.svc.GetBySlug(c.Request.Context(), owner, ref)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			return uuid.Nil, false
+		}
+		h.serverError(c, "failed to resolve subscription slug", err, "slug", ref)
+		return uuid.Nil, false
+	}
+	return sub.ID, true
+}
@@ -0,0 +1,151 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+)
+
+// Subscription statuses. Active, paused and cancelled are stored; expired is
+// derived from the end month, so it needs no job to keep it current. Every
+// pause is also kept in subscription_pauses, so summaries leave out only the
+// months a subscription was paused for.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+// transitions lists the statuses each status may move to. Cancelled and
+// expired subscriptions are final.
+var transitions = map[string][]string{
+	StatusActive: {StatusPaused, StatusCancelled},
+	StatusPaused: {StatusActive, StatusCancelled},
+}
+
+// statusVerbs names the request that moves a subscription to a status, for
+// error messages.
+var statusVerbs = map[string]string{
+	StatusActive:    "resume",
+	StatusPaused:    "pause",
+	StatusCancelled: "cancel",
+}
+
+// CurrentStatus returns the status of sub as of now: the stored one, except
+// that an active subscription whose last charged month is before now's is
+// expired. An end month without its own setting is charged when
+// defaultInclusive is set, the deployment default the summaries use.
+func (sub Subscription) CurrentStatus(now time.Time, defaultInclusive bool) string {
+	if sub.Status != StatusActive || sub.EndMonth == nil {
+		return sub.Status
+	}
+	if months.LastCharged(*sub.EndMonth, sub.endInclusive(defaultInclusive)).Before(months.Normalize(now)) {
+		return StatusExpired
+	}
+	return sub.Status
+}
+
+// endInclusive reports whether sub's end month is charged, falling back to
+// defaultInclusive when it has no setting of its own.
+func (sub Subscription) endInclusive(defaultInclusive bool) bool {
+	if sub.EndMonthInclusive == nil {
+		return defaultInclusive
+	}
+	return *sub.EndMonthInclusive
+}
+
+// expiredSQL is the SQL counterpart of the expiry rule in CurrentStatus.
+func expiredSQL(defaultInclusive bool) goqu.Expression {
+	return goqu.L(`status = 'active' AND end_month IS NOT NULL AND `+
+		months.LastChargedSQL("end_month", "COALESCE(end_month_inclusive, ?::boolean)")+
+		` < date_trunc('month', now())::date`, defaultInclusive)
+}
+
+// statusWhere filters on the current status.
+func statusWhere(status string, defaultInclusive bool) goqu.Expression {
+	switch status {
+	case StatusExpired:
+		return expiredSQL(defaultInclusive)
+	case StatusActive:
+		return goqu.And(goqu.C("status").Eq(StatusActive), goqu.L("NOT (?)", expiredSQL(defaultInclusive)))
+	default:
+		return goqu.C("status").Eq(status)
+	}
+}
+
+// ChangeStatus moves a subscription to status to: StatusPaused pauses it,
+// StatusActive resumes it and StatusCancelled cancels it. Cancelling also
+// ends it with the current month unless it ends earlier already. A move the
+// lifecycle does not allow, such as resuming a cancelled subscription,
+// returns ErrInvalidTransition.
+func (s *service) ChangeStatus(ctx context.Context, id uuid.UUID, to string) (Subscription, error) {
+	verb, ok := statusVerbs[to]
+	if !ok {
+		return Subscription{}, &ValidationError{Field: "status", Message: fmt.Sprintf("cannot be set to %q", to)}
+	}
+
+	var updated Subscription
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		current, err := tx.GetByIDForUpdate(ctx, id.String())
+		if err != nil {
+			return err
+		}
+		if err := checkWrite(ctx, current); err != nil {
+			return err
+		}
+		now, defaultInclusive := time.Now().UTC(), tx.EndMonthInclusive()
+		from := current.CurrentStatus(now, defaultInclusive)
+		if !slices.Contains(transitions[from], to) {
+			return fmt.Errorf("%w: cannot %s a subscription that is %s", ErrInvalidTransition, verb, from)
+		}
+
+		params := UpdateParams{ID: id, Status: &to}
+		if to == StatusCancelled {
+			cancelEnd(&params, current, months.Normalize(now), defaultInclusive)
+		}
+		if err := checkLock(ctx, current, params); err != nil {
+			return err
+		}
+		for _, hook := range s.hooks {
+			if err := hookResult(ctx, hook.BeforeUpdate(ctx, params)); err != nil {
+				return fmt.Errorf("%w: %w", ErrRejected, err)
+			}
+		}
+		if updated, err = tx.Update(ctx, params); err != nil {
+			return err
+		}
+		return tx.RecordAudit(ctx, AuditEntry{
+			SubscriptionID: id,
+			Action:         ActionUpdate,
+			ActorID:        callerID(ctx),
+			Before:         &current,
+			After:          &updated,
+		})
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	s.emit(ctx, EventUpdated, updated)
+	return updated, nil
+}
+
+// cancelEnd makes a subscription cancelled in month stop being charged after
+// it. One that has not started yet is never charged: it ends, exclusively,
+// where it starts.
+func cancelEnd(params *UpdateParams, current Subscription, month time.Time, defaultInclusive bool) {
+	if current.EndMonth != nil && !months.LastCharged(*current.EndMonth, current.endInclusive(defaultInclusive)).After(month) {
+		return
+	}
+	end, charged := month, true
+	if current.StartMonth.After(month) {
+		end, charged = current.StartMonth, false
+	}
+	params.EndMonth, params.EndMonthSet, params.EndMonthInclusive = &end, true, &charged
+}
@@ -0,0 +1,37 @@
+package subscription
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 32
+)
+
+// normalizeTags lowercases and trims tags and drops repeats, keeping the
+// order they were first given in, so "Work" and " work" are one tag.
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return nil, &ValidationError{Field: "tags", Message: "cannot contain empty tags"}
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("must be at most %d characters each", maxTagLength)}
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) > maxTags {
+		return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("accepts at most %d tags", maxTags)}
+	}
+	return out, nil
+}
@@ -0,0 +1,48 @@
+package subscription
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, maxTags+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", tags: nil, want: []string{}},
+		{name: "lowercased and trimmed", tags: []string{" Work ", "VIDEO"}, want: []string{"work", "video"}},
+		{name: "repeats dropped in order", tags: []string{"b", "a", "B", " a"}, want: []string{"b", "a"}},
+		{name: "repeats do not count toward the limit", tags: append(slices.Clone(tooMany[:maxTags]), "T"), want: tooMany[:maxTags]},
+		{name: "longest tag", tags: []string{strings.Repeat("я", maxTagLength)}, want: []string{strings.Repeat("я", maxTagLength)}},
+		{name: "empty tag", tags: []string{"work", "  "}, wantErr: true},
+		{name: "tag too long", tags: []string{strings.Repeat("x", maxTagLength+1)}, wantErr: true},
+		{name: "too many tags", tags: tooMany, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTags(tt.tags)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("normalizeTags(%q) error = %v, want ErrInvalidInput", tt.tags, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeTags(%q): %v", tt.tags, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("normalizeTags(%q) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package subscription
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+// Timeline item kinds.
+const (
+	TimelineCreated      = "created"
+	TimelineUpdated      = "updated"
+	TimelinePriceChanged = "price_changed"
+	TimelineLocked       = "locked"
+	TimelineUnlocked     = "unlocked"
+	TimelineTransferred  = "transferred"
+	TimelineUndone       = "undone"
+	TimelineStarted      = "started"
+	TimelineEnded        = "ended"
+	TimelinePaused       = "paused"
+	TimelineResumed      = "resumed"
+	TimelineCancelled    = "cancelled"
+)
+
+// TimelineItem is one event in the life of a subscription. Changes lists the
+// fields an audited change touched; started and ended mark the months the
+// subscription began and stopped being charged.
+type TimelineItem struct {
+	At      time.Time     `json:"at"`
+	Kind    string        `json:"kind" enums:"created,updated,price_changed,locked,unlocked,transferred,undone,started,ended,paused,resumed,cancelled"`
+	ActorID *uuid.UUID    `json:"actor_id,omitempty"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is the old and new value of one field.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// Timeline returns the events of a subscription in chronological order: its
+// creation, every audited change, and the months it started and ended, as far
+// as they are in the past.
+func (s *service) Timeline(ctx context.Context, id uuid.UUID) ([]TimelineItem, error) {
+	sub, err := s.GetByID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.AuditTrail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return buildTimeline(sub, entries, time.Now().UTC(), s.repo.EndMonthInclusive()), nil
+}
+
+func buildTimeline(sub Subscription, entries []AuditEntry, now time.Time, defaultInclusive bool) []TimelineItem {
+	items := []TimelineItem{{At: sub.CreatedAt, Kind: TimelineCreated}}
+	for _, entry := range entries {
+		item := TimelineItem{At: entry.OccurredAt, ActorID: entry.ActorID}
+		if entry.Before != nil && entry.After != nil {
+			item.Changes = diffFields(*entry.Before, *entry.After)
+		}
+		item.Kind = auditKind(entry.Action, item.Changes)
+		items = append(items, item)
+	}
+
+	if !sub.StartMonth.After(now) {
+		items = append(items, TimelineItem{At: sub.StartMonth, Kind: TimelineStarted})
+	}
+	if sub.EndMonth != nil {
+		ended := months.LastCharged(*sub.EndMonth, sub.endInclusive(defaultInclusive)).AddDate(0, 1, 0)
+		if !ended.After(now) {
+			items = append(items, TimelineItem{At: ended, Kind: TimelineEnded})
+		}
+	}
+
+	slices.SortStableFunc(items, func(a, b TimelineItem) int {
+		return a.At.Compare(b.At)
+	})
+	return items
+}
+
+func auditKind(action string, changes []FieldChange) string {
+	switch action {
+	case ActionTransfer:
+		return TimelineTransferred
+	case ActionUndo:
+		return TimelineUndone
+	}
+	if len(changes) == 1 && changes[0].Field == "locked" {
+		if changes[0].To == true {
+			return TimelineLocked
+		}
+		return TimelineUnlocked
+	}
+	for _, c := range changes {
+		if c.Field != "status" {
+			continue
+		}
+		switch c.To {
+		case StatusPaused:
+			return TimelinePaused
+		case StatusActive:
+			return TimelineResumed
+		case StatusCancelled:
+			return TimelineCancelled
+		}
+	}
+	if slices.ContainsFunc(changes, func(c FieldChange) bool {
+		return c.Field == "price" || c.Field == "amount_minor" || c.Field == "billing_period"
+	}) {
+		return TimelinePriceChanged
+	}
+	return TimelineUpdated
+}
+
+// diffFields lists the client-visible fields that differ between before and
+// after, named as in the API.
+func diffFields(before, after Subscription) []FieldChange {
+	var changes []FieldChange
+	if before.ServiceName != after.ServiceName {
+		changes = append(changes, FieldChange{Field: "service_name", From: before.ServiceName, To: after.ServiceName})
+	}
+	if before.PriceRUB != after.PriceRUB {
+		changes = append(changes, FieldChange{Field: "price", From: before.PriceRUB, To: after.PriceRUB})
+	}
+	if before.Currency != after.Currency {
+		changes = append(changes, FieldChange{Field: "currency", From: before.Currency, To: after.Currency})
+	}
+	if before.AmountMinor != after.AmountMinor {
+		changes = append(changes, FieldChange{Field: "amount_minor", From: before.AmountMinor, To: after.AmountMinor})
+	}
+	// Snapshots from before billing periods existed have none.
+	if before.BillingPeriod != after.BillingPeriod && before.BillingPeriod != "" && after.BillingPeriod != "" {
+		changes = append(changes, FieldChange{Field: "billing_period", From: before.BillingPeriod, To: after.BillingPeriod})
+	}
+	if !samePlan(before.PlanID, after.PlanID) {
+		changes = append(changes, FieldChange{Field: "plan_id", From: before.PlanID, To: after.PlanID})
+	}
+	if before.UserID != after.UserID {
+		changes = append(changes, FieldChange{Field: "user_id", From: before.UserID, To: after.UserID})
+	}
+	if !before.StartMonth.Equal(after.StartMonth) {
+		changes = append(changes, FieldChange{Field: "start_date", From: types.NewMonth(before.StartMonth), To: types.NewMonth(after.StartMonth)})
+	}
+	if !sameMonth(before.EndMonth, after.EndMonth) {
+		changes = append(changes, FieldChange{Field: "end_date", From: types.MonthPtr(before.EndMonth), To: types.MonthPtr(after.EndMonth)})
+	}
+	if !sameBool(before.EndMonthInclusive, after.EndMonthInclusive) {
+		changes = append(changes, FieldChange{Field: "end_month_inclusive", From: before.EndMonthInclusive, To: after.EndMonthInclusive})
+	}
+	if before.Locked != after.Locked {
+		changes = append(changes, FieldChange{Field: "locked", From: before.Locked, To: after.Locked})
+	}
+	// Snapshots from before statuses existed have none.
+	if before.Status != after.Status && before.Status != "" && after.Status != "" {
+		changes = append(changes, FieldChange{Field: "status", From: before.Status, To: after.Status})
+	}
+	return changes
+}
@@ -0,0 +1,176 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Undo reverts the most recent update of a subscription if it is at most
+// window old. Only the fields that update changed are restored, and only if
+// none of them changed again since; otherwise an UndoConflictError names
+// them. The undo is itself audited, so it cannot be undone in turn.
+func (s *service) Undo(ctx context.Context, id uuid.UUID, window time.Duration) (Subscription, error) {
+	var updated Subscription
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		current, err := tx.GetByIDForUpdate(ctx, id.String())
+		if err != nil {
+			return err
+		}
+		if err := checkWrite(ctx, current); err != nil {
+			return err
+		}
+		entry, err := tx.LatestAudit(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return err
+		}
+		if entry.Action != ActionUpdate || entry.Before == nil || entry.After == nil {
+			return fmt.Errorf("%w: the last change was a %s", ErrNothingToUndo, entry.Action)
+		}
+		if time.Since(entry.OccurredAt) > window {
+			return fmt.Errorf("%w: the last change is older than %s", ErrNothingToUndo, window)
+		}
+
+		if entry.Before.EndMonthInclusive == nil && entry.After.EndMonthInclusive != nil {
+			// Updates can set the flag but not return it to the deployment
+			// default.
+			return fmt.Errorf("%w: end_month_inclusive cannot be reset to the default", ErrNothingToUndo)
+		}
+
+		params, conflicts := revertParams(*entry.Before, *entry.After, current)
+		if len(conflicts) > 0 {
+			return &UndoConflictError{Fields: conflicts}
+		}
+		if !params.changesFields() && params.Locked == nil {
+			return fmt.Errorf("%w: the last change did not modify any field", ErrNothingToUndo)
+		}
+
+		if err := checkLock(ctx, current, params); err != nil {
+			return err
+		}
+		for _, hook := range s.hooks {
+			if err := hookResult(ctx, hook.BeforeUpdate(ctx, params)); err != nil {
+				return fmt.Errorf("%w: %w", ErrRejected, err)
+			}
+		}
+		if updated, err = tx.Update(ctx, params); err != nil {
+			return err
+		}
+		return tx.RecordAudit(ctx, AuditEntry{
+			SubscriptionID: id,
+			Action:         ActionUndo,
+			ActorID:        callerID(ctx),
+			Before:         &current,
+			After:          &updated,
+		})
+	})
+	if err != nil {
+		return Subscription{}, err
+	}
+	s.emit(ctx, EventUpdated, updated)
+	return updated, nil
+}
+
+// revertParams builds the update restoring before for every field that
+// differs between before and after. A field whose current value is no longer
+// after's was changed again and is reported as a conflict.
+func revertParams(before, after, current Subscription) (UpdateParams, []string) {
+	params := UpdateParams{ID: current.ID}
+	var conflicts []string
+
+	if before.ServiceName != after.ServiceName {
+		if current.ServiceName != after.ServiceName {
+			conflicts = append(conflicts, "service_name")
+		}
+		params.ServiceName = &before.ServiceName
+	}
+	// The price is restored as stored, not converted again at today's rate.
+	if !samePrice(before, after) {
+		if !samePrice(current, after) {
+			conflicts = append(conflicts, "price")
+		}
+		params.PriceRUB = &before.PriceRUB
+		if before.Currency != "" {
+			params.Currency, params.AmountMinor = &before.Currency, &before.AmountMinor
+		}
+	}
+	if before.BillingPeriod != after.BillingPeriod && before.BillingPeriod != "" {
+		if current.BillingPeriod != after.BillingPeriod {
+			conflicts = append(conflicts, "billing_period")
+		}
+		params.BillingPeriod = &before.BillingPeriod
+	}
+	if !before.StartMonth.Equal(after.StartMonth) {
+		if !current.StartMonth.Equal(after.StartMonth) {
+			conflicts = append(conflicts, "start_date")
+		}
+		params.StartMonth = &before.StartMonth
+	}
+	if !samePlan(before.PlanID, after.PlanID) {
+		if !samePlan(current.PlanID, after.PlanID) {
+			conflicts = append(conflicts, "plan_id")
+		}
+		var plan int64
+		if before.PlanID != nil {
+			plan = *before.PlanID
+		}
+		params.PlanID = &plan
+	}
+	if !sameMonth(before.EndMonth, after.EndMonth) {
+		if !sameMonth(current.EndMonth, after.EndMonth) {
+			conflicts = append(conflicts, "end_date")
+		}
+		params.EndMonth, params.EndMonthSet = before.EndMonth, true
+	}
+	if !sameBool(before.EndMonthInclusive, after.EndMonthInclusive) {
+		if !sameBool(current.EndMonthInclusive, after.EndMonthInclusive) {
+			conflicts = append(conflicts, "end_month_inclusive")
+		}
+		params.EndMonthInclusive = before.EndMonthInclusive
+	}
+	if before.Locked != after.Locked {
+		if current.Locked != after.Locked {
+			conflicts = append(conflicts, "locked")
+		}
+		params.Locked = &before.Locked
+	}
+	if before.Status != after.Status && before.Status != "" {
+		if current.Status != after.Status {
+			conflicts = append(conflicts, "status")
+		}
+		params.Status = &before.Status
+	}
+	return params, conflicts
+}
+
+func samePlan(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func samePrice(a, b Subscription) bool {
+	return a.PriceRUB == b.PriceRUB && a.Currency == b.Currency && a.AmountMinor == b.AmountMinor
+}
+
+func sameMonth(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameBool(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,178 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/rbac"
+)
+
+// NaturalKey identifies a subscription without its ID, for sync clients that
+// only know what the subscription is: its owner, service name (compared
+// case-insensitively) and start month.
+type NaturalKey struct {
+	UserID      uuid.UUID
+	ServiceName string
+	StartMonth  time.Time
+}
+
+// lockName names the advisory lock that serializes upserts of the key.
+func (k NaturalKey) lockName() string {
+	return fmt.Sprintf("subscription:%s:%s:%s", k.UserID, strings.ToLower(k.ServiceName), k.StartMonth.Format("2006-01"))
+}
+
+// LockByKey loads the oldest subscription with key and locks its row until
+// the surrounding transaction ends. It first takes a transaction-scoped
+// advisory lock on the key, so concurrent upserts of a key that does not
+// exist yet cannot both insert it. It must be called from within InTx.
+func (r *Repository) LockByKey(ctx context.Context, key NaturalKey) (Subscription, error) {
+	ctx, cancel := r.withDeadline(ctx, r.timeouts.Get)
+	defer cancel()
+
+	if !r.inTx {
+		return Subscription{}, errors.New("LockByKey requires a transaction")
+	}
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key.lockName()); err != nil {
+		return Subscription{}, fmt.Errorf("lock subscription key: %w", err)
+	}
+
+	ds := r.builder.From("subscriptions").Select(subscriptionColumns...).
+		Where(
+			goqu.C("user_id").Eq(key.UserID),
+			goqu.Func("LOWER", goqu.C("service_name")).Eq(strings.ToLower(key.ServiceName)),
+			goqu.C("start_month").Eq(key.StartMonth),
+		).
+		Order(goqu.C("created_at").Asc(), goqu.C("id").Asc()).
+		Limit(1).
+		ForUpdate(exp.Wait)
+
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return Subscription{}, fmt.Errorf("build lock subscription by key: %w", err)
+	}
+
+	var sub Subscription
+	if err := scanSubscription(r.db.QueryRowContext(ctx, query, args...), &sub); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Subscription{}, err
+		}
+		return Subscription{}, fmt.Errorf("lock subscription by key: %w", err)
+	}
+	return sub, nil
+}
+
+// Upsert creates the subscription params describes unless one with the same
+// natural key exists, in which case that one is updated to match: price,
+// currency, billing period, plan and end month are replaced, end_month_inclusive when given,
+// and the service name takes the spelling of params. Re-running the same upsert
+// changes nothing, not even the version. It reports whether the subscription
+// was created.
+func (s *service) Upsert(ctx context.Context, params CreateParams) (Subscription, bool, error) {
+	params.ServiceName = strings.TrimSpace(params.ServiceName)
+	if err := validateCreate(params); err != nil {
+		return Subscription{}, false, err
+	}
+	if !rbac.CanAccessUser(ctx, params.UserID, rbac.WriteAnyUser) {
+		return Subscription{}, false, fmt.Errorf("%w: only the owner or an admin can change these subscriptions", ErrForbidden)
+	}
+	key := NaturalKey{UserID: params.UserID, ServiceName: params.ServiceName, StartMonth: params.StartMonth}
+
+	var (
+		sub     Subscription
+		created bool
+		changed bool
+	)
+	err := s.repo.InTx(ctx, func(tx Store) error {
+		current, err := tx.LockByKey(ctx, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			sub, err = s.createIn(ctx, tx, params)
+			created = err == nil
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		update, err := s.upsertChanges(ctx, current, params)
+		if err != nil || !update.changesFields() {
+			sub = current
+			return err
+		}
+		if err := checkLock(ctx, current, update); err != nil {
+			return err
+		}
+		if err := checkPlanUpdate(ctx, tx, current, update); err != nil {
+			return err
+		}
+		for _, hook := range s.hooks {
+			if err := hookResult(ctx, hook.BeforeUpdate(ctx, update)); err != nil {
+				return fmt.Errorf("%w: %w", ErrRejected, err)
+			}
+		}
+		if sub, err = tx.Update(ctx, update); err != nil {
+			return err
+		}
+		changed = true
+		return tx.RecordAudit(ctx, AuditEntry{
+			SubscriptionID: sub.ID,
+			Action:         ActionUpdate,
+			ActorID:        callerID(ctx),
+			Before:         &current,
+			After:          &sub,
+		})
+	})
+	if err != nil {
+		return Subscription{}, false, err
+	}
+	switch {
+	case created:
+		s.emit(ctx, EventCreated, sub)
+	case changed:
+		s.emit(ctx, EventUpdated, sub)
+	}
+	return sub, created, nil
+}
+
+// upsertChanges builds the update that makes current match params, leaving
+// out fields that already do. The price counts as unchanged when the
+// currency and amount are, so an unchanged price is not converted again at
+// a newer rate.
+func (s *service) upsertChanges(ctx context.Context, current Subscription, params CreateParams) (UpdateParams, error) {
+	if err := s.priceCreate(ctx, &params); err != nil {
+		return UpdateParams{}, err
+	}
+	update := UpdateParams{ID: current.ID}
+	if current.ServiceName != params.ServiceName {
+		update.ServiceName = &params.ServiceName
+	}
+	if current.Currency != params.Currency || current.AmountMinor != *params.AmountMinor {
+		update.PriceRUB, update.Currency, update.AmountMinor = &params.PriceRUB, &params.Currency, params.AmountMinor
+	}
+	if params.BillingPeriod == "" {
+		params.BillingPeriod = BillingMonthly
+	}
+	if current.BillingPeriod != params.BillingPeriod {
+		update.BillingPeriod = &params.BillingPeriod
+	}
+	if !sameMonth(current.EndMonth, params.EndMonth) {
+		update.EndMonth, update.EndMonthSet = params.EndMonth, true
+	}
+	if !samePlan(current.PlanID, params.PlanID) {
+		var plan int64
+		if params.PlanID != nil {
+			plan = *params.PlanID
+		}
+		update.PlanID = &plan
+	}
+	if params.EndMonthInclusive != nil && !sameBool(current.EndMonthInclusive, params.EndMonthInclusive) {
+		update.EndMonthInclusive = params.EndMonthInclusive
+	}
+	return update, nil
+}
@@ -0,0 +1,109 @@
+package subscription
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/money"
+)
+
+// validateCreate checks the business rules every new subscription must meet,
+// whichever transport it came through.
+func validateCreate(params CreateParams) error {
+	if params.ServiceName == "" {
+		return &ValidationError{Field: "service_name", Message: "is required"}
+	}
+	if params.PriceRUB < 0 {
+		return &ValidationError{Field: "price", Message: "cannot be negative"}
+	}
+	if err := validateAmount(params.Currency, params.AmountMinor); err != nil {
+		return err
+	}
+	if params.BillingPeriod != "" {
+		if err := validateBillingPeriod(params.BillingPeriod); err != nil {
+			return err
+		}
+	}
+	if params.PlanID != nil && *params.PlanID <= 0 {
+		return &ValidationError{Field: "plan_id", Message: "must be positive"}
+	}
+	if params.UserID == uuid.Nil {
+		return &ValidationError{Field: "user_id", Message: "is required"}
+	}
+	if params.StartMonth.IsZero() {
+		return &ValidationError{Field: "start_date", Message: "is required"}
+	}
+	return validateRange(params.StartMonth, params.EndMonth)
+}
+
+// validateUpdate checks the fields an update sets on their own. The date
+// order is checked separately against the stored record.
+func validateUpdate(params UpdateParams) error {
+	if params.ServiceName != nil && *params.ServiceName == "" {
+		return &ValidationError{Field: "service_name", Message: "cannot be empty"}
+	}
+	if params.PriceRUB != nil && *params.PriceRUB < 0 {
+		return &ValidationError{Field: "price", Message: "cannot be negative"}
+	}
+	currency := ""
+	if params.Currency != nil {
+		if strings.TrimSpace(*params.Currency) == "" {
+			return &ValidationError{Field: "currency", Message: "cannot be empty"}
+		}
+		currency = *params.Currency
+	}
+	if err := validateAmount(currency, params.AmountMinor); err != nil {
+		return err
+	}
+	if params.BillingPeriod != nil {
+		if err := validateBillingPeriod(*params.BillingPeriod); err != nil {
+			return err
+		}
+	}
+	if params.PlanID != nil && *params.PlanID < 0 {
+		return &ValidationError{Field: "plan_id", Message: "cannot be negative"}
+	}
+	if params.StartMonth != nil && params.StartMonth.IsZero() {
+		return &ValidationError{Field: "start_date", Message: "cannot be empty"}
+	}
+	if params.UserID != nil && *params.UserID == uuid.Nil {
+		return &ValidationError{Field: "user_id", Message: "cannot be empty"}
+	}
+	if params.StartMonth != nil && params.EndMonthSet {
+		return validateRange(*params.StartMonth, params.EndMonth)
+	}
+	return nil
+}
+
+// validateAmount checks a price given in a currency: the code, when set,
+// must be supported and the amount must not be negative.
+func validateAmount(currency string, amountMinor *int64) error {
+	if currency != "" {
+		if _, ok := money.Lookup(currency); !ok {
+			return &ValidationError{Field: "currency", Message: "must be one of " + strings.Join(money.Codes(), ", ")}
+		}
+	}
+	if amountMinor != nil && *amountMinor < 0 {
+		return &ValidationError{Field: "amount_minor", Message: "cannot be negative"}
+	}
+	return nil
+}
+
+// validateClientID checks an ID chosen by the client rather than generated
+// here. Only random (v4) and time-ordered (v7) UUIDs are accepted, so IDs
+// cannot be derived from names or MAC addresses.
+func validateClientID(id uuid.UUID) error {
+	if id.Variant() != uuid.RFC4122 || (id.Version() != 4 && id.Version() != 7) {
+		return &ValidationError{Field: "id", Message: "must be a version 4 or 7 UUID"}
+	}
+	return nil
+}
+
+func validateRange(start time.Time, end *time.Time) error {
+	if end != nil && end.Before(start) {
+		return &ValidationError{Field: "end_date", Message: "cannot be before start_date"}
+	}
+	return nil
+}
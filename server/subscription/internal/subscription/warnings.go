@@ -0,0 +1,80 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Warning is a non-fatal finding about a write. A ValidationHook returns one
+// (or Warnings) instead of an error to inform the caller without blocking the
+// operation; the warnings are returned alongside the result.
+type Warning struct {
+	Rule    string `json:"rule" example:"price_high"`
+	Message string `json:"message" example:"price 9000 is unusually high"`
+}
+
+func (w *Warning) Error() string {
+	return fmt.Sprintf("warning %s: %s", w.Rule, w.Message)
+}
+
+// Warnings lets a hook raise several warnings at once.
+type Warnings []Warning
+
+func (ws Warnings) Error() string {
+	msgs := make([]string, 0, len(ws))
+	for _, w := range ws {
+		msgs = append(msgs, w.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type warningsKey struct{}
+
+type warningSet struct {
+	mu   sync.Mutex
+	list []Warning
+}
+
+// CollectWarnings returns a context that gathers the warnings raised while
+// serving it, and a function returning them. Warnings raised under a context
+// without a collector are dropped.
+func CollectWarnings(ctx context.Context) (context.Context, func() []Warning) {
+	set := &warningSet{}
+	return context.WithValue(ctx, warningsKey{}, set), func() []Warning {
+		set.mu.Lock()
+		defer set.mu.Unlock()
+		return append([]Warning(nil), set.list...)
+	}
+}
+
+// hookResult turns a hook's return value into the error that aborts the
+// operation, recording warnings in ctx instead.
+func hookResult(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	var one *Warning
+	var many Warnings
+	switch {
+	case errors.As(err, &one):
+		addWarnings(ctx, *one)
+	case errors.As(err, &many):
+		addWarnings(ctx, many...)
+	default:
+		return err
+	}
+	return nil
+}
+
+func addWarnings(ctx context.Context, ws ...Warning) {
+	set, ok := ctx.Value(warningsKey{}).(*warningSet)
+	if !ok {
+		return
+	}
+	set.mu.Lock()
+	set.list = append(set.list, ws...)
+	set.mu.Unlock()
+}
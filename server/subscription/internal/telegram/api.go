@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const apiBaseURL = "https://api.telegram.org"
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message"`
+}
+
+type message struct {
+	Text string `json:"text"`
+	From *user  `json:"from"`
+	Chat chat   `json:"chat"`
+}
+
+type user struct {
+	ID int64 `json:"id"`
+}
+
+type chat struct {
+	ID int64 `json:"id"`
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// api is a minimal client for the Telegram Bot HTTP API.
+type api struct {
+	token      string
+	httpClient *http.Client
+}
+
+func (a *api) getUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]update, error) {
+	var updates []update
+	err := a.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+func (a *api) sendMessage(ctx context.Context, chatID int64, text string) error {
+	return a.call(ctx, "sendMessage", map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	}, nil)
+}
+
+func (a *api) call(ctx context.Context, method string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", method, err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/%s", apiBaseURL, a.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build %s: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		// The token is part of the URL, so never surface the raw transport error.
+		return fmt.Errorf("call %s: request failed", method)
+	}
+	defer resp.Body.Close()
+
+	var decoded apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return fmt.Errorf("decode %s: %w", method, err)
+	}
+	if !decoded.OK {
+		return fmt.Errorf("call %s: %s", method, decoded.Description)
+	}
+	if out != nil {
+		if err := json.Unmarshal(decoded.Result, out); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Package telegram exposes the subscription service through a Telegram chat bot.
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/billing"
+	"github.com/beheryahmed1991/subscription-service.git/internal/money"
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+)
+
+const (
+	monthLayout   = "2006-01"
+	listPageLimit = 20
+	retryDelay    = 5 * time.Second
+)
+
+// userNamespace seeds the deterministic mapping from Telegram user IDs to
+// service user IDs, so the same chat user always owns the same records.
+var userNamespace = uuid.MustParse("3f1c2b7e-6d0a-4d43-9a53-8c5f0e2a9b11")
+
+const helpText = `Commands:
+/add <service> <price> <start YYYY-MM> [end YYYY-MM] - track a subscription
+/list - show your subscriptions
+/total [start YYYY-MM] [end YYYY-MM] - total cost, current month by default`
+
+// Config controls the bot's polling behaviour.
+type Config struct {
+	Token       string
+	PollTimeout time.Duration
+}
+
+// Bot long-polls Telegram and answers chat commands using the Service layer.
+type Bot struct {
+	api         *api
+	svc         subscription.Service
+	logger      *slog.Logger
+	pollTimeout time.Duration
+}
+
+// New creates a Bot backed by the given service.
+func New(cfg Config, svc subscription.Service, logger *slog.Logger) (*Bot, error) {
+	if cfg.Token == "" {
+		return nil, errors.New("telegram token is empty")
+	}
+	if cfg.PollTimeout <= 0 {
+		cfg.PollTimeout = 30 * time.Second
+	}
+
+	return &Bot{
+		api: &api{
+			token:      cfg.Token,
+			httpClient: &http.Client{Timeout: cfg.PollTimeout + 10*time.Second},
+		},
+		svc:         svc,
+		logger:      logger,
+		pollTimeout: cfg.PollTimeout,
+	}, nil
+}
+
+// UserID maps a Telegram user ID to the service user ID that owns its records.
+func UserID(telegramID int64) uuid.UUID {
+	return uuid.NewSHA1(userNamespace, []byte(strconv.FormatInt(telegramID, 10)))
+}
+
+// Run polls for updates until ctx is cancelled.
+func (b *Bot) Run(ctx context.Context) error {
+	var offset int64
+	for {
+		updates, err := b.api.getUpdates(ctx, offset, b.pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			b.logger.Error("telegram poll failed", "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil || u.Message.From == nil {
+				continue
+			}
+			reply := b.handle(ctx, UserID(u.Message.From.ID), u.Message.Text)
+			if err := b.api.sendMessage(ctx, u.Message.Chat.ID, reply); err != nil {
+				b.logger.Error("telegram send failed", "chat_id", u.Message.Chat.ID, "error", err)
+			}
+		}
+	}
+}
+
+func (b *Bot) handle(ctx context.Context, userID uuid.UUID, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return helpText
+	}
+
+	// Commands may arrive as /cmd@BotName in group chats.
+	command, _, _ := strings.Cut(fields[0], "@")
+	args := fields[1:]
+
+	switch command {
+	case "/add":
+		return b.add(ctx, userID, args)
+	case "/list":
+		return b.list(ctx, userID)
+	case "/total":
+		return b.total(ctx, userID, args)
+	default:
+		return helpText
+	}
+}
+
+func (b *Bot) add(ctx context.Context, userID uuid.UUID, args []string) string {
+	if len(args) < 3 {
+		return "usage: /add <service> <price> <start YYYY-MM> [end YYYY-MM]"
+	}
+
+	// The service name may contain spaces, so parse the fixed fields from the end.
+	var end *time.Time
+	if len(args) >= 4 {
+		if parsed, err := time.Parse(monthLayout, args[len(args)-1]); err == nil {
+			if _, err := time.Parse(monthLayout, args[len(args)-2]); err == nil {
+				end = &parsed
+				args = args[:len(args)-1]
+			}
+		}
+	}
+
+	start, err := time.Parse(monthLayout, args[len(args)-1])
+	if err != nil {
+		return "start must be in YYYY-MM format"
+	}
+	price, err := strconv.Atoi(args[len(args)-2])
+	if err != nil || price < 0 {
+		return "price must be a non-negative whole number"
+	}
+	name := strings.Join(args[:len(args)-2], " ")
+	if name == "" {
+		return "service name is required"
+	}
+	sub, err := b.svc.Create(ctx, subscription.CreateParams{
+		ServiceName: name,
+		PriceRUB:    price,
+		UserID:      userID,
+		StartMonth:  start,
+		EndMonth:    end,
+	})
+	if err != nil {
+		var invalid *subscription.ValidationError
+		if errors.As(err, &invalid) {
+			return invalid.Error()
+		}
+		if errors.Is(err, subscription.ErrRejected) {
+			return err.Error()
+		}
+		b.logger.Error("telegram create failed", "error", err)
+		return "could not save the subscription, please try again later"
+	}
+	return fmt.Sprintf("Added %s for %s starting %s.", sub.ServiceName, formatPrice(sub), sub.StartMonth.Format(monthLayout))
+}
+
+// periodUnits names what each billing period charges for.
+var periodUnits = map[string]string{
+	billing.Weekly:    "week",
+	billing.Monthly:   "month",
+	billing.Quarterly: "quarter",
+	billing.Yearly:    "year",
+}
+
+// formatPrice renders the price of sub as charged, e.g. "9.99 USD/year".
+// Subscriptions without a known currency fall back to whole rubles.
+func formatPrice(sub subscription.Subscription) string {
+	unit, ok := periodUnits[sub.BillingPeriod]
+	if !ok {
+		unit = periodUnits[billing.Monthly]
+	}
+	if cur, ok := money.Lookup(sub.Currency); ok {
+		amount := strconv.FormatFloat(cur.Major(sub.AmountMinor), 'f', cur.Exponent, 64)
+		return fmt.Sprintf("%s %s/%s", amount, cur.Code, unit)
+	}
+	return fmt.Sprintf("%d RUB/%s", sub.PriceRUB, unit)
+}
+
+func (b *Bot) list(ctx context.Context, userID uuid.UUID) string {
+	subs, total, err := b.svc.List(ctx, subscription.ListOptions{
+		Limit:  listPageLimit,
+		Sort:   subscription.Sort{Column: "start_month"},
+		UserID: &userID,
+	})
+	if err != nil {
+		b.logger.Error("telegram list failed", "error", err)
+		return "could not load subscriptions, please try again later"
+	}
+	if len(subs) == 0 {
+		return "You have no subscriptions yet. Use /add to track one."
+	}
+
+	var sb strings.Builder
+	for _, sub := range subs {
+		fmt.Fprintf(&sb, "- %s: %s since %s", sub.ServiceName, formatPrice(sub), sub.StartMonth.Format(monthLayout))
+		if sub.EndMonth != nil {
+			fmt.Fprintf(&sb, " until %s", sub.EndMonth.Format(monthLayout))
+		}
+		sb.WriteString("\n")
+	}
+	if total > len(subs) {
+		fmt.Fprintf(&sb, "...and %d more", total-len(subs))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (b *Bot) total(ctx context.Context, userID uuid.UUID, args []string) string {
+	now := time.Now().UTC()
+	start := months.Normalize(now)
+	end := start
+
+	if len(args) > 0 {
+		parsed, err := time.Parse(monthLayout, args[0])
+		if err != nil {
+			return "start must be in YYYY-MM format"
+		}
+		start, end = parsed, parsed
+	}
+	if len(args) > 1 {
+		parsed, err := time.Parse(monthLayout, args[1])
+		if err != nil {
+			return "end must be in YYYY-MM format"
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		return "end cannot be before start"
+	}
+
+	total, err := b.svc.SumByPeriod(ctx, subscription.SumFilter{
+		StartMonth: &start,
+		EndMonth:   &end,
+		UserID:     &userID,
+	})
+	if err != nil {
+		b.logger.Error("telegram total failed", "error", err)
+		return "could not calculate the total, please try again later"
+	}
+	return fmt.Sprintf("Total for %s..%s: %d RUB", start.Format(monthLayout), end.Format(monthLayout), total)
+}
@@ -0,0 +1,136 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+)
+
+// Contract is one row of a handler contract table: a request, the service
+// behaviour it runs against, and what the handler must do with it.
+//
+//	testutil.RunContracts(t, testutil.SubscriptionRouter, []testutil.Contract{{
+//		Name:   "get missing",
+//		Method: http.MethodGet, Path: "/subscriptions/" + id.String(),
+//		Setup:  func(m *testutil.ServiceMock) { m.GetByIDFunc = notFound },
+//		Status: http.StatusNotFound,
+//		Schema: testutil.Schema{"error": "string"},
+//		Calls:  []string{"GetByID"},
+//	}})
+type Contract struct {
+	Name   string
+	Method string
+	Path   string
+	// Body is encoded as JSON; nil sends none.
+	Body    any
+	Headers map[string]string
+	// Setup configures the mock before the request.
+	Setup func(m *ServiceMock)
+
+	Status int
+	// Schema, when set, is checked against the response body.
+	Schema Schema
+	// Calls lists the service methods the request must call, in order. Nil
+	// skips the check; an empty slice means none.
+	Calls []string
+}
+
+// Schema maps the top-level fields of a JSON object to their kinds: string,
+// number, bool, object, array or null. Fields outside it are errors, so a new
+// field needs the contract updated.
+type Schema map[string]string
+
+// RunContracts runs every contract as a subtest, each against a new
+// ServiceMock served by newRouter.
+func RunContracts(t *testing.T, newRouter func(subscription.Service) http.Handler, contracts []Contract) {
+	t.Helper()
+	for _, c := range contracts {
+		t.Run(c.Name, func(t *testing.T) {
+			mock := &ServiceMock{}
+			if c.Setup != nil {
+				c.Setup(mock)
+			}
+			req := JSONRequest(c.Method, c.Path, c.Body)
+			for name, value := range c.Headers {
+				req.Header.Set(name, value)
+			}
+			rec := Do(newRouter(mock), req)
+
+			if rec.Code != c.Status {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, c.Status, rec.Body.String())
+			}
+			if c.Schema != nil {
+				if err := c.Schema.Check(rec.Body.Bytes()); err != nil {
+					t.Errorf("body %s: %v", rec.Body.String(), err)
+				}
+			}
+			if c.Calls != nil {
+				if got := mock.Methods(); !slices.Equal(got, c.Calls) {
+					t.Errorf("service calls = %v, want %v", got, c.Calls)
+				}
+			}
+		})
+	}
+}
+
+// Check reports the first difference between body and the schema.
+func (s Schema) Check(body []byte) error {
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("body is not a JSON object: %w", err)
+	}
+	fields := make([]string, 0, len(doc))
+	for field := range doc {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		want, ok := s[field]
+		if !ok {
+			return fmt.Errorf("unexpected field %q", field)
+		}
+		if got := jsonKind(doc[field]); got != want {
+			return fmt.Errorf("field %q is %s, want %s", field, got, want)
+		}
+	}
+	for field := range s {
+		if _, ok := doc[field]; !ok {
+			return fmt.Errorf("missing field %q", field)
+		}
+	}
+	return nil
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// SubscriptionRouter serves the subscription routes over svc with the
+// default handler config, for use with RunContracts.
+func SubscriptionRouter(svc subscription.Service) http.Handler {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	subscription.NewHandler(svc, slog.New(slog.DiscardHandler), subscription.HandlerConfig{}).RegisterRoutes(router)
+	return router
+}
@@ -0,0 +1,49 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// JSONRequest builds a request with body encoded as JSON. A nil body sends
+// none.
+func JSONRequest(method, path string, body any) *http.Request {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			panic(fmt.Sprintf("testutil: encode request body: %v", err))
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// Do serves req with handler and returns the recorded response.
+func Do(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// DoJSON is Do with a JSON request built by JSONRequest.
+func DoJSON(handler http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	return Do(handler, JSONRequest(method, path, body))
+}
+
+// DecodeJSON decodes a recorded response body into T.
+func DecodeJSON[T any](rec *httptest.ResponseRecorder) (T, error) {
+	var out T
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		return out, fmt.Errorf("decode response %d %q: %w", rec.Code, rec.Body.String(), err)
+	}
+	return out, nil
+}
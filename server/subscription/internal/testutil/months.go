@@ -0,0 +1,67 @@
+package testutil
+
+import (
+	"time"
+)
+
+// MonthCase is a golden case for month arithmetic. Tests run it through both
+// months.Between/LastCharged and their SQL counterparts, which must agree
+// with Want.
+type MonthCase struct {
+	Name      string
+	Start     time.Time
+	End       time.Time
+	Inclusive bool
+	// Want is the number of charged months from Start to the last charged
+	// month of End.
+	Want int
+}
+
+func mustZone(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone(name, 0)
+	}
+	return loc
+}
+
+// MonthCases covers the edges billing has to get right: leap years, times
+// near midnight in zones with daylight saving, year boundaries and exclusive
+// ends.
+var MonthCases = []MonthCase{
+	{Name: "same month", Start: date(2025, 3, 1), End: date(2025, 3, 1), Inclusive: true, Want: 1},
+	{Name: "same month exclusive", Start: date(2025, 3, 1), End: date(2025, 3, 1), Inclusive: false, Want: 0},
+	{Name: "end before start", Start: date(2025, 5, 1), End: date(2025, 3, 1), Inclusive: true, Want: 0},
+	{Name: "across year", Start: date(2024, 11, 1), End: date(2025, 2, 1), Inclusive: true, Want: 4},
+	{Name: "across year exclusive", Start: date(2024, 11, 1), End: date(2025, 2, 1), Inclusive: false, Want: 3},
+	{Name: "leap day start", Start: date(2024, 2, 29), End: date(2025, 2, 28), Inclusive: true, Want: 13},
+	{Name: "leap february only", Start: date(2024, 2, 1), End: date(2024, 2, 29), Inclusive: true, Want: 1},
+	{Name: "century non-leap", Start: date(2100, 2, 28), End: date(2100, 3, 1), Inclusive: true, Want: 2},
+	{Name: "mid-month days ignored", Start: date(2025, 1, 31), End: date(2025, 3, 15), Inclusive: true, Want: 3},
+	{Name: "ten years", Start: date(2015, 1, 1), End: date(2024, 12, 1), Inclusive: true, Want: 120},
+	{
+		Name:      "DST spring forward, Europe",
+		Start:     time.Date(2025, 3, 30, 2, 30, 0, 0, mustZone("Europe/Berlin")),
+		End:       time.Date(2025, 4, 1, 0, 0, 0, 0, mustZone("Europe/Berlin")),
+		Inclusive: true,
+		Want:      2,
+	},
+	{
+		Name:      "DST fall back, America",
+		Start:     time.Date(2025, 11, 2, 1, 30, 0, 0, mustZone("America/New_York")),
+		End:       time.Date(2025, 11, 30, 23, 59, 0, 0, mustZone("America/New_York")),
+		Inclusive: true,
+		Want:      1,
+	},
+	{
+		Name:      "local month differs from UTC",
+		Start:     time.Date(2025, 3, 1, 0, 30, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		End:       time.Date(2025, 3, 31, 23, 30, 0, 0, time.FixedZone("MSK", 3*60*60)),
+		Inclusive: true,
+		Want:      1,
+	},
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
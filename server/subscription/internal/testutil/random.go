@@ -0,0 +1,40 @@
+package testutil
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/months"
+)
+
+var serviceNames = []string{
+	"Yandex Plus", "Netflix", "Spotify", "Kinopoisk", "Okko", "IVI", "VK Music", "Apple One",
+}
+
+// NewID returns a time-ordered ID like the service generates.
+func NewID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
+
+// ServiceName returns a realistic service name.
+func ServiceName() string {
+	return serviceNames[rand.IntN(len(serviceNames))]
+}
+
+// Price returns a positive monthly price in whole rubles.
+func Price() int {
+	return 99 + rand.IntN(1900)
+}
+
+// Month truncates t to the first day of its month in UTC, the form the
+// service stores.
+func Month(t time.Time) time.Time {
+	return months.Normalize(t)
+}
+
+// MonthsAgo returns the month n months before the current one.
+func MonthsAgo(n int) time.Time {
+	return Month(time.Now().UTC().AddDate(0, -n, 0))
+}
@@ -0,0 +1,287 @@
+package testutil
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+)
+
+// Call is one recorded call to a ServiceMock method. Args leave out the
+// context and callbacks.
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// ServiceMock is a subscription.Service for handler tests. Every method
+// records its call and delegates to the matching ...Func field; methods
+// without one return zero values.
+type ServiceMock struct {
+	CreateFunc            func(subscription.CreateParams) (subscription.Subscription, error)
+	GetByIDFunc           func(id string) (subscription.Subscription, error)
+	GetByIDsFunc          func(ids []uuid.UUID) ([]subscription.Subscription, []uuid.UUID, error)
+	GetBySlugFunc         func(userID uuid.UUID, slug string) (subscription.Subscription, error)
+	ListFunc              func(subscription.ListOptions) ([]subscription.Subscription, int, error)
+	ListVersionFunc       func(subscription.ListOptions) (subscription.CollectionVersion, error)
+	StreamFunc            func(subscription.ListOptions, func(subscription.Subscription) error) error
+	UpdateFunc            func(subscription.UpdateParams) (subscription.Subscription, error)
+	UpsertFunc            func(subscription.CreateParams) (subscription.Subscription, bool, error)
+	UpdateWhereFunc       func(subscription.BulkFilter, subscription.UpdateParams) (subscription.BulkResult, error)
+	MatchingFunc          func(filter subscription.BulkFilter, limit int) ([]subscription.Subscription, error)
+	DeleteFunc            func(id string) error
+	TransferFunc          func(id, toUserID uuid.UUID) (subscription.Subscription, error)
+	ChangeStatusFunc      func(id uuid.UUID, to string) (subscription.Subscription, error)
+	UndoFunc              func(id uuid.UUID, window time.Duration) (subscription.Subscription, error)
+	TimelineFunc          func(id uuid.UUID) ([]subscription.TimelineItem, error)
+	SumByPeriodFunc       func(subscription.SumFilter) (int64, error)
+	SumProjectedFunc      func(subscription.SumFilter) (subscription.SpendProjection, error)
+	SumByUsersFunc        func(subscription.BatchSumFilter) (map[uuid.UUID]int64, error)
+	SumByTagsFunc         func(subscription.SumFilter, subscription.TagMode) ([]subscription.TagTotal, error)
+	SumTimeSeriesFunc     func(subscription.SumFilter, subscription.Granularity) ([]subscription.TimeSeriesPoint, error)
+	SearchFunc            func(subscription.SearchQuery) ([]subscription.Subscription, int, error)
+	SpendDistributionFunc func(start, end time.Time) (subscription.SpendDistribution, error)
+	ExportFunc            func(userID uuid.UUID, fn func(subscription.Subscription) error) error
+	ExportHistoryFunc     func(userID uuid.UUID, fn func(subscription.AuditEntry) error) error
+	RestoreFunc           func(userID uuid.UUID, subs iter.Seq2[subscription.Subscription, error]) (subscription.RestoreResult, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ subscription.Service = (*ServiceMock)(nil)
+
+// Calls returns the calls made so far, in order.
+func (m *ServiceMock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// Methods returns the names of the methods called so far, in order.
+func (m *ServiceMock) Methods() []string {
+	calls := m.Calls()
+	names := make([]string, len(calls))
+	for i, call := range calls {
+		names[i] = call.Method
+	}
+	return names
+}
+
+func (m *ServiceMock) record(method string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func (m *ServiceMock) Create(_ context.Context, params subscription.CreateParams) (subscription.Subscription, error) {
+	m.record("Create", params)
+	if m.CreateFunc == nil {
+		return subscription.Subscription{}, nil
+	}
+	return m.CreateFunc(params)
+}
+
+func (m *ServiceMock) GetByID(_ context.Context, id string) (subscription.Subscription, error) {
+	m.record("GetByID", id)
+	if m.GetByIDFunc == nil {
+		return subscription.Subscription{}, nil
+	}
+	return m.GetByIDFunc(id)
+}
+
+func (m *ServiceMock) GetByIDs(_ context.Context, ids []uuid.UUID) ([]subscription.Subscription, []uuid.UUID, error) {
+	m.record("GetByIDs", ids)
+	if m.GetByIDsFunc == nil {
+		return nil, nil, nil
+	}
+	return m.GetByIDsFunc(ids)
+}
+
+func (m *ServiceMock) GetBySlug(_ context.Context, userID uuid.UUID, slug string) (subscription.Subscription, error) {
+	m.record("GetBySlug", userID, slug)
+	if m.GetBySlugFunc == nil {
+		return subscription.Subscription{}, nil
+	}
+	return m.GetBySlugFunc(userID, slug)
+}
+
+func (m *ServiceMock) List(_ context.Context, opts subscription.ListOptions) ([]subscription.Subscription, int, error) {
+	m.record("List", opts)
+	if m.ListFunc == nil {
+		return nil, 0, nil
+	}
+	return m.ListFunc(opts)
+}
+
+func (m *ServiceMock) ListVersion(_ context.Context, opts subscription.ListOptions) (subscription.CollectionVersion, error) {
+	m.record("ListVersion", opts)
+	if m.ListVersionFunc == nil {
+		return subscription.CollectionVersion{}, nil
+	}
+	return m.ListVersionFunc(opts)
+}
+
+func (m *ServiceMock) Stream(_ context.Context, opts subscription.ListOptions, fn func(subscription.Subscription) error) error {
+	m.record("Stream", opts)
+	if m.StreamFunc == nil {
+		return nil
+	}
+	return m.StreamFunc(opts, fn)
+}
+
+func (m *ServiceMock) Update(_ context.Context, params subscription.UpdateParams) (subscription.Subscription, error) {
+	m.record("Update", params)
+	if m.UpdateFunc == nil {
+		return subscription.Subscription{}, nil
+	}
+	return m.UpdateFunc(params)
+}
+
+func (m *ServiceMock) Upsert(_ context.Context, params subscription.CreateParams) (subscription.Subscription, bool, error) {
+	m.record("Upsert", params)
+	if m.UpsertFunc == nil {
+		return subscription.Subscription{}, false, nil
+	}
+	return m.UpsertFunc(params)
+}
+
+func (m *ServiceMock) UpdateWhere(_ context.Context, filter subscription.BulkFilter, change subscription.UpdateParams) (subscription.BulkResult, error) {
+	m.record("UpdateWhere", filter, change)
+	if m.UpdateWhereFunc == nil {
+		return subscription.BulkResult{}, nil
+	}
+	return m.UpdateWhereFunc(filter, change)
+}
+
+func (m *ServiceMock) Matching(_ context.Context, filter subscription.BulkFilter, limit int) ([]subscription.Subscription, error) {
+	m.record("Matching", filter, limit)
+	if m.MatchingFunc == nil {
+		return nil, nil
+	}
+	return m.MatchingFunc(filter, limit)
+}
+
+func (m *ServiceMock) Delete(_ context.Context, id string) error {
+	m.record("Delete", id)
+	if m.DeleteFunc == nil {
+		return nil
+	}
+	return m.DeleteFunc(id)
+}
+
+func (m *ServiceMock) Transfer(_ context.Context, id, toUserID uuid.UUID) (subscription.Subscription, error) {
+	m.record("Transfer", id, toUserID)
+	if m.TransferFunc == nil {
+		return subscription.Subscription{}, nil
+	}
+	return m.TransferFunc(id, toUserID)
+}
+
+func (m *ServiceMock) ChangeStatus(_ context.Context, id uuid.UUID, to string) (subscription.Subscription, error) {
+	m.record("ChangeStatus", id, to)
+	if m.ChangeStatusFunc == nil {
+		return subscription.Subscription{}, nil
+	}
+	return m.ChangeStatusFunc(id, to)
+}
+
+func (m *ServiceMock) Undo(_ context.Context, id uuid.UUID, window time.Duration) (subscription.Subscription, error) {
+	m.record("Undo", id, window)
+	if m.UndoFunc == nil {
+		return subscription.Subscription{}, nil
+	}
+	return m.UndoFunc(id, window)
+}
+
+func (m *ServiceMock) Timeline(_ context.Context, id uuid.UUID) ([]subscription.TimelineItem, error) {
+	m.record("Timeline", id)
+	if m.TimelineFunc == nil {
+		return nil, nil
+	}
+	return m.TimelineFunc(id)
+}
+
+func (m *ServiceMock) SumByPeriod(_ context.Context, filter subscription.SumFilter) (int64, error) {
+	m.record("SumByPeriod", filter)
+	if m.SumByPeriodFunc == nil {
+		return 0, nil
+	}
+	return m.SumByPeriodFunc(filter)
+}
+
+func (m *ServiceMock) SumProjected(_ context.Context, filter subscription.SumFilter) (subscription.SpendProjection, error) {
+	m.record("SumProjected", filter)
+	if m.SumProjectedFunc == nil {
+		return subscription.SpendProjection{}, nil
+	}
+	return m.SumProjectedFunc(filter)
+}
+
+func (m *ServiceMock) SumByUsers(_ context.Context, filter subscription.BatchSumFilter) (map[uuid.UUID]int64, error) {
+	m.record("SumByUsers", filter)
+	if m.SumByUsersFunc == nil {
+		return nil, nil
+	}
+	return m.SumByUsersFunc(filter)
+}
+
+func (m *ServiceMock) SumByTags(_ context.Context, filter subscription.SumFilter, mode subscription.TagMode) ([]subscription.TagTotal, error) {
+	m.record("SumByTags", filter, mode)
+	if m.SumByTagsFunc == nil {
+		return nil, nil
+	}
+	return m.SumByTagsFunc(filter, mode)
+}
+
+func (m *ServiceMock) SumTimeSeries(_ context.Context, filter subscription.SumFilter, granularity subscription.Granularity) ([]subscription.TimeSeriesPoint, error) {
+	m.record("SumTimeSeries", filter, granularity)
+	if m.SumTimeSeriesFunc == nil {
+		return nil, nil
+	}
+	return m.SumTimeSeriesFunc(filter, granularity)
+}
+
+func (m *ServiceMock) Search(_ context.Context, q subscription.SearchQuery) ([]subscription.Subscription, int, error) {
+	m.record("Search", q)
+	if m.SearchFunc == nil {
+		return nil, 0, nil
+	}
+	return m.SearchFunc(q)
+}
+
+func (m *ServiceMock) SpendDistribution(_ context.Context, start, end time.Time) (subscription.SpendDistribution, error) {
+	m.record("SpendDistribution", start, end)
+	if m.SpendDistributionFunc == nil {
+		return subscription.SpendDistribution{}, nil
+	}
+	return m.SpendDistributionFunc(start, end)
+}
+
+func (m *ServiceMock) Export(_ context.Context, userID uuid.UUID, fn func(subscription.Subscription) error) error {
+	m.record("Export", userID)
+	if m.ExportFunc == nil {
+		return nil
+	}
+	return m.ExportFunc(userID, fn)
+}
+
+func (m *ServiceMock) ExportHistory(_ context.Context, userID uuid.UUID, fn func(subscription.AuditEntry) error) error {
+	m.record("ExportHistory", userID)
+	if m.ExportHistoryFunc == nil {
+		return nil
+	}
+	return m.ExportHistoryFunc(userID, fn)
+}
+
+func (m *ServiceMock) Restore(_ context.Context, userID uuid.UUID, subs iter.Seq2[subscription.Subscription, error]) (subscription.RestoreResult, error) {
+	m.record("Restore", userID)
+	if m.RestoreFunc == nil {
+		return subscription.RestoreResult{}, nil
+	}
+	return m.RestoreFunc(userID, subs)
+}
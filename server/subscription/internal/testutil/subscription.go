@@ -0,0 +1,121 @@
+// Package testutil provides builders, generators and HTTP helpers shared by
+// tests, so they do not repeat hand-built structs and literal JSON bodies.
+package testutil
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/subscription"
+)
+
+// SubscriptionBuilder assembles a valid subscription with overridable fields.
+type SubscriptionBuilder struct {
+	sub subscription.Subscription
+}
+
+// NewSubscriptionBuilder starts from a valid subscription: a random service
+// and price, owned by a new user, started last month and open-ended.
+func NewSubscriptionBuilder() *SubscriptionBuilder {
+	now := time.Now().UTC()
+	return &SubscriptionBuilder{sub: subscription.Subscription{
+		ID:          NewID(),
+		ServiceName: ServiceName(),
+		PriceRUB:    Price(),
+		UserID:      uuid.New(),
+		StartMonth:  Month(now.AddDate(0, -1, 0)),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}}
+}
+
+func (b *SubscriptionBuilder) WithID(id uuid.UUID) *SubscriptionBuilder {
+	b.sub.ID = id
+	return b
+}
+
+func (b *SubscriptionBuilder) WithService(name string) *SubscriptionBuilder {
+	b.sub.ServiceName = name
+	return b
+}
+
+func (b *SubscriptionBuilder) WithPrice(price int) *SubscriptionBuilder {
+	b.sub.PriceRUB = price
+	return b
+}
+
+func (b *SubscriptionBuilder) WithUser(userID uuid.UUID) *SubscriptionBuilder {
+	b.sub.UserID = userID
+	return b
+}
+
+// Between sets the start and end months; the day of month is dropped.
+func (b *SubscriptionBuilder) Between(start, end time.Time) *SubscriptionBuilder {
+	b.sub.StartMonth = Month(start)
+	endMonth := Month(end)
+	b.sub.EndMonth = &endMonth
+	return b
+}
+
+// Active makes the subscription cover the current month with no end.
+func (b *SubscriptionBuilder) Active() *SubscriptionBuilder {
+	b.sub.StartMonth = Month(time.Now().UTC())
+	b.sub.EndMonth = nil
+	return b
+}
+
+// Ended makes the subscription finish before the current month.
+func (b *SubscriptionBuilder) Ended() *SubscriptionBuilder {
+	now := time.Now().UTC()
+	return b.Between(now.AddDate(0, -6, 0), now.AddDate(0, -1, 0))
+}
+
+func (b *SubscriptionBuilder) EndInclusive(inclusive bool) *SubscriptionBuilder {
+	b.sub.EndMonthInclusive = &inclusive
+	return b
+}
+
+// Build returns the subscription. The builder can be reused afterwards.
+func (b *SubscriptionBuilder) Build() subscription.Subscription {
+	sub := b.sub
+	if sub.EndMonth != nil {
+		end := *sub.EndMonth
+		sub.EndMonth = &end
+	}
+	return sub
+}
+
+// CreateParams returns the input that would create the built subscription.
+func (b *SubscriptionBuilder) CreateParams() subscription.CreateParams {
+	sub := b.Build()
+	return subscription.CreateParams{
+		ID:                sub.ID,
+		ServiceName:       sub.ServiceName,
+		PriceRUB:          sub.PriceRUB,
+		UserID:            sub.UserID,
+		StartMonth:        sub.StartMonth,
+		EndMonth:          sub.EndMonth,
+		EndMonthInclusive: sub.EndMonthInclusive,
+	}
+}
+
+// RequestBody returns the JSON body POST /subscriptions expects for the built
+// subscription.
+func (b *SubscriptionBuilder) RequestBody() map[string]any {
+	sub := b.Build()
+	body := map[string]any{
+		"service_name": sub.ServiceName,
+		"price":        sub.PriceRUB,
+		"user_id":      sub.UserID.String(),
+		"start_date":   sub.StartMonth.Format("2006-01"),
+	}
+	if sub.EndMonth != nil {
+		body["end_date"] = sub.EndMonth.Format("2006-01")
+	}
+	if sub.EndMonthInclusive != nil {
+		body["end_month_inclusive"] = *sub.EndMonthInclusive
+	}
+	return body
+}
@@ -0,0 +1,161 @@
+// Package types holds value types whose JSON form is chosen by configuration,
+// so every API surface, export and notification renders dates the same way.
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Built-in format names.
+const (
+	// FormatRFC3339 renders full timestamps ("2025-03-01T00:00:00Z"). It is the
+	// default because it is what the API returned before formats existed.
+	FormatRFC3339 = "rfc3339"
+	// FormatISO renders "2025-03" for months and "2025-03-14" for dates.
+	FormatISO = "iso"
+	// FormatMonthYear renders "03-2025" for months and "14-03-2025" for dates.
+	FormatMonthYear = "mm-yyyy"
+	// FormatEpoch renders Unix seconds as a JSON number.
+	FormatEpoch = "epoch"
+)
+
+// Layout describes how one format renders Month and Date values. Month and
+// Date are time layouts; Epoch renders both as Unix seconds instead.
+type Layout struct {
+	Month string
+	Date  string
+	Epoch bool
+}
+
+type namedLayout struct {
+	name   string
+	layout Layout
+}
+
+var (
+	mu sync.RWMutex
+	// registry is kept in registration order, which is the order unmarshal
+	// tries the layouts in, so ambiguous values always parse the same way.
+	registry = []namedLayout{
+		{FormatRFC3339, Layout{Month: time.RFC3339, Date: time.RFC3339}},
+		{FormatISO, Layout{Month: "2006-01", Date: "2006-01-02"}},
+		{FormatMonthYear, Layout{Month: "01-2006", Date: "02-01-2006"}},
+		{FormatEpoch, Layout{Epoch: true}},
+	}
+	current = registry[0].layout
+)
+
+// lookupLocked returns the index of the format called name, or -1.
+func lookupLocked(name string) int {
+	for i, entry := range registry {
+		if entry.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Register adds a named format, tried after the existing ones when parsing,
+// or replaces one in place.
+func Register(name string, layout Layout) error {
+	if name == "" {
+		return errors.New("format name is required")
+	}
+	if !layout.Epoch && (layout.Month == "" || layout.Date == "") {
+		return fmt.Errorf("format %q needs both a month and a date layout", name)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if i := lookupLocked(name); i >= 0 {
+		registry[i].layout = layout
+	} else {
+		registry = append(registry, namedLayout{name, layout})
+	}
+	return nil
+}
+
+// Use selects the format every Month and Date marshals with.
+func Use(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	i := lookupLocked(name)
+	if i < 0 {
+		return fmt.Errorf("unknown date format %q (known: %v)", name, namesLocked())
+	}
+	current = registry[i].layout
+	return nil
+}
+
+// Names lists the registered formats.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(registry))
+	for _, entry := range registry {
+		names = append(names, entry.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func active() Layout {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// marshal renders t with the month or date layout of the active format.
+func marshal(t time.Time, month bool) []byte {
+	layout := active()
+	if layout.Epoch {
+		return strconv.AppendInt(nil, t.Unix(), 10)
+	}
+	pattern := layout.Date
+	if month {
+		pattern = layout.Month
+	}
+	return strconv.AppendQuote(nil, t.Format(pattern))
+}
+
+// unmarshal accepts every registered format, not only the active one, so
+// payloads written under another setting (old exports, other deployments)
+// still parse.
+func unmarshal(data []byte) (time.Time, error) {
+	if len(data) > 0 && data[0] != '"' {
+		secs, err := strconv.ParseInt(string(bytes.TrimSpace(data)), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("date must be a string or Unix seconds: %w", err)
+		}
+		return time.Unix(secs, 0).UTC(), nil
+	}
+
+	value, err := strconv.Unquote(string(data))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date string: %w", err)
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, entry := range registry {
+		if entry.layout.Epoch {
+			continue
+		}
+		for _, pattern := range []string{entry.layout.Month, entry.layout.Date} {
+			if t, err := time.Parse(pattern, value); err == nil {
+				return t.UTC(), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q matches no known format", value)
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Month is a calendar month, stored as midnight UTC on its first day.
+type Month struct {
+	time.Time
+}
+
+// NewMonth returns the month t falls in.
+func NewMonth(t time.Time) Month {
+	t = t.UTC()
+	return Month{time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// MonthPtr converts an optional time, keeping nil as nil.
+func MonthPtr(t *time.Time) *Month {
+	if t == nil {
+		return nil
+	}
+	m := NewMonth(*t)
+	return &m
+}
+
+func (m Month) MarshalJSON() ([]byte, error) {
+	return marshal(m.Time, true), nil
+}
+
+func (m *Month) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	t, err := unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*m = NewMonth(t)
+	return nil
+}
+
+// Date is a calendar day, stored as midnight UTC.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the day t falls on.
+func NewDate(t time.Time) Date {
+	t = t.UTC()
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return marshal(d.Time, false), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	t, err := unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*d = NewDate(t)
+	return nil
+}
+
+// Scan reads a DATE or TIMESTAMP column.
+func (m *Month) Scan(src any) error {
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Month", src)
+	}
+	*m = NewMonth(t)
+	return nil
+}
+
+// Value writes the month as its first day.
+func (m Month) Value() (driver.Value, error) {
+	return m.Time, nil
+}
+
+// Scan reads a DATE or TIMESTAMP column.
+func (d *Date) Scan(src any) error {
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	*d = NewDate(t)
+	return nil
+}
+
+// Value writes the date as midnight UTC.
+func (d Date) Value() (driver.Value, error) {
+	return d.Time, nil
+}
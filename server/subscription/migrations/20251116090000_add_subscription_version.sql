@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION subscriptions_track_change()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.updated_at = now();
+  NEW.version = OLD.version + 1;
+  RETURN NEW;
+END; $$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscriptions_set_updated_at ON subscriptions;
+
+CREATE TRIGGER subscriptions_track_change
+BEFORE UPDATE ON subscriptions
+FOR EACH ROW EXECUTE PROCEDURE subscriptions_track_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS subscriptions_track_change ON subscriptions;
+DROP FUNCTION IF EXISTS subscriptions_track_change;
+
+CREATE TRIGGER subscriptions_set_updated_at
+BEFORE UPDATE ON subscriptions
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at();
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -0,0 +1,6 @@
+-- +goose Up
+-- NULL means the deployment default (SUMMARY_END_MONTH_INCLUSIVE) applies.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS end_month_inclusive BOOLEAN;
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS end_month_inclusive;
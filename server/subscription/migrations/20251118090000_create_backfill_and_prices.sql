@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS data_migrations (
+  name TEXT PRIMARY KEY,
+  last_key TEXT NOT NULL DEFAULT '',
+  processed BIGINT NOT NULL DEFAULT 0,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  completed_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS subscription_prices (
+  subscription_id UUID PRIMARY KEY REFERENCES subscriptions(id) ON DELETE CASCADE,
+  amount_minor BIGINT NOT NULL CHECK (amount_minor >= 0),
+  currency CHAR(3) NOT NULL DEFAULT 'RUB',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS subscription_prices;
+DROP TABLE IF EXISTS data_migrations;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Subscription IDs are generated by the application as UUIDv7 (time-ordered).
+-- Existing UUIDv4 rows stay valid: both versions share the UUID type, and the
+-- column default is kept for rows inserted outside the service. Ordering by id
+-- follows creation time only for v7 rows; legacy v4 rows sort arbitrarily
+-- among themselves.
+COMMENT ON COLUMN subscriptions.id IS 'UUIDv7 generated by the service; legacy rows may be UUIDv4 from the column default';
+
+-- +goose Down
+COMMENT ON COLUMN subscriptions.id IS NULL;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS report_schedules (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL,
+  frequency TEXT NOT NULL CHECK (frequency IN ('weekly', 'monthly')),
+  kind TEXT NOT NULL CHECK (kind IN ('summary', 'export')),
+  channel TEXT NOT NULL CHECK (channel IN ('email', 'webhook')),
+  destination TEXT NOT NULL CHECK (length(trim(destination)) > 0),
+  next_run_at TIMESTAMPTZ NOT NULL,
+  last_run_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS report_schedules_user_id_idx ON report_schedules (user_id);
+CREATE INDEX IF NOT EXISTS report_schedules_next_run_at_idx ON report_schedules (next_run_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS report_schedules;
+-- +goose StatementEnd
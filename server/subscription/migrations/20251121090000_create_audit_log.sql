@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id UUID NOT NULL,
+  action TEXT NOT NULL,
+  actor_id UUID,
+  before JSONB,
+  after JSONB,
+  occurred_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- No foreign key: entries must outlive the subscriptions they describe.
+CREATE INDEX IF NOT EXISTS audit_log_subscription_idx ON audit_log (subscription_id, occurred_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_log;
+-- +goose StatementEnd
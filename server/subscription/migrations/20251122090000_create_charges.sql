@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- charges is the append-only ledger of what each subscription cost in each
+-- closed month. Rows are written once by the ledger job and never change, so
+-- historical reports stay stable when subscriptions are later edited.
+CREATE TABLE IF NOT EXISTS charges (
+  subscription_id UUID NOT NULL,
+  month DATE NOT NULL,
+  user_id UUID NOT NULL,
+  service_name TEXT NOT NULL,
+  amount BIGINT NOT NULL CHECK (amount >= 0),
+  currency CHAR(3) NOT NULL DEFAULT 'RUB',
+  computed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (subscription_id, month)
+);
+
+-- No foreign key: charges must outlive the subscriptions they were billed for.
+CREATE INDEX IF NOT EXISTS charges_month_idx ON charges (month);
+CREATE INDEX IF NOT EXISTS charges_user_month_idx ON charges (user_id, month);
+
+-- charge_months records which months have been materialized. A month listed
+-- here is closed: the job never recomputes it.
+CREATE TABLE IF NOT EXISTS charge_months (
+  month DATE PRIMARY KEY,
+  charges BIGINT NOT NULL,
+  computed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION charges_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'charges is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER charges_append_only
+  BEFORE UPDATE OR DELETE ON charges
+  FOR EACH ROW EXECUTE FUNCTION charges_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS charge_months;
+DROP TABLE IF EXISTS charges;
+DROP FUNCTION IF EXISTS charges_append_only();
+-- +goose StatementEnd
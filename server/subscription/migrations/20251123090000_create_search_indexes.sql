@@ -0,0 +1,25 @@
+-- +goose NO TRANSACTION
+-- Built concurrently so applying this on a live database does not block
+-- writes. Statements run one by one: concurrent index builds cannot share a
+-- transaction. Keep the list in sync with admin.ManagedIndexes.
+
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS subscriptions_user_id_idx
+  ON subscriptions (user_id);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS subscriptions_service_name_lower_idx
+  ON subscriptions (LOWER(service_name));
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS subscriptions_period_idx
+  ON subscriptions (start_month, end_month);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS subscriptions_service_name_trgm_idx
+  ON subscriptions USING gin (service_name gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS subscriptions_service_name_trgm_idx;
+DROP INDEX CONCURRENTLY IF EXISTS subscriptions_period_idx;
+DROP INDEX CONCURRENTLY IF EXISTS subscriptions_service_name_lower_idx;
+DROP INDEX CONCURRENTLY IF EXISTS subscriptions_user_id_idx;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- slug is a short per-user handle ("netflix-2") for links and support
+-- tickets. Existing rows are numbered per user and service in creation order.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS slug TEXT;
+
+WITH bases AS (
+  SELECT
+    id,
+    user_id,
+    COALESCE(
+      NULLIF(trim(BOTH '-' FROM left(regexp_replace(lower(service_name), '[^a-z0-9]+', '-', 'g'), 40)), ''),
+      'sub'
+    ) AS base,
+    created_at
+  FROM subscriptions
+  WHERE slug IS NULL
+)
+UPDATE subscriptions s
+SET slug = numbered.base || '-' || numbered.n
+FROM (
+  SELECT id, base, row_number() OVER (PARTITION BY user_id, base ORDER BY created_at, id) AS n
+  FROM bases
+) numbered
+WHERE s.id = numbered.id;
+
+ALTER TABLE subscriptions ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_user_slug_key UNIQUE (user_id, slug);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_slug_key;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS slug;
+-- +goose StatementEnd
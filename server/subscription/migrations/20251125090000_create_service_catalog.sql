@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- service_catalog lists well-known service names offered as suggestions, so
+-- users pick "Netflix" instead of typing "Netflx".
+CREATE TABLE IF NOT EXISTS service_catalog (
+  name TEXT PRIMARY KEY CHECK (length(trim(name)) > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS service_catalog_name_trgm_idx ON service_catalog USING gin (name gin_trgm_ops);
+
+INSERT INTO service_catalog (name) VALUES
+  ('Netflix'),
+  ('YouTube Premium'),
+  ('Spotify'),
+  ('Apple Music'),
+  ('Apple TV+'),
+  ('iCloud+'),
+  ('Disney+'),
+  ('Amazon Prime'),
+  ('Google One'),
+  ('Microsoft 365'),
+  ('Adobe Creative Cloud'),
+  ('Dropbox'),
+  ('GitHub Copilot'),
+  ('ChatGPT Plus'),
+  ('Notion'),
+  ('Slack'),
+  ('Zoom'),
+  ('Yandex Plus'),
+  ('Kinopoisk'),
+  ('VK Music'),
+  ('Okko'),
+  ('ivi'),
+  ('Start'),
+  ('Wink'),
+  ('Premier'),
+  ('Litres'),
+  ('Bookmate'),
+  ('Telegram Premium'),
+  ('Sber Prime'),
+  ('Ozon Premium')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS service_catalog;
+-- +goose StatementEnd
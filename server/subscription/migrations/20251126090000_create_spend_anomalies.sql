@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- spend_anomalies records users whose spend in a closed month jumped against
+-- the month before. One row per user and month; re-runs do not duplicate.
+CREATE TABLE IF NOT EXISTS spend_anomalies (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL,
+  month DATE NOT NULL,
+  previous_total BIGINT NOT NULL,
+  current_total BIGINT NOT NULL,
+  change_pct NUMERIC(12, 2) NOT NULL,
+  detected_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (user_id, month)
+);
+
+CREATE INDEX IF NOT EXISTS spend_anomalies_month_idx ON spend_anomalies (month, change_pct DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS spend_anomalies;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user_quotas overrides the default API rate limit for individual users,
+-- e.g. to give premium accounts more headroom. Users without a row get the
+-- configured default.
+CREATE TABLE IF NOT EXISTS user_quotas (
+  user_id UUID PRIMARY KEY,
+  requests_per_minute INTEGER NOT NULL CHECK (requests_per_minute > 0),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_quotas;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Locked subscriptions refuse deletion and changes until they are unlocked,
+-- protecting important records from accidental edits on shared accounts.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS locked;
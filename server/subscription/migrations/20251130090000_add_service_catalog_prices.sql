@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Reference prices for catalog services. list_price_rub is curated by hand
+-- (the provider's current price); avg_price_rub and price_samples are
+-- refreshed by the catalog price job from subscriptions running this month.
+ALTER TABLE service_catalog
+  ADD COLUMN IF NOT EXISTS list_price_rub INTEGER CHECK (list_price_rub >= 0),
+  ADD COLUMN IF NOT EXISTS avg_price_rub INTEGER,
+  ADD COLUMN IF NOT EXISTS price_samples INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS prices_refreshed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS service_catalog_lower_name_idx ON service_catalog (LOWER(name));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS service_catalog_lower_name_idx;
+ALTER TABLE service_catalog
+  DROP COLUMN IF EXISTS prices_refreshed_at,
+  DROP COLUMN IF EXISTS price_samples,
+  DROP COLUMN IF EXISTS avg_price_rub,
+  DROP COLUMN IF EXISTS list_price_rub;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose NO TRANSACTION
+-- Indexes for the per-user and per-month lookups, built concurrently like
+-- the search indexes. Keep the list in sync with admin.ManagedIndexes.
+--
+-- subscriptions_user_created_idx matches the default list order, so a page
+-- of one user's subscriptions is read in order instead of sorting all of
+-- them. subscriptions_ongoing_idx covers subscriptions without an end,
+-- which the "running in month X" queries read as a separate branch.
+-- subscriptions_user_service_start_idx serves lookups by user and service,
+-- compared case-insensitively like everywhere else.
+
+-- +goose Up
+CREATE INDEX CONCURRENTLY IF NOT EXISTS subscriptions_user_created_idx
+  ON subscriptions (user_id, created_at DESC, id);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS subscriptions_ongoing_idx
+  ON subscriptions (user_id, start_month) WHERE end_month IS NULL;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS subscriptions_user_service_start_idx
+  ON subscriptions (user_id, LOWER(service_name), start_month);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS subscriptions_user_service_start_idx;
+DROP INDEX CONCURRENTLY IF EXISTS subscriptions_ongoing_idx;
+DROP INDEX CONCURRENTLY IF EXISTS subscriptions_user_created_idx;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- idempotency_keys remembers the response to every POST sent with an
+-- Idempotency-Key header, so a client retrying after a lost response gets the
+-- original result instead of creating a duplicate. status_code is NULL while
+-- the first request is still running. Rows are purged after expires_at.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  scope TEXT NOT NULL,
+  key TEXT NOT NULL,
+  fingerprint TEXT NOT NULL,
+  status_code INTEGER,
+  content_type TEXT,
+  response_body BYTEA,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_idx ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
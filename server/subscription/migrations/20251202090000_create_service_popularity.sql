@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- service_popularity is an hourly rollup of the services running this month
+-- across all users, behind GET /services/popular. name is the most common
+-- spelling of each case-insensitive service name.
+CREATE TABLE IF NOT EXISTS service_popularity (
+  name_key TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  subscribers INTEGER NOT NULL,
+  subscriptions INTEGER NOT NULL,
+  avg_price_rub INTEGER NOT NULL,
+  refreshed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS service_popularity_rank_idx ON service_popularity (subscribers DESC, subscriptions DESC, name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS service_popularity;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- job_leases makes a periodic job run on one replica per interval. The
+-- replica that runs a job holds its lease until expires_at; the others skip
+-- the job until then, and take it over once a lease runs out unrenewed.
+CREATE TABLE IF NOT EXISTS job_leases (
+  name TEXT PRIMARY KEY,
+  holder TEXT NOT NULL,
+  acquired_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS job_leases;
+-- +goose StatementEnd
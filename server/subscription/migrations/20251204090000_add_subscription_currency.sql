@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The price as charged: amount_minor in the minor units (cents, kopecks) of
+-- an ISO 4217 currency. price_rub stays the ruble equivalent, converted when
+-- the price is written, so summaries keep adding up one currency.
+ALTER TABLE subscriptions
+  ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+  ADD COLUMN IF NOT EXISTS amount_minor BIGINT;
+
+UPDATE subscriptions SET amount_minor = price_rub::bigint * 100 WHERE amount_minor IS NULL;
+
+ALTER TABLE subscriptions
+  ALTER COLUMN amount_minor SET NOT NULL,
+  ADD CONSTRAINT subscriptions_amount_minor_check CHECK (amount_minor >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+  DROP COLUMN IF EXISTS amount_minor,
+  DROP COLUMN IF EXISTS currency;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- service_plans lists the tiers a catalog service is sold in, curated by hand
+-- like list_price_rub. rank orders the tiers of one service from the
+-- cheapest and most limited up; features describes what a tier adds.
+CREATE TABLE IF NOT EXISTS service_plans (
+  id BIGSERIAL PRIMARY KEY,
+  service_name TEXT NOT NULL REFERENCES service_catalog (name) ON UPDATE CASCADE ON DELETE CASCADE,
+  tier TEXT NOT NULL CHECK (length(trim(tier)) > 0),
+  rank INTEGER NOT NULL DEFAULT 0,
+  price_rub INTEGER NOT NULL CHECK (price_rub >= 0),
+  features TEXT[] NOT NULL DEFAULT '{}',
+  UNIQUE (service_name, tier)
+);
+
+CREATE INDEX IF NOT EXISTS service_plans_lower_service_idx ON service_plans (LOWER(service_name), price_rub);
+
+-- A subscription may say which plan it is on. Plans that are removed leave
+-- their subscriptions without one.
+ALTER TABLE subscriptions
+  ADD COLUMN IF NOT EXISTS plan_id BIGINT REFERENCES service_plans (id) ON DELETE SET NULL;
+
+INSERT INTO service_plans (service_name, tier, rank, price_rub, features) VALUES
+  ('Netflix', 'Basic', 1, 599, '{"1 screen","HD"}'),
+  ('Netflix', 'Standard', 2, 899, '{"2 screens","Full HD","downloads"}'),
+  ('Netflix', 'Premium', 3, 1199, '{"4 screens","Ultra HD","spatial audio"}'),
+  ('Yandex Plus', 'Basic', 1, 399, '{"music","films and series"}'),
+  ('Yandex Plus', 'Multi', 2, 499, '{"up to 4 family members"}'),
+  ('Spotify', 'Individual', 1, 169, '{"1 account"}'),
+  ('Spotify', 'Duo', 2, 219, '{"2 accounts"}'),
+  ('Spotify', 'Family', 3, 269, '{"up to 6 accounts"}')
+ON CONFLICT (service_name, tier) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS plan_id;
+DROP TABLE IF EXISTS service_plans;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- status is where a subscription is in its lifecycle. Paused subscriptions
+-- are left out of cost summaries until resumed; cancelled ones end with the
+-- month they were cancelled in and cannot be resumed. Expired is not stored:
+-- it is an active subscription whose last charged month has passed.
+ALTER TABLE subscriptions
+  ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'paused', 'cancelled'));
+
+CREATE INDEX IF NOT EXISTS subscriptions_user_status_idx ON subscriptions (user_id, status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS subscriptions_user_status_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- billing_period is how often price_rub is charged. Summaries spread the
+-- price over the months it covers, so a yearly price counts one twelfth in
+-- each month of the subscription. Existing rows were all entered as monthly.
+ALTER TABLE subscriptions
+  ADD COLUMN IF NOT EXISTS billing_period TEXT NOT NULL DEFAULT 'monthly'
+    CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_period;
+-- +goose StatementEnd
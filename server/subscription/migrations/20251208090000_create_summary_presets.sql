@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- summary_presets are named summary filters a user saved, so clients can ask
+-- for GET /subscriptions/summary?preset=work instead of repeating the
+-- parameters. Names are unique per user.
+CREATE TABLE IF NOT EXISTS summary_presets (
+  user_id UUID NOT NULL,
+  name TEXT NOT NULL CHECK (name ~ '^[a-z0-9][a-z0-9_-]{0,39}$'),
+  start_month DATE,
+  end_month DATE,
+  service_name TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, name),
+  CHECK (end_month IS NULL OR start_month IS NULL OR end_month >= start_month)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS summary_presets;
+-- +goose StatementEnd
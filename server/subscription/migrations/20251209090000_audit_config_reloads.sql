@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Configuration reloads are audited too; their entries describe no
+-- subscription.
+ALTER TABLE audit_log ALTER COLUMN subscription_id DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM audit_log WHERE subscription_id IS NULL;
+ALTER TABLE audit_log ALTER COLUMN subscription_id SET NOT NULL;
+-- +goose StatementEnd
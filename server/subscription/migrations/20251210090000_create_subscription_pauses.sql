@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- subscription_pauses records every pause, so cost summaries leave out only
+-- the months a subscription was paused for and keep the months before it.
+-- A pause covers paused_month up to, but not including, resumed_month; an
+-- open pause has no resumed_month.
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+  subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+  paused_month DATE NOT NULL,
+  resumed_month DATE,
+  paused_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  resumed_at TIMESTAMPTZ,
+  PRIMARY KEY (subscription_id, paused_at),
+  CHECK (resumed_month IS NULL OR resumed_month >= paused_month)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS subscription_pauses_open_idx
+  ON subscription_pauses (subscription_id) WHERE resumed_month IS NULL;
+
+-- Subscriptions paused before pauses were recorded count as paused since
+-- their last change.
+INSERT INTO subscription_pauses (subscription_id, paused_month, paused_at)
+SELECT id, date_trunc('month', updated_at)::date, updated_at
+FROM subscriptions
+WHERE status = 'paused'
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS subscription_pauses;
+-- +goose StatementEnd
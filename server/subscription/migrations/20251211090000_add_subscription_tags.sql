@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tags are free-form labels chosen by the owner, stored lowercased and
+-- without duplicates. The GIN index serves the search filters on them.
+ALTER TABLE subscriptions
+  ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS subscriptions_tags_idx ON subscriptions USING gin (tags);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS subscriptions_tags_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tags;
+-- +goose StatementEnd
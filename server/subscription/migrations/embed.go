@@ -4,3 +4,13 @@ import "embed"
 
 //go:embed *.sql
 var Files embed.FS
+
+// Strict holds the optional constraint migrations enabled by DB_STRICT_CONSTRAINTS.
+//
+//go:embed strict/*.sql
+var Strict embed.FS
+
+// Schema is the expected schema manifest checked for drift at startup.
+//
+//go:embed schema.txt
+var Schema string
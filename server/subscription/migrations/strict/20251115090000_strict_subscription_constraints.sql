@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+  ALTER COLUMN service_name SET NOT NULL,
+  ALTER COLUMN price_rub SET NOT NULL,
+  ALTER COLUMN user_id SET NOT NULL,
+  ALTER COLUMN start_month SET NOT NULL;
+
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'subscriptions_price_rub_non_negative') THEN
+    ALTER TABLE subscriptions
+      ADD CONSTRAINT subscriptions_price_rub_non_negative CHECK (price_rub >= 0);
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'subscriptions_end_after_start') THEN
+    ALTER TABLE subscriptions
+      ADD CONSTRAINT subscriptions_end_after_start CHECK (end_month IS NULL OR end_month >= start_month);
+  END IF;
+END $$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_end_after_start;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_price_rub_non_negative;
+-- +goose StatementEnd
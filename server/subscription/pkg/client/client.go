@@ -0,0 +1,362 @@
+// Package client is a typed HTTP client for the subscription service API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/beheryahmed1991/subscription-service.git/internal/types"
+)
+
+const (
+	monthLayout       = "2006-01"
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 2
+	defaultBackoff    = 200 * time.Millisecond
+)
+
+var (
+	// ErrNotFound is returned when the service responds with 404.
+	ErrNotFound = errors.New("subscription not found")
+	// ErrInvalidRequest is returned when the service rejects the input with 400.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrConflict is returned when the service responds with 409, e.g. when a
+	// client-supplied ID is already taken.
+	ErrConflict = errors.New("conflict")
+)
+
+// APIError describes a non-2xx response from the service.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("subscription api: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap maps well-known status codes to the package sentinel errors so callers
+// can use errors.Is.
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest:
+		return ErrInvalidRequest
+	case http.StatusConflict:
+		return ErrConflict
+	default:
+		return nil
+	}
+}
+
+// Month is a calendar month as the service renders it. It decodes whichever
+// DATE_FORMAT the server is configured with.
+type Month = types.Month
+
+// NewMonth returns the month t falls in.
+func NewMonth(t time.Time) Month {
+	return types.NewMonth(t)
+}
+
+// Subscription mirrors the JSON representation returned by the service.
+type Subscription struct {
+	ID                uuid.UUID `json:"id"`
+	Slug              string    `json:"slug"`
+	ServiceName       string    `json:"service_name"`
+	PriceRUB          int       `json:"price_rub"`
+	UserID            uuid.UUID `json:"user_id"`
+	StartMonth        Month     `json:"start_month"`
+	EndMonth          *Month    `json:"end_month,omitempty"`
+	EndMonthInclusive *bool     `json:"end_month_inclusive,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	Version           int64     `json:"version"`
+}
+
+// CreateRequest holds the fields needed to create a subscription. ID is
+// optional; set it to create records offline and sync them without
+// duplicates.
+type CreateRequest struct {
+	ID          uuid.UUID
+	ServiceName string
+	PriceRUB    int
+	UserID      uuid.UUID
+	StartMonth  Month
+	EndMonth    *Month
+}
+
+// ListParams controls pagination for List. Zero values use server defaults.
+type ListParams struct {
+	Page  int
+	Limit int
+}
+
+// ListResult is a single page of subscriptions.
+type ListResult struct {
+	Items []Subscription `json:"items"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+	Total int            `json:"total"`
+}
+
+// BatchResult is the answer to GetMany.
+type BatchResult struct {
+	Items   []Subscription `json:"items"`
+	Missing []uuid.UUID    `json:"missing"`
+}
+
+// SummaryParams filters the summary calculation. Nil fields are not sent.
+type SummaryParams struct {
+	StartMonth  *Month
+	EndMonth    *Month
+	UserID      *uuid.UUID
+	ServiceName string
+}
+
+// Client talks to a subscription service instance.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	token      string
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http.Client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times idempotent requests are retried on network
+// errors and 5xx responses, and the base delay between attempts.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithToken authenticates every request with token as a bearer token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New creates a Client for the service reachable at baseURL.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("base url must be absolute: %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Create creates a subscription. It is never retried because the endpoint is
+// not idempotent.
+func (c *Client) Create(ctx context.Context, req CreateRequest) (Subscription, error) {
+	body := map[string]any{
+		"service_name": req.ServiceName,
+		"price":        req.PriceRUB,
+		"user_id":      req.UserID.String(),
+		"start_date":   req.StartMonth.Format(monthLayout),
+	}
+	if req.EndMonth != nil {
+		body["end_date"] = req.EndMonth.Format(monthLayout)
+	}
+	if req.ID != uuid.Nil {
+		body["id"] = req.ID.String()
+	}
+
+	var sub Subscription
+	if err := c.do(ctx, http.MethodPost, "/subscriptions", nil, body, false, &sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Get fetches a subscription by ID.
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	var sub Subscription
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/"+id.String(), nil, nil, true, &sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// GetMany fetches up to 100 subscriptions in one request. Items follow the
+// order of ids; IDs that do not exist are returned in Missing. It is retried
+// like Get because the endpoint only reads.
+func (c *Client) GetMany(ctx context.Context, ids []uuid.UUID) (BatchResult, error) {
+	body := map[string]any{"ids": ids}
+
+	var result BatchResult
+	if err := c.do(ctx, http.MethodPost, "/subscriptions/batch-get", nil, body, true, &result); err != nil {
+		return BatchResult{}, err
+	}
+	return result, nil
+}
+
+// List returns a page of subscriptions.
+func (c *Client) List(ctx context.Context, params ListParams) (ListResult, error) {
+	query := url.Values{}
+	if params.Page > 0 {
+		query.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+
+	var result ListResult
+	if err := c.do(ctx, http.MethodGet, "/subscriptions", query, nil, true, &result); err != nil {
+		return ListResult{}, err
+	}
+	return result, nil
+}
+
+// Summary returns the total subscription cost matching the filters.
+func (c *Client) Summary(ctx context.Context, params SummaryParams) (int64, error) {
+	query := url.Values{}
+	if params.StartMonth != nil {
+		query.Set("start", params.StartMonth.Format(monthLayout))
+	}
+	if params.EndMonth != nil {
+		query.Set("end", params.EndMonth.Format(monthLayout))
+	}
+	if params.UserID != nil {
+		query.Set("user_id", params.UserID.String())
+	}
+	if params.ServiceName != "" {
+		query.Set("service_name", params.ServiceName)
+	}
+
+	var result struct {
+		TotalPrice int64 `json:"total_price"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/summary", query, nil, true, &result); err != nil {
+		return 0, err
+	}
+	return result.TotalPrice, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, retryable bool, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	endpoint := *c.baseURL
+	endpoint.Path += path
+	endpoint.RawQuery = query.Encode()
+
+	attempts := 1
+	if retryable && c.maxRetries > 0 {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := c.backoff * time.Duration(1<<(attempt-1))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		retry, err := c.send(ctx, method, endpoint.String(), payload, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte, out any) (bool, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return false, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("%s %s: %w", method, endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode >= http.StatusInternalServerError, decodeAPIError(resp)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("decode response: %w", err)
+	}
+	return false, nil
+}
+
+func decodeAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Error != "" {
+		apiErr.Message = body.Error
+	} else {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}